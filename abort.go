@@ -0,0 +1,199 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"time"
+)
+
+// Deadline implements context.Context, so drivers can hand *Context to
+// libraries that take one. It is cancelled when the client aborts the
+// transfer, disconnects or the server shuts down.
+func (ctx *Context) Deadline() (time.Time, bool) {
+	return ctx.context().Deadline()
+}
+
+// Done implements context.Context
+func (ctx *Context) Done() <-chan struct{} {
+	return ctx.context().Done()
+}
+
+// Err implements context.Context
+func (ctx *Context) Err() error {
+	return ctx.context().Err()
+}
+
+// Value implements context.Context
+func (ctx *Context) Value(key interface{}) interface{} {
+	return ctx.context().Value(key)
+}
+
+func (ctx *Context) context() context.Context {
+	if ctx.Sess == nil {
+		return context.Background()
+	}
+	return ctx.Sess.commandContext()
+}
+
+// commandContext returns the context of the command being executed, or
+// the session's when there is none.
+func (sess *Session) commandContext() context.Context {
+	if sess.cmdCtx != nil {
+		return sess.cmdCtx
+	}
+	if sess.Ctx != nil {
+		return sess.Ctx
+	}
+	return context.Background()
+}
+
+// abortCommand cancels the context of the running command, as ABOR does.
+// It may be called from any goroutine.
+func (sess *Session) abortCommand() {
+	sess.cmdMu.Lock()
+	defer sess.cmdMu.Unlock()
+	if sess.cmdCancel != nil {
+		sess.cmdCancel()
+	}
+}
+
+// aborted reports whether the running command was aborted with ABOR.
+func (sess *Session) aborted() bool {
+	return sess.cmdCtx != nil && sess.cmdCtx.Err() != nil &&
+		(sess.Ctx == nil || sess.Ctx.Err() == nil)
+}
+
+// watchControl watches the control connection during a transfer. ABOR
+// aborts it: the command context is cancelled and the data connection
+// closed, the ABOR line being left to be read and answered once the
+// transfer returns. NOOP, STAT and QUIT are answered while the transfer
+// runs, see answerDuringTransfer. Anything else was pipelined behind the
+// transfer and waits for it to finish.
+// The returned function stops watching, it is also kept in stopWatching
+// for commands which panic before calling it.
+func (sess *Session) watchControl(dataConn DataSocket) func() {
+	done := make(chan struct{})
+	finished := make(chan struct{})
+
+	// DataSocket.CancelTransfer aborts this transfer, and only this one
+	if socket, ok := dataConn.(interface{ bindTransfer(context.CancelFunc) }); ok {
+		sess.cmdMu.Lock()
+		socket.bindTransfer(sess.cmdCancel)
+		sess.cmdMu.Unlock()
+	}
+
+	// The idle timeout of the command loop doesn't apply while the
+	// transfer runs, the control connection is quiet until it is over.
+	_ = sess.Conn.SetReadDeadline(time.Time{})
+
+	go func() {
+		defer close(finished)
+
+		for n := 1; ; n++ {
+			if _, err := sess.controlReader.Peek(n); err != nil {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				// Woken up by a shutdown drain, the transfer goes on
+				if isTimeout(err) && sess.Ctx.Err() == nil {
+					_ = sess.Conn.SetReadDeadline(time.Time{})
+					n = sess.controlReader.Buffered()
+					continue
+				}
+				// The session is being shut down, the transfer with it.
+				if sess.Ctx.Err() != nil {
+					dataConn.Close()
+				}
+				return
+			}
+			select {
+			case <-done:
+				return
+			default:
+			}
+
+			buf, _ := sess.controlReader.Peek(sess.controlReader.Buffered())
+			// The LF of a CRLF whose CR ended the previous line
+			if sess.skipLF && buf[0] == '\n' {
+				_, _ = sess.controlReader.Discard(1)
+				sess.skipLF = false
+				n = 0
+				continue
+			}
+			// Lines end with LF or CR alone, as readLine reads them
+			i := bytes.IndexAny(buf, "\r\n")
+			if i < 0 {
+				n = len(buf)
+				continue
+			}
+			if isAbortLine(buf[:i]) {
+				sess.log("Transfer aborted by client")
+				sess.abortCommand()
+				dataConn.Close()
+				return
+			}
+			if !sess.answerDuringTransfer(string(buf[:i])) {
+				return
+			}
+			n = 0
+		}
+	}()
+
+	sess.stopWatching = func() {
+		sess.stopWatching = nil
+		close(done)
+		// Unblock the pending Peek, the command loop sets its own
+		// deadline before the next read.
+		_ = sess.Conn.SetReadDeadline(time.Now())
+		<-finished
+		_ = sess.Conn.SetReadDeadline(time.Time{})
+	}
+	return sess.stopWatching
+}
+
+// answerDuringTransfer answers the keepalives clients send while a
+// transfer runs, reading line off the control connection. NOOP and STAT
+// are answered right away, QUIT once the transfer is over. It returns false,
+// leaving the line unread, for other commands.
+func (sess *Session) answerDuringTransfer(line string) bool {
+	command, param := sess.parseLine(line)
+	command = strings.ToUpper(command)
+	switch {
+	case command == "NOOP", command == "QUIT":
+	case command == "STAT" && strings.TrimSpace(param) == "":
+	default:
+		return false
+	}
+	if msg := sess.checkFlood(); msg != "" {
+		return false
+	}
+	if _, err := sess.readLine(); err != nil {
+		return false
+	}
+	sess.sessionLogger().PrintCommand(sess.id, command, param)
+
+	switch command {
+	case "NOOP":
+		sess.writeMessage(200, "OK")
+	case "STAT":
+		sess.writeMessage(213, "Status: "+sess.command+" in progress")
+	case "QUIT":
+		sess.quitAfterTransfer = true
+		return false
+	}
+	return true
+}
+
+// isAbortLine reports whether a command line is ABOR, ignoring any telnet
+// sequences in front of it.
+func isAbortLine(line []byte) bool {
+	line = bytes.TrimSpace([]byte(trimTelnet(string(line))))
+	return bytes.EqualFold(line, []byte("ABOR"))
+}
@@ -5,8 +5,15 @@
 package ftp
 
 import (
+	"bufio"
 	"crypto/subtle"
+	"fmt"
+	"os"
 	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/globalcyberalliance/ftp-go/credentials"
 )
 
 // Auth is an interface to auth your ftp user login.
@@ -14,9 +21,19 @@ type Auth interface {
 	CheckPasswd(*Context, string, string) (bool, error)
 }
 
+// AccountChecker is implemented by Auths that can tell whether a user may
+// still log in without their password. Sessions resumed with SITE RESUME
+// are refused when CheckAccount returns an error, such as
+// ErrAccountExpired or ErrPasswordChangeRequired.
+type AccountChecker interface {
+	CheckAccount(ctx *Context, name string) error
+}
+
 var (
 	_ Auth = &SimpleAuth{}
 	_ Auth = &RegexAuth{}
+	_ Auth = &MapAuth{}
+	_ Auth = &FileAuth{}
 )
 
 // SimpleAuth implements Auth interface to provide a memory user login auth
@@ -55,3 +72,81 @@ func (a *RegexAuth) CheckPasswd(ctx *Context, username, pass string) (bool, erro
 
 	return false, nil
 }
+
+// MapAuth implements Auth interface with the password hashes of users, by
+// name. Hashes are bcrypt or argon2id ones, see the credentials package.
+type MapAuth struct {
+	Users map[string]string
+}
+
+// CheckPasswd will check user's password
+func (a *MapAuth) CheckPasswd(ctx *Context, name, pass string) (bool, error) {
+	hash, ok := a.Users[name]
+	if !ok {
+		credentials.VerifyDummy(pass)
+		return false, nil
+	}
+	return credentials.Verify(hash, pass)
+}
+
+// FileAuth implements Auth interface with the password hashes of a file of
+// "name:hash" lines, as htpasswd writes them with bcrypt. Blank lines and
+// lines starting with # are skipped.
+type FileAuth struct {
+	path  string
+	lock  sync.RWMutex
+	users MapAuth
+}
+
+// NewFileAuth reads the users of the file at path. Plain text passwords are
+// rejected.
+func NewFileAuth(path string) (*FileAuth, error) {
+	a := &FileAuth{path: path}
+	if err := a.Reload(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Reload reads the file again, for instance once users were added. The
+// users read before are kept when it fails.
+func (a *FileAuth) Reload() error {
+	f, err := os.Open(a.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	users := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		name, hash, ok := strings.Cut(text, ":")
+		if !ok || name == "" {
+			return fmt.Errorf("ftp: %s:%d: expected name:hash", a.path, line)
+		}
+		if !credentials.IsHash(hash) {
+			return fmt.Errorf("ftp: %s:%d: password of %s is not a bcrypt or argon2id hash", a.path, line, name)
+		}
+		users[name] = hash
+	}
+	if err = scanner.Err(); err != nil {
+		return err
+	}
+
+	a.lock.Lock()
+	a.users.Users = users
+	a.lock.Unlock()
+	return nil
+}
+
+// CheckPasswd will check user's password
+func (a *FileAuth) CheckPasswd(ctx *Context, name, pass string) (bool, error) {
+	a.lock.RLock()
+	users := a.users
+	a.lock.RUnlock()
+	return users.CheckPasswd(ctx, name, pass)
+}
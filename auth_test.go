@@ -0,0 +1,53 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/globalcyberalliance/ftp-go/credentials"
+)
+
+func TestFileAuth(t *testing.T) {
+	hash, err := credentials.HashBcrypt("secret", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "users")
+	if err = os.WriteFile(path, []byte("# users\n\nalice:"+hash+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	auth, err := NewFileAuth(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name, pass string
+		ok         bool
+	}{
+		{"alice", "secret", true},
+		{"alice", "Secret", false},
+		{"bob", "secret", false},
+	} {
+		ok, err := auth.CheckPasswd(nil, test.name, test.pass)
+		if err != nil || ok != test.ok {
+			t.Errorf("CheckPasswd(%s, %s) = %v, %v, want %v", test.name, test.pass, ok, err, test.ok)
+		}
+	}
+
+	// Plain text passwords are rejected, the users read before are kept
+	if err = os.WriteFile(path, []byte("alice:"+hash+"\nbob:secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err = auth.Reload(); err == nil {
+		t.Fatal("plain text password accepted")
+	}
+	if ok, _ := auth.CheckPasswd(nil, "alice", "secret"); !ok {
+		t.Error("users lost by a failed reload")
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"sync"
+	"time"
+)
+
+// BanList holds the source IPs whose connections are refused, see
+// Options.BanList. Implementations backed by a shared database allow
+// several servers to refuse the same sources.
+type BanList interface {
+	// Banned reports whether ip is banned.
+	Banned(ip string) (bool, error)
+
+	// Ban bans ip for d.
+	Ban(ip string, d time.Duration) error
+
+	// Unban lifts the ban of ip, if any.
+	Unban(ip string) error
+}
+
+var _ BanList = &MemoryBanList{}
+
+// MemoryBanList is an in-memory BanList, bans are lost on restart.
+type MemoryBanList struct {
+	lock      sync.Mutex
+	bans      map[string]time.Time
+	lastSweep time.Time
+}
+
+// NewMemoryBanList creates a MemoryBanList
+func NewMemoryBanList() *MemoryBanList {
+	return &MemoryBanList{
+		bans: make(map[string]time.Time),
+	}
+}
+
+// Banned implements BanList
+func (list *MemoryBanList) Banned(ip string) (bool, error) {
+	list.lock.Lock()
+	defer list.lock.Unlock()
+
+	until, ok := list.bans[ip]
+	if ok && !time.Now().Before(until) {
+		delete(list.bans, ip)
+		ok = false
+	}
+	return ok, nil
+}
+
+// Ban implements BanList
+func (list *MemoryBanList) Ban(ip string, d time.Duration) error {
+	list.lock.Lock()
+	defer list.lock.Unlock()
+
+	now := time.Now()
+	// Expired bans are swept once in a while
+	if now.Sub(list.lastSweep) >= time.Minute {
+		for key, until := range list.bans {
+			if !now.Before(until) {
+				delete(list.bans, key)
+			}
+		}
+		list.lastSweep = now
+	}
+	list.bans[ip] = now.Add(d)
+	return nil
+}
+
+// Unban implements BanList
+func (list *MemoryBanList) Unban(ip string) error {
+	list.lock.Lock()
+	delete(list.bans, ip)
+	list.lock.Unlock()
+	return nil
+}
+
+// banned reports whether connections from ip are refused, letting them
+// through when Options.BanList fails.
+func (server *Server) banned(ip string) bool {
+	if server.BanList == nil {
+		return false
+	}
+	banned, err := server.BanList.Banned(ip)
+	if err != nil {
+		server.logger.Printf("", "checking ban of %s: %v", ip, err)
+		return false
+	}
+	return banned
+}
@@ -0,0 +1,143 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// bandwidthScheduleInterval is how often the limits of sessions in a
+// bandwidth class are brought in line with its windows.
+const bandwidthScheduleInterval = time.Minute
+
+// BandwidthClass is a named set of rate limits sessions are put in with
+// Session.SetBandwidthClass, see Options.BandwidthClasses. Its limits
+// override the server's and follow its windows as time passes, transfers
+// in progress included.
+type BandwidthClass struct {
+	// Limits in bytes per second outside of the windows, 0 means no limit
+	Up   int64
+	Down int64
+
+	// Windows with other limits, such as an unlimited off-peak window.
+	// The first window containing the time of day applies.
+	Windows []BandwidthWindow
+}
+
+// BandwidthWindow sets the limits of a BandwidthClass during a part of
+// the day.
+type BandwidthWindow struct {
+	// Start and end of the window as offsets from midnight, local time of
+	// the server. A window ending before it starts spans midnight.
+	Start time.Duration
+	End   time.Duration
+
+	// Limits in bytes per second within the window, 0 means no limit
+	Up   int64
+	Down int64
+}
+
+// contains reports whether the time of day of t is within the window.
+func (w BandwidthWindow) contains(t time.Time) bool {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	offset := t.Sub(midnight)
+	if w.Start <= w.End {
+		return offset >= w.Start && offset < w.End
+	}
+	return offset >= w.Start || offset < w.End
+}
+
+// Limits returns the upload and download limits of the class at t
+func (class BandwidthClass) Limits(t time.Time) (up, down int64) {
+	for _, w := range class.Windows {
+		if w.contains(t) {
+			return w.Up, w.Down
+		}
+	}
+	return class.Up, class.Down
+}
+
+// checkBandwidthClasses checks the windows of the classes lie within a day.
+func checkBandwidthClasses(classes map[string]BandwidthClass) error {
+	for name, class := range classes {
+		if name == "" {
+			return errors.New("ftp: bandwidth class without a name")
+		}
+		for _, w := range class.Windows {
+			if w.Start < 0 || w.Start >= 24*time.Hour || w.End < 0 || w.End > 24*time.Hour {
+				return fmt.Errorf("ftp: bandwidth class %q has a window outside of a day", name)
+			}
+		}
+	}
+	return nil
+}
+
+// SetBandwidthClass puts the session in the class of Options.BandwidthClasses
+// named name, applying its limits at once and as its windows change. An
+// empty name takes the session out of its class, leaving its limits as
+// they are.
+func (sess *Session) SetBandwidthClass(name string) error {
+	if name == "" {
+		sess.bandwidthClass.Store("")
+		return nil
+	}
+	class, ok := sess.server.BandwidthClasses[name]
+	if !ok {
+		return fmt.Errorf("ftp: unknown bandwidth class %q", name)
+	}
+	sess.bandwidthClass.Store(name)
+	sess.applyBandwidthClass(class, time.Now())
+	return nil
+}
+
+// BandwidthClass returns the name of the session's bandwidth class, "" if
+// it is in none.
+func (sess *Session) BandwidthClass() string {
+	name, _ := sess.bandwidthClass.Load().(string)
+	return name
+}
+
+// applyBandwidthClass sets the session's limits to those of class at t.
+func (sess *Session) applyBandwidthClass(class BandwidthClass, t time.Time) {
+	up, down := class.Limits(t)
+	if sess.uploadLimiter.Rate() != up {
+		sess.uploadLimiter.SetRate(up)
+	}
+	if sess.downloadLimiter.Rate() != down {
+		sess.downloadLimiter.SetRate(down)
+	}
+}
+
+// applyBandwidthClasses sets the limits of the sessions being served to
+// those of their bandwidth class at t.
+func (server *Server) applyBandwidthClasses(t time.Time) {
+	server.sessionsMu.Lock()
+	defer server.sessionsMu.Unlock()
+
+	for sess := range server.sessions {
+		if class, ok := server.BandwidthClasses[sess.BandwidthClass()]; ok {
+			sess.applyBandwidthClass(class, t)
+		}
+	}
+}
+
+// scheduleBandwidth keeps the sessions' limits in line with the windows of
+// their bandwidth classes until ctx is done.
+func (server *Server) scheduleBandwidth(ctx context.Context) {
+	ticker := time.NewTicker(bandwidthScheduleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case t := <-ticker.C:
+			server.applyBandwidthClasses(t)
+		}
+	}
+}
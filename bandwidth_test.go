@@ -0,0 +1,98 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBandwidthClassLimits(t *testing.T) {
+	class := BandwidthClass{
+		Up:   100,
+		Down: 200,
+		Windows: []BandwidthWindow{
+			// off-peak, unlimited
+			{Start: 22 * time.Hour, End: 6 * time.Hour},
+			{Start: 12 * time.Hour, End: 13 * time.Hour, Up: 50, Down: 50},
+		},
+	}
+
+	day := time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		at       time.Duration
+		up, down int64
+	}{
+		{0, 0, 0},
+		{5*time.Hour + 59*time.Minute, 0, 0},
+		{6 * time.Hour, 100, 200},
+		{12*time.Hour + 30*time.Minute, 50, 50},
+		{13 * time.Hour, 100, 200},
+		{22 * time.Hour, 0, 0},
+	} {
+		if up, down := class.Limits(day.Add(tt.at)); up != tt.up || down != tt.down {
+			t.Errorf("Limits at %v = %d/%d, want %d/%d", tt.at, up, down, tt.up, tt.down)
+		}
+	}
+}
+
+func TestSessionBandwidthClass(t *testing.T) {
+	if _, err := NewServer(&Options{
+		Perm: NewSimplePerm("test", "test"),
+		BandwidthClasses: map[string]BandwidthClass{
+			"bad": {Windows: []BandwidthWindow{{Start: 25 * time.Hour}}},
+		},
+	}); err == nil {
+		t.Fatal("expected an error for a window outside of a day")
+	}
+
+	s, err := NewServer(&Options{
+		Perm:      NewSimplePerm("test", "test"),
+		RateLimit: 1000,
+		BandwidthClasses: map[string]BandwidthClass{
+			"basic": {
+				Up:      100,
+				Down:    200,
+				Windows: []BandwidthWindow{{Start: 0, End: 6 * time.Hour}},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sess := s.newSession(newSessionID(), mockConn{})
+	if err = sess.SetBandwidthClass("premium"); err == nil {
+		t.Fatal("expected an error for an unknown class")
+	}
+	if err = sess.SetBandwidthClass("basic"); err != nil {
+		t.Fatal(err)
+	}
+	if sess.BandwidthClass() != "basic" {
+		t.Fatalf("BandwidthClass() = %q", sess.BandwidthClass())
+	}
+
+	// The schedule follows the class's windows
+	s.sessions = map[*Session]struct{}{sess: {}}
+	now := time.Now()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	s.applyBandwidthClasses(midnight.Add(time.Hour))
+	if up, down := sess.RateLimit(); up != 0 || down != 0 {
+		t.Fatalf("expected no limits within the window, got %d/%d", up, down)
+	}
+	s.applyBandwidthClasses(midnight.Add(12 * time.Hour))
+	if up, down := sess.RateLimit(); up != 100 || down != 200 {
+		t.Fatalf("expected limits 100/200, got %d/%d", up, down)
+	}
+
+	// Out of its class, the session keeps its limits
+	if err = sess.SetBandwidthClass(""); err != nil {
+		t.Fatal(err)
+	}
+	s.applyBandwidthClasses(midnight.Add(time.Hour))
+	if up, down := sess.RateLimit(); up != 100 || down != 200 {
+		t.Fatalf("expected limits 100/200, got %d/%d", up, down)
+	}
+}
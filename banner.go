@@ -0,0 +1,134 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strings"
+	"text/template"
+)
+
+// BannerData is what Options.WelcomeMessage is executed with when
+// Options.WelcomeTemplate is set, as in
+// "Welcome to {{.ServerName}}, {{.ClientIP}}".
+type BannerData struct {
+	ServerName string
+	ServerIP   string
+	ClientIP   string
+	TLS        bool
+}
+
+func parseWelcomeMessage(message string) (*template.Template, error) {
+	tmpl, err := template.New("welcome").Parse(message)
+	if err != nil {
+		return nil, fmt.Errorf("ftp: welcome message: %w", err)
+	}
+	return tmpl, nil
+}
+
+// SetWelcomeMessage sets the message the session is greeted with, overriding
+// Options.WelcomeMessage. Options.OnConnect may call it.
+func (sess *Session) SetWelcomeMessage(message string) {
+	sess.welcome = message
+}
+
+// welcomeMessage renders Options.WelcomeMessage for the session, unless
+// SetWelcomeMessage set another.
+func (sess *Session) welcomeMessage() string {
+	if sess.welcome != "" {
+		return sess.welcome
+	}
+	if sess.server.welcomeTemplate == nil {
+		return sess.server.WelcomeMessage
+	}
+	var b strings.Builder
+	err := sess.server.welcomeTemplate.Execute(&b, BannerData{
+		ServerName: sess.server.Name,
+		ServerIP:   addrIP(sess.Conn.LocalAddr()),
+		ClientIP:   addrIP(sess.RemoteAddr()),
+		TLS:        sess.tls,
+	})
+	if err != nil {
+		sess.logf("welcome message: %v", err)
+		return sess.server.WelcomeMessage
+	}
+	return b.String()
+}
+
+// addrIP returns the IP of addr without the port.
+func addrIP(addr net.Addr) string {
+	ip := addr.String()
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	return ip
+}
+
+// policyBanner returns Options.PolicyBanner, or the content of
+// Options.PolicyBannerFile, "" if none.
+func (sess *Session) policyBanner() string {
+	if sess.server.PolicyBanner != "" {
+		return sess.server.PolicyBanner
+	}
+	if sess.server.PolicyBannerFile != "" {
+		banner, err := ioutil.ReadFile(sess.server.PolicyBannerFile)
+		if err != nil {
+			sess.logf("reading policy banner: %v", err)
+			return ""
+		}
+		return string(banner)
+	}
+	return ""
+}
+
+// writeWelcome greets the client with the policy banner and the rendered
+// welcome message, all lines but the last as 220- continuation lines.
+func (sess *Session) writeWelcome() {
+	welcome := strings.TrimRight(strings.ReplaceAll(sess.welcomeMessage(), "\r\n", "\n"), "\n")
+	if banner := strings.TrimRight(sess.policyBanner(), "\r\n"); banner != "" {
+		welcome = banner + "\n" + welcome
+	}
+	if i := strings.LastIndex(welcome, "\n"); i >= 0 {
+		sess.writeMessageLines(220, welcome[:i], welcome[i+1:])
+		return
+	}
+	sess.writeMessage(220, welcome)
+}
+
+// SetMOTD sets the message of the day sent after the session logs in,
+// overriding Options.MOTD and Options.MOTDFile. Auth implementations may
+// call it from CheckPasswd.
+func (sess *Session) SetMOTD(motd string) {
+	sess.motd = motd
+}
+
+// loginMessage returns the message of the day for a session which just
+// logged in, or "".
+func (sess *Session) loginMessage(ctx *Context) string {
+	if sess.motd != "" {
+		return sess.motd
+	}
+	if sess.server.MOTD != nil {
+		return sess.server.MOTD(ctx)
+	}
+	if sess.server.MOTDFile != "" {
+		motd, err := ioutil.ReadFile(sess.server.MOTDFile)
+		if err != nil {
+			sess.logf("reading MOTD: %v", err)
+			return ""
+		}
+		return string(motd)
+	}
+	return ""
+}
+
+// writeMessageLines sends message as a multiline reply, each of its lines
+// prefixed with the code, closed with last.
+func (sess *Session) writeMessageLines(code int, message, last string) {
+	message = strings.TrimRight(strings.ReplaceAll(message, "\r\n", "\n"), "\n")
+	sess.writeReply(code, message, sess.phrase(code, last), false)
+}
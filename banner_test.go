@@ -0,0 +1,120 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"fmt"
+	"net"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWelcomeMessage(t *testing.T) {
+	if _, err := NewServer(&Options{
+		Perm:            NewSimplePerm("test", "test"),
+		WelcomeMessage:  "Welcome {{.Missing",
+		WelcomeTemplate: true,
+	}); err == nil {
+		t.Fatal("expected an error for a malformed welcome message")
+	}
+
+	s, err := NewServer(&Options{
+		Name:            "test ftpd",
+		Perm:            NewSimplePerm("test", "test"),
+		Logger:          new(DiscardLogger),
+		Auth:            &SimpleAuth{Name: "admin", Password: "admin"},
+		WelcomeMessage:  "Authorized use only.\n{{.ServerName}} greets {{.ClientIP}}, TLS {{.TLS}}",
+		WelcomeTemplate: true,
+		MOTD: func(ctx *Context) string {
+			return "Hello " + ctx.Sess.LoginUser() + "\n230 is not the end"
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serverConn, clientConn := net.Pipe()
+	sess := s.newSession(newSessionID(), serverConn)
+	go sess.Serve()
+
+	client := textproto.NewConn(clientConn)
+	defer client.Close()
+	_, msg, err := client.ReadResponse(220)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Authorized use only.\ntest ftpd greets pipe, TLS false"; msg != want {
+		t.Fatalf("expected %q, got %q", want, msg)
+	}
+
+	expectCode(t, client, 331, "USER admin")
+	if msg, want := expectCode(t, client, 230, "PASS admin"), "Hello admin\n230 is not the end\nPassword ok, continue"; msg != want {
+		t.Fatalf("expected %q, got %q", want, msg)
+	}
+}
+
+func TestWelcomeMessageVerbatim(t *testing.T) {
+	// Without WelcomeTemplate, braces are sent as they are
+	s, err := NewServer(&Options{
+		Perm:           NewSimplePerm("test", "test"),
+		Logger:         new(DiscardLogger),
+		WelcomeMessage: "Welcome {{.Missing",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serverConn, clientConn := net.Pipe()
+	sess := s.newSession(newSessionID(), serverConn)
+	go sess.Serve()
+
+	client := textproto.NewConn(clientConn)
+	defer client.Close()
+	_, msg, err := client.ReadResponse(220)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Welcome {{.Missing"; msg != want {
+		t.Fatalf("expected %q, got %q", want, msg)
+	}
+}
+
+func TestPolicyBanner(t *testing.T) {
+	var lines []string
+	for i := 0; i < 200; i++ {
+		lines = append(lines, fmt.Sprintf("%d. Unauthorized access is prohibited.", i))
+	}
+	banner := filepath.Join(t.TempDir(), "banner")
+	if err := os.WriteFile(banner, []byte(strings.Join(lines, "\r\n")+"\r\n\r\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewServer(&Options{
+		Perm:             NewSimplePerm("test", "test"),
+		Logger:           new(DiscardLogger),
+		PolicyBannerFile: banner,
+		WelcomeMessage:   "Welcome",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serverConn, clientConn := net.Pipe()
+	sess := s.newSession(newSessionID(), serverConn)
+	go sess.Serve()
+
+	client := textproto.NewConn(clientConn)
+	defer client.Close()
+	_, msg, err := client.ReadResponse(220)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.Join(lines, "\n") + "\nWelcome"; msg != want {
+		t.Fatalf("expected %q, got %q", want, msg)
+	}
+}
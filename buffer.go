@@ -0,0 +1,79 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"context"
+	"io"
+)
+
+const (
+	defaultTransferBufferSize = 32 << 10
+	// maxTransferBufferSize caps the memory a single transfer buffers
+	maxTransferBufferSize = 4 << 20
+)
+
+// getBuffer returns a transfer buffer from the server's pool, it must be
+// given back with putBuffer. It waits for one to be given back while
+// Options.TransferBufferMemory is in use, unless ctx is done first, as when
+// the client aborts the transfer.
+func (server *Server) getBuffer(ctx context.Context) (*[]byte, error) {
+	if server.bufferSlots != nil {
+		select {
+		case server.bufferSlots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if buf, ok := server.bufferPool.Get().(*[]byte); ok {
+		return buf, nil
+	}
+	buf := make([]byte, server.TransferBufferSize)
+	return &buf, nil
+}
+
+// putBuffer returns a buffer taken with getBuffer to the pool.
+func (server *Server) putBuffer(buf *[]byte) {
+	server.bufferPool.Put(buf)
+	if server.bufferSlots != nil {
+		<-server.bufferSlots
+	}
+}
+
+// copyBuffer copies src to dst through a pooled buffer, waiting for one
+// until ctx is done.
+func (server *Server) copyBuffer(ctx context.Context, dst io.Writer, src io.Reader) (int64, error) {
+	buf, err := server.getBuffer(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer server.putBuffer(buf)
+
+	// Hide any WriterTo and ReaderFrom implementations, which would make
+	// io.CopyBuffer allocate its own buffer instead of using ours.
+	return io.CopyBuffer(writerOnly{dst}, readerOnly{src}, *buf)
+}
+
+type readerOnly struct {
+	io.Reader
+}
+
+type writerOnly struct {
+	io.Writer
+}
+
+// pooledReader is handed to drivers as the upload stream. Since io.Copy
+// prefers the source's WriterTo, drivers copying it get the pooled buffer
+// without any changes.
+type pooledReader struct {
+	io.Reader
+	ctx    context.Context
+	server *Server
+}
+
+// WriteTo implements io.WriterTo
+func (r *pooledReader) WriteTo(w io.Writer) (int64, error) {
+	return r.server.copyBuffer(r.ctx, w, r.Reader)
+}
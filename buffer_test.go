@@ -0,0 +1,62 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTransferBufferMemory(t *testing.T) {
+	s, err := NewServer(&Options{
+		Perm:               NewSimplePerm("test", "test"),
+		TransferBufferSize: maxTransferBufferSize + 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.TransferBufferSize != maxTransferBufferSize {
+		t.Errorf("got buffers of %d bytes, want them lowered to %d", s.TransferBufferSize, maxTransferBufferSize)
+	}
+
+	s, err = NewServer(&Options{
+		Perm:                 NewSimplePerm("test", "test"),
+		TransferBufferSize:   1024,
+		TransferBufferMemory: 1500,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A single buffer fits, the second waits for it
+	buf, err := s.getBuffer(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// An aborted transfer stops waiting
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err = s.getBuffer(ctx); err != context.Canceled {
+		t.Errorf("got %v waiting for a buffer after the transfer was aborted", err)
+	}
+	got := make(chan *[]byte)
+	go func() {
+		buf, _ := s.getBuffer(context.Background())
+		got <- buf
+	}()
+	select {
+	case <-got:
+		t.Fatal("buffer allocated over TransferBufferMemory")
+	case <-time.After(50 * time.Millisecond):
+	}
+	s.putBuffer(buf)
+	select {
+	case buf = <-got:
+		s.putBuffer(buf)
+	case <-time.After(time.Second):
+		t.Fatal("buffer not handed over once given back")
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"fmt"
+	"net"
+	"path"
+	"time"
+)
+
+// CanaryEvent describes a command touching a canary path, see
+// Options.CanaryPaths.
+type CanaryEvent struct {
+	Time       time.Time
+	SessionID  string
+	User       string // empty before login
+	RemoteAddr net.Addr
+	LocalAddr  net.Addr
+	TLS        bool
+	ClientSoft string // as announced with CLNT
+	Command    string
+	Path       string
+	Pattern    string // the canary pattern matched
+	// the client so far
+	Fingerprint Fingerprint
+}
+
+func checkCanaryPatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("ftp: canary pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// matchCanary returns the canary pattern matching p or one of its parent
+// directories, or "" when there is none.
+func (server *Server) matchCanary(p string) string {
+	for _, pattern := range server.CanaryPaths {
+		for dir := p; ; dir = path.Dir(dir) {
+			if ok, _ := path.Match(pattern, dir); ok {
+				return pattern
+			}
+			if dir == "/" || dir == "." {
+				break
+			}
+		}
+	}
+	return ""
+}
+
+// checkCanary fires OnCanary when p is a canary path.
+func (sess *Session) checkCanary(ctx *Context, p string) {
+	pattern := sess.server.matchCanary(p)
+	if pattern == "" {
+		return
+	}
+
+	sess.logf("canary path %s touched by %s", p, ctx.Cmd)
+	sess.server.notifiers.OnCanary(ctx, &CanaryEvent{
+		Time:        time.Now(),
+		SessionID:   sess.id,
+		User:        sess.user,
+		RemoteAddr:  sess.Conn.RemoteAddr(),
+		LocalAddr:   sess.Conn.LocalAddr(),
+		TLS:         sess.tls,
+		ClientSoft:  sess.clientSoft,
+		Command:     ctx.Cmd,
+		Path:        p,
+		Pattern:     pattern,
+		Fingerprint: sess.Fingerprint(),
+	})
+}
@@ -0,0 +1,70 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"strings"
+)
+
+// checksumAlgorithms are the hash algorithms Options.UploadChecksums accepts.
+var checksumAlgorithms = map[string]func() hash.Hash{
+	"crc32":  func() hash.Hash { return crc32.NewIEEE() },
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+func checkChecksumAlgorithms(algorithms []string) error {
+	for _, algorithm := range algorithms {
+		if _, ok := checksumAlgorithms[strings.ToLower(algorithm)]; !ok {
+			return fmt.Errorf("ftp: unknown checksum algorithm %q", algorithm)
+		}
+	}
+	return nil
+}
+
+// checksumReader hashes an upload as the driver reads it.
+type checksumReader struct {
+	r      io.Reader
+	names  []string
+	hashes []hash.Hash
+}
+
+func newChecksumReader(r io.Reader, algorithms []string) *checksumReader {
+	reader := &checksumReader{r: r}
+	for _, algorithm := range algorithms {
+		algorithm = strings.ToLower(algorithm)
+		reader.names = append(reader.names, algorithm)
+		reader.hashes = append(reader.hashes, checksumAlgorithms[algorithm]())
+	}
+	return reader
+}
+
+func (c *checksumReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	for _, h := range c.hashes {
+		h.Write(p[:n])
+	}
+	return n, err
+}
+
+// sums returns the hex digests of the data read so far by algorithm.
+func (c *checksumReader) sums() map[string]string {
+	sums := make(map[string]string, len(c.hashes))
+	for i, h := range c.hashes {
+		sums[c.names[i]] = hex.EncodeToString(h.Sum(nil))
+	}
+	return sums
+}
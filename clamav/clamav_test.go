@@ -0,0 +1,138 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package clamav
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/globalcyberalliance/ftp-go"
+	"github.com/globalcyberalliance/ftp-go/driver/file"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClamd answers INSTREAM scans, streams containing "EICAR" are infected.
+func fakeClamd(t *testing.T) *Client {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go serveClamd(conn)
+		}
+	}()
+	return NewClient("tcp", l.Addr().String())
+}
+
+func serveClamd(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	cmd, err := r.ReadString(0)
+	if err != nil {
+		return
+	}
+	if cmd == "zPING\x00" {
+		conn.Write([]byte("PONG\x00"))
+		return
+	}
+
+	var data bytes.Buffer
+	for {
+		var size uint32
+		if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+			return
+		}
+		if size == 0 {
+			break
+		}
+		if _, err := io.CopyN(&data, r, int64(size)); err != nil {
+			return
+		}
+	}
+	if bytes.Contains(data.Bytes(), []byte("EICAR")) {
+		conn.Write([]byte("stream: Eicar-Test-Signature FOUND\x00"))
+	} else {
+		conn.Write([]byte("stream: OK\x00"))
+	}
+}
+
+func TestScan(t *testing.T) {
+	client := fakeClamd(t)
+	client.ChunkSize = 4
+
+	assert.NoError(t, client.Ping(&ftp.Context{}))
+
+	virus, err := client.Scan(&ftp.Context{}, strings.NewReader("hello world"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, "", virus)
+
+	virus, err = client.Scan(&ftp.Context{}, strings.NewReader("X5O!EICAR-STANDARD"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, "Eicar-Test-Signature", virus)
+
+	_, err = parseScanReply("INSTREAM size limit exceeded. ERROR")
+	assert.EqualError(t, err, "clamav: INSTREAM size limit exceeded.")
+}
+
+func TestDriver(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "quarantine"), os.ModePerm))
+	base, err := file.NewDriver(dir)
+	assert.NoError(t, err)
+
+	driver := NewDriver(fakeClamd(t), base)
+	ctx := &ftp.Context{}
+
+	size, err := driver.PutFile(ctx, "/clean.txt", strings.NewReader("hello"), -1)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 5, size)
+	_, err = os.Stat(filepath.Join(dir, "clean.txt"))
+	assert.NoError(t, err)
+
+	_, err = driver.PutFile(ctx, "/virus.txt", strings.NewReader("X5O!EICAR-STANDARD"), -1)
+	assert.ErrorIs(t, err, ErrInfected)
+	var replyErr *ftp.ReplyError
+	if assert.True(t, errors.As(err, &replyErr)) {
+		assert.EqualValues(t, 550, replyErr.Code)
+		assert.EqualValues(t, "Virus found: Eicar-Test-Signature", replyErr.Message)
+	}
+	_, err = os.Stat(filepath.Join(dir, "virus.txt"))
+	assert.True(t, os.IsNotExist(err))
+
+	driver.QuarantineDir = "/quarantine"
+	_, err = driver.PutFile(ctx, "/virus.txt", strings.NewReader("X5O!EICAR-STANDARD"), -1)
+	assert.ErrorIs(t, err, ErrInfected)
+	quarantined, err := os.ReadDir(filepath.Join(dir, "quarantine"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, len(quarantined))
+
+	// clamd is down.
+	driver.client = NewClient("tcp", "127.0.0.1:1")
+	_, err = driver.PutFile(ctx, "/unscanned.txt", strings.NewReader("hello"), -1)
+	if assert.True(t, errors.As(err, &replyErr)) {
+		assert.EqualValues(t, 451, replyErr.Code)
+	}
+	_, err = os.Stat(filepath.Join(dir, "unscanned.txt"))
+	assert.True(t, os.IsNotExist(err))
+
+	driver.FailOpen = true
+	_, err = driver.PutFile(ctx, "/unscanned.txt", strings.NewReader("hello"), -1)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,187 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package clamav scans uploads for viruses with a clamd daemon. Driver wraps
+// another ftp.Driver and streams every upload to clamd as it is stored, files
+// found infected are deleted or quarantined and the client gets a 550 reply.
+package clamav
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+const defaultChunkSize = 32 << 10
+
+// Client talks to a clamd daemon over its unix or TCP socket.
+type Client struct {
+	// Network is "unix" or "tcp"
+	Network string
+
+	// Address of the clamd socket, e.g. "/var/run/clamav/clamd.ctl" or
+	// "127.0.0.1:3310"
+	Address string
+
+	// Timeout bounds a whole scan, 0 means no timeout.
+	Timeout time.Duration
+
+	// Size of the chunks streamed to clamd, defaults to 32KiB.
+	ChunkSize int
+}
+
+// NewClient creates a Client for the clamd socket at address
+func NewClient(network, address string) *Client {
+	return &Client{
+		Network: network,
+		Address: address,
+	}
+}
+
+func (client *Client) dial(ctx context.Context) (net.Conn, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, client.Network, client.Address)
+	if err != nil {
+		return nil, fmt.Errorf("clamav: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	return conn, nil
+}
+
+func (client *Client) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if client.Timeout > 0 {
+		return context.WithTimeout(ctx, client.Timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// Ping checks clamd is reachable.
+func (client *Client) Ping(ctx context.Context) error {
+	ctx, cancel := client.context(ctx)
+	defer cancel()
+
+	conn, err := client.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err = conn.Write([]byte("zPING\x00")); err != nil {
+		return fmt.Errorf("clamav: %w", err)
+	}
+	reply, err := readReply(conn)
+	if err != nil {
+		return err
+	}
+	if reply != "PONG" {
+		return fmt.Errorf("clamav: unexpected reply %q", reply)
+	}
+	return nil
+}
+
+// Scan streams r to clamd with the INSTREAM command. It returns the name
+// of the virus found, or "" when r is clean.
+func (client *Client) Scan(ctx context.Context, r io.Reader) (string, error) {
+	ctx, cancel := client.context(ctx)
+	defer cancel()
+
+	conn, err := client.dial(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	// Unblock the connection when ctx is cancelled.
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.SetDeadline(time.Now())
+	})
+	defer stop()
+
+	if err = client.stream(conn, r); err != nil {
+		// clamd answers before closing when it refuses the stream, for
+		// instance over its size limit.
+		if reply, replyErr := readReply(conn); replyErr == nil {
+			return parseScanReply(reply)
+		}
+		return "", err
+	}
+
+	reply, err := readReply(conn)
+	if err != nil {
+		return "", err
+	}
+	return parseScanReply(reply)
+}
+
+// stream sends r as INSTREAM chunks, each prefixed with its length.
+func (client *Client) stream(conn net.Conn, r io.Reader) error {
+	w := bufio.NewWriter(conn)
+	if _, err := w.WriteString("zINSTREAM\x00"); err != nil {
+		return fmt.Errorf("clamav: %w", err)
+	}
+
+	size := client.ChunkSize
+	if size <= 0 {
+		size = defaultChunkSize
+	}
+	buf := make([]byte, 4+size)
+	for {
+		n, err := io.ReadFull(r, buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf, uint32(n))
+			if _, werr := w.Write(buf[:4+n]); werr != nil {
+				return fmt.Errorf("clamav: %w", werr)
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// A zero length chunk ends the stream.
+	binary.BigEndian.PutUint32(buf, 0)
+	if _, err := w.Write(buf[:4]); err != nil {
+		return fmt.Errorf("clamav: %w", err)
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("clamav: %w", err)
+	}
+	return nil
+}
+
+// readReply reads a NUL terminated reply.
+func readReply(conn net.Conn) (string, error) {
+	reply, err := bufio.NewReader(conn).ReadBytes(0)
+	if err != nil && (err != io.EOF || len(reply) == 0) {
+		return "", fmt.Errorf("clamav: reading reply: %w", err)
+	}
+	return string(bytes.TrimRight(reply, "\x00\n")), nil
+}
+
+// parseScanReply parses "stream: OK", "stream: <name> FOUND" and
+// "<message> ERROR" replies.
+func parseScanReply(reply string) (string, error) {
+	reply = strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case reply == "OK":
+		return "", nil
+	case strings.HasSuffix(reply, " FOUND"):
+		return strings.TrimSuffix(reply, " FOUND"), nil
+	case strings.HasSuffix(reply, " ERROR"):
+		return "", errors.New("clamav: " + strings.TrimSuffix(reply, " ERROR"))
+	}
+	return "", fmt.Errorf("clamav: unexpected reply %q", reply)
+}
@@ -0,0 +1,125 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package clamav
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"time"
+
+	"github.com/globalcyberalliance/ftp-go"
+)
+
+// ErrInfected is wrapped in the error PutFile returns for infected files.
+var ErrInfected = errors.New("clamav: virus found")
+
+var _ ftp.Driver = &Driver{}
+
+// Driver wraps another ftp.Driver and scans every upload with clamd while
+// it is stored. Resumed uploads are scanned whole once stored.
+//
+// Infected files are deleted, or moved to QuarantineDir, and PutFile fails
+// with a 550 ftp.ReplyError wrapping ErrInfected. When the scan itself
+// fails the file is deleted and the client gets a 451 reply, unless
+// FailOpen is set.
+type Driver struct {
+	ftp.Driver
+	client *Client
+
+	// QuarantineDir is a directory of the wrapped driver infected files are
+	// moved to, they are deleted when blank.
+	QuarantineDir string
+
+	// FailOpen keeps uploads that could not be scanned.
+	FailOpen bool
+}
+
+// NewDriver creates a Driver scanning the uploads to base with client
+func NewDriver(client *Client, base ftp.Driver) *Driver {
+	return &Driver{
+		Driver: base,
+		client: client,
+	}
+}
+
+// PutFile implements ftp.Driver
+func (driver *Driver) PutFile(ctx *ftp.Context, p string, data io.Reader, offset int64) (int64, error) {
+	if offset > 0 {
+		size, err := driver.Driver.PutFile(ctx, p, data, offset)
+		if err != nil {
+			return size, err
+		}
+		return size, driver.check(ctx, p, driver.scanStored(ctx, p))
+	}
+
+	pr, pw := io.Pipe()
+	result := make(chan scanResult, 1)
+	go func() {
+		virus, err := driver.client.Scan(ctx, pr)
+		// Keep the upload flowing if clamd stopped reading early.
+		_, _ = io.Copy(ioutil.Discard, pr)
+		result <- scanResult{virus, err}
+	}()
+
+	size, err := driver.Driver.PutFile(ctx, p, io.TeeReader(data, pw), offset)
+	pw.Close()
+	scan := <-result
+	if err != nil {
+		return size, err
+	}
+	return size, driver.check(ctx, p, scan)
+}
+
+type scanResult struct {
+	virus string
+	err   error
+}
+
+// scanStored scans the file stored at p.
+func (driver *Driver) scanStored(ctx *ftp.Context, p string) scanResult {
+	_, r, err := driver.Driver.GetFile(ctx, p, 0)
+	if err != nil {
+		return scanResult{err: err}
+	}
+	defer r.Close()
+	virus, err := driver.client.Scan(ctx, r)
+	return scanResult{virus, err}
+}
+
+// check removes the file at p when the scan did not find it clean.
+func (driver *Driver) check(ctx *ftp.Context, p string, scan scanResult) error {
+	switch {
+	case scan.err != nil && driver.FailOpen:
+		return nil
+	case scan.err != nil:
+		if err := driver.Driver.DeleteFile(ctx, p); err != nil {
+			return fmt.Errorf("clamav: removing unscanned file: %w", err)
+		}
+		return &ftp.ReplyError{Code: 451, Message: "Virus scan failed", Err: scan.err}
+	case scan.virus == "":
+		return nil
+	}
+
+	if err := driver.remove(ctx, p); err != nil {
+		return fmt.Errorf("clamav: removing infected file: %w", err)
+	}
+	return &ftp.ReplyError{
+		Code:    550,
+		Message: "Virus found: " + scan.virus,
+		Err:     ErrInfected,
+	}
+}
+
+// remove deletes or quarantines an infected file.
+func (driver *Driver) remove(ctx *ftp.Context, p string) error {
+	if driver.QuarantineDir == "" {
+		return driver.Driver.DeleteFile(ctx, p)
+	}
+	name := fmt.Sprintf("%d-%s", time.Now().UnixNano(), path.Base(p))
+	return driver.Driver.Rename(ctx, p, path.Join(driver.QuarantineDir, name))
+}
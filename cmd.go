@@ -5,15 +5,14 @@
 package ftp
 
 import (
-	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
-	"log"
 	"os"
 	"path"
-	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Command represents a Command interface to a ftp command
@@ -25,6 +24,7 @@ type Command interface {
 }
 
 var defaultCommands = map[string]Command{
+	"ABOR": commandAbor{},
 	"ADAT": commandAdat{},
 	"ALLO": commandAllo{},
 	"APPE": commandAppe{},
@@ -39,6 +39,8 @@ var defaultCommands = map[string]Command{
 	"EPRT": commandEprt{},
 	"EPSV": commandEpsv{},
 	"FEAT": commandFeat{},
+	"HELP": commandHelp{},
+	"HOST": commandHost{},
 	"LIST": commandList{},
 	"LPRT": commandLprt{},
 	"NLST": commandNlst{},
@@ -61,6 +63,7 @@ var defaultCommands = map[string]Command{
 	"RNFR": commandRnfr{},
 	"RNTO": commandRnto{},
 	"RMD":  commandRmd{},
+	"SITE": commandSite{},
 	"SIZE": commandSize{},
 	"STAT": commandStat{},
 	"STOR": commandStor{},
@@ -80,10 +83,56 @@ func DefaultCommands() map[string]Command {
 	return defaultCommands
 }
 
-// commandAllo responds to the ALLO FTP command.
+// isActiveModeCommand reports whether the command opens an active mode data connection.
+func isActiveModeCommand(cmd string) bool {
+	return cmd == "PORT" || cmd == "EPRT" || cmd == "LPRT"
+}
+
+// checkActiveMode replies with an error and returns false if the session may
+// not open an active mode data connection.
+func checkActiveMode(sess *Session) bool {
+	if sess.server.DisableActiveMode {
+		sess.writeMessage(502, "Active mode is disabled")
+		return false
+	}
+	if sess.epsvAll {
+		sess.writeMessage(503, "EPSV ALL in effect, use EPSV")
+		return false
+	}
+	return true
+}
+
+// commandAbor responds to the ABOR FTP command.
 //
-// This is essentially a ping from the client so we just respond with an
-// basic OK message.
+// A transfer in progress is aborted while it runs, see Session.watchControl,
+// so by the time the command itself is executed there is only an unused
+// data connection left to close.
+type commandAbor struct{}
+
+func (cmd commandAbor) IsExtend() bool {
+	return false
+}
+
+func (cmd commandAbor) RequireParam() bool {
+	return false
+}
+
+func (cmd commandAbor) RequireAuth() bool {
+	return true
+}
+
+func (cmd commandAbor) Execute(sess *Session, param string) {
+	if sess.dataConn != nil {
+		sess.dataConn.Close()
+		sess.setDataConn(nil)
+	}
+	sess.writeMessage(226, "ABOR command successful")
+}
+
+// commandAllo responds to the ALLO FTP command, with which a client
+// announces the size of its next upload. Uploads exceeding the user's
+// transfer quota or the space a SpaceDriver reports are refused at once,
+// an AllocateDriver is asked by the upload itself.
 type commandAllo struct{}
 
 func (cmd commandAllo) IsExtend() bool {
@@ -95,15 +144,54 @@ func (cmd commandAllo) RequireParam() bool {
 }
 
 func (cmd commandAllo) RequireAuth() bool {
-	return false
+	return true
 }
 
 func (cmd commandAllo) Execute(sess *Session, param string) {
-	sess.writeMessage(202, "Obsolete")
+	sess.allocSize = 0
+	if param == "" {
+		sess.writeMessage(202, "No storage allocation necessary")
+		return
+	}
+
+	// The optional record size, "R <size>", is of no use
+	fields := strings.Fields(param)
+	size, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil || size < 0 {
+		sess.writeMessage(501, "Syntax error in parameters or arguments")
+		return
+	}
+
+	remaining, _, err := sess.QuotaRemaining()
+	if err != nil {
+		sess.logf("checking transfer quota: %v", err)
+		sess.writeMessage(451, "Checking transfer quota failed")
+		return
+	}
+	if remaining >= 0 && size > remaining {
+		sess.writeMessage(552, "Transfer quota exceeded")
+		return
+	}
+
+	_, available, hasSpace, err := sess.space("ALLO")
+	if err != nil {
+		sess.logf("checking storage usage: %v", err)
+		sess.writeMessage(451, "Checking storage usage failed")
+		return
+	}
+	if hasSpace && available >= 0 && size > available {
+		sess.writeMessage(552, "Exceeded storage allocation")
+		return
+	}
+
+	sess.allocSize = size
+	sess.writeMessage(200, "ALLO command successful")
 }
 
 // commandAppe responds to the APPE FTP command. It allows the user to upload a
-// new file but always append if file exists otherwise create one.
+// new file but always append if file exists otherwise create one, see
+// AppendDriver. After REST the upload is written from the given offset, as
+// for STOR.
 type commandAppe struct{}
 
 func (cmd commandAppe) IsExtend() bool {
@@ -119,31 +207,7 @@ func (cmd commandAppe) RequireAuth() bool {
 }
 
 func (cmd commandAppe) Execute(sess *Session, param string) {
-	targetPath := sess.buildPath(param)
-	sess.writeMessage(150, "Data transfer starting")
-
-	if sess.preCommand != "REST" {
-		sess.lastFilePos = -1
-	}
-	defer func() {
-		sess.lastFilePos = -1
-	}()
-
-	ctx := Context{
-		Sess:  sess,
-		Cmd:   "APPE",
-		Param: param,
-		Data:  make(map[string]interface{}),
-	}
-	sess.server.notifiers.BeforePutFile(&ctx, targetPath)
-	size, err := sess.server.Driver.PutFile(&ctx, targetPath, sess.dataConn, sess.lastFilePos)
-	sess.server.notifiers.AfterFilePut(&ctx, targetPath, size, err)
-	if err == nil {
-		msg := fmt.Sprintf("OK, received %d bytes", size)
-		sess.writeMessage(226, msg)
-	} else {
-		sess.writeMessage(450, fmt.Sprint("error during transfer: ", err))
-	}
+	executePut("APPE", sess, param)
 }
 
 type commandCLNT struct{}
@@ -162,6 +226,7 @@ func (cmd commandCLNT) RequireAuth() bool {
 
 func (cmd commandCLNT) Execute(sess *Session, param string) {
 	sess.clientSoft = param
+	sess.detectQuirks(param, "")
 	sess.writeMessage(200, "OK")
 }
 
@@ -212,7 +277,50 @@ func (cmd commandFeat) RequireAuth() bool {
 }
 
 func (cmd commandFeat) Execute(sess *Session, param string) {
-	sess.writeMessageMultiline(211, sess.server.feats)
+	sess.writeFeatures()
+}
+
+// commandHost responds to the HOST FTP command, see RFC 7151.
+//
+// Selects the virtual host the client wants, before it logs in.
+type commandHost struct{}
+
+func (cmd commandHost) IsExtend() bool {
+	return true
+}
+
+func (cmd commandHost) RequireParam() bool {
+	return true
+}
+
+func (cmd commandHost) RequireAuth() bool {
+	return false
+}
+
+func (cmd commandHost) Execute(sess *Session, param string) {
+	if sess.reqUser != "" || sess.IsLogin() {
+		sess.writeMessage(503, "HOST must be sent before USER")
+		return
+	}
+	// Servers without virtual hosts accept any name, as RFC 7151 advises
+	if len(sess.server.virtualHosts) == 0 {
+		sess.writeMessage(220, "Host accepted")
+		return
+	}
+
+	host := sess.server.virtualHost(param)
+	if host == nil {
+		sess.writeMessage(504, fmt.Sprintf("Unknown host %s", param))
+		return
+	}
+	if state, ok := sess.TLSConnectionState(); ok {
+		if tlsHost := sess.server.virtualHost(state.ServerName); tlsHost != nil && tlsHost != host {
+			sess.writeMessage(504, fmt.Sprintf("Host %s does not match the TLS server name", param))
+			return
+		}
+	}
+	sess.vhost = host
+	sess.writeMessage(220, "Host accepted")
 }
 
 // cmdCdup responds to the CDUP FTP command.
@@ -233,8 +341,7 @@ func (cmd commandCdup) RequireAuth() bool {
 }
 
 func (cmd commandCdup) Execute(sess *Session, param string) {
-	otherCmd := &commandCwd{}
-	otherCmd.Execute(sess, "..")
+	executeCwd("CDUP", sess, "..")
 }
 
 // commandCwd responds to the CWD FTP command. It allows the client to change the
@@ -254,32 +361,31 @@ func (cmd commandCwd) RequireAuth() bool {
 }
 
 func (cmd commandCwd) Execute(sess *Session, param string) {
+	executeCwd("CWD", sess, param)
+}
+
+// executeCwd changes the current directory for the CWD and CDUP commands.
+func executeCwd(cmd string, sess *Session, param string) {
 	buildPath := sess.buildPath(param)
 	ctx := Context{
 		Sess:  sess,
-		Cmd:   "CWD",
+		Cmd:   cmd,
 		Param: param,
-		Data:  make(map[string]interface{}),
-	}
-	info, err := sess.server.Driver.Stat(&ctx, buildPath)
-	if err != nil {
-		sess.logf("%v", err)
-		sess.writeMessage(550, fmt.Sprint("Directory change to ", buildPath, " failed."))
-		return
-	}
-	if !info.IsDir() {
-		sess.writeMessage(550, fmt.Sprint("Directory change to ", buildPath, " is a file"))
-		return
+		Data:  NewStore(),
 	}
 
-	sess.server.notifiers.BeforeChangeCurDir(&ctx, sess.curDir, buildPath)
-	err = sess.changeCurDir(buildPath)
-	sess.server.notifiers.AfterCurDirChanged(&ctx, sess.curDir, buildPath, err)
+	oldDir := sess.curDir
+	sess.server.notifiers.BeforeChangeCurDir(&ctx, oldDir, buildPath)
+	err := sess.server.notifiers.Intercept(&ctx, buildPath)
+	if err == nil {
+		err = sess.changeCurDir(&ctx, buildPath)
+	}
+	sess.server.notifiers.AfterCurDirChanged(&ctx, oldDir, buildPath, err)
 	if err == nil {
 		sess.writeMessage(250, "Directory changed to "+buildPath)
 	} else {
 		sess.logf("%v", err)
-		sess.writeMessage(550, fmt.Sprint("Directory change to ", buildPath, " failed."))
+		sess.writeError(err, 550, fmt.Sprint("Directory change to ", buildPath, " failed."))
 	}
 }
 
@@ -305,16 +411,19 @@ func (cmd commandDele) Execute(sess *Session, param string) {
 		Sess:  sess,
 		Cmd:   "DELE",
 		Param: param,
-		Data:  make(map[string]interface{}),
+		Data:  NewStore(),
 	}
 	sess.server.notifiers.BeforeDeleteFile(&ctx, buildPath)
-	err := sess.server.Driver.DeleteFile(&ctx, buildPath)
+	err := sess.server.notifiers.Intercept(&ctx, buildPath)
+	if err == nil {
+		err = sess.driver().DeleteFile(&ctx, buildPath)
+	}
 	sess.server.notifiers.AfterFileDeleted(&ctx, buildPath, err)
 	if err == nil {
 		sess.writeMessage(250, "File deleted")
 	} else {
 		sess.logf("%v", err)
-		sess.writeMessage(550, "File delete failed. ")
+		sess.writeError(err, 550, "File delete failed. ")
 	}
 }
 
@@ -336,6 +445,10 @@ func (cmd commandEprt) RequireAuth() bool {
 }
 
 func (cmd commandEprt) Execute(sess *Session, param string) {
+	if !checkActiveMode(sess) {
+		return
+	}
+
 	delim := string(param[0:1])
 	parts := strings.Split(param, delim)
 	addressFamily, err := strconv.Atoi(parts[1])
@@ -356,10 +469,10 @@ func (cmd commandEprt) Execute(sess *Session, param string) {
 	}
 	socket, err := newActiveSocket(sess, host, port)
 	if err != nil {
-		sess.writeMessage(425, "Data connection failed")
+		sess.writeDataConnError(err)
 		return
 	}
-	sess.dataConn = socket
+	sess.setDataConn(socket)
 	sess.writeMessage(200, "Connection established ("+strconv.Itoa(port)+")")
 }
 
@@ -381,6 +494,10 @@ func (cmd commandLprt) RequireAuth() bool {
 }
 
 func (cmd commandLprt) Execute(sess *Session, param string) {
+	if !checkActiveMode(sess) {
+		return
+	}
+
 	// No tests for this code yet
 
 	parts := strings.Split(param, ",")
@@ -431,16 +548,22 @@ func (cmd commandLprt) Execute(sess *Session, param string) {
 
 	socket, err := newActiveSocket(sess, host, port)
 	if err != nil {
-		sess.writeMessage(425, "Data connection failed")
+		sess.writeDataConnError(err)
 		return
 	}
-	sess.dataConn = socket
+	sess.setDataConn(socket)
 	sess.writeMessage(200, "Connection established ("+strconv.Itoa(port)+")")
 }
 
 // commandEpsv responds to the EPSV FTP command. It allows the client to
 // request a passive data socket with more options than the original PASV
-// command. It mainly adds ipv6 support, although we don't support that yet.
+// command. The reply only holds the port, the client connecting to the
+// address of the control connection, so it works over IPv6 too.
+//
+// "EPSV ALL" tells the server the client will only use EPSV from now on, so
+// every other data connection setup command is refused. "EPSV 1" and
+// "EPSV 2" ask for IPv4 and IPv6, which must be the network protocol of the
+// control connection (RFC 2428).
 type commandEpsv struct{}
 
 func (cmd commandEpsv) IsExtend() bool {
@@ -456,10 +579,20 @@ func (cmd commandEpsv) RequireAuth() bool {
 }
 
 func (cmd commandEpsv) Execute(sess *Session, param string) {
+	if strings.EqualFold(param, "ALL") {
+		sess.epsvAll = true
+		sess.writeMessage(200, "EPSV ALL command successful")
+		return
+	}
+	if proto := sess.controlNetProtocol(); param != "" && param != strconv.Itoa(proto) {
+		sess.writeMessage(522, fmt.Sprintf("Network protocol not supported, use (%d)", proto))
+		return
+	}
+
 	socket, err := sess.newPassiveSocket()
 	if err != nil {
 		sess.log(err)
-		sess.writeMessage(425, "Data connection failed")
+		sess.writeDataConnError(err)
 		return
 	}
 
@@ -483,81 +616,54 @@ func (cmd commandList) RequireAuth() bool {
 	return true
 }
 
-func convertFileInfo(sess *Session, f os.FileInfo, p string) (FileInfo, error) {
-	mode, err := sess.server.Perm.GetMode(p)
+func convertFileInfo(sess *Session, ctx *Context, f os.FileInfo, p string) (FileInfo, error) {
+	var (
+		owner, group string
+		mode         os.FileMode
+		err          error
+	)
+	if driver, ok := sess.baseDriver().(OwnerDriver); ok {
+		owner, group, mode, err = driver.FileOwner(ctx, sess.realPath(p), f)
+	} else {
+		mode, err = sess.server.Perm.GetMode(p)
+	}
 	if err != nil {
 		return nil, err
 	}
 	if f.IsDir() {
 		mode |= os.ModeDir
 	}
-	owner, err := sess.server.Perm.GetOwner(p)
-	if err != nil {
-		return nil, err
+	if f.Mode()&os.ModeSymlink != 0 {
+		mode |= os.ModeSymlink
 	}
-	group, err := sess.server.Perm.GetGroup(p)
-	if err != nil {
-		return nil, err
+	if owner == "" {
+		if owner, err = sess.server.Perm.GetOwner(p); err != nil {
+			return nil, err
+		}
+	}
+	if group == "" {
+		if group, err = sess.server.Perm.GetGroup(p); err != nil {
+			return nil, err
+		}
 	}
 	return &fileInfo{
 		FileInfo: f,
 		mode:     mode,
 		owner:    owner,
 		group:    group,
+		target:   sess.linkTarget(ctx, f, p),
 	}, nil
 }
 
-func list(sess *Session, cmd, p, param string) ([]FileInfo, error) {
-	ctx := &Context{
-		Sess:  sess,
-		Cmd:   cmd,
-		Param: param,
-		Data:  make(map[string]interface{}),
-	}
-	info, err := sess.server.Driver.Stat(ctx, p)
-	if err != nil {
-		return nil, err
-	}
-
-	if info == nil {
-		sess.logf("%s: no such file or directory.\n", p)
-		return []FileInfo{}, nil
-	}
-
-	var files []FileInfo
-	if info.IsDir() {
-		err = sess.server.Driver.ListDir(ctx, p, func(f os.FileInfo) error {
-			info, err := convertFileInfo(sess, f, path.Join(p, f.Name()))
-			if err != nil {
-				return err
-			}
-			files = append(files, info)
-			return nil
-		})
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		newInfo, err := convertFileInfo(sess, info, p)
-		if err != nil {
-			return nil, err
-		}
-		files = append(files, newInfo)
-	}
-	return files, nil
-}
-
 func (cmd commandList) Execute(sess *Session, param string) {
-	p := sess.buildPath(parseListParam(param))
-
-	files, err := list(sess, "LIST", p, param)
-	if err != nil {
-		sess.writeMessage(550, err.Error())
-		return
-	}
-
-	sess.writeMessage(150, "Opening ASCII mode data connection for file list")
-	sess.sendOutofbandData(listFormatter(files).Detailed())
+	sess.sendListing(listing{
+		cmd:    "LIST",
+		path:   sess.buildPath(parseListParam(param)),
+		param:  param,
+		all:    listAll(param),
+		detail: true,
+		format: sess.listFormat.writer(sess.listStyle()),
+	})
 }
 
 func parseListParam(param string) (path string) {
@@ -594,61 +700,14 @@ func (cmd commandNlst) RequireAuth() bool {
 }
 
 func (cmd commandNlst) Execute(sess *Session, param string) {
-	ctx := &Context{
-		Sess:  sess,
-		Cmd:   "NLST",
-		Param: param,
-		Data:  make(map[string]interface{}),
-	}
-
-	buildPath := sess.buildPath(parseListParam(param))
-	info, err := sess.server.Driver.Stat(ctx, buildPath)
-	if err != nil {
-		sess.writeMessage(550, err.Error())
-		return
-	}
-	if !info.IsDir() {
-		sess.writeMessage(550, param+" is not a directory")
-		return
-	}
-
-	var files []FileInfo
-	err = sess.server.Driver.ListDir(ctx, buildPath, func(f os.FileInfo) error {
-		mode, err := sess.server.Perm.GetMode(buildPath)
-		if err != nil {
-			return err
-		}
-
-		if info.IsDir() {
-			mode |= os.ModeDir
-		}
-
-		owner, err := sess.server.Perm.GetOwner(buildPath)
-		if err != nil {
-			return err
-		}
-
-		group, err := sess.server.Perm.GetGroup(buildPath)
-		if err != nil {
-			return err
-		}
-
-		files = append(files, &fileInfo{
-			FileInfo: f,
-			mode:     mode,
-			owner:    owner,
-			group:    group,
-		})
-
-		return nil
+	sess.sendListing(listing{
+		cmd:     "NLST",
+		path:    sess.buildPath(parseListParam(param)),
+		param:   param,
+		all:     listAll(param),
+		dirOnly: true,
+		format:  writeShort,
 	})
-	if err != nil {
-		sess.writeMessage(550, err.Error())
-		return
-	}
-
-	sess.writeMessage(150, "Opening ASCII mode data connection for file list")
-	sess.sendOutofbandData(listFormatter(files).Short())
 }
 
 // commandMdtm responds to the MDTM FTP command. It allows the client to
@@ -668,18 +727,12 @@ func (cmd commandMdtm) RequireAuth() bool {
 }
 
 func (cmd commandMdtm) Execute(sess *Session, param string) {
-	buildPath := sess.buildPath(param)
-	stat, err := sess.server.Driver.Stat(&Context{
-		Sess:  sess,
-		Cmd:   "MDTM",
-		Param: param,
-		Data:  make(map[string]interface{}),
-	}, buildPath)
-	if err == nil {
-		sess.writeMessage(213, stat.ModTime().Format("20060102150405"))
-	} else {
-		sess.writeMessage(450, "File not available")
+	stat, err := sess.statFile("MDTM", param)
+	if err != nil {
+		sess.writeError(err, 550, "File not available")
+		return
 	}
+	sess.writeMessage(213, sess.listStyle().time(stat.ModTime()).Format("20060102150405"))
 }
 
 // commandMkd responds to the MKD FTP command. It allows the client to create
@@ -704,15 +757,18 @@ func (cmd commandMkd) Execute(sess *Session, param string) {
 		Sess:  sess,
 		Cmd:   "MKD",
 		Param: param,
-		Data:  make(map[string]interface{}),
+		Data:  NewStore(),
 	}
 	sess.server.notifiers.BeforeCreateDir(&ctx, buildPath)
-	err := sess.server.Driver.MakeDir(&ctx, buildPath)
+	err := sess.server.notifiers.Intercept(&ctx, buildPath)
+	if err == nil {
+		err = sess.driver().MakeDir(&ctx, buildPath)
+	}
 	sess.server.notifiers.AfterDirCreated(&ctx, buildPath, err)
 	if err == nil {
 		sess.writeMessage(257, "Directory created")
 	} else {
-		sess.writeMessage(550, fmt.Sprint("Action not taken: ", err))
+		sess.writeError(err, 550, fmt.Sprint("Action not taken: ", err))
 	}
 }
 
@@ -786,7 +842,7 @@ func (cmd commandPass) Execute(sess *Session, param string) {
 	auth := sess.server.Auth
 
 	// If the driver implements Auth, call that instead of the server version.
-	if driverAuth, found := sess.server.Driver.(Auth); found {
+	if driverAuth, found := sess.baseDriver().(Auth); found {
 		auth = driverAuth
 	}
 
@@ -794,21 +850,35 @@ func (cmd commandPass) Execute(sess *Session, param string) {
 		Sess:  sess,
 		Cmd:   "PASS",
 		Param: param,
-		Data:  make(map[string]interface{}),
+		Data:  NewStore(),
 	}
 
 	ok, err := auth.CheckPasswd(&ctx, sess.reqUser, param)
 	sess.server.notifiers.AfterUserLogin(&ctx, sess.reqUser, param, ok, err)
+	if errors.Is(err, ErrAccountExpired) {
+		sess.server.notifiers.OnAccountExpired(&ctx, sess.reqUser)
+	}
 	if err != nil {
-		sess.writeMessage(550, "Checking password error")
+		sess.writeError(err, 550, "Checking password error")
 		return
 	}
 
 	if ok {
+		if isAnonymousUser(sess.reqUser) {
+			sess.detectQuirks("", param)
+		}
+		sess.server.logins.Add(1)
+		sess.tarpitLogin()
 		sess.user = sess.reqUser
 		sess.reqUser = ""
-		sess.writeMessage(230, "Password ok, continue")
+		sess.writeMessageLines(230, sess.loginMessage(&ctx), "Password ok, continue")
 	} else {
+		sess.server.failedLogins.Add(1)
+		if sess.tarpitFailedLogin() {
+			sess.writeMessage(421, "Too many failed logins, closing control connection")
+			sess.Close()
+			return
+		}
 		sess.writeMessage(530, "Incorrect password, not logged in")
 	}
 }
@@ -832,17 +902,26 @@ func (cmd commandPasv) RequireAuth() bool {
 }
 
 func (cmd commandPasv) Execute(sess *Session, param string) {
-	listenIP := sess.passiveListenIP()
+	if sess.epsvAll {
+		sess.writeMessage(503, "EPSV ALL in effect, use EPSV")
+		return
+	}
 
-	// TODO: IPv6 for this command is not implemented
-	if strings.HasPrefix(listenIP, "::") {
-		sess.writeMessage(550, "Action not taken")
+	// PASV replies only hold IPv4 addresses, IPv6 clients use EPSV
+	// (RFC 2428).
+	if sess.controlNetProtocol() != 1 {
+		sess.writeMessage(522, "Network protocol not supported, use EPSV")
+		return
+	}
+	listenIP := sess.passiveListenIP()
+	if listenIP == "" {
+		sess.writeMessage(425, "No IPv4 address to announce, use EPSV")
 		return
 	}
 
 	socket, err := sess.newPassiveSocket()
 	if err != nil {
-		sess.writeMessage(425, "Data connection failed")
+		sess.writeDataConnError(err)
 		return
 	}
 
@@ -874,6 +953,10 @@ func (cmd commandPort) RequireAuth() bool {
 }
 
 func (cmd commandPort) Execute(sess *Session, param string) {
+	if !checkActiveMode(sess) {
+		return
+	}
+
 	nums := strings.Split(param, ",")
 	portOne, _ := strconv.Atoi(nums[4])
 	portTwo, _ := strconv.Atoi(nums[5])
@@ -882,11 +965,11 @@ func (cmd commandPort) Execute(sess *Session, param string) {
 
 	socket, err := newActiveSocket(sess, host, port)
 	if err != nil {
-		sess.writeMessage(425, "Data connection failed")
+		sess.writeDataConnError(err)
 		return
 	}
 
-	sess.dataConn = socket
+	sess.setDataConn(socket)
 	sess.writeMessage(200, "Connection established ("+strconv.Itoa(port)+")")
 }
 
@@ -928,6 +1011,8 @@ func (cmd commandQuit) RequireAuth() bool {
 }
 
 func (cmd commandQuit) Execute(sess *Session, param string) {
+	// Sessions which end cleanly are not to be resumed
+	sess.revokeTicket()
 	sess.writeMessage(221, "Goodbye")
 	sess.Close()
 }
@@ -958,32 +1043,96 @@ func (cmd commandRetr) Execute(sess *Session, param string) {
 	defer func() {
 		sess.lastFilePos = -1
 	}()
+	if sess.dataConn == nil {
+		sess.writeMessage(425, "Can't open data connection")
+		return
+	}
+	release, ok := sess.acquireTransfer()
+	if !ok {
+		return
+	}
+	defer release()
 
 	ctx := Context{
 		Sess:  sess,
 		Cmd:   "RETR",
 		Param: param,
-		Data:  make(map[string]interface{}),
+		Data:  NewStore(),
 	}
 
+	sess.checkCanary(&ctx, buildPath)
 	sess.server.notifiers.BeforeDownloadFile(&ctx, buildPath)
+	if err := sess.server.notifiers.Intercept(&ctx, buildPath); err != nil {
+		sess.server.notifiers.AfterFileDownloaded(&ctx, buildPath, 0, err)
+		if sess.dataConn != nil {
+			sess.dataConn.Close()
+			sess.setDataConn(nil)
+		}
+		sess.writeError(err, 550, fmt.Sprint("Action not taken: ", err))
+		return
+	}
+
 	readPos := sess.lastFilePos
 	if readPos < 0 {
 		readPos = 0
 	}
 
-	size, data, err := sess.server.Driver.GetFile(&ctx, buildPath, readPos)
+	_, remaining, err := sess.QuotaRemaining()
+	if err != nil {
+		sess.logf("checking transfer quota: %v", err)
+		if sess.dataConn != nil {
+			sess.dataConn.Close()
+			sess.setDataConn(nil)
+		}
+		sess.writeMessage(451, "Checking transfer quota failed")
+		return
+	}
+	// Resumed downloads only send, and are only charged, from the offset
+	if remaining >= 0 {
+		if info, err := sess.driver().Stat(&ctx, buildPath); err == nil && info.Size()-readPos > remaining {
+			sess.server.notifiers.AfterFileDownloaded(&ctx, buildPath, 0, ErrQuotaExceeded)
+			if sess.dataConn != nil {
+				sess.dataConn.Close()
+				sess.setDataConn(nil)
+			}
+			sess.writeMessage(552, "Transfer quota exceeded")
+			return
+		}
+	}
+
+	size, data, err := sess.driver().GetFile(&ctx, buildPath, readPos)
+
 	if err == nil {
 		defer data.Close()
+		staged, closeStages, err := sess.stageTransfer(&ctx, TransferDownload, buildPath, data)
+		if err != nil {
+			sess.server.notifiers.AfterFileDownloaded(&ctx, buildPath, 0, err)
+			if sess.dataConn != nil {
+				sess.dataConn.Close()
+				sess.setDataConn(nil)
+			}
+			sess.writeError(err, 550, fmt.Sprint("Action not taken: ", err))
+			return
+		}
+		defer closeStages()
 		sess.writeMessage(150, fmt.Sprintf("Data transfer starting %d bytes", size))
-		err = sess.sendOutofBandDataWriter(data)
+		var sent int64
+		stopWatch := sess.watchControl(sess.dataConn)
+		counted, endTransfer := sess.startTransfer(TransferDownload, buildPath, staged)
+		sent, err = sess.sendOutofBandDataWriter(counted)
+		endTransfer()
+		stopWatch()
+		sess.addTransferred(0, sent)
 		sess.server.notifiers.AfterFileDownloaded(&ctx, buildPath, size, err)
-		if err != nil {
-			sess.writeMessage(551, "Error reading file")
+		if err != nil && sess.aborted() {
+			sess.server.notifiers.AfterTransferAborted(&ctx, buildPath, sent)
+			sess.writeMessage(426, "Connection closed; transfer aborted")
+		} else if err != nil {
+			sess.writeError(err, 551, "Error reading file")
 		}
 	} else {
 		sess.server.notifiers.AfterFileDownloaded(&ctx, buildPath, size, err)
-		sess.writeMessage(551, "File not available")
+		sess.writeError(err, 551, "File not available")
 	}
 }
 
@@ -1031,17 +1180,17 @@ func (cmd commandRnfr) RequireAuth() bool {
 func (cmd commandRnfr) Execute(sess *Session, param string) {
 	sess.renameFrom = ""
 	p := sess.buildPath(param)
-	if _, err := sess.server.Driver.Stat(&Context{
+	if _, err := sess.driver().Stat(&Context{
 		Sess:  sess,
 		Cmd:   "RNFR",
 		Param: param,
-		Data:  make(map[string]interface{}),
+		Data:  NewStore(),
 	}, p); err != nil {
-		sess.writeMessage(550, fmt.Sprint("Action not taken: ", err))
+		sess.writeError(err, 550, fmt.Sprint("Action not taken: ", err))
 		return
 	}
 
-	sess.renameFrom = p
+	sess.renameFrom, sess.renameAt = p, time.Now()
 	sess.writeMessage(350, "Requested file action pending further information.")
 }
 
@@ -1062,21 +1211,37 @@ func (cmd commandRnto) RequireAuth() bool {
 }
 
 func (cmd commandRnto) Execute(sess *Session, param string) {
+	fromPath := sess.renameFrom
+	sess.renameFrom = ""
+	if timeout := sess.server.RenameTimeout; timeout > 0 && time.Since(sess.renameAt) > timeout {
+		fromPath = ""
+	}
+	if fromPath == "" {
+		sess.writeMessage(503, "Bad sequence of commands, send RNFR first")
+		return
+	}
+
 	toPath := sess.buildPath(param)
-	err := sess.server.Driver.Rename(&Context{
+	ctx := Context{
 		Sess:  sess,
 		Cmd:   "RNTO",
 		Param: param,
-		Data:  make(map[string]interface{}),
-	}, sess.renameFrom, toPath)
-	defer func() {
-		sess.renameFrom = ""
-	}()
+		Data:  NewStore(),
+	}
+	sess.server.notifiers.BeforeRename(&ctx, fromPath, toPath)
+	err := sess.server.notifiers.Intercept(&ctx, fromPath)
+	if err == nil {
+		err = sess.server.notifiers.Intercept(&ctx, toPath)
+	}
+	if err == nil {
+		err = sess.rename(&ctx, fromPath, toPath)
+	}
+	sess.server.notifiers.AfterRename(&ctx, fromPath, toPath, err)
 
 	if err == nil {
 		sess.writeMessage(250, "File renamed")
 	} else {
-		sess.writeMessage(550, fmt.Sprint("Action not taken: ", err))
+		sess.writeError(err, 550, fmt.Sprint("Action not taken: ", err))
 	}
 }
 
@@ -1125,7 +1290,7 @@ func executeRmd(cmd string, sess *Session, param string) {
 		Sess:  sess,
 		Cmd:   cmd,
 		Param: param,
-		Data:  make(map[string]interface{}),
+		Data:  NewStore(),
 	}
 
 	if param == "/" || param == "" {
@@ -1136,8 +1301,11 @@ func executeRmd(cmd string, sess *Session, param string) {
 	needChangeCurDir := strings.HasPrefix(param, sess.curDir)
 
 	sess.server.notifiers.BeforeDeleteDir(&ctx, p)
-	err := sess.server.Driver.DeleteDir(&ctx, p)
-	if needChangeCurDir {
+	err := sess.server.notifiers.Intercept(&ctx, p)
+	if err == nil {
+		err = sess.driver().DeleteDir(&ctx, p)
+	}
+	if err == nil && needChangeCurDir {
 		sess.curDir = path.Dir(param)
 	}
 
@@ -1145,7 +1313,7 @@ func executeRmd(cmd string, sess *Session, param string) {
 	if err == nil {
 		sess.writeMessage(250, "Directory deleted")
 	} else {
-		sess.writeMessage(550, fmt.Sprint("Directory delete failed: ", err))
+		sess.writeError(err, 550, fmt.Sprint("Directory delete failed: ", err))
 	}
 }
 
@@ -1261,44 +1429,17 @@ func (cmd commandMLSD) RequireAuth() bool {
 	return true
 }
 
-func toMLSDFormat(files []FileInfo) []byte {
-	var buf bytes.Buffer
-	for _, file := range files {
-		fileType := "file"
-		if file.IsDir() {
-			fileType = "dir"
-		}
-		/*Possible facts "Size" / "Modify" / "Create" /
-				  "Type" / "Unique" / "Perm" /
-				  "Lang" / "Media-Type" / "CharSet"
-				  TODO: Perm pvals        = "a" / "c" / "d" / "e" / "f" /
-		                     "l" / "m" / "p" / "r" / "w"
-		*/
-		fmt.Fprintf(&buf,
-			"Type=%s;Modify=%s;Size=%d; %s\n",
-			fileType,
-			file.ModTime().Format("20060102150405"),
-			file.Size(),
-			file.Name(),
-		)
-	}
-	return buf.Bytes()
-}
-
 func (cmd commandMLSD) Execute(sess *Session, param string) {
 	if param == "" {
 		param = sess.curDir
 	}
-	p := sess.buildPath(param)
-
-	files, err := list(sess, "MLSD", p, param)
-	if err != nil {
-		sess.writeMessage(550, err.Error())
-		return
-	}
-
-	sess.writeMessage(150, "Opening ASCII mode data connection for file list")
-	sess.sendOutofbandData(toMLSDFormat(files))
+	sess.sendListing(listing{
+		cmd:    "MLSD",
+		path:   sess.buildPath(param),
+		param:  param,
+		detail: true,
+		format: sess.listStyle().writeMLSD,
+	})
 }
 
 type commandPbsz struct{}
@@ -1382,19 +1523,31 @@ func (cmd commandSize) RequireAuth() bool {
 }
 
 func (cmd commandSize) Execute(sess *Session, param string) {
-	buildPath := sess.buildPath(param)
-	stat, err := sess.server.Driver.Stat(&Context{
+	stat, err := sess.statFile("SIZE", param)
+	if err != nil {
+		sess.writeError(err, 550, fmt.Sprintf("path %s not found", param))
+		return
+	}
+	sess.writeMessage(213, strconv.FormatInt(stat.Size(), 10))
+}
+
+// statFile returns the info of the file at param for cmd, which SIZE and
+// MDTM only report for files (RFC 3659): directories are answered ErrIsDir
+// and missing paths ErrNotFound, both with 550.
+func (sess *Session) statFile(cmd, param string) (os.FileInfo, error) {
+	stat, err := sess.driver().Stat(&Context{
 		Sess:  sess,
-		Cmd:   "SIZE",
+		Cmd:   cmd,
 		Param: param,
-		Data:  make(map[string]interface{}),
-	}, buildPath)
+		Data:  NewStore(),
+	}, sess.buildPath(param))
 	if err != nil {
-		log.Printf("Size: error(%s)", err)
-		sess.writeMessage(450, fmt.Sprintf("path %s not found", param))
-	} else {
-		sess.writeMessage(213, strconv.Itoa(int(stat.Size())))
+		return nil, err
+	}
+	if stat.IsDir() {
+		return nil, ErrIsDir
 	}
+	return stat, nil
 }
 
 // commandStat responds to the STAT FTP command. It returns the stat of the
@@ -1416,13 +1569,17 @@ func (cmd commandStat) RequireAuth() bool {
 func (cmd commandStat) Execute(sess *Session, param string) {
 	// System stat.
 	if param == "" {
-		sess.writeMessage(211, fmt.Sprintf("%s FTP server status:\nVersion %s"+
-			"Connected to %s (%s)\n"+
-			"Logged in %s\n"+
-			"TYPE: ASCII, FORM: Nonprint; STRUcture: File; transfer MODE: Stream\n"+
-			"No data connection", sess.PublicIP(), version, sess.PublicIP(),
-			version, sess.LoginUser()))
-		sess.writeMessage(211, "End of status")
+		dataConn := "No data connection"
+		if sess.dataConn != nil {
+			dataConn = "Data connection open"
+		}
+		sess.writeMessageMultiline(211, fmt.Sprintf("FTP server status:\n"+
+			" Version %s\n"+
+			" Connected to %s from %s\n"+
+			" Logged in as %s\n"+
+			" TYPE: ASCII, FORM: Nonprint; STRUcture: File; transfer MODE: Stream\n"+
+			" %s", version, sess.PublicIP(), addrIP(sess.RemoteAddr()),
+			sess.LoginUser(), dataConn), "End of status")
 		return
 	}
 
@@ -1430,45 +1587,45 @@ func (cmd commandStat) Execute(sess *Session, param string) {
 		Sess:  sess,
 		Cmd:   "STAT",
 		Param: param,
-		Data:  make(map[string]interface{}),
+		Data:  NewStore(),
 	}
 
-	// File or directory stat.
+	// File or directory stat, listed over the control connection.
 	buildPath := sess.buildPath(param)
 
-	stat, err := sess.server.Driver.Stat(&ctx, buildPath)
+	stat, err := sess.driver().Stat(&ctx, buildPath)
 	if err != nil {
-		log.Printf("Size: error(%s)", err)
-		sess.writeMessage(450, fmt.Sprintf("path %s not found", buildPath))
-	} else {
-		var files []FileInfo
-
-		if stat.IsDir() {
-			err = sess.server.Driver.ListDir(&ctx, buildPath, func(f os.FileInfo) error {
-				info, err := convertFileInfo(sess, f, filepath.Join(buildPath, f.Name()))
-				if err != nil {
-					return err
-				}
-				files = append(files, info)
-				return nil
-			})
-			if err != nil {
-				sess.writeMessage(550, err.Error())
-				return
-			}
-			sess.writeMessage(213, "Opening ASCII mode data connection for file list")
-		} else {
-			info, err := convertFileInfo(sess, stat, buildPath)
+		sess.writeError(err, 450, fmt.Sprintf("path %s not found", buildPath))
+		return
+	}
+
+	var files []FileInfo
+	last := "End of status"
+	if stat.IsDir() {
+		err = sess.listDir(&ctx, buildPath, false, func(entry Entry) error {
+			info, err := convertFileInfo(sess, &ctx, entry.FileInfo, entry.Path)
 			if err != nil {
-				sess.writeMessage(550, err.Error())
-				return
+				return err
 			}
-
 			files = append(files, info)
-			sess.writeMessage(212, "Opening ASCII mode data connection for file list")
+			return nil
+		})
+		if errors.Is(err, ErrListTruncated) {
+			last = fmt.Sprintf("End of status, truncated at %d entries", len(files))
+		} else if err != nil {
+			sess.writeError(err, 550, err.Error())
+			return
 		}
-		sess.sendOutofbandData(listFormatter(files).Detailed())
+	} else {
+		info, err := convertFileInfo(sess, &ctx, stat, buildPath)
+		if err != nil {
+			sess.writeError(err, 550, err.Error())
+			return
+		}
+		files = append(files, info)
 	}
+	listing := string(listFormatter(files).Detailed(sess.listStyle()))
+	sess.writeMessageMultiline(213, fmt.Sprintf("Status of %s:\n%s", buildPath, listing), last)
 }
 
 // commandStor responds to the STOR FTP command. It allows the user to upload a new file.
@@ -1487,7 +1644,75 @@ func (cmd commandStor) RequireAuth() bool {
 }
 
 func (cmd commandStor) Execute(sess *Session, param string) {
+	executePut("STOR", sess, param)
+}
+
+// executePut receives a file from the client for the STOR and APPE commands.
+func executePut(cmd string, sess *Session, param string) {
 	targetPath := sess.buildPath(param)
+	allocSize := sess.allocSize
+	sess.allocSize = 0
+	if sess.dataConn == nil {
+		sess.writeMessage(425, "Can't open data connection")
+		return
+	}
+	release, ok := sess.acquireTransfer()
+	if !ok {
+		return
+	}
+	defer release()
+
+	remaining, _, err := sess.QuotaRemaining()
+	if err != nil {
+		sess.logf("checking transfer quota: %v", err)
+		if sess.dataConn != nil {
+			sess.dataConn.Close()
+			sess.setDataConn(nil)
+		}
+		sess.writeMessage(451, "Checking transfer quota failed")
+		return
+	}
+	if remaining == 0 {
+		if sess.dataConn != nil {
+			sess.dataConn.Close()
+			sess.setDataConn(nil)
+		}
+		sess.writeMessage(552, "Transfer quota exceeded")
+		return
+	}
+
+	ctx := Context{
+		Sess:  sess,
+		Cmd:   cmd,
+		Param: param,
+		Data:  NewStore(),
+	}
+	sess.checkCanary(&ctx, targetPath)
+	sess.server.notifiers.BeforePutFile(&ctx, targetPath)
+	if err := sess.server.notifiers.Intercept(&ctx, targetPath); err != nil {
+		sess.server.notifiers.AfterFilePut(&ctx, targetPath, 0, err)
+		if sess.dataConn != nil {
+			sess.dataConn.Close()
+			sess.setDataConn(nil)
+		}
+		sess.writeError(err, 550, fmt.Sprint("Action not taken: ", err))
+		return
+	}
+
+	if driver, ok := sess.baseDriver().(AllocateDriver); ok && allocSize > 0 {
+		err := driver.Allocate(&ctx, sess.realPath(targetPath), allocSize)
+		sess.forgetCached(targetPath)
+		if err != nil {
+			sess.server.notifiers.AfterFilePut(&ctx, targetPath, 0, err)
+			if sess.dataConn != nil {
+				sess.dataConn.Close()
+				sess.setDataConn(nil)
+			}
+			sess.writeError(err, 552, fmt.Sprint("Storage allocation failed: ", err))
+			return
+		}
+	}
+
 	sess.writeMessage(150, "Data transfer starting")
 
 	if sess.preCommand != "REST" {
@@ -1498,20 +1723,65 @@ func (cmd commandStor) Execute(sess *Session, param string) {
 		sess.lastFilePos = -1
 	}()
 
-	ctx := Context{
-		Sess:  sess,
-		Cmd:   "STOR",
-		Param: param,
-		Data:  make(map[string]interface{}),
+	data, endTransfer := sess.startTransfer(TransferUpload, targetPath, sess.dataConn)
+	if remaining > 0 {
+		data = NewQuotaReader(data, remaining, ErrQuotaExceeded)
 	}
-	sess.server.notifiers.BeforePutFile(&ctx, targetPath)
-	size, err := sess.server.Driver.PutFile(&ctx, targetPath, sess.dataConn, sess.lastFilePos)
+	var checksums *checksumReader
+	if len(sess.server.UploadChecksums) > 0 {
+		checksums = newChecksumReader(data, sess.server.UploadChecksums)
+		data = checksums
+	}
+	data, closeStages, err := sess.stageTransfer(&ctx, TransferUpload, targetPath, data)
+	if err != nil {
+		endTransfer()
+		sess.server.notifiers.AfterFilePut(&ctx, targetPath, 0, err)
+		if sess.dataConn != nil {
+			sess.dataConn.Close()
+			sess.setDataConn(nil)
+		}
+		sess.writeError(err, 550, fmt.Sprint("Action not taken: ", err))
+		return
+	}
+	data = &pooledReader{Reader: data, ctx: &ctx, server: sess.server}
+
+	// Only new files are uploaded under a partial name, resumed and
+	// appended ones need the existing content.
+	putPath := targetPath
+	if sess.server.AtomicUploads && cmd == "STOR" && sess.lastFilePos < 0 {
+		putPath = sess.partialUploadPath(targetPath)
+	}
+
+	var size int64
+	stopWatch := sess.watchControl(sess.dataConn)
+	if cmd == "APPE" && sess.lastFilePos < 0 {
+		size, err = appendFile(&ctx, sess.driver(), putPath, data)
+	} else {
+		size, err = sess.driver().PutFile(&ctx, putPath, data, sess.lastFilePos)
+	}
+	received := endTransfer()
+	stopWatch()
+	closeStages()
+	if sess.dataConn != nil {
+		sess.dataConn.Close()
+		sess.setDataConn(nil)
+	}
+	if putPath != targetPath {
+		err = sess.finishUpload(&ctx, putPath, targetPath, err)
+	}
+	if err == nil && checksums != nil {
+		ctx.Checksums = checksums.sums()
+	}
+	sess.addTransferred(received, 0)
 	sess.server.notifiers.AfterFilePut(&ctx, targetPath, size, err)
 	if err == nil {
 		msg := fmt.Sprintf("OK, received %d bytes", size)
 		sess.writeMessage(226, msg)
+	} else if sess.aborted() {
+		sess.server.notifiers.AfterTransferAborted(&ctx, targetPath, size)
+		sess.writeMessage(426, "Connection closed; transfer aborted")
 	} else {
-		sess.writeMessage(450, fmt.Sprint("error during transfer: ", err))
+		sess.writeError(err, 450, fmt.Sprint("error during transfer: ", err))
 	}
 }
 
@@ -1559,7 +1829,7 @@ func (cmd commandSyst) RequireAuth() bool {
 }
 
 func (cmd commandSyst) Execute(sess *Session, param string) {
-	sess.writeMessage(215, "UNIX Type: L8")
+	sess.writeMessage(215, sess.system())
 }
 
 // commandType responds to the TYPE FTP command.
@@ -1614,7 +1884,7 @@ func (cmd commandUser) Execute(sess *Session, param string) {
 		Sess:  sess,
 		Cmd:   "USER",
 		Param: param,
-		Data:  make(map[string]interface{}),
+		Data:  NewStore(),
 	}, sess.reqUser)
 	sess.writeMessage(331, "User name ok, password required")
 }
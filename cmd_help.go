@@ -0,0 +1,103 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CommandHelper may be implemented by a Command to describe itself in HELP
+// replies, such as "SITE CHMOD <mode> <path>: change file mode".
+type CommandHelper interface {
+	Help() string
+}
+
+// helpColumns is the number of command names per line of a HELP listing.
+const helpColumns = 8
+
+// commandHelp responds to the HELP FTP command with the commands the server
+// has registered, or with help on one of them, SITE subcommands included.
+type commandHelp struct{}
+
+func (cmd commandHelp) IsExtend() bool {
+	return false
+}
+
+func (cmd commandHelp) RequireParam() bool {
+	return false
+}
+
+func (cmd commandHelp) RequireAuth() bool {
+	return false
+}
+
+func (cmd commandHelp) Execute(sess *Session, param string) {
+	name, subParam := sess.parseLine(param)
+	name = strings.ToUpper(name)
+	subName := strings.ToUpper(strings.TrimSpace(subParam))
+
+	// receiveLine holds CommandsMu while the command executes.
+	switch {
+	case name == "":
+		sess.writeMessageMultiline(214, "The following commands are recognized.\n"+
+			helpListing(sess.helpNames(sess.server.Commands, true)), "Help OK.")
+	case name == "SITE" && subName == "":
+		sess.writeMessageMultiline(214, "The following SITE commands are recognized.\n"+
+			helpListing(sess.helpNames(sess.server.SiteCommands, false)), "Help OK.")
+	case name == "SITE":
+		sess.writeHelp("SITE "+subName, sess.server.SiteCommands[subName], true)
+	default:
+		c := sess.server.Commands[name]
+		sess.writeHelp(name, c, !sess.quirks.disables(name) &&
+			!(sess.server.DisableActiveMode && isActiveModeCommand(name)))
+	}
+}
+
+// writeHelp replies with the help of one command, enabled is false when
+// the session may not use it.
+func (sess *Session) writeHelp(name string, c Command, enabled bool) {
+	if c == nil || !enabled {
+		sess.writeMessage(502, fmt.Sprintf("Unknown command %s", name))
+		return
+	}
+	if helper, ok := c.(CommandHelper); ok {
+		sess.writeMessage(214, helper.Help())
+		return
+	}
+	sess.writeMessage(214, fmt.Sprintf("Syntax: %s", name))
+}
+
+// helpNames returns the sorted names of the commands the session may use.
+func (sess *Session) helpNames(commands map[string]Command, top bool) []string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		if top && (sess.quirks.disables(name) || sess.server.DisableActiveMode && isActiveModeCommand(name)) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// helpListing lays names out in columns.
+func helpListing(names []string) string {
+	var b strings.Builder
+	for i, name := range names {
+		if i%helpColumns == 0 {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			b.WriteString(" ")
+		}
+		fmt.Fprintf(&b, "%-5s", name)
+		if i%helpColumns != helpColumns-1 && i != len(names)-1 {
+			b.WriteString(" ")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,191 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var defaultSiteCommands = map[string]Command{
+	"CKSM":    commandSiteCksm{},
+	"DEBUG":   commandSiteDebug{},
+	"HASH":    commandSiteHash{},
+	"MVDIR":   commandSiteMvdir{},
+	"QUOTA":   commandSiteQuota{},
+	"RESUME":  commandSiteResume{},
+	"RMDIR":   commandSiteRmdir{},
+	"SYMLINK": commandSiteSymlink{},
+	"TICKET":  commandSiteTicket{},
+	"USAGE":   commandSiteUsage{},
+}
+
+// DefaultSiteCommands returns a copy of the default SITE subcommands, to
+// be extended and set as Options.SiteCommands
+func DefaultSiteCommands() map[string]Command {
+	commands := make(map[string]Command, len(defaultSiteCommands))
+	for name, cmd := range defaultSiteCommands {
+		commands[name] = cmd
+	}
+	return commands
+}
+
+// commandSite responds to the SITE FTP command by dispatching to the
+// subcommand registered in Options.SiteCommands.
+type commandSite struct{}
+
+func (cmd commandSite) IsExtend() bool {
+	return false
+}
+
+func (cmd commandSite) RequireParam() bool {
+	return true
+}
+
+func (cmd commandSite) RequireAuth() bool {
+	return false
+}
+
+func (cmd commandSite) Execute(sess *Session, param string) {
+	name, subParam := sess.parseLine(param)
+	name = strings.ToUpper(name)
+
+	subCmd, ok := sess.server.SiteCommands[name]
+	if !ok {
+		sess.writeMessage(502, fmt.Sprintf("SITE %s not implemented", name))
+		return
+	}
+
+	if subCmd.RequireParam() && subParam == "" {
+		sess.writeMessage(501, "action aborted, required param missing")
+	} else if subCmd.RequireAuth() && sess.user == "" {
+		sess.writeMessage(530, "not logged in")
+	} else if sess.deniesWrite("SITE " + name) {
+		sess.writeMessage(550, "Permission denied, read-only session")
+	} else {
+		subCmd.Execute(sess, subParam)
+	}
+}
+
+// commandSiteQuota responds to SITE QUOTA with the transfer quota of the
+// user and how much of it is left in the current period, and with the
+// storage left when the driver is a SpaceDriver.
+type commandSiteQuota struct{}
+
+func (cmd commandSiteQuota) IsExtend() bool {
+	return false
+}
+
+func (cmd commandSiteQuota) RequireParam() bool {
+	return false
+}
+
+func (cmd commandSiteQuota) RequireAuth() bool {
+	return true
+}
+
+func (cmd commandSiteQuota) Help() string {
+	return "Syntax: SITE QUOTA (show the transfer and storage quota left)"
+}
+
+func (cmd commandSiteQuota) Execute(sess *Session, param string) {
+	quota := sess.TransferQuota()
+	up, down, err := sess.QuotaRemaining()
+	if err != nil {
+		sess.logf("checking transfer quota: %v", err)
+		sess.writeMessage(451, "Checking transfer quota failed")
+		return
+	}
+
+	used, available, hasSpace, err := sess.space("SITE QUOTA")
+	if err != nil {
+		sess.logf("checking storage usage: %v", err)
+		sess.writeMessage(451, "Checking storage usage failed")
+		return
+	}
+
+	period := quota.Period.Start(time.Now())
+	msg := fmt.Sprintf("Transfer quota (%s, since %s):\n"+
+		" Upload: %s\n"+
+		" Download: %s",
+		quota.Period, period.Format(time.DateOnly),
+		formatQuota(quota.Upload, up), formatQuota(quota.Download, down))
+	if hasSpace {
+		msg += "\nStorage: " + formatQuota(used+available, available)
+	}
+	sess.writeMessageMultiline(211, msg, "End")
+}
+
+// commandSiteUsage responds to SITE USAGE with the bytes the user
+// transferred in the current quota period, and with the storage they use
+// when the driver is a SpaceDriver.
+type commandSiteUsage struct{}
+
+func (cmd commandSiteUsage) IsExtend() bool {
+	return false
+}
+
+func (cmd commandSiteUsage) RequireParam() bool {
+	return false
+}
+
+func (cmd commandSiteUsage) RequireAuth() bool {
+	return true
+}
+
+func (cmd commandSiteUsage) Help() string {
+	return "Syntax: SITE USAGE (show the bytes transferred and stored)"
+}
+
+func (cmd commandSiteUsage) Execute(sess *Session, param string) {
+	up, down, err := sess.QuotaUsage()
+	if err != nil {
+		sess.logf("checking transfer quota: %v", err)
+		sess.writeMessage(451, "Checking transfer quota failed")
+		return
+	}
+
+	used, _, hasSpace, err := sess.space("SITE USAGE")
+	if err != nil {
+		sess.logf("checking storage usage: %v", err)
+		sess.writeMessage(451, "Checking storage usage failed")
+		return
+	}
+
+	quota := sess.TransferQuota()
+	period := quota.Period.Start(time.Now())
+	msg := fmt.Sprintf("Transferred (%s, since %s):\n"+
+		" Upload: %d bytes\n"+
+		" Download: %d bytes",
+		quota.Period, period.Format(time.DateOnly), up, down)
+	if hasSpace {
+		msg += fmt.Sprintf("\nStorage: %d bytes used", used)
+	}
+	sess.writeMessageMultiline(211, msg, "End")
+}
+
+// space asks a SpaceDriver for the storage used and available to the
+// session's user, ok is false when the driver is not one.
+func (sess *Session) space(command string) (used, available int64, ok bool, err error) {
+	driver, ok := sess.baseDriver().(SpaceDriver)
+	if !ok {
+		return 0, 0, false, nil
+	}
+	used, available, err = driver.Space(&Context{
+		Sess: sess,
+		Cmd:  command,
+		Data: NewStore(),
+	})
+	return used, available, true, err
+}
+
+// formatQuota describes a quota and the bytes remaining of it.
+func formatQuota(limit, remaining int64) string {
+	if remaining < 0 {
+		return "unlimited"
+	}
+	return fmt.Sprintf("%d of %d bytes remaining", remaining, limit)
+}
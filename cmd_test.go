@@ -4,7 +4,10 @@
 
 package ftp
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestParseListParam(t *testing.T) {
 	paramTests := []struct {
@@ -32,3 +35,180 @@ func TestParseListParam(t *testing.T) {
 		}
 	}
 }
+
+func TestEpsvAll(t *testing.T) {
+	client := newPipeSession(t, &Options{
+		Auth: &SimpleAuth{Name: "admin", Password: "admin"},
+	})
+
+	expectCode(t, client, 331, "USER admin")
+	expectCode(t, client, 230, "PASS admin")
+	expectCode(t, client, 200, "EPSV ALL")
+	expectCode(t, client, 503, "PASV")
+	expectCode(t, client, 503, "PORT 127,0,0,1,4,1")
+}
+
+func TestEpsvNetProtocol(t *testing.T) {
+	client := newPipeSession(t, &Options{
+		Auth: &SimpleAuth{Name: "admin", Password: "admin"},
+	})
+
+	expectCode(t, client, 331, "USER admin")
+	expectCode(t, client, 230, "PASS admin")
+	if msg := expectCode(t, client, 522, "EPSV 2"); !strings.Contains(msg, "(1)") {
+		t.Fatalf("expected the reply to name the supported protocol, got %q", msg)
+	}
+	expectCode(t, client, 522, "EPSV 3")
+}
+
+func TestDisableActiveMode(t *testing.T) {
+	client := newPipeSession(t, &Options{
+		Auth:              &SimpleAuth{Name: "admin", Password: "admin"},
+		DisableActiveMode: true,
+	})
+
+	expectCode(t, client, 331, "USER admin")
+	expectCode(t, client, 230, "PASS admin")
+	expectCode(t, client, 502, "PORT 127,0,0,1,4,1")
+	expectCode(t, client, 502, "EPRT |1|127.0.0.1|1025|")
+}
+
+func TestSiteQuota(t *testing.T) {
+	client := newPipeSession(t, &Options{
+		Auth:          &SimpleAuth{Name: "admin", Password: "admin"},
+		TransferQuota: TransferQuota{Period: QuotaMonthly, Upload: 1024},
+	})
+
+	expectCode(t, client, 530, "SITE QUOTA")
+	expectCode(t, client, 331, "USER admin")
+	expectCode(t, client, 230, "PASS admin")
+	expectCode(t, client, 502, "SITE NOPE")
+
+	msg := expectCode(t, client, 211, "SITE QUOTA")
+	if !strings.Contains(msg, "Upload: 1024 of 1024 bytes remaining") || !strings.Contains(msg, "Download: unlimited") {
+		t.Fatalf("unexpected SITE QUOTA reply: %s", msg)
+	}
+
+	msg = expectCode(t, client, 211, "SITE USAGE")
+	if !strings.Contains(msg, "Upload: 0 bytes") || strings.Contains(msg, "Storage") {
+		t.Fatalf("unexpected SITE USAGE reply: %s", msg)
+	}
+}
+
+func TestAllo(t *testing.T) {
+	client := newPipeSession(t, &Options{
+		Auth:          &SimpleAuth{Name: "admin", Password: "admin"},
+		TransferQuota: TransferQuota{Upload: 1024},
+	})
+
+	expectCode(t, client, 530, "ALLO 10")
+	expectCode(t, client, 331, "USER admin")
+	expectCode(t, client, 230, "PASS admin")
+	expectCode(t, client, 202, "ALLO")
+	expectCode(t, client, 501, "ALLO many")
+	expectCode(t, client, 200, "ALLO 1024")
+	expectCode(t, client, 200, "ALLO 512 R 128")
+	expectCode(t, client, 552, "ALLO 1025")
+}
+
+func TestFloodProtection(t *testing.T) {
+	t.Run("pre-auth", func(t *testing.T) {
+		client := newPipeSession(t, &Options{MaxPreAuthCommands: 2})
+		expectCode(t, client, 200, "NOOP")
+		expectCode(t, client, 200, "NOOP")
+		expectCode(t, client, 421, "NOOP")
+	})
+
+	t.Run("rate", func(t *testing.T) {
+		client := newPipeSession(t, &Options{MaxCommandRate: 3})
+		for i := 0; i < 3; i++ {
+			expectCode(t, client, 200, "NOOP")
+		}
+		expectCode(t, client, 421, "NOOP")
+	})
+
+	t.Run("line length", func(t *testing.T) {
+		client := newPipeSession(t, &Options{MaxLineLength: 64})
+		expectCode(t, client, 200, "NOOP")
+		expectCode(t, client, 421, "USER %s", strings.Repeat("a", 100))
+	})
+}
+
+func TestHelp(t *testing.T) {
+	commands := make(map[string]Command, len(defaultCommands)+1)
+	for name, c := range defaultCommands {
+		commands[name] = c
+	}
+	commands["XTRA"] = commandNoop{}
+
+	client := newPipeSession(t, &Options{
+		Commands:          commands,
+		DisableActiveMode: true,
+	})
+
+	msg := expectCode(t, client, 214, "HELP")
+	for _, name := range []string{"ABOR", "HELP", "SITE", "XTRA"} {
+		if !strings.Contains(msg, name) {
+			t.Fatalf("expected %s in HELP, got %s", name, msg)
+		}
+	}
+	if strings.Contains(msg, "PORT") {
+		t.Fatalf("expected PORT left out of HELP, got %s", msg)
+	}
+
+	expectCode(t, client, 214, "HELP stor")
+	expectCode(t, client, 502, "HELP PORT")
+	expectCode(t, client, 502, "HELP NOPE")
+
+	if msg := expectCode(t, client, 214, "HELP SITE"); !strings.Contains(msg, "QUOTA") {
+		t.Fatalf("expected QUOTA in HELP SITE, got %s", msg)
+	}
+	if msg := expectCode(t, client, 214, "HELP SITE quota"); !strings.Contains(msg, "SITE QUOTA") {
+		t.Fatalf("unexpected HELP SITE QUOTA reply: %s", msg)
+	}
+}
+
+func TestCommandLineParsing(t *testing.T) {
+	client := newPipeSession(t, &Options{})
+
+	// A CR ends the line even when more follows in the same write, as
+	// clients ending lines with CR alone pipeline commands.
+	for _, test := range []struct {
+		line    string
+		replies int
+	}{
+		{"NOOP\n", 1},
+		{"NOOP\r", 1},
+		{"NOOP\r\n", 1},
+		{"NOOP\rNOOP\r", 2},
+		{"NOOP\r\nNOOP\r", 2},
+	} {
+		if _, err := client.W.WriteString(test.line); err != nil {
+			t.Fatal(err)
+		}
+		if err := client.W.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < test.replies; i++ {
+			if _, _, err := client.ReadResponse(200); err != nil {
+				t.Fatalf("%q: %v", test.line, err)
+			}
+		}
+	}
+
+	// The LF of a CRLF is skipped even when it comes on its own
+	for _, part := range []string{"NOOP\r", "\nNOOP\r\n"} {
+		if _, err := client.W.WriteString(part); err != nil {
+			t.Fatal(err)
+		}
+		if err := client.W.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := client.ReadResponse(200); err != nil {
+			t.Fatalf("%q: %v", part, err)
+		}
+	}
+
+	expectCode(t, client, 501, "MODE S\x00")
+	expectCode(t, client, 200, "NOOP")
+}
@@ -0,0 +1,77 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// connLimiterSweepInterval is how often the buckets of sources which went
+// quiet are dropped
+const connLimiterSweepInterval = time.Minute
+
+// connLimiter is a token bucket per source IP limiting how fast connections
+// are accepted, see Options.MaxConnectionRate.
+type connLimiter struct {
+	rate      float64
+	burst     float64
+	lock      sync.Mutex
+	buckets   map[string]*connBucket
+	lastSweep time.Time
+}
+
+type connBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newConnLimiter(rate float64, burst int) *connLimiter {
+	if burst <= 0 {
+		burst = int(math.Max(1, math.Ceil(2*rate)))
+	}
+	return &connLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[string]*connBucket),
+	}
+}
+
+// allow takes a token of ip's bucket, it returns false when there is none
+// left.
+func (limiter *connLimiter) allow(ip string, now time.Time) bool {
+	limiter.lock.Lock()
+	defer limiter.lock.Unlock()
+
+	if now.Sub(limiter.lastSweep) >= connLimiterSweepInterval {
+		for key, bucket := range limiter.buckets {
+			if limiter.refill(bucket, now) >= limiter.burst {
+				delete(limiter.buckets, key)
+			}
+		}
+		limiter.lastSweep = now
+	}
+
+	bucket, ok := limiter.buckets[ip]
+	if !ok {
+		bucket = &connBucket{tokens: limiter.burst, last: now}
+		limiter.buckets[ip] = bucket
+	}
+	if limiter.refill(bucket, now) < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+// refill adds the tokens earned since the bucket was last used
+func (limiter *connLimiter) refill(bucket *connBucket, now time.Time) float64 {
+	if elapsed := now.Sub(bucket.last).Seconds(); elapsed > 0 {
+		bucket.tokens = math.Min(limiter.burst, bucket.tokens+elapsed*limiter.rate)
+		bucket.last = now
+	}
+	return bucket.tokens
+}
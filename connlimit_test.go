@@ -0,0 +1,51 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConnLimiter(t *testing.T) {
+	limiter := newConnLimiter(0.5, 0)
+	now := time.Now()
+
+	// The default burst is twice the rate, at least 1
+	if !limiter.allow("192.0.2.1", now) {
+		t.Fatal("first connection refused")
+	}
+	if limiter.allow("192.0.2.1", now) {
+		t.Fatal("connection over the burst allowed")
+	}
+	if !limiter.allow("192.0.2.2", now) {
+		t.Fatal("other source refused")
+	}
+	if limiter.allow("192.0.2.1", now.Add(time.Second)) {
+		t.Fatal("connection allowed before a token was earned")
+	}
+	if !limiter.allow("192.0.2.1", now.Add(2*time.Second)) {
+		t.Fatal("connection refused once a token was earned")
+	}
+
+	// Sources whose bucket refilled are forgotten
+	limiter.allow("192.0.2.3", now.Add(connLimiterSweepInterval))
+	if len(limiter.buckets) != 1 {
+		t.Errorf("%d buckets kept after a sweep, want 1", len(limiter.buckets))
+	}
+}
+
+func TestConnLimiterBurst(t *testing.T) {
+	limiter := newConnLimiter(1, 3)
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		if !limiter.allow("192.0.2.1", now) {
+			t.Fatalf("connection %d refused within the burst", i+1)
+		}
+	}
+	if limiter.allow("192.0.2.1", now) {
+		t.Fatal("connection over the burst allowed")
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package credentials hashes and verifies passwords, so that they are not
+// stored in plain text. Hashes are bcrypt ones or argon2id PHC strings, as
+// written by htpasswd, Dovecot or the argon2 command line tool.
+package credentials
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrUnknownHash is returned when verifying a hash of an unsupported
+// algorithm, or a string which is no hash at all.
+var ErrUnknownHash = errors.New("credentials: unknown hash format")
+
+// Argon2Params are the cost parameters of argon2id hashes
+type Argon2Params struct {
+	// memory in KiB
+	Memory  uint32
+	Time    uint32
+	Threads uint8
+	// bytes of the random salt and of the hash
+	SaltLength uint32
+	KeyLength  uint32
+}
+
+// DefaultArgon2Params are the first parameters recommended by RFC 9106
+// for memory constrained environments.
+var DefaultArgon2Params = Argon2Params{
+	Memory:     64 * 1024,
+	Time:       3,
+	Threads:    4,
+	SaltLength: 16,
+	KeyLength:  32,
+}
+
+// Hash hashes password with argon2id and DefaultArgon2Params
+func Hash(password string) (string, error) {
+	return HashArgon2id(password, DefaultArgon2Params)
+}
+
+// HashArgon2id hashes password with argon2id, returning a PHC string
+func HashArgon2id(password string, params Argon2Params) (string, error) {
+	salt := make([]byte, params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	phc := &PHC{
+		ID:      "argon2id",
+		Version: argon2.Version,
+		Params: []Param{
+			{Name: "m", Value: fmt.Sprint(params.Memory)},
+			{Name: "t", Value: fmt.Sprint(params.Time)},
+			{Name: "p", Value: fmt.Sprint(params.Threads)},
+		},
+		Salt: salt,
+		Hash: argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, params.KeyLength),
+	}
+	return phc.String(), nil
+}
+
+// HashBcrypt hashes password with bcrypt at cost, bcrypt.DefaultCost when
+// 0. bcrypt only uses the first 72 bytes of passwords, longer ones are
+// rejected.
+func HashBcrypt(password string, cost int) (string, error) {
+	if cost == 0 {
+		cost = bcrypt.DefaultCost
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// Verify reports whether password matches hash, a bcrypt hash or an
+// argon2id PHC string. The error is only set for malformed or unsupported
+// hashes.
+func Verify(hash, password string) (bool, error) {
+	switch {
+	case IsBcrypt(hash):
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		return err == nil, err
+	case strings.HasPrefix(hash, "$argon2id$"):
+		return verifyArgon2id(hash, password)
+	default:
+		return false, ErrUnknownHash
+	}
+}
+
+// IsHash reports whether s looks like a hash Verify supports, to tell
+// hashed passwords from plain text ones.
+func IsHash(s string) bool {
+	return IsBcrypt(s) || strings.HasPrefix(s, "$argon2id$")
+}
+
+// IsBcrypt reports whether s has the prefix of a bcrypt hash
+func IsBcrypt(s string) bool {
+	for _, prefix := range []string{"$2a$", "$2b$", "$2y$"} {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func verifyArgon2id(hash, password string) (bool, error) {
+	phc, err := ParsePHC(hash)
+	if err != nil {
+		return false, err
+	}
+	if phc.Version != argon2.Version {
+		return false, fmt.Errorf("credentials: unsupported argon2 version %d", phc.Version)
+	}
+	if len(phc.Salt) == 0 || len(phc.Hash) == 0 {
+		return false, ErrInvalidPHC
+	}
+
+	var params [3]int
+	for i, name := range []string{"m", "t", "p"} {
+		if params[i], err = phc.IntParam(name); err != nil {
+			return false, err
+		}
+	}
+	memory, iterations, threads := params[0], params[1], params[2]
+	if memory <= 0 || memory > 1<<32-1 || iterations <= 0 || iterations > 1<<32-1 || threads <= 0 || threads > 255 {
+		return false, errors.New("credentials: argon2 parameters out of range")
+	}
+
+	key := argon2.IDKey([]byte(password), phc.Salt, uint32(iterations), uint32(memory), uint8(threads), uint32(len(phc.Hash)))
+	return subtle.ConstantTimeCompare(key, phc.Hash) == 1, nil
+}
+
+var (
+	dummyOnce sync.Once
+	dummyHash string
+)
+
+// VerifyDummy verifies password against a hash made up on first use with
+// DefaultArgon2Params, discarding the result. Call it when there is no
+// hash to verify, for an unknown user, so that the login takes as long as
+// for a known one and does not tell which names exist.
+func VerifyDummy(password string) {
+	dummyOnce.Do(func() {
+		dummyHash, _ = Hash("dummy")
+	})
+	_, _ = Verify(dummyHash, password)
+}
+
+// Equal compares a and b in constant time, at least for strings of equal
+// length, to check secrets which can't be hashed.
+func Equal(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
@@ -0,0 +1,81 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package credentials
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testArgon2Params keep the tests fast
+var testArgon2Params = Argon2Params{Memory: 64, Time: 1, Threads: 1, SaltLength: 8, KeyLength: 16}
+
+func TestVerify(t *testing.T) {
+	argon, err := HashArgon2id("secret", testArgon2Params)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(argon, "$argon2id$v=19$m=64,t=1,p=1$"), argon)
+	bcrypt, err := HashBcrypt("secret", 4)
+	assert.NoError(t, err)
+
+	for _, test := range []struct {
+		hash     string
+		password string
+	}{
+		{argon, "secret"},
+		{bcrypt, "secret"},
+		// from the crypt_blowfish test vectors
+		{"$2a$05$CCCCCCCCCCCCCCCCCCCCC.E5YPO9kmyuRGyh0XouQYb4YMJKvyOeW", "U*U"},
+	} {
+		assert.True(t, IsHash(test.hash), test.hash)
+		ok, err := Verify(test.hash, test.password)
+		assert.NoError(t, err)
+		assert.True(t, ok, test.hash)
+		ok, err = Verify(test.hash, strings.ToUpper(test.password)+"x")
+		assert.NoError(t, err)
+		assert.False(t, ok, test.hash)
+	}
+
+	for _, hash := range []string{"secret", "sha256$00$00", "$argon2i$v=19$m=64,t=1,p=1$c2FsdA$aGFzaA"} {
+		assert.False(t, IsHash(hash), hash)
+		_, err = Verify(hash, "secret")
+		assert.ErrorIs(t, err, ErrUnknownHash)
+	}
+	_, err = Verify("$argon2id$v=19$m=64,t=1$c2FsdA$aGFzaA", "secret")
+	assert.Error(t, err)
+}
+
+func TestParsePHC(t *testing.T) {
+	for _, s := range []string{
+		"$argon2id",
+		"$argon2id$v=19$m=64,t=1,p=1$c2FsdA$aGFzaA",
+		"$pbkdf2-sha256$i=1000$c2FsdA",
+		"$scrypt$c2FsdA$aGFzaA",
+	} {
+		phc, err := ParsePHC(s)
+		if assert.NoError(t, err, s) {
+			assert.Equal(t, s, phc.String())
+		}
+	}
+
+	phc, err := ParsePHC("$argon2id$v=19$m=64,t=1,p=1$c2FsdA$aGFzaA")
+	assert.NoError(t, err)
+	assert.Equal(t, "argon2id", phc.ID)
+	assert.Equal(t, 19, phc.Version)
+	assert.Equal(t, "64", phc.Param("m"))
+	assert.Equal(t, []byte("salt"), phc.Salt)
+	assert.Equal(t, []byte("hash"), phc.Hash)
+
+	for _, s := range []string{"", "argon2id", "$", "$Argon2id", "$argon2id$v=x", "$argon2id$m=$c2FsdA", "$argon2id$c2FsdA$aGFzaA$x", "$argon2id$c2Fsd!"} {
+		_, err = ParsePHC(s)
+		assert.ErrorIs(t, err, ErrInvalidPHC, s)
+	}
+}
+
+func TestVerifyDummy(t *testing.T) {
+	VerifyDummy("secret")
+	assert.True(t, strings.HasPrefix(dummyHash, "$argon2id$"), dummyHash)
+}
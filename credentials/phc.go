@@ -0,0 +1,145 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package credentials
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidPHC is returned when parsing a malformed PHC string
+var ErrInvalidPHC = errors.New("credentials: invalid PHC string")
+
+// phcEncoding is the base64 of PHC strings, without padding
+var phcEncoding = base64.RawStdEncoding
+
+// Param is a parameter of a PHC string
+type Param struct {
+	Name  string
+	Value string
+}
+
+// PHC is a hash in the PHC string format:
+//
+//	$<id>[$v=<version>][$<param>=<value>(,<param>=<value>)*][$<salt>[$<hash>]]
+//
+// See https://github.com/P-H-C/phc-string-format.
+type PHC struct {
+	ID string
+	// 0 when the string has no version
+	Version int
+	Params  []Param
+	Salt    []byte
+	Hash    []byte
+}
+
+// ParsePHC parses a PHC string
+func ParsePHC(s string) (*PHC, error) {
+	fields := strings.Split(s, "$")
+	if len(fields) < 2 || fields[0] != "" || !validPHCName(fields[1]) {
+		return nil, ErrInvalidPHC
+	}
+	phc := &PHC{ID: fields[1]}
+	fields = fields[2:]
+
+	if len(fields) > 0 && strings.HasPrefix(fields[0], "v=") {
+		version, err := strconv.Atoi(fields[0][2:])
+		if err != nil || version < 0 {
+			return nil, ErrInvalidPHC
+		}
+		phc.Version = version
+		fields = fields[1:]
+	}
+	if len(fields) > 0 && strings.Contains(fields[0], "=") {
+		for _, param := range strings.Split(fields[0], ",") {
+			name, value, ok := strings.Cut(param, "=")
+			if !ok || !validPHCName(name) || value == "" {
+				return nil, ErrInvalidPHC
+			}
+			phc.Params = append(phc.Params, Param{Name: name, Value: value})
+		}
+		fields = fields[1:]
+	}
+
+	var err error
+	switch len(fields) {
+	case 2:
+		if phc.Hash, err = phcEncoding.DecodeString(fields[1]); err != nil {
+			return nil, ErrInvalidPHC
+		}
+		fallthrough
+	case 1:
+		if phc.Salt, err = phcEncoding.DecodeString(fields[0]); err != nil {
+			return nil, ErrInvalidPHC
+		}
+	case 0:
+	default:
+		return nil, ErrInvalidPHC
+	}
+	return phc, nil
+}
+
+// validPHCName reports whether name is a valid function or parameter name
+func validPHCName(name string) bool {
+	if name == "" || len(name) > 32 {
+		return false
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// Param returns the value of the parameter name, "" if the string has none
+func (phc *PHC) Param(name string) string {
+	for _, param := range phc.Params {
+		if param.Name == name {
+			return param.Value
+		}
+	}
+	return ""
+}
+
+// IntParam returns the value of the parameter name as a decimal integer
+func (phc *PHC) IntParam(name string) (int, error) {
+	value := phc.Param(name)
+	if value == "" {
+		return 0, fmt.Errorf("credentials: missing %s parameter", name)
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("credentials: invalid %s parameter %q", name, value)
+	}
+	return n, nil
+}
+
+// String formats phc as a PHC string
+func (phc *PHC) String() string {
+	var b strings.Builder
+	b.WriteString("$" + phc.ID)
+	if phc.Version > 0 {
+		b.WriteString("$v=" + strconv.Itoa(phc.Version))
+	}
+	for i, param := range phc.Params {
+		if i == 0 {
+			b.WriteString("$")
+		} else {
+			b.WriteString(",")
+		}
+		b.WriteString(param.Name + "=" + param.Value)
+	}
+	if phc.Salt != nil {
+		b.WriteString("$" + phcEncoding.EncodeToString(phc.Salt))
+		if phc.Hash != nil {
+			b.WriteString("$" + phcEncoding.EncodeToString(phc.Hash))
+		}
+	}
+	return b.String()
+}
@@ -5,15 +5,17 @@
 package ftp
 
 import (
+	"context"
 	"crypto/tls"
 	"errors"
+	"fmt"
 	"io"
 	"net"
 	"os"
 	"runtime"
 	"strconv"
-	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -38,40 +40,107 @@ type (
 
 		// Close implements the standard io.Closer interface.
 		Close() error
+
+		// SetDeadline fails the reads and writes of the data connection
+		// once t passed, the zero time clears it. Passive sockets wait for
+		// the client to connect first.
+		SetDeadline(t time.Time) error
+
+		// CancelTransfer aborts the transfer the data connection serves as
+		// ABOR does, it is answered with 426. The data connection is
+		// closed, failing a transfer yet to start.
+		CancelTransfer() error
 	}
 
 	activeSocket struct {
-		conn   *net.TCPConn
-		reader io.Reader
-		writer io.Writer
-		sess   *Session
-		host   string
-		port   int
+		conn     net.Conn
+		dataConn *stallConn
+		reader   io.Reader
+		writer   io.Writer
+		sess     *Session
+		host     string
+		port     int
+		// gives conn's descriptor back to Options.MaxFileDescriptors
+		releaseFD sync.Once
+		transferCancel
 	}
 )
 
+// transferCancel aborts the transfer a data connection serves, see
+// DataSocket.CancelTransfer. It is bound when the transfer starts, so that
+// a late call can't abort whichever command runs next.
+type transferCancel struct {
+	mu     sync.Mutex
+	cancel context.CancelFunc
+}
+
+// bindTransfer binds cancel, which aborts the transfer starting.
+func (t *transferCancel) bindTransfer(cancel context.CancelFunc) {
+	t.mu.Lock()
+	t.cancel = cancel
+	t.mu.Unlock()
+}
+
+// cancelTransfer aborts the transfer bound, if any.
+func (t *transferCancel) cancelTransfer() {
+	t.mu.Lock()
+	cancel := t.cancel
+	t.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// activeModeDataPort is the well known ftp-data port.
+const activeModeDataPort = 20
+
 func newActiveSocket(sess *Session, remote string, port int) (DataSocket, error) {
 	connectTo := net.JoinHostPort(remote, strconv.Itoa(port))
 
 	sess.log("Opening active data connection to " + connectTo)
 
-	raddr, err := net.ResolveTCPAddr("tcp", connectTo)
+	localIP, err := sess.dataBindIP()
 	if err != nil {
 		sess.log(err)
 		return nil, err
 	}
 
-	tcpConn, err := net.DialTCP("tcp", nil, raddr)
+	var laddr *net.TCPAddr
+	if localIP != nil {
+		laddr = &net.TCPAddr{IP: localIP}
+	}
+	if sess.server.ActiveModePort20 {
+		// Without a configured data address bind to the control connection's
+		// address, so the data connection leaves from the same interface.
+		if addr, ok := sess.Conn.LocalAddr().(*net.TCPAddr); ok && localIP == nil {
+			localIP = addr.IP
+		}
+		laddr = &net.TCPAddr{IP: localIP, Port: activeModeDataPort}
+	}
+
+	if !sess.server.fds.acquire() {
+		sess.log(errTooManyOpenFiles)
+		return nil, errTooManyOpenFiles
+	}
+	conn, err := sess.server.DataTransport.DialActive(sess, laddr, connectTo)
 	if err != nil {
+		sess.server.fds.release()
 		sess.log(err)
 		return nil, err
 	}
+	if err = sess.server.tuneConn(conn, SocketData); err != nil {
+		sess.server.fds.release()
+		sess.log(err)
+		_ = conn.Close()
+		return nil, err
+	}
 
 	socket := new(activeSocket)
 	socket.sess = sess
-	socket.conn = tcpConn
-	socket.reader = ratelimit.Reader(tcpConn, sess.server.rateLimiter)
-	socket.writer = ratelimit.Writer(tcpConn, sess.server.rateLimiter)
+	socket.conn = conn
+	socket.dataConn = sess.watchStall(conn)
+	socket.reader = ratelimit.Reader(socket.dataConn, sess.uploadLimiter, sess.server.rateLimiter)
+	socket.writer = ratelimit.Writer(socket.dataConn, sess.downloadLimiter, sess.server.rateLimiter)
 	socket.host = remote
 	socket.port = port
 
@@ -91,7 +160,10 @@ func (socket *activeSocket) Read(p []byte) (n int, err error) {
 }
 
 func (socket *activeSocket) ReadFrom(r io.Reader) (int64, error) {
-	return io.Copy(socket.writer, r)
+	if n, ok, err := socket.sess.sendFile(socket.dataConn, socket.writer, r); ok {
+		return n, err
+	}
+	return socket.sess.server.copyBuffer(socket.sess.commandContext(), socket.writer, r)
 }
 
 func (socket *activeSocket) Write(p []byte) (n int, err error) {
@@ -99,20 +171,210 @@ func (socket *activeSocket) Write(p []byte) (n int, err error) {
 }
 
 func (socket *activeSocket) Close() error {
-	return socket.conn.Close()
+	err := socket.conn.Close()
+	socket.releaseFD.Do(socket.sess.server.fds.release)
+	return err
+}
+
+func (socket *activeSocket) SetDeadline(t time.Time) error {
+	return socket.dataConn.SetDeadline(t)
+}
+
+func (socket *activeSocket) CancelTransfer() error {
+	socket.cancelTransfer()
+	return socket.Close()
+}
+
+// ErrTransferStalled is returned by data connections when a transfer makes no
+// progress within Options.TransferStallTimeout.
+var ErrTransferStalled = errors.New("ftp: transfer stalled")
+
+// stallConn fails reads and writes that make no progress within timeout,
+// if not 0, or that are still running at the deadline of SetDeadline.
+type stallConn struct {
+	net.Conn
+	timeout time.Duration
+	// deadline of SetDeadline in unix nanoseconds, 0 if none
+	deadline atomic.Int64
+}
+
+// watchStall wraps a data connection so it fails with ErrTransferStalled when
+// the transfer makes no progress, unless stall detection is disabled.
+func (sess *Session) watchStall(conn net.Conn) *stallConn {
+	return &stallConn{
+		Conn:    conn,
+		timeout: sess.transferStallTimeout(),
+	}
+}
+
+// SetDeadline implements net.Conn, the stall timeout never extends it
+func (c *stallConn) SetDeadline(t time.Time) error {
+	var deadline int64
+	if !t.IsZero() {
+		deadline = t.UnixNano()
+	}
+	c.deadline.Store(deadline)
+	return c.Conn.SetDeadline(t)
+}
+
+// next returns the deadline of the next read or write, only called with
+// stall detection enabled.
+func (c *stallConn) next() time.Time {
+	next := time.Now().Add(c.timeout)
+	if deadline := c.deadline.Load(); deadline != 0 && deadline < next.UnixNano() {
+		return time.Unix(0, deadline)
+	}
+	return next
+}
+
+// error turns the deadline errors of stalls into ErrTransferStalled
+func (c *stallConn) error(err error) error {
+	if c.timeout <= 0 {
+		return err
+	}
+	if deadline := c.deadline.Load(); deadline != 0 && time.Now().UnixNano() >= deadline {
+		return err
+	}
+	return stallError(err, c.timeout)
+}
+
+func (c *stallConn) Read(p []byte) (int, error) {
+	if c.timeout > 0 {
+		_ = c.Conn.SetReadDeadline(c.next())
+	}
+	n, err := c.Conn.Read(p)
+	return n, c.error(err)
+}
+
+func (c *stallConn) Write(p []byte) (int, error) {
+	if c.timeout > 0 {
+		_ = c.Conn.SetWriteDeadline(c.next())
+	}
+	n, err := c.Conn.Write(p)
+	return n, c.error(err)
+}
+
+// stallError turns a deadline error into ErrTransferStalled.
+func stallError(err error, timeout time.Duration) error {
+	if isTimeout(err) {
+		return fmt.Errorf("%w: no progress for %s", ErrTransferStalled, timeout)
+	}
+	return err
+}
+
+// isTimeout reports whether err is a network timeout.
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// dataBindIP returns the local IP data connections are bound to, or nil to
+// let the system choose.
+func (sess *Session) dataBindIP() (net.IP, error) {
+	addr := sess.server.DataBindAddress
+	if addr == "" {
+		return nil, nil
+	}
+	if ip := net.ParseIP(addr); ip != nil {
+		return ip, nil
+	}
+
+	iface, err := net.InterfaceByName(addr)
+	if err != nil {
+		return nil, fmt.Errorf("data bind address %q: %w", addr, err)
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("data bind address %q: %w", addr, err)
+	}
+
+	// Prefer an IPv4 address, as PASV can only advertise those.
+	var found net.IP
+	for _, a := range addrs {
+		ipNet, ok := a.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		if ipNet.IP.To4() != nil {
+			return ipNet.IP, nil
+		}
+		if found == nil {
+			found = ipNet.IP
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("data bind address %q: interface has no IP address", addr)
+	}
+	return found, nil
+}
+
+// DataSourceCheck controls how the source of passive data connections is verified.
+type DataSourceCheck int
+
+const (
+	// DataSourceCheckNone accepts data connections from anywhere, as needed
+	// by FXP and some NAT setups.
+	DataSourceCheckNone DataSourceCheck = iota
+	// DataSourceCheckIP only accepts data connections from the control connection's IP.
+	DataSourceCheckIP
+	// DataSourceCheckNetwork accepts data connections from the control connection's
+	// /24 IPv4 or /64 IPv6 network, for clients behind pools of NAT addresses.
+	DataSourceCheckNetwork
+)
+
+// checkDataSource returns an error if a data connection from addr is not
+// allowed by the DataSourceCheck option.
+func (sess *Session) checkDataSource(addr net.Addr) error {
+	if sess.server.DataSourceCheck == DataSourceCheckNone {
+		return nil
+	}
+
+	control, ok := sess.RemoteAddr().(*net.TCPAddr)
+	if !ok {
+		// Control connections over other transports can't be compared.
+		return nil
+	}
+	data, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return fmt.Errorf("rejected data connection from %v: not a TCP address", addr)
+	}
+
+	if data.IP.Equal(control.IP) {
+		return nil
+	}
+
+	if sess.server.DataSourceCheck == DataSourceCheckNetwork {
+		mask := net.CIDRMask(64, 128)
+		if control.IP.To4() != nil {
+			mask = net.CIDRMask(24, 32)
+		}
+		if data.IP.Mask(mask).Equal(control.IP.Mask(mask)) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("rejected data connection from %s: control connection is from %s", data.IP, control.IP)
 }
 
 type passiveSocket struct {
-	conn    net.Conn
-	reader  io.Reader
-	writer  io.Writer
-	err     error
-	sess    *Session
-	ingress chan []byte
-	egress  chan []byte
-	host    string
-	port    int
-	lock    sync.Mutex // protects conn and err
+	conn     net.Conn
+	dataConn *stallConn
+	reader   io.Reader
+	writer   io.Writer
+	err      error
+	sess     *Session
+	ingress  chan []byte
+	egress   chan []byte
+	host     string
+	port     int
+	lock     sync.Mutex // protects conn and err
+	// set once listening, closed by Close without the lock, which the
+	// accepting goroutine holds until a connection comes
+	listener net.Listener
+	// gives the descriptor of the listener, then conn, back to
+	// Options.MaxFileDescriptors
+	releaseFD sync.Once
+	transferCancel
 }
 
 // isErrorAddressAlreadyInUse detects if an error is "bind: address already in use"
@@ -164,7 +426,7 @@ func (sess *Session) newPassiveSocket() (DataSocket, error) {
 		}
 		break
 	}
-	sess.dataConn = socket
+	sess.setDataConn(socket)
 	return socket, err
 }
 
@@ -176,98 +438,160 @@ func (socket *passiveSocket) Port() int {
 	return socket.port
 }
 
-func (socket *passiveSocket) Read(p []byte) (n int, err error) {
+// ready waits for the data connection to be accepted. The lock is only
+// held while waiting, so Close can interrupt a transfer in progress.
+func (socket *passiveSocket) ready() error {
 	socket.lock.Lock()
 	defer socket.lock.Unlock()
-	if socket.err != nil {
-		return 0, socket.err
+	return socket.err
+}
+
+func (socket *passiveSocket) Read(p []byte) (n int, err error) {
+	if err = socket.ready(); err != nil {
+		return 0, err
 	}
 	return socket.reader.Read(p)
 }
 
 func (socket *passiveSocket) ReadFrom(r io.Reader) (int64, error) {
-	socket.lock.Lock()
-	defer socket.lock.Unlock()
-	if socket.err != nil {
-		return 0, socket.err
+	if err := socket.ready(); err != nil {
+		return 0, err
 	}
 
 	// For normal TCPConn, this will use sendfile syscall; if not, it will just downgrade to normal read/write
 	// procedure.
-	return io.Copy(socket.writer, r)
+	if n, ok, err := socket.sess.sendFile(socket.dataConn, socket.writer, r); ok {
+		return n, err
+	}
+	return socket.sess.server.copyBuffer(socket.sess.commandContext(), socket.writer, r)
 }
 
 func (socket *passiveSocket) Write(p []byte) (n int, err error) {
-	socket.lock.Lock()
-	defer socket.lock.Unlock()
-	if socket.err != nil {
-		return 0, socket.err
+	if err = socket.ready(); err != nil {
+		return 0, err
 	}
 	return socket.writer.Write(p)
 }
 
 func (socket *passiveSocket) Close() error {
+	// Unblocks the pending Accept, which holds the lock
+	if socket.listener != nil {
+		socket.listener.Close()
+	}
 	socket.lock.Lock()
 	defer socket.lock.Unlock()
 	if socket.conn != nil {
-		return socket.conn.Close()
+		err := socket.conn.Close()
+		socket.releaseFD.Do(socket.sess.server.fds.release)
+		return err
 	}
 	return nil
 }
 
-func (socket *passiveSocket) ListenAndServe() (err error) {
-	laddr, err := net.ResolveTCPAddr("tcp", net.JoinHostPort("", strconv.Itoa(socket.port)))
-	if err != nil {
-		socket.sess.log(err)
+func (socket *passiveSocket) SetDeadline(t time.Time) error {
+	if err := socket.ready(); err != nil {
 		return err
 	}
+	return socket.dataConn.SetDeadline(t)
+}
 
-	var tcplistener *net.TCPListener
-	tcplistener, err = net.ListenTCP("tcp", laddr)
+func (socket *passiveSocket) CancelTransfer() error {
+	socket.cancelTransfer()
+	return socket.Close()
+}
+
+func (socket *passiveSocket) ListenAndServe() (err error) {
+	bindIP, err := socket.sess.dataBindIP()
 	if err != nil {
 		socket.sess.log(err)
 		return err
 	}
 
-	// The timeout, for a remote client to establish connection with a PASV style data connection.
-	const acceptTimeout = 60 * time.Second
-	err = tcplistener.SetDeadline(time.Now().Add(acceptTimeout))
+	laddr := &net.TCPAddr{IP: bindIP, Port: socket.port}
+
+	// The descriptor of the listener is handed over to the connection
+	// accepted, which replaces it.
+	fds := socket.sess.server.fds
+	if !fds.acquire() {
+		socket.sess.log(errTooManyOpenFiles)
+		return errTooManyOpenFiles
+	}
+	listener, err := socket.sess.server.DataTransport.ListenPassive(socket.sess, laddr)
 	if err != nil {
+		fds.release()
 		socket.sess.log(err)
 		return err
 	}
 
-	var listener net.Listener = tcplistener
-	add := listener.Addr()
-	parts := strings.Split(add.String(), ":")
-	port, err := strconv.Atoi(parts[len(parts)-1])
-	if err != nil {
+	addr, ok := listener.Addr().(*net.TCPAddr)
+	if !ok {
+		listener.Close()
+		fds.release()
+		err = fmt.Errorf("ftp: passive listener address %v is not a TCP address", listener.Addr())
 		socket.sess.log(err)
 		return err
 	}
+	socket.port = addr.Port
 
-	socket.port = port
-	if socket.sess.server.tlsConfig != nil {
-		listener = tls.NewListener(listener, socket.sess.server.tlsConfig)
+	// The timeout, for a remote client to establish connection with a PASV style data connection.
+	// Listeners without deadlines are closed instead.
+	const acceptTimeout = 60 * time.Second
+	stopTimeout := func() bool { return true }
+	if deadliner, ok := listener.(interface{ SetDeadline(time.Time) error }); ok {
+		if err = deadliner.SetDeadline(time.Now().Add(acceptTimeout)); err != nil {
+			listener.Close()
+			fds.release()
+			socket.sess.log(err)
+			return err
+		}
+	} else {
+		stopTimeout = time.AfterFunc(acceptTimeout, func() { listener.Close() }).Stop
+	}
+
+	if config := socket.sess.tlsConfig(); config != nil {
+		listener = tls.NewListener(listener, config)
 	}
+	socket.listener = listener
 
 	socket.lock.Lock()
 
 	go func() {
 		defer socket.lock.Unlock()
-
-		conn, err := listener.Accept()
-		if err != nil {
-			socket.err = err
+		defer listener.Close()
+		defer stopTimeout()
+		defer func() {
+			if socket.conn == nil {
+				fds.release()
+			}
+		}()
+
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				socket.err = err
+				return
+			}
+
+			// Keep listening after a rejected connection, so a hijack
+			// attempt doesn't break the legitimate client's transfer.
+			if err = socket.sess.checkDataSource(conn.RemoteAddr()); err != nil {
+				socket.sess.log(err)
+				_ = conn.Close()
+				continue
+			}
+			if err = socket.sess.server.tuneConn(conn, SocketData); err != nil {
+				_ = conn.Close()
+				socket.err = err
+				return
+			}
+
+			socket.err = nil
+			socket.conn = conn
+			socket.dataConn = socket.sess.watchStall(conn)
+			socket.reader = ratelimit.Reader(socket.dataConn, socket.sess.uploadLimiter, socket.sess.server.rateLimiter)
+			socket.writer = ratelimit.Writer(socket.dataConn, socket.sess.downloadLimiter, socket.sess.server.rateLimiter)
 			return
 		}
-
-		socket.err = nil
-		socket.conn = conn
-		socket.reader = ratelimit.Reader(socket.conn, socket.sess.server.rateLimiter)
-		socket.writer = ratelimit.Writer(socket.conn, socket.sess.server.rateLimiter)
-
-		_ = listener.Close()
 	}()
 
 	return nil
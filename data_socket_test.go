@@ -0,0 +1,139 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStallConn(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+
+	sess := &Session{
+		server: &Server{
+			Options: &Options{
+				TransferStallTimeout: 10 * time.Millisecond,
+			},
+		},
+	}
+	conn := sess.watchStall(serverConn)
+	defer conn.Close()
+
+	go func() {
+		_, _ = clientConn.Write([]byte("x"))
+	}()
+
+	buf := make([]byte, 1)
+	if _, err := conn.Read(buf); err != nil {
+		t.Fatalf("expected the first read to succeed: %v", err)
+	}
+
+	// The client sends nothing more, so the transfer stalls.
+	if _, err := conn.Read(buf); !errors.Is(err, ErrTransferStalled) {
+		t.Fatalf("expected ErrTransferStalled, got %v", err)
+	}
+}
+
+func TestStallConnDeadline(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+
+	sess := &Session{
+		server: &Server{
+			Options: &Options{
+				TransferStallTimeout: time.Minute,
+			},
+		},
+	}
+	conn := sess.watchStall(serverConn)
+	defer conn.Close()
+
+	// The deadline is kept by reads, and failing at it is no stall
+	if err := conn.SetDeadline(time.Now().Add(10 * time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 1)
+	_, err := conn.Read(buf)
+	if !isTimeout(err) || errors.Is(err, ErrTransferStalled) {
+		t.Fatalf("expected the deadline to pass, got %v", err)
+	}
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	io.Writer
+	n atomic.Int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n.Add(int64(len(p)))
+	return w.Writer.Write(p)
+}
+
+func TestSendFileRateLimit(t *testing.T) {
+	s, err := NewServer(&Options{Perm: NewSimplePerm("test", "test"), Logger: new(DiscardLogger)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	client, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	server, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	sess := s.newSession(newSessionID(), server)
+
+	size := 64 * sendFileChunk
+	f, err := os.CreateTemp(t.TempDir(), "sendfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err = f.Write(make([]byte, size)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+
+	// A rate limit set once the download started applies to the rest
+	received := make(chan int64)
+	go func() {
+		buf := make([]byte, sendFileChunk)
+		n, _ := io.ReadFull(client, buf)
+		sess.SetDownloadRateLimit(1 << 40)
+		rest, _ := io.Copy(io.Discard, client)
+		received <- int64(n) + rest
+	}()
+	conn := sess.watchStall(server)
+	limited := &countingWriter{Writer: conn}
+	n, ok, err := sess.sendFile(conn, limited, f)
+	if !ok || err != nil || n != int64(size) {
+		t.Fatalf("sendFile = %d, %v, %v", n, ok, err)
+	}
+	server.Close()
+	if got := <-received; got != int64(size) {
+		t.Errorf("received %d bytes, want %d", got, size)
+	}
+	if limited.n.Load() == 0 {
+		t.Error("nothing was copied with the rate limit")
+	}
+}
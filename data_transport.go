@@ -0,0 +1,51 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import "net"
+
+// DataTransport opens the data connections of sessions, see
+// Options.DataTransport. Their addresses are TCP ones, as PASV and PORT
+// exchange them with clients, but the connections may be carried by
+// anything, for instance handed over by a local proxy.
+type DataTransport interface {
+	// ListenPassive listens for the passive data connection of sess on
+	// laddr, whose port is 0 to have one picked. The listener's Addr must
+	// be a *net.TCPAddr, its port is announced to the client.
+	ListenPassive(sess *Session, laddr *net.TCPAddr) (net.Listener, error)
+
+	// DialActive connects the active data connection of sess to raddr, a
+	// host and port, from laddr unless nil.
+	DialActive(sess *Session, laddr *net.TCPAddr, raddr string) (net.Conn, error)
+}
+
+var _ DataTransport = TCPDataTransport{}
+
+// TCPDataTransport is the default DataTransport, listening and dialing
+// over TCP.
+type TCPDataTransport struct{}
+
+// ListenPassive implements DataTransport
+func (TCPDataTransport) ListenPassive(sess *Session, laddr *net.TCPAddr) (net.Listener, error) {
+	return net.ListenTCP("tcp", laddr)
+}
+
+// DialActive implements DataTransport, through Options.ActiveProxy when
+// set. The address is reused when dialing from a fixed port as every active
+// connection from port 20 shares it.
+func (TCPDataTransport) DialActive(sess *Session, laddr *net.TCPAddr, raddr string) (net.Conn, error) {
+	if proxy := sess.server.activeProxy; proxy != nil {
+		return proxy.dial(sess.commandContext(), raddr)
+	}
+
+	dialer := net.Dialer{}
+	if laddr != nil {
+		dialer.LocalAddr = laddr
+		if laddr.Port != 0 {
+			dialer.Control = reuseAddrControl
+		}
+	}
+	return dialer.Dial("tcp", raddr)
+}
@@ -0,0 +1,124 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// serveDebug serves pprof and expvar on Options.DebugAddr until ctx is done
+func (server *Server) serveDebug(ctx context.Context) error {
+	l, err := net.Listen("tcp", server.DebugAddr)
+	if err != nil {
+		return fmt.Errorf("ftp: debug listener: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+	debug := &http.Server{Handler: mux}
+
+	go func() {
+		<-ctx.Done()
+		_ = debug.Close()
+	}()
+	go func() {
+		server.logger.Printf("", "serving debug endpoints on %s", l.Addr())
+		_ = debug.Serve(l)
+	}()
+	return nil
+}
+
+// sessionCounts returns the number of sessions being served, and of those
+// transferring a file.
+func (server *Server) sessionCounts() (sessions, transfers int) {
+	server.sessionsMu.Lock()
+	defer server.sessionsMu.Unlock()
+	for sess := range server.sessions {
+		if sess.transfer.Load() != nil {
+			transfers++
+		}
+	}
+	return len(server.sessions), transfers
+}
+
+// IsAdmin reports whether the session's user is one of Options.AdminUsers
+func (sess *Session) IsAdmin() bool {
+	return sess.user != "" && slices.Contains(sess.server.AdminUsers, sess.user)
+}
+
+// commandSiteDebug responds to SITE DEBUG, for the users of
+// Options.AdminUsers, with the goroutines, sessions and memory of the
+// process. SITE DEBUG PROFILE ON turns the block and mutex profiles of
+// pprof on, at some cost, to find what sessions wait for, OFF turns them
+// off.
+type commandSiteDebug struct{}
+
+func (cmd commandSiteDebug) IsExtend() bool {
+	return false
+}
+
+func (cmd commandSiteDebug) RequireParam() bool {
+	return false
+}
+
+func (cmd commandSiteDebug) RequireAuth() bool {
+	return true
+}
+
+func (cmd commandSiteDebug) Help() string {
+	return "Syntax: SITE DEBUG [PROFILE ON|OFF] (show runtime state, toggle block and mutex profiling)"
+}
+
+func (cmd commandSiteDebug) Execute(sess *Session, param string) {
+	if !sess.IsAdmin() {
+		sess.writeMessage(550, "Permission denied")
+		return
+	}
+
+	if param != "" {
+		switch strings.ToUpper(strings.Join(strings.Fields(param), " ")) {
+		case "PROFILE ON":
+			runtime.SetBlockProfileRate(1)
+			runtime.SetMutexProfileFraction(1)
+			sess.logf("Block and mutex profiling turned on")
+			sess.writeMessage(200, "Block and mutex profiling on")
+		case "PROFILE OFF":
+			runtime.SetBlockProfileRate(0)
+			runtime.SetMutexProfileFraction(0)
+			sess.logf("Block and mutex profiling turned off")
+			sess.writeMessage(200, "Block and mutex profiling off")
+		default:
+			sess.writeMessage(501, cmd.Help())
+		}
+		return
+	}
+
+	sessions, transfers := sess.server.sessionCounts()
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	msg := fmt.Sprintf("Runtime state:\n"+
+		" Goroutines: %d\n"+
+		" Sessions: %d\n"+
+		" Transfers: %d\n"+
+		" Draining: %v\n"+
+		" Heap: %d bytes in use, %d bytes from the system\n"+
+		" GC cycles: %d",
+		runtime.NumGoroutine(), sessions, transfers,
+		sess.server.Draining(), mem.HeapInuse, mem.HeapSys, mem.NumGC)
+	sess.writeMessageMultiline(211, msg, "End")
+}
@@ -0,0 +1,58 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import "time"
+
+const defaultShutdownMessage = "Service shutting down, closing control connection"
+
+// drain stops accepting connections and has the sessions end once their
+// command completes, see Options.ShutdownDrain. It returns once they all
+// have or the drain window is over, with the error closing the listener.
+func (server *Server) drain() error {
+	server.draining.Store(true)
+	var err error
+	if server.listener != nil {
+		err = server.listener.Close()
+	}
+
+	// Idle sessions are woken from reading their next command, sessions
+	// setting a deadline afterwards see draining first.
+	server.sessionsMu.Lock()
+	for sess := range server.sessions {
+		_ = sess.rawConn.SetReadDeadline(time.Now())
+	}
+	server.sessionsMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		server.sessionsWG.Wait()
+		close(done)
+	}()
+	timer := time.NewTimer(server.ShutdownDrain)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+	}
+	return err
+}
+
+// Draining reports whether Shutdown is waiting for the sessions to finish
+// their commands, see Options.ShutdownDrain.
+func (server *Server) Draining() bool {
+	return server.draining.Load()
+}
+
+// endDrainedSession sends the shutdown notice to the session and ends it if
+// the server is draining, reporting whether it did.
+func (sess *Session) endDrainedSession() bool {
+	if !sess.server.draining.Load() {
+		return false
+	}
+	sess.log("Server shutting down, disconnecting")
+	sess.writeMessage(421, sess.server.ShutdownMessage)
+	return true
+}
@@ -5,9 +5,9 @@
 package ftp
 
 import (
-	"errors"
 	"io"
 	"os"
+	"path"
 	"strings"
 )
 
@@ -58,12 +58,151 @@ type Driver interface {
 	// returns - a string containing the file data to send to the client
 	GetFile(*Context, string, int64) (int64, io.ReadCloser, error)
 
-	// params  - destination path, an io.Reader containing the file data
+	// params  - destination path, an io.Reader containing the file data,
+	//           offset: -1 creates or replaces the file, otherwise the data
+	//           is written from offset on and the file ends with it, as for
+	//           an upload resumed with REST. The reader streams from the
+	//           data connection, copying it with io.Copy uses the server's
+	//           pooled buffer rather than reading it whole into memory.
 	// returns - the number of bytes written and the first error encountered while writing, if any.
 	PutFile(*Context, string, io.Reader, int64) (int64, error)
 }
 
-var _ Driver = &MultiDriver{}
+// OwnerDriver is implemented by drivers knowing the owner, group and
+// permissions of their files, which listings then show instead of what
+// Options.Perm reports.
+type OwnerDriver interface {
+	// params  - path, the entry Stat or ListDir returned for it
+	// returns - the owner, group and permission bits of the entry. Options.Perm
+	//           is asked for an owner or group left blank.
+	FileOwner(*Context, string, os.FileInfo) (string, string, os.FileMode, error)
+}
+
+// AppendDriver is implemented by drivers appending to files natively, which
+// APPE then uses. For other drivers APPE calls PutFile with the size of the
+// existing file as offset.
+type AppendDriver interface {
+	// params  - path, an io.Reader containing the data to append
+	// returns - the number of bytes written and the first error encountered
+	//           while writing, if any. The file is created if it does not
+	//           exist.
+	AppendFile(*Context, string, io.Reader) (int64, error)
+}
+
+// AllocateDriver is implemented by drivers reserving storage for uploads.
+// A STOR or APPE following ALLO calls Allocate before the transfer starts,
+// so that the client is refused early.
+type AllocateDriver interface {
+	// params  - path, the size in bytes the client announced with ALLO
+	// returns - nil, or the error refusing the upload, such as
+	//           ErrStorageExceeded or ErrInsufficientStorage
+	Allocate(*Context, string, int64) error
+}
+
+// appendFile appends data to the file at p, see AppendDriver.
+func appendFile(ctx *Context, driver Driver, p string, data io.Reader) (int64, error) {
+	if appender, ok := driver.(AppendDriver); ok {
+		return appender.AppendFile(ctx, p, data)
+	}
+
+	offset := int64(-1)
+	info, err := driver.Stat(ctx, p)
+	switch {
+	case err == nil && info != nil:
+		if info.IsDir() {
+			return 0, ErrIsDir
+		}
+		offset = info.Size()
+	case err != nil && !isNotExist(err):
+		return 0, err
+	}
+	return driver.PutFile(ctx, p, data, offset)
+}
+
+// SpaceDriver is implemented by drivers knowing how much storage the user
+// of a session takes, which SITE QUOTA and SITE USAGE then report.
+type SpaceDriver interface {
+	// returns - the bytes the user stores and the bytes they may still
+	//           store, -1 when there is no limit
+	Space(*Context) (int64, int64, error)
+}
+
+// Entry is a file or subdir found listing a directory, along with its path.
+type Entry struct {
+	os.FileInfo
+
+	// Path of the entry, the directory listed joined with its name
+	Path string
+}
+
+// EntryDriver is implemented by drivers listing directories with the path
+// of each entry, which backends walking their tree know already.
+type EntryDriver interface {
+	// params  - path, function on the entry of each file or subdir found
+	// returns - error
+	ListEntries(*Context, string, func(Entry) error) error
+}
+
+// defaultListPageSize is the number of entries asked per page of a
+// PagedDriver listing, see Options.ListPageSize.
+const defaultListPageSize = 1000
+
+// PagedDriver is implemented by drivers listing directories a page at a
+// time, as object stores answer with continuation tokens. Listings then
+// stop asking for pages once the callback returns an error, for instance
+// once Options.MaxListEntries are listed, instead of walking the whole
+// directory.
+type PagedDriver interface {
+	// params  - path, token of the page ("" for the first one), maximum
+	//           number of entries of the page
+	// returns - the entries of the page, the token of the next page ("" if
+	//           none) and error
+	ListDirPage(*Context, string, string, int) ([]Entry, string, error)
+}
+
+// ListEntries lists the directory at dir with driver, through PagedDriver
+// or EntryDriver when implemented and ListDir otherwise, joining dir with
+// the names found.
+func ListEntries(ctx *Context, driver Driver, dir string, callback func(Entry) error) error {
+	if driver, ok := driver.(PagedDriver); ok {
+		return listPages(ctx, driver, dir, callback)
+	}
+	if driver, ok := driver.(EntryDriver); ok {
+		return driver.ListEntries(ctx, dir, callback)
+	}
+	return driver.ListDir(ctx, dir, func(info os.FileInfo) error {
+		return callback(Entry{FileInfo: info, Path: path.Join(dir, info.Name())})
+	})
+}
+
+// listPages lists the directory at dir a page at a time
+func listPages(ctx *Context, driver PagedDriver, dir string, callback func(Entry) error) error {
+	limit := defaultListPageSize
+	if ctx != nil && ctx.Sess != nil && ctx.Sess.server.ListPageSize > 0 {
+		limit = ctx.Sess.server.ListPageSize
+	}
+	var token string
+	for {
+		entries, next, err := driver.ListDirPage(ctx, dir, token, limit)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err = callback(entry); err != nil {
+				return err
+			}
+		}
+		if next == "" || next == token {
+			return nil
+		}
+		token = next
+	}
+}
+
+var (
+	_ Driver      = &MultiDriver{}
+	_ EntryDriver = &MultiDriver{}
+)
 
 // MultiDriver represents a composite driver
 type MultiDriver struct {
@@ -84,7 +223,7 @@ func (driver *MultiDriver) Stat(ctx *Context, path string) (os.FileInfo, error)
 			return driver.Stat(ctx, strings.TrimPrefix(path, prefix))
 		}
 	}
-	return nil, errors.New("not a file")
+	return nil, ErrNotFound
 }
 
 // ListDir implements Driver
@@ -94,7 +233,20 @@ func (driver *MultiDriver) ListDir(ctx *Context, path string, callback func(os.F
 			return driver.ListDir(ctx, strings.TrimPrefix(path, prefix), callback)
 		}
 	}
-	return errors.New("not a directory")
+	return ErrNotFound
+}
+
+// ListEntries implements EntryDriver
+func (driver *MultiDriver) ListEntries(ctx *Context, p string, callback func(Entry) error) error {
+	for prefix, driver := range driver.drivers {
+		if strings.HasPrefix(p, prefix) {
+			return ListEntries(ctx, driver, strings.TrimPrefix(p, prefix), func(entry Entry) error {
+				entry.Path = prefix + entry.Path
+				return callback(entry)
+			})
+		}
+	}
+	return ErrNotFound
 }
 
 // DeleteDir implements Driver
@@ -104,7 +256,7 @@ func (driver *MultiDriver) DeleteDir(ctx *Context, path string) error {
 			return driver.DeleteDir(ctx, strings.TrimPrefix(path, prefix))
 		}
 	}
-	return errors.New("not a directory")
+	return ErrNotFound
 }
 
 // DeleteFile implements Driver
@@ -115,7 +267,7 @@ func (driver *MultiDriver) DeleteFile(ctx *Context, path string) error {
 		}
 	}
 
-	return errors.New("not a file")
+	return ErrNotFound
 }
 
 // Rename implements Driver
@@ -126,7 +278,7 @@ func (driver *MultiDriver) Rename(ctx *Context, fromPath string, toPath string)
 		}
 	}
 
-	return errors.New("not a file")
+	return ErrNotFound
 }
 
 // MakeDir implements Driver
@@ -136,7 +288,7 @@ func (driver *MultiDriver) MakeDir(ctx *Context, path string) error {
 			return driver.MakeDir(ctx, strings.TrimPrefix(path, prefix))
 		}
 	}
-	return errors.New("not a directory")
+	return ErrNotFound
 }
 
 // GetFile implements Driver
@@ -147,7 +299,7 @@ func (driver *MultiDriver) GetFile(ctx *Context, path string, offset int64) (int
 		}
 	}
 
-	return 0, nil, errors.New("not a file")
+	return 0, nil, ErrNotFound
 }
 
 // PutFile implements Driver
@@ -158,5 +310,5 @@ func (driver *MultiDriver) PutFile(ctx *Context, destPath string, data io.Reader
 		}
 	}
 
-	return 0, errors.New("not a file")
+	return 0, ErrNotFound
 }
@@ -5,10 +5,10 @@
 package file
 
 import (
-	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
@@ -20,6 +20,12 @@ type Driver struct {
 	RootPath string
 }
 
+var (
+	_ ftp.SymlinkDriver = &Driver{}
+	_ ftp.AppendDriver  = &Driver{}
+	_ ftp.EntryDriver   = &Driver{}
+)
+
 // NewDriver implements Driver
 func NewDriver(rootPath string) (ftp.Driver, error) {
 	var err error
@@ -47,14 +53,21 @@ func (driver *Driver) Stat(ctx *ftp.Context, path string) (os.FileInfo, error) {
 
 // ListDir implements Driver
 func (driver *Driver) ListDir(ctx *ftp.Context, path string, callback func(os.FileInfo) error) error {
-	basepath := driver.realPath(path)
+	return driver.ListEntries(ctx, path, func(entry ftp.Entry) error {
+		return callback(entry.FileInfo)
+	})
+}
+
+// ListEntries implements ftp.EntryDriver
+func (driver *Driver) ListEntries(ctx *ftp.Context, dir string, callback func(ftp.Entry) error) error {
+	basepath := driver.realPath(dir)
 	return filepath.Walk(basepath, func(f string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 		rPath, _ := filepath.Rel(basepath, f)
 		if rPath == info.Name() {
-			err = callback(info)
+			err = callback(ftp.Entry{FileInfo: info, Path: path.Join(dir, info.Name())})
 			if err != nil {
 				return err
 			}
@@ -76,7 +89,7 @@ func (driver *Driver) DeleteDir(ctx *ftp.Context, path string) error {
 	if f.IsDir() {
 		return os.RemoveAll(rPath)
 	}
-	return errors.New("Not a directory")
+	return ftp.ErrNotDir
 }
 
 // DeleteFile implements Driver
@@ -89,7 +102,7 @@ func (driver *Driver) DeleteFile(ctx *ftp.Context, path string) error {
 	if !f.IsDir() {
 		return os.Remove(rPath)
 	}
-	return errors.New("Not a file")
+	return ftp.ErrIsDir
 }
 
 // Rename implements Driver
@@ -105,7 +118,8 @@ func (driver *Driver) MakeDir(ctx *ftp.Context, path string) error {
 	return os.MkdirAll(rPath, os.ModePerm)
 }
 
-// GetFile implements Driver
+// GetFile implements Driver. The returned reader is the *os.File itself, which
+// lets the server send it with sendfile(2) when no TLS or rate limit applies.
 func (driver *Driver) GetFile(ctx *ftp.Context, path string, offset int64) (int64, io.ReadCloser, error) {
 	rPath := driver.realPath(path)
 	f, err := os.Open(rPath)
@@ -139,13 +153,13 @@ func (driver *Driver) PutFile(ctx *ftp.Context, destPath string, data io.Reader,
 	if err == nil {
 		isExist = true
 		if f.IsDir() {
-			return 0, errors.New("A dir has the same name")
+			return 0, ftp.ErrIsDir
 		}
 	} else {
 		if os.IsNotExist(err) {
 			isExist = false
 		} else {
-			return 0, errors.New(fmt.Sprintln("Put File error:", err))
+			return 0, fmt.Errorf("Put File error: %w", err)
 		}
 	}
 
@@ -172,7 +186,7 @@ func (driver *Driver) PutFile(ctx *ftp.Context, destPath string, data io.Reader,
 		return bytes, nil
 	}
 
-	of, err := os.OpenFile(rPath, os.O_APPEND|os.O_RDWR, 0o660)
+	of, err := os.OpenFile(rPath, os.O_WRONLY, 0o660)
 	if err != nil {
 		return 0, err
 	}
@@ -186,7 +200,10 @@ func (driver *Driver) PutFile(ctx *ftp.Context, destPath string, data io.Reader,
 		return 0, fmt.Errorf("Offset %d is beyond file size %d", offset, info.Size())
 	}
 
-	_, err = of.Seek(offset, os.SEEK_END)
+	if err = of.Truncate(offset); err != nil {
+		return 0, err
+	}
+	_, err = of.Seek(offset, io.SeekStart)
 	if err != nil {
 		return 0, err
 	}
@@ -198,3 +215,45 @@ func (driver *Driver) PutFile(ctx *ftp.Context, destPath string, data io.Reader,
 
 	return bytes, nil
 }
+
+// AppendFile implements AppendDriver
+func (driver *Driver) AppendFile(ctx *ftp.Context, destPath string, data io.Reader) (int64, error) {
+	rPath := driver.realPath(destPath)
+	if info, err := os.Lstat(rPath); err == nil && info.IsDir() {
+		return 0, ftp.ErrIsDir
+	}
+
+	f, err := os.OpenFile(rPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o666)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	return io.Copy(f, data)
+}
+
+// Readlink implements SymlinkDriver. Links pointing outside of RootPath are
+// not disclosed.
+func (driver *Driver) Readlink(ctx *ftp.Context, linkPath string) (string, error) {
+	target, err := os.Readlink(driver.realPath(linkPath))
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(driver.realPath(linkPath)), target)
+	}
+	rel, err := filepath.Rel(driver.RootPath, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", ftp.ErrPermissionDenied
+	}
+	return "/" + filepath.ToSlash(rel), nil
+}
+
+// Symlink implements SymlinkDriver. The link stores the absolute path of
+// its target, so that it keeps pointing inside RootPath when it is moved.
+func (driver *Driver) Symlink(ctx *ftp.Context, target, linkPath string) error {
+	if !path.IsAbs(target) {
+		target = path.Join(path.Dir(linkPath), target)
+	}
+	return os.Symlink(driver.realPath(path.Clean("/"+target)), driver.realPath(linkPath))
+}
@@ -0,0 +1,51 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+//go:build !windows
+
+package file
+
+import (
+	"os"
+	"os/user"
+	"strconv"
+	"sync"
+	"syscall"
+
+	"github.com/globalcyberalliance/ftp-go"
+)
+
+var _ ftp.OwnerDriver = &Driver{}
+
+// names caches the user and group names by id, listings look the same
+// few up for every entry.
+var names sync.Map
+
+// FileOwner implements OwnerDriver with the owner and group of the file on
+// disk, shown as ids when they have no name.
+func (driver *Driver) FileOwner(ctx *ftp.Context, path string, info os.FileInfo) (string, string, os.FileMode, error) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", "", info.Mode().Perm(), nil
+	}
+	return lookupName("u", stat.Uid), lookupName("g", stat.Gid), info.Mode().Perm(), nil
+}
+
+func lookupName(kind string, id uint32) string {
+	key := kind + strconv.FormatUint(uint64(id), 10)
+	if name, ok := names.Load(key); ok {
+		return name.(string)
+	}
+
+	name := strconv.FormatUint(uint64(id), 10)
+	if kind == "u" {
+		if u, err := user.LookupId(name); err == nil {
+			name = u.Username
+		}
+	} else if g, err := user.LookupGroupId(name); err == nil {
+		name = g.Name
+	}
+	names.Store(key, name)
+	return name
+}
@@ -0,0 +1,21 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+//go:build windows
+
+package file
+
+import (
+	"os"
+
+	"github.com/globalcyberalliance/ftp-go"
+)
+
+var _ ftp.OwnerDriver = &Driver{}
+
+// FileOwner implements OwnerDriver with the permissions of the file, the
+// owner and group are left to Options.Perm.
+func (driver *Driver) FileOwner(ctx *ftp.Context, path string, info os.FileInfo) (string, string, os.FileMode, error) {
+	return "", "", info.Mode().Perm(), nil
+}
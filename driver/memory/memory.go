@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 
 	"github.com/absfs/memfs"
 	"github.com/globalcyberalliance/ftp-go"
@@ -40,6 +41,14 @@ func (driver *Driver) Stat(ctx *ftp.Context, filePath string) (os.FileInfo, erro
 }
 
 func (driver *Driver) ListDir(ctx *ftp.Context, filePath string, callback func(os.FileInfo) error) error {
+	return driver.ListEntries(ctx, filePath, func(entry ftp.Entry) error {
+		return callback(entry.FileInfo)
+	})
+}
+
+// ListEntries lists the entries right below filePath, with the paths Walk
+// tells, not descending into subdirectories.
+func (driver *Driver) ListEntries(ctx *ftp.Context, filePath string, callback func(ftp.Entry) error) error {
 	return driver.fs.Walk(filePath, func(currPath string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -49,10 +58,13 @@ func (driver *Driver) ListDir(ctx *ftp.Context, filePath string, callback func(o
 			return nil
 		}
 
-		if err = callback(info); err != nil {
+		if err = callback(ftp.Entry{FileInfo: info, Path: currPath}); err != nil {
 			return err
 		}
 
+		if info.IsDir() {
+			return filepath.SkipDir
+		}
 		return nil
 	})
 }
@@ -101,7 +113,7 @@ func (driver *Driver) PutFile(ctx *ftp.Context, filePath string, data io.Reader,
 	if err == nil {
 		exists = true
 		if f.IsDir() {
-			return 0, fmt.Errorf("dir already exists: %s", filePath)
+			return 0, fmt.Errorf("dir already exists: %s: %w", filePath, ftp.ErrIsDir)
 		}
 	} else {
 		if os.IsNotExist(err) {
@@ -137,7 +149,7 @@ func (driver *Driver) PutFile(ctx *ftp.Context, filePath string, data io.Reader,
 		return bytesWritten, nil
 	}
 
-	of, err := driver.fs.Open(filePath)
+	of, err := driver.fs.OpenFile(filePath, os.O_WRONLY, 0)
 	if err != nil {
 		return 0, fmt.Errorf(errOpenFileF, filePath, err)
 	}
@@ -152,7 +164,10 @@ func (driver *Driver) PutFile(ctx *ftp.Context, filePath string, data io.Reader,
 		return 0, fmt.Errorf("offset %d is beyond file size %d", offset, stat.Size())
 	}
 
-	if _, err = of.Seek(offset, io.SeekEnd); err != nil {
+	if err = of.Truncate(offset); err != nil {
+		return 0, err
+	}
+	if _, err = of.Seek(offset, io.SeekStart); err != nil {
 		return 0, fmt.Errorf(errSeekFileF, filePath, offset, err)
 	}
 
@@ -163,3 +178,21 @@ func (driver *Driver) PutFile(ctx *ftp.Context, filePath string, data io.Reader,
 
 	return bytesPut, nil
 }
+
+func (driver *Driver) AppendFile(ctx *ftp.Context, filePath string, data io.Reader) (int64, error) {
+	if stat, err := driver.fs.Lstat(filePath); err == nil && stat.IsDir() {
+		return 0, fmt.Errorf("dir already exists: %s: %w", filePath, ftp.ErrIsDir)
+	}
+
+	f, err := driver.fs.OpenFile(filePath, os.O_CREATE|os.O_WRONLY, defaultFileMode)
+	if err != nil {
+		return 0, fmt.Errorf(errOpenFileF, filePath, err)
+	}
+	defer f.Close()
+
+	if _, err = f.Seek(0, io.SeekEnd); err != nil {
+		return 0, fmt.Errorf(errSeekFileF, filePath, 0, err)
+	}
+
+	return io.Copy(f, data)
+}
@@ -0,0 +1,329 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+const defaultDriverBreakerCooldown = 30 * time.Second
+
+var (
+	// ErrDriverTimeout is returned in place of the result of a driver call
+	// which outlasted Options.DriverTimeout.
+	ErrDriverTimeout = errors.New("ftp: driver call timed out")
+
+	// ErrDriverUnavailable is returned without calling the driver while
+	// its circuit breaker is open, see Options.DriverFailureThreshold.
+	ErrDriverUnavailable = errors.New("ftp: driver unavailable")
+)
+
+var (
+	_ Driver       = &guardedDriver{}
+	_ AppendDriver = &guardedDriver{}
+	_ EntryDriver  = &guardedDriver{}
+)
+
+// guardedDriver gives up on the calls of the wrapped driver after a
+// timeout, and stops calling it for a while after repeated failures so
+// that a hung backend is answered promptly.
+type guardedDriver struct {
+	Driver
+	logger    Logger
+	timeout   time.Duration
+	threshold int
+	cooldown  time.Duration
+
+	lock      sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+	// replaced in tests
+	now func() time.Time
+}
+
+func newGuardedDriver(driver Driver, opts *Options) *guardedDriver {
+	return &guardedDriver{
+		Driver:    driver,
+		logger:    opts.Logger,
+		timeout:   opts.DriverTimeout,
+		threshold: opts.DriverFailureThreshold,
+		cooldown:  opts.DriverBreakerCooldown,
+		now:       time.Now,
+	}
+}
+
+// guardedCall is a driver call in progress. The callbacks and readers the
+// driver is handed report their progress through it, and stop passing
+// anything on once the call was given up.
+type guardedCall struct {
+	lock      sync.Mutex
+	progress  chan struct{}
+	abandoned bool
+	// first error of a callback or reader, so that failures on the
+	// client's side are not held against the driver
+	clientErr error
+}
+
+// enter is called before a callback or read, it returns false once the call
+// was given up. Unless it did, leave must follow.
+func (call *guardedCall) enter() bool {
+	call.lock.Lock()
+	if call.abandoned {
+		call.lock.Unlock()
+		return false
+	}
+	select {
+	case call.progress <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+func (call *guardedCall) leave(err error) {
+	if err != nil && err != io.EOF && call.clientErr == nil {
+		call.clientErr = err
+	}
+	call.lock.Unlock()
+}
+
+func (call *guardedCall) abandon() {
+	call.lock.Lock()
+	call.abandoned = true
+	call.lock.Unlock()
+}
+
+// guard runs fn through the circuit breaker, giving up on it once it makes
+// no progress for the timeout. The result of a call given up is passed to
+// discard, if not nil, when it finally returns.
+func guard[T any](driver *guardedDriver, fn func(call *guardedCall) (T, error), discard func(T)) (T, error) {
+	var zero T
+	if err := driver.allow(); err != nil {
+		return zero, err
+	}
+
+	call := &guardedCall{progress: make(chan struct{}, 1)}
+	if driver.timeout <= 0 {
+		v, err := fn(call)
+		driver.record(call, err)
+		return v, err
+	}
+
+	type result struct {
+		v   T
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		v, err := fn(call)
+		done <- result{v, err}
+	}()
+
+	deadline := time.Now().Add(driver.timeout)
+	timer := time.NewTimer(driver.timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case r := <-done:
+			driver.record(call, r.err)
+			return r.v, r.err
+		case <-call.progress:
+			deadline = time.Now().Add(driver.timeout)
+		case <-timer.C:
+			if wait := time.Until(deadline); wait > 0 {
+				timer.Reset(wait)
+				continue
+			}
+			call.abandon()
+			go func() {
+				if r := <-done; r.err == nil && discard != nil {
+					discard(r.v)
+				}
+			}()
+			driver.record(call, ErrDriverTimeout)
+			return zero, ErrDriverTimeout
+		}
+	}
+}
+
+// allow returns ErrDriverUnavailable while the circuit breaker is open. Once
+// it cooled down, a single call is let through to probe the driver.
+func (driver *guardedDriver) allow() error {
+	if driver.threshold <= 0 {
+		return nil
+	}
+	driver.lock.Lock()
+	defer driver.lock.Unlock()
+
+	if driver.failures < driver.threshold {
+		return nil
+	}
+	if driver.probing || driver.now().Before(driver.openUntil) {
+		return ErrDriverUnavailable
+	}
+	driver.probing = true
+	return nil
+}
+
+// record accounts for the outcome of a call in the circuit breaker.
+func (driver *guardedDriver) record(call *guardedCall, err error) {
+	if driver.threshold <= 0 {
+		return
+	}
+	driver.lock.Lock()
+	defer driver.lock.Unlock()
+
+	driver.probing = false
+	if !isDriverFailure(call, err) {
+		driver.failures = 0
+		return
+	}
+	driver.failures++
+	if driver.failures >= driver.threshold {
+		driver.openUntil = driver.now().Add(driver.cooldown)
+		driver.logger.Printf("", "driver failed %d times in a row, not calling it for %s: %v", driver.failures, driver.cooldown, err)
+	}
+}
+
+// isDriverFailure reports whether err shows a problem of the driver's
+// backend, rather than of the request or the client.
+func isDriverFailure(call *guardedCall, err error) bool {
+	switch {
+	case err == nil, errors.Is(err, context.Canceled):
+		return false
+	case errors.Is(err, ErrDriverTimeout):
+		return true
+	}
+	call.lock.Lock()
+	clientErr := call.clientErr
+	call.lock.Unlock()
+	if clientErr != nil {
+		return false
+	}
+	code, _ := errorReply(err, 0, "")
+	return code == 0
+}
+
+// Stat implements Driver
+func (driver *guardedDriver) Stat(ctx *Context, p string) (os.FileInfo, error) {
+	return guard(driver, func(*guardedCall) (os.FileInfo, error) {
+		return driver.Driver.Stat(ctx, p)
+	}, nil)
+}
+
+// ListDir implements Driver, the timeout applies between entries
+func (driver *guardedDriver) ListDir(ctx *Context, p string, callback func(os.FileInfo) error) error {
+	_, err := guard(driver, func(call *guardedCall) (struct{}, error) {
+		return struct{}{}, driver.Driver.ListDir(ctx, p, func(info os.FileInfo) error {
+			if !call.enter() {
+				return ErrDriverTimeout
+			}
+			err := callback(info)
+			call.leave(err)
+			return err
+		})
+	}, nil)
+	return err
+}
+
+// ListEntries implements EntryDriver, the timeout applies between entries
+func (driver *guardedDriver) ListEntries(ctx *Context, p string, callback func(Entry) error) error {
+	_, err := guard(driver, func(call *guardedCall) (struct{}, error) {
+		return struct{}{}, ListEntries(ctx, driver.Driver, p, func(entry Entry) error {
+			if !call.enter() {
+				return ErrDriverTimeout
+			}
+			err := callback(entry)
+			call.leave(err)
+			return err
+		})
+	}, nil)
+	return err
+}
+
+// DeleteDir implements Driver
+func (driver *guardedDriver) DeleteDir(ctx *Context, p string) error {
+	_, err := guard(driver, func(*guardedCall) (struct{}, error) {
+		return struct{}{}, driver.Driver.DeleteDir(ctx, p)
+	}, nil)
+	return err
+}
+
+// DeleteFile implements Driver
+func (driver *guardedDriver) DeleteFile(ctx *Context, p string) error {
+	_, err := guard(driver, func(*guardedCall) (struct{}, error) {
+		return struct{}{}, driver.Driver.DeleteFile(ctx, p)
+	}, nil)
+	return err
+}
+
+// Rename implements Driver
+func (driver *guardedDriver) Rename(ctx *Context, fromPath, toPath string) error {
+	_, err := guard(driver, func(*guardedCall) (struct{}, error) {
+		return struct{}{}, driver.Driver.Rename(ctx, fromPath, toPath)
+	}, nil)
+	return err
+}
+
+// MakeDir implements Driver
+func (driver *guardedDriver) MakeDir(ctx *Context, p string) error {
+	_, err := guard(driver, func(*guardedCall) (struct{}, error) {
+		return struct{}{}, driver.Driver.MakeDir(ctx, p)
+	}, nil)
+	return err
+}
+
+type openedFile struct {
+	size int64
+	r    io.ReadCloser
+}
+
+// GetFile implements Driver, the timeout applies to opening the file and
+// not to reading it. A file opened too late is closed.
+func (driver *guardedDriver) GetFile(ctx *Context, p string, offset int64) (int64, io.ReadCloser, error) {
+	f, err := guard(driver, func(*guardedCall) (openedFile, error) {
+		size, r, err := driver.Driver.GetFile(ctx, p, offset)
+		return openedFile{size, r}, err
+	}, func(f openedFile) {
+		f.r.Close()
+	})
+	return f.size, f.r, err
+}
+
+// PutFile implements Driver, the timeout applies between the reads of the
+// upload and after the last one.
+func (driver *guardedDriver) PutFile(ctx *Context, p string, data io.Reader, offset int64) (int64, error) {
+	return guard(driver, func(call *guardedCall) (int64, error) {
+		return driver.Driver.PutFile(ctx, p, &guardedReader{r: data, call: call}, offset)
+	}, nil)
+}
+
+// AppendFile implements AppendDriver, the timeout applies as for PutFile
+func (driver *guardedDriver) AppendFile(ctx *Context, p string, data io.Reader) (int64, error) {
+	return guard(driver, func(call *guardedCall) (int64, error) {
+		return appendFile(ctx, driver.Driver, p, &guardedReader{r: data, call: call})
+	}, nil)
+}
+
+// guardedReader is the upload a guarded PutFile reads, which it stops
+// reading once the call was given up.
+type guardedReader struct {
+	r    io.Reader
+	call *guardedCall
+}
+
+func (r *guardedReader) Read(p []byte) (int, error) {
+	if !r.call.enter() {
+		return 0, ErrDriverTimeout
+	}
+	n, err := r.r.Read(p)
+	r.call.leave(err)
+	return n, err
+}
@@ -0,0 +1,111 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+// stubDriver answers Stat with err after delay, and lists count entries
+// delay apart.
+type stubDriver struct {
+	Driver
+	delay time.Duration
+	count int
+	err   error
+	calls int
+}
+
+func (driver *stubDriver) Stat(ctx *Context, p string) (os.FileInfo, error) {
+	driver.calls++
+	time.Sleep(driver.delay)
+	return nil, driver.err
+}
+
+func (driver *stubDriver) ListDir(ctx *Context, p string, callback func(os.FileInfo) error) error {
+	for i := 0; i < driver.count; i++ {
+		time.Sleep(driver.delay)
+		if err := callback(nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestGuardedDriverTimeout(t *testing.T) {
+	stub := &stubDriver{delay: time.Second}
+	driver := newGuardedDriver(stub, &Options{
+		Logger:        new(DiscardLogger),
+		DriverTimeout: 20 * time.Millisecond,
+	})
+
+	start := time.Now()
+	if _, err := driver.Stat(nil, "/"); !errors.Is(err, ErrDriverTimeout) {
+		t.Fatalf("expected ErrDriverTimeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("Stat gave up after %v", elapsed)
+	}
+	if code, _ := errorReply(ErrDriverTimeout, 550, ""); code != 451 {
+		t.Fatalf("expected ErrDriverTimeout to be answered with 451, got %d", code)
+	}
+
+	// A listing making progress is not given up, however long it takes
+	driver = newGuardedDriver(&stubDriver{delay: 5 * time.Millisecond, count: 10}, &Options{
+		Logger:        new(DiscardLogger),
+		DriverTimeout: 20 * time.Millisecond,
+	})
+	entries := 0
+	err := driver.ListDir(nil, "/", func(os.FileInfo) error {
+		entries++
+		return nil
+	})
+	if err != nil || entries != 10 {
+		t.Fatalf("expected 10 entries, got %d (%v)", entries, err)
+	}
+}
+
+func TestGuardedDriverBreaker(t *testing.T) {
+	stub := &stubDriver{err: errors.New("backend down")}
+	driver := newGuardedDriver(stub, &Options{
+		Logger:                 new(DiscardLogger),
+		DriverFailureThreshold: 2,
+		DriverBreakerCooldown:  time.Minute,
+	})
+	now := time.Unix(0, 0)
+	driver.now = func() time.Time { return now }
+
+	// Errors a client causes are not failures
+	stub.err = ErrNotFound
+	for i := 0; i < 3; i++ {
+		if _, err := driver.Stat(nil, "/"); err != ErrNotFound {
+			t.Fatalf("expected ErrNotFound, got %v", err)
+		}
+	}
+
+	stub.err = errors.New("backend down")
+	for i := 0; i < 2; i++ {
+		_, _ = driver.Stat(nil, "/")
+	}
+	calls := stub.calls
+	if _, err := driver.Stat(nil, "/"); !errors.Is(err, ErrDriverUnavailable) {
+		t.Fatalf("expected ErrDriverUnavailable, got %v", err)
+	}
+	if stub.calls != calls {
+		t.Fatal("the driver was called while the breaker is open")
+	}
+
+	// Once cooled down, a successful probe closes the breaker
+	now = now.Add(time.Minute)
+	stub.err = nil
+	for i := 0; i < 2; i++ {
+		if _, err := driver.Stat(nil, "/"); err != nil {
+			t.Fatalf("expected the breaker to be closed, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,345 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package elastic ships server events to an Elasticsearch or OpenSearch
+// index. A Sink is an io.Writer taking one JSON document per Write, as the
+// eventlog.Exporter and honeypot.JSONRecorder write them, and indexes them
+// in the background with the bulk API.
+//
+//	sink, err := elastic.NewSink(&elastic.Options{
+//		URL:    "https://localhost:9200",
+//		Index:  "ftp-events",
+//		APIKey: key,
+//	})
+//	defer sink.Close()
+//	hp := honeypot.New(honeypot.NewJSONRecorder(sink))
+package elastic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultBatchSize     = 500
+	defaultFlushInterval = 5 * time.Second
+	defaultMaxQueued     = 10000
+	defaultMaxRetries    = 5
+	defaultMinBackoff    = 500 * time.Millisecond
+	defaultMaxBackoff    = 30 * time.Second
+	defaultTimeout       = 30 * time.Second
+)
+
+// ErrClosed is returned writing to a closed Sink
+var ErrClosed = errors.New("elastic: sink closed")
+
+// Options configure a Sink
+type Options struct {
+	// URL of the cluster, e.g. "https://localhost:9200"
+	URL string
+
+	// Index, alias or data stream the events are indexed in
+	Index string
+
+	// Credentials, an API key or a user and password. Optional.
+	APIKey   string
+	Username string
+	Password string
+
+	// HTTP client requests are sent with. Optional, defaults to one timing
+	// out after 30 seconds.
+	HTTPClient *http.Client
+
+	// Number of events sent in a bulk request. Optional, defaults to 500.
+	BatchSize int
+
+	// How long events wait for a batch to fill up before they are sent.
+	// Optional, defaults to 5 seconds.
+	FlushInterval time.Duration
+
+	// Number of events held while the cluster is slow or unreachable, the
+	// events written past it are dropped. Optional, defaults to 10000.
+	MaxQueued int
+
+	// Number of times a batch is retried, backing off from MinBackoff to
+	// MaxBackoff, before its events are dropped. Rejected requests and
+	// events are retried when the cluster is overloaded (429) or failing
+	// (5xx). Optional, defaults to 5, 500ms and 30s.
+	MaxRetries int
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// Called with the errors of events which could not be indexed and
+	// were dropped. Optional.
+	OnError func(err error)
+}
+
+// Sink indexes the JSON documents written to it in batches, see NewSink. It
+// is safe for concurrent use and Write never waits for the cluster.
+type Sink struct {
+	opts    Options
+	dropped atomic.Int64
+
+	mu     sync.Mutex
+	queue  [][]byte
+	closed bool
+
+	wake      chan struct{}
+	done      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+}
+
+// NewSink creates a Sink and starts indexing what is written to it
+func NewSink(opts *Options) (*Sink, error) {
+	if opts.URL == "" {
+		return nil, errors.New("elastic: no URL")
+	}
+	if opts.Index == "" {
+		return nil, errors.New("elastic: no index")
+	}
+	s := &Sink{
+		opts:    *opts,
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	if s.opts.HTTPClient == nil {
+		s.opts.HTTPClient = &http.Client{Timeout: defaultTimeout}
+	}
+	if s.opts.BatchSize <= 0 {
+		s.opts.BatchSize = defaultBatchSize
+	}
+	if s.opts.FlushInterval <= 0 {
+		s.opts.FlushInterval = defaultFlushInterval
+	}
+	if s.opts.MaxQueued <= 0 {
+		s.opts.MaxQueued = defaultMaxQueued
+	}
+	if s.opts.MaxRetries <= 0 {
+		s.opts.MaxRetries = defaultMaxRetries
+	}
+	if s.opts.MinBackoff <= 0 {
+		s.opts.MinBackoff = defaultMinBackoff
+	}
+	if s.opts.MaxBackoff <= 0 {
+		s.opts.MaxBackoff = defaultMaxBackoff
+	}
+	go s.run()
+	return s, nil
+}
+
+// Write implements io.Writer, p is a JSON document. It is queued and sent
+// with the next batch, or dropped if the queue is full.
+func (s *Sink) Write(p []byte) (int, error) {
+	doc := bytes.TrimSpace(p)
+	if len(doc) == 0 {
+		return len(p), nil
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return 0, ErrClosed
+	}
+	if len(s.queue) >= s.opts.MaxQueued {
+		s.mu.Unlock()
+		s.dropped.Add(1)
+		return len(p), nil
+	}
+	s.queue = append(s.queue, bytes.Clone(doc))
+	full := len(s.queue) >= s.opts.BatchSize
+	s.mu.Unlock()
+
+	if full {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+	return len(p), nil
+}
+
+// Dropped returns the number of events dropped so far, as the queue was
+// full or they could not be indexed.
+func (s *Sink) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// Close sends the events queued, trying each batch once, and stops the
+// sink.
+func (s *Sink) Close() error {
+	s.closeOnce.Do(func() {
+		s.mu.Lock()
+		s.closed = true
+		s.mu.Unlock()
+		close(s.done)
+	})
+	<-s.stopped
+	return nil
+}
+
+func (s *Sink) run() {
+	defer close(s.stopped)
+	ticker := time.NewTicker(s.opts.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.wake:
+		case <-ticker.C:
+		case <-s.done:
+			s.flush()
+			return
+		}
+		s.flush()
+	}
+}
+
+// flush sends the queue batch by batch
+func (s *Sink) flush() {
+	for {
+		s.mu.Lock()
+		n := min(len(s.queue), s.opts.BatchSize)
+		batch := s.queue[:n:n]
+		s.queue = s.queue[n:]
+		s.mu.Unlock()
+		if n == 0 {
+			return
+		}
+		s.send(batch)
+	}
+}
+
+// send indexes docs, retrying those which may succeed later
+func (s *Sink) send(docs [][]byte) {
+	backoff := s.opts.MinBackoff
+	for attempt := 0; ; attempt++ {
+		retry, err := s.bulk(docs)
+		if err == nil {
+			return
+		}
+		if len(retry) == 0 || attempt >= s.opts.MaxRetries || !s.sleep(backoff) {
+			s.drop(len(docs), err)
+			return
+		}
+		docs = retry
+		backoff = min(2*backoff, s.opts.MaxBackoff)
+	}
+}
+
+// sleep waits for d, it returns false if the sink was closed meanwhile
+func (s *Sink) sleep(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+func (s *Sink) drop(n int, err error) {
+	s.dropped.Add(int64(n))
+	if s.opts.OnError != nil {
+		s.opts.OnError(fmt.Errorf("elastic: dropped %d events: %w", n, err))
+	}
+}
+
+// bulkResponse is the part of the bulk API's reply telling which documents
+// failed
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int             `json:"status"`
+		Error  json.RawMessage `json:"error"`
+	} `json:"items"`
+}
+
+// retryable tells statuses worth retrying
+func retryable(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// bulk sends docs in a bulk request. It returns the documents to retry, all
+// of them if the request failed, and the error of the failed ones. The
+// documents rejected for good are dropped.
+func (s *Sink) bulk(docs [][]byte) ([][]byte, error) {
+	action, err := json.Marshal(map[string]interface{}{
+		"create": map[string]string{"_index": s.opts.Index},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var body bytes.Buffer
+	for _, doc := range docs {
+		body.Write(action)
+		body.WriteByte('\n')
+		body.Write(doc)
+		body.WriteByte('\n')
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, strings.TrimSuffix(s.opts.URL, "/")+"/_bulk", &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if s.opts.APIKey != "" {
+		req.Header.Set("Authorization", "ApiKey "+s.opts.APIKey)
+	} else if s.opts.Username != "" {
+		req.SetBasicAuth(s.opts.Username, s.opts.Password)
+	}
+
+	resp, err := s.opts.HTTPClient.Do(req)
+	if err != nil {
+		return docs, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		reply, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		err = fmt.Errorf("bulk request: %s: %s", resp.Status, bytes.TrimSpace(reply))
+		if retryable(resp.StatusCode) {
+			return docs, err
+		}
+		return nil, err
+	}
+
+	var reply bulkResponse
+	if err = json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		return nil, fmt.Errorf("bulk reply: %w", err)
+	}
+	if !reply.Errors {
+		return nil, nil
+	}
+
+	var retry [][]byte
+	for i, item := range reply.Items {
+		if i >= len(docs) {
+			break
+		}
+		for _, result := range item {
+			switch {
+			case result.Status/100 == 2:
+			case retryable(result.Status):
+				retry = append(retry, docs[i])
+			default:
+				s.drop(1, fmt.Errorf("status %d: %s", result.Status, result.Error))
+			}
+		}
+	}
+	if len(retry) == 0 {
+		return nil, nil
+	}
+	return retry, fmt.Errorf("%d events rejected as the cluster is busy", len(retry))
+}
@@ -0,0 +1,159 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package elastic
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeCluster answers bulk requests, rejecting the documents named in busy
+// once and those in invalid always
+type fakeCluster struct {
+	mu       sync.Mutex
+	requests int
+	indexed  []string
+	busy     map[string]bool
+	invalid  map[string]bool
+	down     int // number of requests failing with 503
+}
+
+func (c *fakeCluster) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests++
+
+	if r.URL.Path != "/_bulk" || r.Header.Get("Authorization") != "ApiKey secret" {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	if c.down > 0 {
+		c.down--
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	var items []string
+	var errors bool
+	scanner := bufio.NewScanner(r.Body)
+	for scanner.Scan() {
+		var action map[string]map[string]string
+		if err := json.Unmarshal(scanner.Bytes(), &action); err != nil || action["create"]["_index"] != "events" || !scanner.Scan() {
+			http.Error(w, "bad action", http.StatusBadRequest)
+			return
+		}
+		var doc struct{ Name string }
+		_ = json.Unmarshal(scanner.Bytes(), &doc)
+		status := 201
+		switch {
+		case c.busy[doc.Name]:
+			delete(c.busy, doc.Name)
+			status = 429
+		case c.invalid[doc.Name]:
+			status = 400
+		default:
+			c.indexed = append(c.indexed, doc.Name)
+		}
+		errors = errors || status != 201
+		items = append(items, fmt.Sprintf(`{"create":{"status":%d}}`, status))
+	}
+	fmt.Fprintf(w, `{"errors":%v,"items":[%s]}`, errors, strings.Join(items, ","))
+}
+
+func (c *fakeCluster) state() (int, []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.requests, append([]string(nil), c.indexed...)
+}
+
+// newTestSink returns a sink indexing into cluster and the func returning
+// the errors it reported
+func newTestSink(t *testing.T, cluster *fakeCluster, batchSize int) (*Sink, func() []error) {
+	server := httptest.NewServer(cluster)
+	t.Cleanup(server.Close)
+
+	var mu sync.Mutex
+	var errs []error
+	sink, err := NewSink(&Options{
+		URL:           server.URL,
+		Index:         "events",
+		APIKey:        "secret",
+		BatchSize:     batchSize,
+		FlushInterval: time.Hour,
+		MinBackoff:    time.Millisecond,
+		MaxBackoff:    4 * time.Millisecond,
+		OnError: func(err error) {
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+		},
+	})
+	assert.NoError(t, err)
+	return sink, func() []error {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]error(nil), errs...)
+	}
+}
+
+func TestSink(t *testing.T) {
+	_, err := NewSink(&Options{URL: "http://localhost:9200"})
+	assert.Error(t, err)
+
+	cluster := &fakeCluster{
+		busy:    map[string]bool{"b": true},
+		invalid: map[string]bool{"c": true},
+		down:    1,
+	}
+	sink, errs := newTestSink(t, cluster, 3)
+
+	// A full batch is sent at once, retried while the cluster is down or
+	// busy, its invalid document dropped
+	for _, name := range []string{"a", "b", "c"} {
+		_, err = fmt.Fprintf(sink, "{\"name\":%q}\n", name)
+		assert.NoError(t, err)
+	}
+	assert.Eventually(t, func() bool {
+		_, indexed := cluster.state()
+		return len(indexed) == 2
+	}, time.Second, time.Millisecond)
+	requests, indexed := cluster.state()
+	assert.Equal(t, 3, requests)
+	assert.Equal(t, []string{"a", "b"}, indexed)
+	assert.EqualValues(t, 1, sink.Dropped())
+	assert.Len(t, errs(), 1)
+
+	// Close sends what is left
+	_, err = sink.Write([]byte(`{"name":"d"}`))
+	assert.NoError(t, err)
+	assert.NoError(t, sink.Close())
+	_, indexed = cluster.state()
+	assert.Equal(t, []string{"a", "b", "d"}, indexed)
+	_, err = sink.Write([]byte(`{"name":"e"}`))
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+func TestSinkGivesUp(t *testing.T) {
+	cluster := &fakeCluster{down: 100}
+	sink, errs := newTestSink(t, cluster, 1)
+	_, err := sink.Write([]byte(`{"name":"a"}`))
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return len(errs()) == 1
+	}, time.Second, time.Millisecond)
+	requests, _ := cluster.state()
+	assert.Equal(t, 1+defaultMaxRetries, requests)
+	assert.EqualValues(t, 1, sink.Dropped())
+	assert.NoError(t, sink.Close())
+}
@@ -0,0 +1,107 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// OptionsFromEnv returns Options configured by environment variables, as
+// containers are. Variables which are unset or empty leave the defaults:
+//
+//	FTP_HOST                 Hostname
+//	FTP_PORT                 Port
+//	FTP_PASSIVE_PORTS        PassivePorts, such as "30000-30009"
+//	FTP_PUBLIC_IP            PublicIP
+//	FTP_PUBLIC_IP_FROM       name of a variable holding PublicIP
+//	FTP_PUBLIC_IP_DISCOVERY  PublicIPDiscovery
+//	FTP_TLS_CERT_FILE        CertFile, enabling explicit TLS
+//	FTP_TLS_KEY_FILE         KeyFile
+//	FTP_TLS_IMPLICIT         implicit TLS rather than explicit
+//	FTP_FORCE_TLS            ForceTLS
+//	FTP_USER                 name of a SimpleAuth
+//	FTP_PASSWORD             password of FTP_USER
+//	FTP_PASSWORD_FILE        file holding the password, as a mounted secret
+//
+// FTP_PUBLIC_IP_FROM names a variable set by the platform, such as one
+// Kubernetes sets to the node's address with the downward API
+// (status.hostIP) for pods reached through a NodePort or a host port. The
+// passive ports must then be exposed under the same numbers.
+//
+// Kubernetes sets FTP_PORT to a URL for pods sharing a namespace with a
+// service named "ftp", such values are ignored.
+//
+// The driver and the permissions are left for the caller to set.
+func OptionsFromEnv() (*Options, error) {
+	opts := &Options{
+		Hostname:          os.Getenv("FTP_HOST"),
+		PassivePorts:      os.Getenv("FTP_PASSIVE_PORTS"),
+		PublicIP:          os.Getenv("FTP_PUBLIC_IP"),
+		PublicIPDiscovery: os.Getenv("FTP_PUBLIC_IP_DISCOVERY"),
+		CertFile:          os.Getenv("FTP_TLS_CERT_FILE"),
+		KeyFile:           os.Getenv("FTP_TLS_KEY_FILE"),
+	}
+
+	if port := os.Getenv("FTP_PORT"); port != "" && !strings.Contains(port, "://") {
+		n, err := strconv.Atoi(port)
+		if err != nil || n < 0 || n > 0xffff {
+			return nil, fmt.Errorf("ftp: invalid FTP_PORT %q", port)
+		}
+		opts.Port = n
+	}
+
+	if name := os.Getenv("FTP_PUBLIC_IP_FROM"); name != "" && opts.PublicIP == "" {
+		opts.PublicIP = os.Getenv(name)
+		if opts.PublicIP == "" {
+			return nil, fmt.Errorf("ftp: FTP_PUBLIC_IP_FROM names %s, which is not set", name)
+		}
+	}
+
+	if opts.CertFile != "" || opts.KeyFile != "" {
+		implicit, err := envBool("FTP_TLS_IMPLICIT")
+		if err != nil {
+			return nil, err
+		}
+		opts.TLS = true
+		opts.ExplicitFTPS = !implicit
+	}
+	forceTLS, err := envBool("FTP_FORCE_TLS")
+	if err != nil {
+		return nil, err
+	}
+	opts.ForceTLS = forceTLS
+
+	if user := os.Getenv("FTP_USER"); user != "" {
+		password := os.Getenv("FTP_PASSWORD")
+		if file := os.Getenv("FTP_PASSWORD_FILE"); file != "" {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("ftp: reading FTP_PASSWORD_FILE: %w", err)
+			}
+			password = strings.TrimRight(string(data), "\r\n")
+		}
+		if password == "" {
+			return nil, fmt.Errorf("ftp: FTP_USER %s has no FTP_PASSWORD or FTP_PASSWORD_FILE", user)
+		}
+		opts.Auth = &SimpleAuth{Name: user, Password: password}
+	}
+	return opts, nil
+}
+
+// envBool returns the boolean variable name, false when unset
+func envBool(name string) (bool, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("ftp: invalid %s %q", name, value)
+	}
+	return b, nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"errors"
+	"io/fs"
+)
+
+// Errors a Driver may return, possibly wrapped, to have them answered with
+// a matching reply instead of the command's generic failure.
+var (
+	// ErrNotFound is returned when the file or directory does not exist.
+	ErrNotFound = errors.New("ftp: no such file or directory")
+
+	// ErrPermissionDenied is returned when the user may not access the path.
+	ErrPermissionDenied = errors.New("ftp: permission denied")
+
+	// ErrNotDir is returned when a directory was expected.
+	ErrNotDir = errors.New("ftp: not a directory")
+
+	// ErrIsDir is returned when a file was expected.
+	ErrIsDir = errors.New("ftp: is a directory")
+
+	// ErrExist is returned when the target already exists.
+	ErrExist = errors.New("ftp: file already exists")
+
+	// ErrInvalidName is returned when a file name is not allowed.
+	ErrInvalidName = errors.New("ftp: file name not allowed")
+
+	// ErrStorageExceeded is returned when a write would exceed the storage
+	// allocated to the user.
+	ErrStorageExceeded = errors.New("ftp: storage allocation exceeded")
+
+	// ErrInsufficientStorage is returned when the backend is out of space.
+	ErrInsufficientStorage = errors.New("ftp: insufficient storage space")
+
+	// ErrUnavailable is returned when the file is temporarily unavailable,
+	// for instance locked or busy. The client may retry later.
+	ErrUnavailable = errors.New("ftp: file unavailable")
+)
+
+// Errors an Auth may return from CheckPasswd, possibly wrapped, to have the
+// login refused with a matching reply.
+var (
+	// ErrAccountExpired is returned when the account of the user expired.
+	ErrAccountExpired = errors.New("ftp: account expired")
+
+	// ErrPasswordChangeRequired is returned when the user must change their
+	// password before logging in again.
+	ErrPasswordChangeRequired = errors.New("ftp: password change required")
+)
+
+// isNotExist reports whether err tells the file does not exist.
+func isNotExist(err error) bool {
+	return errors.Is(err, ErrNotFound) || errors.Is(err, fs.ErrNotExist)
+}
+
+// ReplyError is an error a Driver returns to choose the reply itself.
+type ReplyError struct {
+	Code    int    // reply code, 4xx or 5xx
+	Message string // reply text
+	Err     error  // underlying error, if any
+}
+
+// Error implements error
+func (e *ReplyError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap returns the underlying error
+func (e *ReplyError) Unwrap() error {
+	return e.Err
+}
+
+var errorReplies = []struct {
+	err     error
+	code    int
+	message string
+}{
+	{ErrQuotaExceeded, 552, "Transfer quota exceeded"},
+	{ErrTransferStalled, 426, "Connection closed; transfer aborted, no progress"},
+	{ErrNotFound, 550, "No such file or directory"},
+	{fs.ErrNotExist, 550, "No such file or directory"},
+	{ErrPermissionDenied, 550, "Permission denied"},
+	{fs.ErrPermission, 550, "Permission denied"},
+	{ErrNotDir, 550, "Not a directory"},
+	{ErrIsDir, 550, "Is a directory"},
+	{ErrExist, 550, "File already exists"},
+	{fs.ErrExist, 550, "File already exists"},
+	{ErrInvalidName, 553, "File name not allowed"},
+	{ErrStorageExceeded, 552, "Exceeded storage allocation"},
+	{ErrInsufficientStorage, 452, "Insufficient storage space"},
+	{ErrUnavailable, 450, "File unavailable"},
+	{ErrDriverTimeout, 451, "Requested action aborted: storage backend timed out"},
+	{ErrDriverUnavailable, 451, "Requested action aborted: storage backend unavailable"},
+	{ErrAccountExpired, 530, "Account expired, not logged in"},
+	{ErrPasswordChangeRequired, 532, "Password change required, not logged in"},
+}
+
+// errorReply returns the reply for an error returned by the driver, or
+// code and message when it is none of the known errors.
+func errorReply(err error, code int, message string) (int, string) {
+	var replyErr *ReplyError
+	if errors.As(err, &replyErr) {
+		return replyErr.Code, replyErr.Message
+	}
+	for _, reply := range errorReplies {
+		if errors.Is(err, reply.err) {
+			return reply.code, reply.message
+		}
+	}
+	return code, message
+}
+
+// writeError answers a failed command, see errorReply.
+func (sess *Session) writeError(err error, code int, message string) {
+	sess.writeMessage(errorReply(err, code, message))
+}
@@ -0,0 +1,40 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestErrorReply(t *testing.T) {
+	_, notExist := os.Stat("does/not/exist")
+
+	replyTests := []struct {
+		err     error  // input
+		code    int    // expected code
+		message string // expected message
+	}{
+		{errors.New("boom"), 550, "Action not taken"},
+		{ErrNotFound, 550, "No such file or directory"},
+		{notExist, 550, "No such file or directory"},
+		{fmt.Errorf("stat: %w", ErrPermissionDenied), 550, "Permission denied"},
+		{ErrInvalidName, 553, "File name not allowed"},
+		{ErrStorageExceeded, 552, "Exceeded storage allocation"},
+		{ErrInsufficientStorage, 452, "Insufficient storage space"},
+		{ErrUnavailable, 450, "File unavailable"},
+		{ErrTransferStalled, 426, "Connection closed; transfer aborted, no progress"},
+		{&ReplyError{Code: 451, Message: "Under legal hold", Err: ErrPermissionDenied}, 451, "Under legal hold"},
+	}
+
+	for _, tt := range replyTests {
+		code, message := errorReply(tt.err, 550, "Action not taken")
+		if code != tt.code || message != tt.message {
+			t.Errorf("errorReply(%v): expected %d %s, actual %d %s", tt.err, tt.code, tt.message, code, message)
+		}
+	}
+}
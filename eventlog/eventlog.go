@@ -0,0 +1,329 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package eventlog exports what the clients of an ftp.Server do as JSON
+// Lines, one Event object per line, for ingestion into a SIEM such as
+// Elasticsearch or Splunk.
+//
+//	w, err := eventlog.NewRotatingFile("/var/log/ftp/events.jsonl", eventlog.Retention{
+//		MaxSize:  100 << 20,
+//		MaxAge:   30 * 24 * time.Hour,
+//		Compress: true,
+//	})
+//	exporter := eventlog.New(w)
+//	server, err := ftp.NewServer(&ftp.Options{
+//		...
+//		SessionCallback: exporter.OnConnect,
+//	})
+//	server.RegisterNotifier(exporter)
+//
+// The schema is that of Event, its version is SchemaVersion. Fields are
+// never renamed, retyped or removed within a version, new ones and new
+// event types may be added.
+package eventlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/globalcyberalliance/ftp-go"
+)
+
+// SchemaVersion is the version of the schema of Event, written with every
+// event as "v".
+const SchemaVersion = 1
+
+// Type is the kind of an Event
+type Type string
+
+// Event types
+const (
+	// a control connection was accepted, see Exporter.OnConnect
+	TypeConnect Type = "connect"
+	// a control connection was closed, with the bytes the session
+	// transferred
+	TypeDisconnect Type = "disconnect"
+	// a command was received, with its parameters
+	TypeCommand Type = "command"
+	// a login attempt, with its user and outcome
+	TypeLogin Type = "login"
+	// a login refused as the account expired
+	TypeAccountExpired Type = "account_expired"
+	// a file upload, with STOR or APPE, or download with RETR, ended
+	TypeUpload   Type = "upload"
+	TypeDownload Type = "download"
+	// a transfer was aborted by the client
+	TypeAbort Type = "abort"
+	// file and directory operations
+	TypeDelete Type = "delete"
+	TypeMkdir  Type = "mkdir"
+	TypeRmdir  Type = "rmdir"
+	TypeRename Type = "rename"
+	TypeChdir  Type = "chdir"
+	TypeList   Type = "list"
+	// one of ftp.Options.CanaryPaths was touched
+	TypeCanary Type = "canary"
+	// a panic was recovered while serving the session
+	TypePanic Type = "panic"
+)
+
+// Event is one line of the export. Every event has Time, Version, Type and
+// SessionID, the other fields are set by the types they are listed with
+// and left out when empty.
+type Event struct {
+	// when the event happened, in UTC
+	Time time.Time `json:"time"`
+	// SchemaVersion
+	Version int  `json:"v"`
+	Type    Type `json:"type"`
+
+	SessionID  string `json:"session_id"`
+	RemoteIP   string `json:"remote_ip,omitempty"`
+	RemotePort int    `json:"remote_port,omitempty"`
+	// user logged in, or the one trying to for login events
+	User string `json:"user,omitempty"`
+	// virtual host selected by the session, see ftp.Options.VirtualHosts
+	VirtualHost string `json:"vhost,omitempty"`
+	TLS         bool   `json:"tls,omitempty"`
+
+	// command, the verb upper cased and its parameters, masked for PASS
+	Command string `json:"command,omitempty"`
+	Params  string `json:"params,omitempty"`
+
+	// upload, download, abort, delete, mkdir, rmdir, rename (the source),
+	// chdir (the new directory), list and canary
+	Path string `json:"path,omitempty"`
+	// rename, its target
+	ToPath string `json:"to_path,omitempty"`
+	// upload, download and abort, in bytes
+	Size int64 `json:"size,omitempty"`
+	// upload, hex digests by algorithm
+	Checksums map[string]string `json:"checksums,omitempty"`
+	// list, the number of entries listed
+	Entries int `json:"entries,omitempty"`
+	// login, whether it succeeded
+	Success *bool `json:"success,omitempty"`
+	// disconnect, bytes transferred by the session
+	Uploaded   int64 `json:"uploaded,omitempty"`
+	Downloaded int64 `json:"downloaded,omitempty"`
+	// canary, the pattern matched
+	Pattern string `json:"pattern,omitempty"`
+
+	// the operation's failure, if it failed, or the value of a panic
+	Error string `json:"error,omitempty"`
+}
+
+var (
+	_ ftp.Notifier               = &Exporter{}
+	_ ftp.RenameNotifier         = &Exporter{}
+	_ ftp.ListNotifier           = &Exporter{}
+	_ ftp.AbortNotifier          = &Exporter{}
+	_ ftp.DisconnectNotifier     = &Exporter{}
+	_ ftp.CanaryNotifier         = &Exporter{}
+	_ ftp.PanicNotifier          = &Exporter{}
+	_ ftp.AccountExpiredNotifier = &Exporter{}
+)
+
+// Exporter writes the events of the sessions of a server, register it
+// with Server.RegisterNotifier and use OnConnect as Options.SessionCallback.
+// It is safe for concurrent use.
+type Exporter struct {
+	ftp.NullNotifier
+
+	// Called with the errors writing events, which are dropped otherwise.
+	// Optional.
+	OnError func(err error)
+
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// New creates an Exporter writing to w, one Write per event
+func New(w io.Writer) *Exporter {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &Exporter{enc: enc}
+}
+
+// Export writes event, setting its Time if zero and its Version
+func (e *Exporter) Export(event *Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	event.Time = event.Time.UTC()
+	event.Version = SchemaVersion
+
+	e.mu.Lock()
+	err := e.enc.Encode(event)
+	e.mu.Unlock()
+	if err != nil && e.OnError != nil {
+		e.OnError(err)
+	}
+}
+
+// export writes an event of the session of ctx
+func (e *Exporter) export(ctx *ftp.Context, event *Event) {
+	if ctx != nil && ctx.Sess != nil {
+		e.setSession(ctx.Sess, event)
+	}
+	e.Export(event)
+}
+
+func (e *Exporter) setSession(sess *ftp.Session, event *Event) {
+	event.SessionID = sess.ID()
+	if addr, ok := sess.RemoteAddr().(*net.TCPAddr); ok {
+		event.RemoteIP = addr.IP.String()
+		event.RemotePort = addr.Port
+	} else if addr := sess.RemoteAddr(); addr != nil {
+		event.RemoteIP = addr.String()
+	}
+	if event.User == "" {
+		event.User = sess.LoginUser()
+	}
+	event.VirtualHost = sess.VirtualHost()
+	_, event.TLS = sess.TLSConnectionState()
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// OnConnect exports the connect event of sess, use it as
+// Options.SessionCallback or call it from there.
+func (e *Exporter) OnConnect(sess *ftp.Session) {
+	event := &Event{Type: TypeConnect}
+	e.setSession(sess, event)
+	e.Export(event)
+}
+
+// OnDisconnect implements ftp.DisconnectNotifier
+func (e *Exporter) OnDisconnect(ctx *ftp.Context) {
+	stats := ctx.Sess.Stats()
+	e.export(ctx, &Event{
+		Type:       TypeDisconnect,
+		Uploaded:   stats.Uploaded,
+		Downloaded: stats.Downloaded,
+	})
+}
+
+// BeforeCommand implements ftp.CommandNotifier
+func (e *Exporter) BeforeCommand(ctx *ftp.Context, line string) {
+	line = strings.TrimRight(line, "\r\n")
+	command, params, _ := strings.Cut(line, " ")
+	command = strings.ToUpper(command)
+	e.export(ctx, &Event{
+		Type:    TypeCommand,
+		Command: command,
+		Params:  ctx.Sess.Server().Scrubber().Command(command, params),
+	})
+}
+
+// AfterUserLogin implements ftp.LoginNotifier, the password is not exported
+func (e *Exporter) AfterUserLogin(ctx *ftp.Context, userName, password string, passMatched bool, err error) {
+	success := passMatched && err == nil
+	e.export(ctx, &Event{
+		Type:    TypeLogin,
+		User:    userName,
+		Success: &success,
+		Error:   errorString(err),
+	})
+}
+
+// OnAccountExpired implements ftp.AccountExpiredNotifier
+func (e *Exporter) OnAccountExpired(ctx *ftp.Context, userName string) {
+	e.export(ctx, &Event{
+		Type: TypeAccountExpired,
+		User: userName,
+	})
+}
+
+// AfterFilePut implements ftp.TransferNotifier
+func (e *Exporter) AfterFilePut(ctx *ftp.Context, dstPath string, size int64, err error) {
+	e.export(ctx, &Event{
+		Type:      TypeUpload,
+		Path:      dstPath,
+		Size:      size,
+		Checksums: ctx.Checksums,
+		Error:     errorString(err),
+	})
+}
+
+// AfterFileDownloaded implements ftp.TransferNotifier
+func (e *Exporter) AfterFileDownloaded(ctx *ftp.Context, dstPath string, size int64, err error) {
+	e.export(ctx, &Event{
+		Type:  TypeDownload,
+		Path:  dstPath,
+		Size:  size,
+		Error: errorString(err),
+	})
+}
+
+// AfterTransferAborted implements ftp.AbortNotifier
+func (e *Exporter) AfterTransferAborted(ctx *ftp.Context, dstPath string, size int64) {
+	e.export(ctx, &Event{
+		Type: TypeAbort,
+		Path: dstPath,
+		Size: size,
+	})
+}
+
+// AfterFileDeleted implements ftp.FileNotifier
+func (e *Exporter) AfterFileDeleted(ctx *ftp.Context, dstPath string, err error) {
+	e.export(ctx, &Event{Type: TypeDelete, Path: dstPath, Error: errorString(err)})
+}
+
+// AfterCurDirChanged implements ftp.DirNotifier
+func (e *Exporter) AfterCurDirChanged(ctx *ftp.Context, oldCurDir, newCurDir string, err error) {
+	e.export(ctx, &Event{Type: TypeChdir, Path: newCurDir, Error: errorString(err)})
+}
+
+// AfterDirCreated implements ftp.DirNotifier
+func (e *Exporter) AfterDirCreated(ctx *ftp.Context, dstPath string, err error) {
+	e.export(ctx, &Event{Type: TypeMkdir, Path: dstPath, Error: errorString(err)})
+}
+
+// AfterDirDeleted implements ftp.DirNotifier
+func (e *Exporter) AfterDirDeleted(ctx *ftp.Context, dstPath string, err error) {
+	e.export(ctx, &Event{Type: TypeRmdir, Path: dstPath, Error: errorString(err)})
+}
+
+// AfterRename implements ftp.RenameNotifier
+func (e *Exporter) AfterRename(ctx *ftp.Context, fromPath, toPath string, err error) {
+	e.export(ctx, &Event{Type: TypeRename, Path: fromPath, ToPath: toPath, Error: errorString(err)})
+}
+
+// AfterListDir implements ftp.ListNotifier
+func (e *Exporter) AfterListDir(ctx *ftp.Context, dirPath string, entries int, err error) {
+	e.export(ctx, &Event{Type: TypeList, Path: dirPath, Entries: entries, Error: errorString(err)})
+}
+
+// OnCanary implements ftp.CanaryNotifier
+func (e *Exporter) OnCanary(ctx *ftp.Context, canary *ftp.CanaryEvent) {
+	e.export(ctx, &Event{
+		Time:    canary.Time,
+		Type:    TypeCanary,
+		Command: canary.Command,
+		Path:    canary.Path,
+		Pattern: canary.Pattern,
+	})
+}
+
+// OnPanic implements ftp.PanicNotifier, the stack is left out
+func (e *Exporter) OnPanic(ctx *ftp.Context, panicked *ftp.PanicEvent) {
+	scrubber := ctx.Sess.Server().Scrubber()
+	e.export(ctx, &Event{
+		Type:    TypePanic,
+		Command: panicked.Command,
+		Params:  scrubber.Command(panicked.Command, panicked.Param),
+		Error:   scrubber.String(fmt.Sprint(panicked.Value)),
+	})
+}
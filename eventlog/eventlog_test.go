@@ -0,0 +1,202 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package eventlog
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/globalcyberalliance/ftp-go"
+	"github.com/globalcyberalliance/ftp-go/driver/file"
+	"github.com/globalcyberalliance/ftp-go/ftptest"
+	"github.com/stretchr/testify/assert"
+)
+
+// syncBuffer is a bytes.Buffer written by sessions and read by the test
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) events(t *testing.T) []map[string]interface{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var events []map[string]interface{}
+	scanner := bufio.NewScanner(bytes.NewReader(b.buf.Bytes()))
+	for scanner.Scan() {
+		var event map[string]interface{}
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &event), scanner.Text())
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestExporter(t *testing.T) {
+	driver, err := file.NewDriver(t.TempDir())
+	assert.NoError(t, err)
+	var out syncBuffer
+	exporter := New(&out)
+
+	server, err := ftp.NewServer(&ftp.Options{
+		Driver:          driver,
+		Auth:            &ftp.SimpleAuth{Name: ftptest.Username, Password: ftptest.Password},
+		Perm:            ftp.NewSimplePerm(ftptest.Username, ftptest.Username),
+		Logger:          new(ftp.DiscardLogger),
+		SessionCallback: exporter.OnConnect,
+		UploadChecksums: []string{"sha256"},
+	})
+	assert.NoError(t, err)
+	server.RegisterNotifier(exporter)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	go server.Serve(l)
+	defer server.Shutdown()
+
+	c, err := ftptest.Dial(l.Addr().String())
+	assert.NoError(t, err)
+	assert.NoError(t, c.Login(ftptest.Username, ftptest.Password))
+	assert.NoError(t, c.Stor("a.txt", strings.NewReader("hello")))
+	assert.NoError(t, c.Rename("a.txt", "b.txt"))
+	assert.NoError(t, c.Quit())
+	c.Close()
+
+	var events []map[string]interface{}
+	assert.Eventually(t, func() bool {
+		events = out.events(t)
+		return len(events) > 0 && events[len(events)-1]["type"] == "disconnect"
+	}, time.Second, 10*time.Millisecond)
+
+	var types []string
+	for _, event := range events {
+		assert.EqualValues(t, SchemaVersion, event["v"])
+		assert.NotEmpty(t, event["session_id"])
+		assert.Equal(t, "127.0.0.1", event["remote_ip"])
+		if event["type"] == "command" {
+			types = append(types, event["command"].(string))
+			if event["command"] == "PASS" {
+				assert.Equal(t, "****", event["params"])
+			}
+		} else {
+			types = append(types, event["type"].(string))
+		}
+
+		switch event["type"] {
+		case "login":
+			assert.Equal(t, ftptest.Username, event["user"])
+			assert.Equal(t, true, event["success"])
+		case "upload":
+			assert.Equal(t, "/a.txt", event["path"])
+			assert.EqualValues(t, 5, event["size"])
+			assert.Contains(t, event["checksums"], "sha256")
+		case "rename":
+			assert.Equal(t, "/a.txt", event["path"])
+			assert.Equal(t, "/b.txt", event["to_path"])
+		case "disconnect":
+			assert.EqualValues(t, 5, event["uploaded"])
+		}
+	}
+	assert.Contains(t, strings.Join(types, " "), "connect USER PASS login")
+	assert.Contains(t, strings.Join(types, " "), "STOR upload")
+	assert.Contains(t, strings.Join(types, " "), "RNFR RNTO rename QUIT disconnect")
+}
+
+func TestRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "events.jsonl")
+	r, err := NewRotatingFile(path, Retention{MaxSize: 10, MaxBackups: 2})
+	assert.NoError(t, err)
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		n, err := r.Write([]byte(line))
+		assert.NoError(t, err)
+		assert.Equal(t, len(line), n)
+	}
+	assert.NoError(t, r.Close())
+	_, err = r.Write([]byte("closed\n"))
+	assert.Error(t, err)
+
+	// Every line went to its own file, the oldest was removed
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "fourth\n", string(content))
+	backups, err := r.backups()
+	assert.NoError(t, err)
+	var lines []string
+	for _, b := range backups {
+		assert.True(t, strings.HasPrefix(filepath.Base(b.name), "events-"), b.name)
+		content, err = os.ReadFile(b.name)
+		assert.NoError(t, err)
+		lines = append(lines, string(content))
+	}
+	assert.Equal(t, []string{"third\n", "second\n"}, lines)
+}
+
+func TestRotatingFileRetention(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "events.jsonl")
+
+	// Leftovers of a previous run, one of them too old to keep
+	now := time.Now()
+	old := filepath.Join(dir, "events-"+now.Add(-48*time.Hour).UTC().Format(backupTimeFormat)+".jsonl.gz")
+	recent := filepath.Join(dir, "events-"+now.Add(-time.Hour).UTC().Format(backupTimeFormat)+".jsonl")
+	assert.NoError(t, os.WriteFile(old, []byte("old"), 0o600))
+	assert.NoError(t, os.WriteFile(recent, []byte("recent\n"), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "other.jsonl"), []byte("other"), 0o600))
+
+	r, err := NewRotatingFile(path, Retention{MaxAge: 24 * time.Hour, Compress: true})
+	assert.NoError(t, err)
+	_, err = r.Write([]byte("current\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, r.Rotate())
+	assert.NoError(t, r.Close())
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.Len(t, names, 4, names)
+	assert.NotContains(t, names, filepath.Base(old))
+	assert.Contains(t, names, filepath.Base(recent)+".gz")
+	assert.Contains(t, names, "other.jsonl")
+
+	backups, err := r.backups()
+	assert.NoError(t, err)
+	if assert.Len(t, backups, 2) {
+		f, err := os.Open(backups[0].name)
+		assert.NoError(t, err)
+		defer f.Close()
+		gz, err := gzip.NewReader(f)
+		assert.NoError(t, err)
+		content, err := io.ReadAll(gz)
+		assert.NoError(t, err)
+		assert.Equal(t, "current\n", string(content))
+	}
+
+	// Rotated files are removed once together over MaxTotalSize
+	r, err = NewRotatingFile(path, Retention{MaxTotalSize: 1})
+	assert.NoError(t, err)
+	assert.NoError(t, r.Close())
+	backups, err = r.backups()
+	assert.NoError(t, err)
+	assert.Empty(t, backups)
+}
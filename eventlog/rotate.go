@@ -0,0 +1,296 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package eventlog
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat stamps the names of rotated files, in UTC
+const backupTimeFormat = "20060102T150405.000"
+
+// errClosed is returned writing to a closed RotatingFile
+var errClosed = errors.New("eventlog: file closed")
+
+// Retention tells when a RotatingFile is rotated and which of the rotated
+// files are kept. Files are removed once over any of the limits, oldest
+// first.
+type Retention struct {
+	// Size in bytes the file is rotated at. Optional, 0 rotates it with
+	// RotatingFile.Rotate only.
+	MaxSize int64
+
+	// Number of rotated files kept. Optional, 0 keeps them all.
+	MaxBackups int
+
+	// Age rotated files are removed at. Optional, 0 keeps them all.
+	MaxAge time.Duration
+
+	// Total size in bytes of the rotated files kept. Optional, 0 keeps
+	// them all.
+	MaxTotalSize int64
+
+	// If true, rotated files are compressed with gzip, adding ".gz" to
+	// their name.
+	Compress bool
+}
+
+// RotatingFile is an io.Writer appending to a file which is rotated once it
+// would grow over Retention.MaxSize. The file is renamed with the time of
+// the rotation, "events.jsonl" becoming "events-20261016T101500.000.jsonl",
+// and compressed and removed in the background as its Retention says.
+// Writes are never split across files. It is safe for concurrent use.
+type RotatingFile struct {
+	path      string
+	retention Retention
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+
+	// serializes compressing and removing rotated files
+	millMu  sync.Mutex
+	millErr error
+	mills   sync.WaitGroup
+}
+
+// NewRotatingFile opens path for appending, rotated and retained as
+// retention says. Rotated files left over are cleaned up right away.
+func NewRotatingFile(path string, retention Retention) (*RotatingFile, error) {
+	r := &RotatingFile{path: path, retention: retention}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	r.startMill()
+	return r, nil
+}
+
+func (r *RotatingFile) open() error {
+	file, err := os.OpenFile(r.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o640)
+	if err != nil {
+		return fmt.Errorf("eventlog: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("eventlog: %w", err)
+	}
+	r.file, r.size = file, info.Size()
+	return nil
+}
+
+// Write implements io.Writer, rotating the file first if p would make it
+// grow over Retention.MaxSize.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return 0, errClosed
+	}
+	// Events are still written when rotating fails, unless the file is lost
+	maxSize := r.retention.MaxSize
+	if maxSize > 0 && r.size > 0 && r.size+int64(len(p)) > maxSize {
+		if err := r.rotate(); err != nil && r.file == nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Rotate rotates the file now, for instance on SIGHUP
+func (r *RotatingFile) Rotate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return errClosed
+	}
+	return r.rotate()
+}
+
+// rotate renames the file and reopens it. The file is reopened even if
+// renaming fails, to retry at the next write.
+func (r *RotatingFile) rotate() error {
+	err := r.file.Close()
+	if err == nil {
+		err = os.Rename(r.path, r.backupName(time.Now()))
+	}
+	if openErr := r.open(); openErr != nil {
+		r.file = nil
+		return openErr
+	}
+	if err != nil {
+		return fmt.Errorf("eventlog: %w", err)
+	}
+	r.startMill()
+	return nil
+}
+
+// backupName returns the name the file is rotated to at t, one not taken
+func (r *RotatingFile) backupName(t time.Time) string {
+	ext := filepath.Ext(r.path)
+	prefix := strings.TrimSuffix(r.path, ext) + "-"
+	for {
+		name := prefix + t.UTC().Format(backupTimeFormat) + ext
+		if _, err := os.Stat(name); os.IsNotExist(err) {
+			if _, err = os.Stat(name + ".gz"); os.IsNotExist(err) {
+				return name
+			}
+		}
+		t = t.Add(time.Millisecond)
+	}
+}
+
+// backup is a rotated file
+type backup struct {
+	name string
+	time time.Time
+	size int64
+}
+
+// backups lists the rotated files, newest first
+func (r *RotatingFile) backups() ([]backup, error) {
+	dir := filepath.Dir(r.path)
+	ext := filepath.Ext(r.path)
+	prefix := strings.TrimSuffix(filepath.Base(r.path), ext) + "-"
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []backup
+	for _, entry := range entries {
+		stamp, ok := strings.CutPrefix(entry.Name(), prefix)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		stamp = strings.TrimSuffix(stamp, ".gz")
+		if stamp, ok = strings.CutSuffix(stamp, ext); !ok {
+			continue
+		}
+		t, err := time.Parse(backupTimeFormat, stamp)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{name: filepath.Join(dir, entry.Name()), time: t, size: info.Size()})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].time.After(backups[j].time)
+	})
+	return backups, nil
+}
+
+// startMill compresses and removes rotated files in the background
+func (r *RotatingFile) startMill() {
+	retention := r.retention
+	if retention.MaxBackups == 0 && retention.MaxAge == 0 && retention.MaxTotalSize == 0 && !retention.Compress {
+		return
+	}
+	r.mills.Add(1)
+	go func() {
+		defer r.mills.Done()
+		r.millMu.Lock()
+		defer r.millMu.Unlock()
+		if err := r.mill(time.Now()); err != nil {
+			r.millErr = errors.Join(r.millErr, err)
+		}
+	}()
+}
+
+func (r *RotatingFile) mill(now time.Time) error {
+	backups, err := r.backups()
+	if err != nil {
+		return fmt.Errorf("eventlog: %w", err)
+	}
+
+	var errs []error
+	var kept int
+	var total int64
+	for _, b := range backups {
+		kept++
+		total += b.size
+		if (r.retention.MaxBackups > 0 && kept > r.retention.MaxBackups) ||
+			(r.retention.MaxAge > 0 && now.Sub(b.time) > r.retention.MaxAge) ||
+			(r.retention.MaxTotalSize > 0 && total > r.retention.MaxTotalSize) {
+			if err = os.Remove(b.name); err != nil && !os.IsNotExist(err) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if r.retention.Compress && !strings.HasSuffix(b.name, ".gz") {
+			if err = compressFile(b.name); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("eventlog: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// compressFile replaces name by name.gz
+func compressFile(name string) (err error) {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(name+".gz", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o640)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			dst.Close()
+			os.Remove(name + ".gz")
+		}
+	}()
+
+	gz := gzip.NewWriter(dst)
+	if _, err = io.Copy(gz, src); err != nil {
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+	src.Close()
+	return os.Remove(name)
+}
+
+// Close closes the file, once rotated files are compressed and removed. It
+// returns the errors doing so since the file was opened.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	if r.file == nil {
+		r.mu.Unlock()
+		return errClosed
+	}
+	err := r.file.Close()
+	r.file = nil
+	r.mu.Unlock()
+
+	r.mills.Wait()
+	return errors.Join(err, r.millErr)
+}
@@ -0,0 +1,56 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Command container serves FTP_ROOT, /data by default, configured by the
+// environment variables of ftp.OptionsFromEnv. It drains its sessions on
+// SIGTERM, as pods being terminated are sent. See kubernetes.yaml.
+package main
+
+import (
+	"errors"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/globalcyberalliance/ftp-go"
+	"github.com/globalcyberalliance/ftp-go/driver/file"
+)
+
+func main() {
+	opts, err := ftp.OptionsFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	root := os.Getenv("FTP_ROOT")
+	if root == "" {
+		root = "/data"
+	}
+	if opts.Driver, err = file.NewDriver(root); err != nil {
+		log.Fatal(err)
+	}
+	opts.Perm = ftp.NewSimplePerm("ftp", "ftp")
+	// Within the default grace period of 30 seconds
+	opts.ShutdownDrain = 20 * time.Second
+
+	s, err := ftp.NewServer(opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+		<-signals
+		if err := s.Shutdown(); err != nil {
+			log.Print(err)
+		}
+	}()
+
+	if err = s.ListenAndServe(); err != nil && !errors.Is(err, ftp.ErrServerClosed) {
+		log.Fatal(err)
+	}
+}
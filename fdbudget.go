@@ -0,0 +1,84 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// errTooManyOpenFiles is returned opening a data connection over
+// Options.MaxFileDescriptors.
+var errTooManyOpenFiles = errors.New("ftp: file descriptor budget exhausted")
+
+// fdBudget counts the file descriptors held by data connections, passive
+// listeners and the files of transfers against Options.MaxFileDescriptors.
+// A nil budget has no limit.
+type fdBudget struct {
+	limit int64
+	used  atomic.Int64
+}
+
+// newFDBudget returns the budget of Options.MaxFileDescriptors, nil without
+// one.
+func newFDBudget(max int) (*fdBudget, error) {
+	if max < 0 {
+		limit := openFileLimit()
+		if limit == 0 {
+			return nil, nil
+		}
+		if limit+max <= 0 {
+			return nil, fmt.Errorf("ftp: MaxFileDescriptors %d leaves nothing of the open file limit %d", max, limit)
+		}
+		max += limit
+	}
+	if max == 0 {
+		return nil, nil
+	}
+	return &fdBudget{limit: int64(max)}, nil
+}
+
+// acquire takes a descriptor, it returns false when the budget is spent
+func (budget *fdBudget) acquire() bool {
+	if budget == nil {
+		return true
+	}
+	if budget.used.Add(1) > budget.limit {
+		budget.used.Add(-1)
+		return false
+	}
+	return true
+}
+
+// release gives back a descriptor taken with acquire
+func (budget *fdBudget) release() {
+	if budget != nil {
+		budget.used.Add(-1)
+	}
+}
+
+// exhausted reports whether no descriptor is left
+func (budget *fdBudget) exhausted() bool {
+	return budget != nil && budget.used.Load() >= budget.limit
+}
+
+// OpenFileDescriptors returns the number of file descriptors counted against
+// Options.MaxFileDescriptors, 0 without it.
+func (server *Server) OpenFileDescriptors() int {
+	if server.fds == nil {
+		return 0
+	}
+	return int(server.fds.used.Load())
+}
+
+// writeDataConnError answers a data connection which couldn't be opened
+func (sess *Session) writeDataConnError(err error) {
+	if errors.Is(err, errTooManyOpenFiles) {
+		sess.writeMessage(425, "Too many open files, try again later")
+		return
+	}
+	sess.writeMessage(425, "Data connection failed")
+}
@@ -0,0 +1,13 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+//go:build windows
+
+package ftp
+
+// openFileLimit returns 0 as Windows has no limit of open files to derive
+// Options.MaxFileDescriptors from.
+func openFileLimit() int {
+	return 0
+}
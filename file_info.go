@@ -11,6 +11,8 @@ type fileInfo struct {
 	owner string
 	group string
 	mode  os.FileMode
+	// target of a symbolic link, if known
+	target string
 }
 
 func (f *fileInfo) Mode() os.FileMode {
@@ -24,3 +26,17 @@ func (f *fileInfo) Owner() string {
 func (f *fileInfo) Group() string {
 	return f.group
 }
+
+// LinkTarget returns the target of a symbolic link, "" for other entries
+func (f *fileInfo) LinkTarget() string {
+	return f.target
+}
+
+// linkTarget returns the target of a symbolic link listed by a driver,
+// which FileInfo may implement LinkTarget.
+func linkTarget(f os.FileInfo) string {
+	if link, ok := f.(interface{ LinkTarget() string }); ok {
+		return link.LinkTarget()
+	}
+	return ""
+}
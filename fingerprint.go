@@ -0,0 +1,263 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// fingerprintCommands is the number of commands a fingerprint keeps.
+	fingerprintCommands = 16
+	// maxClientHello bounds what helloConn records, a TLS record is at most
+	// 16KB plus its header.
+	maxClientHello = 5 + 1<<14
+)
+
+// Fingerprint describes how a client behaves, so that sessions of the same
+// tool can be clustered. See Session.Fingerprint.
+type Fingerprint struct {
+	// time from the welcome banner to the first command
+	BannerDelay time.Duration `json:"banner_delay"`
+	// the first commands sent, upper cased and without parameters
+	Commands []string `json:"commands,omitempty"`
+	// as announced with CLNT
+	ClientSoft string `json:"client_soft,omitempty"`
+	CLNT       bool   `json:"clnt,omitempty"`
+	SYST       bool   `json:"syst,omitempty"`
+	FEAT       bool   `json:"feat,omitempty"`
+	// JA3 of the TLS ClientHello, explicit or implicit FTPS only
+	JA3     string `json:"ja3,omitempty"`
+	JA3Hash string `json:"ja3_hash,omitempty"`
+}
+
+// Hash digests the parts of the fingerprint that identify a tool, the
+// command order, CLNT, SYST and FEAT usage and the JA3 hash.
+func (f Fingerprint) Hash() string {
+	h := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%t|%t|%t|%s",
+		strings.Join(f.Commands, ","), f.ClientSoft, f.CLNT, f.SYST, f.FEAT, f.JA3Hash)))
+	return hex.EncodeToString(h[:8])
+}
+
+// Fingerprint returns the fingerprint of the session so far
+func (sess *Session) Fingerprint() Fingerprint {
+	sess.fingerprintMu.Lock()
+	defer sess.fingerprintMu.Unlock()
+	f := sess.fingerprint
+	f.Commands = append([]string(nil), f.Commands...)
+	return f
+}
+
+// recordCommand adds the command line to the fingerprint.
+func (sess *Session) recordCommand(line string) {
+	command, param := sess.parseLine(line)
+	command = strings.ToUpper(command)
+
+	sess.fingerprintMu.Lock()
+	defer sess.fingerprintMu.Unlock()
+	f := &sess.fingerprint
+	if f.Commands == nil && !sess.bannerSent.IsZero() {
+		f.BannerDelay = time.Since(sess.bannerSent)
+	}
+	if len(f.Commands) < fingerprintCommands {
+		if len(command) > 16 {
+			command = command[:16]
+		}
+		f.Commands = append(f.Commands, command)
+	}
+	switch command {
+	case "CLNT":
+		f.CLNT = true
+		f.ClientSoft = param
+	case "SYST":
+		f.SYST = true
+	case "FEAT":
+		f.FEAT = true
+	}
+}
+
+// recordClientHello adds the JA3 of a TLS ClientHello record to the
+// fingerprint.
+func (sess *Session) recordClientHello(record []byte) {
+	ja3, ok := parseJA3(record)
+	if !ok {
+		return
+	}
+	sum := md5.Sum([]byte(ja3))
+
+	sess.fingerprintMu.Lock()
+	defer sess.fingerprintMu.Unlock()
+	sess.fingerprint.JA3 = ja3
+	sess.fingerprint.JA3Hash = hex.EncodeToString(sum[:])
+}
+
+// helloConn records the first TLS record read through it, the ClientHello
+// when it wraps the server side of a handshake.
+type helloConn struct {
+	net.Conn
+	buf  []byte
+	done func(record []byte)
+}
+
+func (c *helloConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if c.done == nil {
+		return n, err
+	}
+
+	c.buf = append(c.buf, p[:n]...)
+	if len(c.buf) >= 5 {
+		if size := 5 + int(binary.BigEndian.Uint16(c.buf[3:5])); len(c.buf) >= size {
+			c.done(c.buf[:size])
+			c.done, c.buf = nil, nil
+		}
+	}
+	if len(c.buf) > maxClientHello {
+		c.done, c.buf = nil, nil
+	}
+	return n, err
+}
+
+// parseJA3 builds the JA3 string of a TLS record holding a ClientHello:
+// version, ciphers, extensions, curves and point formats, GREASE values
+// left out.
+func parseJA3(record []byte) (string, bool) {
+	r := helloReader(record)
+	if typ, ok := r.uint8(); !ok || typ != 22 { // handshake
+		return "", false
+	}
+	if !r.skip(4) { // record version and length
+		return "", false
+	}
+	if typ, ok := r.uint8(); !ok || typ != 1 { // client hello
+		return "", false
+	}
+	if !r.skip(3) {
+		return "", false
+	}
+	version, ok := r.uint16()
+	if !ok || !r.skip(32) { // random
+		return "", false
+	}
+	if _, ok := r.vector8(); !ok { // session id
+		return "", false
+	}
+	cipherData, ok := r.vector16()
+	if !ok {
+		return "", false
+	}
+	if _, ok := r.vector8(); !ok { // compression methods
+		return "", false
+	}
+
+	var extensions, curves, points []string
+	extData, _ := r.vector16()
+	for len(extData) > 0 {
+		ext, ok := extData.uint16()
+		if !ok {
+			return "", false
+		}
+		data, ok := extData.vector16()
+		if !ok {
+			return "", false
+		}
+		if isGREASE(ext) {
+			continue
+		}
+		extensions = append(extensions, strconv.Itoa(int(ext)))
+		switch ext {
+		case 10: // supported groups
+			groups, _ := data.vector16()
+			curves = greaseFree(groups)
+		case 11: // ec point formats
+			formats, _ := data.vector8()
+			for _, format := range formats {
+				points = append(points, strconv.Itoa(int(format)))
+			}
+		}
+	}
+
+	return strings.Join([]string{
+		strconv.Itoa(int(version)),
+		strings.Join(greaseFree(cipherData), "-"),
+		strings.Join(extensions, "-"),
+		strings.Join(curves, "-"),
+		strings.Join(points, "-"),
+	}, ","), true
+}
+
+// isGREASE reports whether v is one of the RFC 8701 reserved values.
+func isGREASE(v uint16) bool {
+	return v&0x0f0f == 0x0a0a && v>>8 == v&0xff
+}
+
+// greaseFree returns the uint16 list in data as strings, GREASE left out.
+func greaseFree(data helloReader) []string {
+	var list []string
+	for len(data) >= 2 {
+		v, _ := data.uint16()
+		if !isGREASE(v) {
+			list = append(list, strconv.Itoa(int(v)))
+		}
+	}
+	return list
+}
+
+// helloReader consumes a TLS message.
+type helloReader []byte
+
+func (r *helloReader) skip(n int) bool {
+	if len(*r) < n {
+		return false
+	}
+	*r = (*r)[n:]
+	return true
+}
+
+func (r *helloReader) uint8() (uint8, bool) {
+	if len(*r) < 1 {
+		return 0, false
+	}
+	v := (*r)[0]
+	*r = (*r)[1:]
+	return v, true
+}
+
+func (r *helloReader) uint16() (uint16, bool) {
+	if len(*r) < 2 {
+		return 0, false
+	}
+	v := binary.BigEndian.Uint16(*r)
+	*r = (*r)[2:]
+	return v, true
+}
+
+func (r *helloReader) vector8() (helloReader, bool) {
+	n, ok := r.uint8()
+	if !ok || len(*r) < int(n) {
+		return nil, false
+	}
+	v := (*r)[:n]
+	*r = (*r)[n:]
+	return v, true
+}
+
+func (r *helloReader) vector16() (helloReader, bool) {
+	n, ok := r.uint16()
+	if !ok || len(*r) < int(n) {
+		return nil, false
+	}
+	v := (*r)[:n]
+	*r = (*r)[n:]
+	return v, true
+}
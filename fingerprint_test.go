@@ -0,0 +1,88 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"crypto/tls"
+	"net"
+	"net/textproto"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFingerprint(t *testing.T) {
+	s, err := NewServer(&Options{
+		Perm:   NewSimplePerm("test", "test"),
+		Logger: new(DiscardLogger),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serverConn, clientConn := net.Pipe()
+	sess := s.newSession(newSessionID(), serverConn)
+	go sess.Serve()
+
+	client := textproto.NewConn(clientConn)
+	defer client.Close()
+	if _, _, err = client.ReadResponse(220); err != nil {
+		t.Fatal(err)
+	}
+
+	expectCode(t, client, 200, "CLNT scanner 1.0")
+	expectCode(t, client, 211, "FEAT")
+	expectCode(t, client, 500, "XYZZY")
+
+	f := sess.Fingerprint()
+	if want := []string{"CLNT", "FEAT", "XYZZY"}; !reflect.DeepEqual(f.Commands, want) {
+		t.Errorf("commands = %v, want %v", f.Commands, want)
+	}
+	if !f.CLNT || f.SYST || !f.FEAT {
+		t.Errorf("CLNT, SYST, FEAT = %t, %t, %t", f.CLNT, f.SYST, f.FEAT)
+	}
+	if f.ClientSoft != "scanner 1.0" {
+		t.Errorf("client soft = %q", f.ClientSoft)
+	}
+	if f.BannerDelay <= 0 {
+		t.Errorf("banner delay = %v", f.BannerDelay)
+	}
+	if f.Hash() == (Fingerprint{}).Hash() {
+		t.Error("hash does not depend on the commands")
+	}
+}
+
+func TestParseJA3(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	go func() {
+		_ = tls.Client(clientConn, &tls.Config{ServerName: "localhost"}).Handshake()
+		clientConn.Close()
+	}()
+
+	var record []byte
+	conn := &helloConn{Conn: serverConn, done: func(r []byte) {
+		record = append([]byte(nil), r...)
+	}}
+	buf := make([]byte, 512)
+	for record == nil {
+		if _, err := conn.Read(buf); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ja3, ok := parseJA3(record)
+	if !ok {
+		t.Fatal("ClientHello not parsed")
+	}
+	fields := strings.Split(ja3, ",")
+	if len(fields) != 5 || fields[0] != "771" || fields[1] == "" || fields[2] == "" {
+		t.Errorf("ja3 = %q", ja3)
+	}
+
+	if _, ok := parseJA3([]byte("USER anonymous\r\n")); ok {
+		t.Error("plain text parsed as a ClientHello")
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"errors"
+	"time"
+)
+
+const defaultMaxLineLength = 4096
+
+var errLineTooLong = errors.New("ftp: command line too long")
+
+// readLine reads one command line from the control connection, refusing
+// lines longer than Options.MaxLineLength instead of buffering them whole.
+// Lines end with CRLF, a bare LF or a bare CR, for clients terminating
+// lines with CR alone. An LF right after a CR is skipped whenever it
+// arrives, so a CR never ends up inside a command.
+func (sess *Session) readLine() (string, error) {
+	var line []byte
+	for {
+		c, err := sess.controlReader.ReadByte()
+		if err != nil {
+			return string(line), err
+		}
+		if sess.skipLF {
+			sess.skipLF = false
+			if c == '\n' && len(line) == 0 {
+				continue
+			}
+		}
+
+		line = append(line, c)
+		if len(line) > sess.server.MaxLineLength {
+			return "", errLineTooLong
+		}
+		switch c {
+		case '\n':
+			return string(line), nil
+		case '\r':
+			sess.skipLF = true
+			return string(line), nil
+		}
+	}
+}
+
+// checkFlood accounts for a received command and returns a reason when
+// the client went over MaxCommandRate or MaxPreAuthCommands.
+func (sess *Session) checkFlood() string {
+	if max := sess.server.MaxPreAuthCommands; max > 0 && !sess.IsLogin() {
+		sess.preAuthCommands++
+		if sess.preAuthCommands > max {
+			return "Too many commands before login"
+		}
+	}
+
+	if max := sess.server.MaxCommandRate; max > 0 {
+		now := time.Now()
+		if now.Sub(sess.cmdWindow) >= time.Second {
+			sess.cmdWindow = now
+			sess.cmdCount = 0
+		}
+		sess.cmdCount++
+		if sess.cmdCount > max {
+			return "Too many commands"
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,324 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftptest
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"strings"
+)
+
+// Client is a minimal FTP client for tests, it sends commands as given
+// and checks reply codes, transferring data over EPSV connections. Failed
+// replies are returned as *textproto.Error, holding the code and message.
+// Commands without a method are sent with Cmd.
+type Client struct {
+	conn *textproto.Conn
+	raw  net.Conn
+	host string
+
+	// TLS configuration of the data connections, once the control
+	// connection is protected.
+	tlsConfig *tls.Config
+}
+
+// Dial connects to the server at addr and reads its welcome message
+func Dial(addr string) (*Client, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return newClient(conn, nil)
+}
+
+// DialTLS connects to the implicit FTPS server at addr and reads its
+// welcome message, data connections are protected with config too.
+func DialTLS(addr string, config *tls.Config) (*Client, error) {
+	conn, err := tls.Dial("tcp", addr, config)
+	if err != nil {
+		return nil, err
+	}
+	return newClient(conn, config)
+}
+
+// DialUnix connects to the server listening on the Unix socket at path and
+// reads its welcome message, data connections go to the loopback address.
+func DialUnix(path string) (*Client, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	return newClient(conn, nil)
+}
+
+func newClient(conn net.Conn, config *tls.Config) (*Client, error) {
+	host := "127.0.0.1"
+	if addr, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
+		host = addr.IP.String()
+	}
+	c := &Client{
+		conn:      textproto.NewConn(conn),
+		raw:       conn,
+		host:      host,
+		tlsConfig: config,
+	}
+	if _, _, err := c.conn.ReadResponse(220); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// AuthTLS upgrades the control connection with AUTH TLS and protects the
+// data connections with PBSZ and PROT, as explicit FTPS clients do.
+func (c *Client) AuthTLS(config *tls.Config) error {
+	if _, err := c.Cmd(234, "AUTH TLS"); err != nil {
+		return err
+	}
+	conn := tls.Client(c.raw, config)
+	if err := conn.Handshake(); err != nil {
+		return err
+	}
+	c.conn = textproto.NewConn(conn)
+	c.raw = conn
+	c.tlsConfig = config
+
+	if _, err := c.Cmd(200, "PBSZ 0"); err != nil {
+		return err
+	}
+	_, err := c.Cmd(200, "PROT P")
+	return err
+}
+
+// Cmd sends a command and reads its reply, which must have code or start
+// with it: 2 accepts any 2xx. It returns the message of the reply.
+func (c *Client) Cmd(code int, format string, args ...interface{}) (string, error) {
+	id, err := c.conn.Cmd(format, args...)
+	if err != nil {
+		return "", err
+	}
+	c.conn.StartResponse(id)
+	defer c.conn.EndResponse(id)
+
+	_, msg, err := c.conn.ReadResponse(code)
+	return msg, err
+}
+
+// Login logs in as user
+func (c *Client) Login(user, password string) error {
+	if _, err := c.Cmd(3, "USER %s", user); err != nil {
+		return err
+	}
+	_, err := c.Cmd(230, "PASS %s", password)
+	return err
+}
+
+// Cwd changes the current directory
+func (c *Client) Cwd(path string) error {
+	_, err := c.Cmd(250, "CWD %s", path)
+	return err
+}
+
+// Mkd creates the directory path
+func (c *Client) Mkd(path string) error {
+	_, err := c.Cmd(257, "MKD %s", path)
+	return err
+}
+
+// Rmd removes the directory path
+func (c *Client) Rmd(path string) error {
+	_, err := c.Cmd(250, "RMD %s", path)
+	return err
+}
+
+// Dele deletes the file path
+func (c *Client) Dele(path string) error {
+	_, err := c.Cmd(250, "DELE %s", path)
+	return err
+}
+
+// Rename renames from to to with RNFR and RNTO
+func (c *Client) Rename(from, to string) error {
+	if _, err := c.Cmd(350, "RNFR %s", from); err != nil {
+		return err
+	}
+	_, err := c.Cmd(250, "RNTO %s", to)
+	return err
+}
+
+// Pwd returns the current directory
+func (c *Client) Pwd() (string, error) {
+	msg, err := c.Cmd(257, "PWD")
+	if err != nil {
+		return "", err
+	}
+	start := strings.IndexByte(msg, '"')
+	end := strings.LastIndexByte(msg, '"')
+	if start < 0 || end <= start {
+		return "", fmt.Errorf("ftptest: unexpected PWD reply %q", msg)
+	}
+	return strings.ReplaceAll(msg[start+1:end], `""`, `"`), nil
+}
+
+// List returns what LIST sends for path, "" for the current directory
+func (c *Client) List(path string) (string, error) {
+	var b strings.Builder
+	err := c.transfer(strings.TrimSpace("LIST "+path), 0, func(data net.Conn) error {
+		_, err := io.Copy(&b, data)
+		return err
+	})
+	return b.String(), err
+}
+
+// Nlst returns the names NLST sends for path, "" for the current directory
+func (c *Client) Nlst(path string) ([]string, error) {
+	var names []string
+	err := c.transfer(strings.TrimSpace("NLST "+path), 0, func(data net.Conn) error {
+		scanner := bufio.NewScanner(data)
+		for scanner.Scan() {
+			names = append(names, strings.TrimSuffix(scanner.Text(), "\r"))
+		}
+		return scanner.Err()
+	})
+	return names, err
+}
+
+// Entry is an entry of an MLSD listing
+type Entry struct {
+	Name string
+	// Facts by lower-cased name, such as "type", "size" and "modify"
+	Facts map[string]string
+}
+
+// Mlsd returns the entries MLSD sends for path, "" for the current
+// directory.
+func (c *Client) Mlsd(path string) ([]Entry, error) {
+	var entries []Entry
+	err := c.transfer(strings.TrimSpace("MLSD "+path), 0, func(data net.Conn) error {
+		scanner := bufio.NewScanner(data)
+		for scanner.Scan() {
+			facts, name, ok := strings.Cut(strings.TrimSuffix(scanner.Text(), "\r"), " ")
+			if !ok {
+				return fmt.Errorf("ftptest: malformed MLSD entry %q", scanner.Text())
+			}
+			entry := Entry{Name: name, Facts: make(map[string]string)}
+			for _, fact := range strings.Split(strings.TrimSuffix(facts, ";"), ";") {
+				key, value, _ := strings.Cut(fact, "=")
+				entry.Facts[strings.ToLower(key)] = value
+			}
+			entries = append(entries, entry)
+		}
+		return scanner.Err()
+	})
+	return entries, err
+}
+
+// Retr downloads the file at path
+func (c *Client) Retr(path string) ([]byte, error) {
+	return c.RetrFrom(path, 0)
+}
+
+// RetrFrom downloads the file at path from offset, sending REST first
+// unless offset is 0.
+func (c *Client) RetrFrom(path string, offset int64) ([]byte, error) {
+	var content []byte
+	err := c.transfer("RETR "+path, offset, func(data net.Conn) error {
+		var err error
+		content, err = io.ReadAll(data)
+		return err
+	})
+	return content, err
+}
+
+// Stor uploads what r reads to path
+func (c *Client) Stor(path string, r io.Reader) error {
+	return c.StorFrom(path, r, 0)
+}
+
+// StorFrom uploads what r reads to path from offset, sending REST first
+// unless offset is 0.
+func (c *Client) StorFrom(path string, r io.Reader, offset int64) error {
+	return c.transfer("STOR "+path, offset, func(data net.Conn) error {
+		_, err := io.Copy(data, r)
+		return err
+	})
+}
+
+// Appe appends what r reads to the file at path
+func (c *Client) Appe(path string, r io.Reader) error {
+	return c.transfer("APPE "+path, 0, func(data net.Conn) error {
+		_, err := io.Copy(data, r)
+		return err
+	})
+}
+
+// Quit ends the session and closes the connection
+func (c *Client) Quit() error {
+	_, err := c.Cmd(221, "QUIT")
+	if closeErr := c.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Close closes the connection without ending the session
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// transfer runs command over a passive data connection, which fn reads or
+// writes before it is closed. A REST is sent first for a non zero offset.
+func (c *Client) transfer(command string, offset int64, fn func(net.Conn) error) error {
+	msg, err := c.Cmd(229, "EPSV")
+	if err != nil {
+		return err
+	}
+	var port int
+	i := strings.Index(msg, "(|||")
+	if i < 0 {
+		return fmt.Errorf("ftptest: unexpected EPSV reply %q", msg)
+	}
+	if _, err = fmt.Sscanf(msg[i:], "(|||%d|)", &port); err != nil {
+		return fmt.Errorf("ftptest: unexpected EPSV reply %q", msg)
+	}
+
+	data, err := net.Dial("tcp", net.JoinHostPort(c.host, fmt.Sprint(port)))
+	if err != nil {
+		return err
+	}
+	if c.tlsConfig != nil {
+		data = tls.Client(data, c.tlsConfig)
+	}
+	defer data.Close()
+
+	if offset != 0 {
+		if _, err = c.Cmd(350, "REST %d", offset); err != nil {
+			return err
+		}
+	}
+
+	id, err := c.conn.Cmd("%s", command)
+	if err != nil {
+		return err
+	}
+	c.conn.StartResponse(id)
+	defer c.conn.EndResponse(id)
+
+	if _, _, err = c.conn.ReadResponse(1); err != nil {
+		return err
+	}
+	err = fn(data)
+	if closeErr := data.Close(); err == nil {
+		err = closeErr
+	}
+	if _, _, replyErr := c.conn.ReadResponse(226); err == nil {
+		err = replyErr
+	}
+	return err
+}
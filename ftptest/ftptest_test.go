@@ -0,0 +1,171 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftptest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/globalcyberalliance/ftp-go"
+	"github.com/globalcyberalliance/ftp-go/driver/file"
+)
+
+func TestServer(t *testing.T) {
+	driver, err := file.NewDriver(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, cleanup := NewServer(driver, nil)
+	defer cleanup()
+
+	c, err := Dial(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	var replyErr *textproto.Error
+	if err = c.Login(Username, "wrong"); !errors.As(err, &replyErr) || replyErr.Code != 530 {
+		t.Fatalf("login with a wrong password: %v", err)
+	}
+	if err = c.Login(Username, Password); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = c.Stor("/hello.txt", strings.NewReader("hello")); err != nil {
+		t.Fatal(err)
+	}
+	list, err := c.List("/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(list, "hello.txt") {
+		t.Errorf("listing %q has no hello.txt", list)
+	}
+	content, err := c.Retr("/hello.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "hello" {
+		t.Errorf("downloaded %q, want %q", content, "hello")
+	}
+	if _, err = c.Retr("/missing.txt"); !errors.As(err, &replyErr) || replyErr.Code/100 != 5 {
+		t.Errorf("downloading a missing file: %v", err)
+	}
+
+	if msg, err := c.Cmd(257, "PWD"); err != nil || !strings.Contains(msg, `"/"`) {
+		t.Errorf("PWD: %q, %v", msg, err)
+	}
+	if err = c.Quit(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeCertificate writes a self-signed certificate for 127.0.0.1 and its
+// key to dir.
+func writeCertificate(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "ftptest"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	if err = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestServerTLS(t *testing.T) {
+	certFile, keyFile := writeCertificate(t, t.TempDir())
+	config := &tls.Config{InsecureSkipVerify: true}
+
+	for _, explicit := range []bool{false, true} {
+		driver, err := file.NewDriver(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		addr, cleanup := NewServer(driver, &ftp.Options{
+			TLS:          true,
+			ExplicitFTPS: explicit,
+			CertFile:     certFile,
+			KeyFile:      keyFile,
+		})
+
+		var c *Client
+		if explicit {
+			if c, err = Dial(addr); err == nil {
+				err = c.AuthTLS(config)
+			}
+		} else {
+			c, err = DialTLS(addr, config)
+		}
+		if err != nil {
+			cleanup()
+			t.Fatal(err)
+		}
+
+		if err = c.Login(Username, Password); err != nil {
+			t.Fatal(err)
+		}
+		if err = c.Stor("/hello.txt", strings.NewReader("hello")); err != nil {
+			t.Fatal(err)
+		}
+		content, err := c.RetrFrom("/hello.txt", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != "ello" {
+			t.Errorf("downloaded %q from offset 1, want %q", content, "ello")
+		}
+		entries, err := c.Mlsd("/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 || entries[0].Name != "hello.txt" || entries[0].Facts["size"] != "5" {
+			t.Errorf("MLSD entries %v", entries)
+		}
+		if err = c.Quit(); err != nil {
+			t.Fatal(err)
+		}
+		cleanup()
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package ftptest provides utilities for testing FTP servers and drivers,
+// in the spirit of net/http/httptest. NewServer starts a server on a
+// loopback port, Dial connects a minimal client to it:
+//
+//	addr, cleanup := ftptest.NewServer(driver, nil)
+//	defer cleanup()
+//
+//	c, err := ftptest.Dial(addr)
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer c.Close()
+//	if err = c.Login(ftptest.Username, ftptest.Password); err != nil {
+//		t.Fatal(err)
+//	}
+//	if err = c.Stor("/hello.txt", strings.NewReader("hello")); err != nil {
+//		t.Fatal(err)
+//	}
+package ftptest
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+
+	"github.com/globalcyberalliance/ftp-go"
+)
+
+// Credentials of the Auth NewServer uses when Options.Auth is not set
+const (
+	Username = "ftptest"
+	Password = "ftptest"
+)
+
+// NewServer starts an FTP server for driver on a loopback port. It returns
+// the address of the server, as host:port, and a func shutting it down.
+// opts may be nil; Auth defaults to Username and Password, Perm to a
+// SimplePerm and Logger to a DiscardLogger, Driver, Hostname and Port are
+// overridden. Clients of a server with Options.TLS connect with DialTLS or
+// AuthTLS. Like httptest.NewServer, it panics when the server cannot
+// start, which is a broken test environment more than a test failure.
+func NewServer(driver ftp.Driver, opts *ftp.Options) (string, func()) {
+	var o ftp.Options
+	if opts != nil {
+		o = *opts
+	}
+	o.Driver = driver
+	if o.Auth == nil {
+		o.Auth = &ftp.SimpleAuth{Name: Username, Password: Password}
+	}
+	if o.Perm == nil {
+		o.Perm = ftp.NewSimplePerm(Username, Username)
+	}
+	if o.Logger == nil {
+		o.Logger = new(ftp.DiscardLogger)
+	}
+
+	if o.TLS {
+		// The server only loads them once serving
+		if _, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile); err != nil {
+			panic(fmt.Sprintf("ftptest: %v", err))
+		}
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("ftptest: failed to listen on a port: %v", err))
+	}
+	o.Hostname = "127.0.0.1"
+	o.Port = l.Addr().(*net.TCPAddr).Port
+
+	server, err := ftp.NewServer(&o)
+	if err != nil {
+		l.Close()
+		panic(fmt.Sprintf("ftptest: %v", err))
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = server.Serve(l)
+	}()
+
+	return l.Addr().String(), func() {
+		_ = server.Shutdown()
+		<-done
+	}
+}
@@ -0,0 +1,320 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fuzzConn is a control connection reading the client's side from a fixed
+// input and discarding what the server writes.
+type fuzzConn struct {
+	r *bytes.Reader
+
+	once   sync.Once
+	closed chan struct{}
+}
+
+func newFuzzConn(input []byte) *fuzzConn {
+	return &fuzzConn{r: bytes.NewReader(input), closed: make(chan struct{})}
+}
+
+func (c *fuzzConn) Read(b []byte) (int, error) {
+	select {
+	case <-c.closed:
+		return 0, net.ErrClosed
+	default:
+	}
+	return c.r.Read(b)
+}
+
+func (c *fuzzConn) Write(b []byte) (int, error) { return len(b), nil }
+
+func (c *fuzzConn) Close() error {
+	c.once.Do(func() { close(c.closed) })
+	return nil
+}
+
+func (c *fuzzConn) LocalAddr() net.Addr {
+	return &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 21}
+}
+
+func (c *fuzzConn) RemoteAddr() net.Addr {
+	return &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 50000}
+}
+
+func (c *fuzzConn) SetDeadline(time.Time) error      { return nil }
+func (c *fuzzConn) SetReadDeadline(time.Time) error  { return nil }
+func (c *fuzzConn) SetWriteDeadline(time.Time) error { return nil }
+
+// fuzzLogger fails the test when a session recovers from a panic, which
+// Serve and receiveLine otherwise only log.
+type fuzzLogger struct {
+	DiscardLogger
+	t *testing.T
+}
+
+func (l *fuzzLogger) Print(sessionID string, message interface{}) {
+	l.check(fmt.Sprint(message))
+}
+
+func (l *fuzzLogger) Printf(sessionID string, format string, v ...interface{}) {
+	l.check(fmt.Sprintf(format, v...))
+}
+
+func (l *fuzzLogger) check(message string) {
+	if strings.HasPrefix(message, "recovered from handle panic") || strings.HasPrefix(message, "handler crashed") {
+		l.t.Error(message)
+	}
+}
+
+// refuseAuth refuses every login.
+type refuseAuth struct{}
+
+func (refuseAuth) CheckPasswd(*Context, string, string) (bool, error) {
+	return false, nil
+}
+
+// fuzzDriver serves the files below root, for the sessions FuzzServeLogin
+// logs in.
+type fuzzDriver struct {
+	root string
+}
+
+func (driver *fuzzDriver) realPath(p string) string {
+	return filepath.Join(driver.root, filepath.FromSlash(path.Clean("/"+p)))
+}
+
+func (driver *fuzzDriver) Stat(ctx *Context, p string) (os.FileInfo, error) {
+	return os.Stat(driver.realPath(p))
+}
+
+func (driver *fuzzDriver) ListDir(ctx *Context, p string, callback func(os.FileInfo) error) error {
+	entries, err := os.ReadDir(driver.realPath(p))
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if err = callback(info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (driver *fuzzDriver) DeleteDir(ctx *Context, p string) error {
+	return os.Remove(driver.realPath(p))
+}
+
+func (driver *fuzzDriver) DeleteFile(ctx *Context, p string) error {
+	return os.Remove(driver.realPath(p))
+}
+
+func (driver *fuzzDriver) Rename(ctx *Context, fromPath, toPath string) error {
+	return os.Rename(driver.realPath(fromPath), driver.realPath(toPath))
+}
+
+func (driver *fuzzDriver) MakeDir(ctx *Context, p string) error {
+	return os.Mkdir(driver.realPath(p), 0o755)
+}
+
+func (driver *fuzzDriver) GetFile(ctx *Context, p string, offset int64) (int64, io.ReadCloser, error) {
+	f, err := os.Open(driver.realPath(p))
+	if err != nil {
+		return 0, nil, err
+	}
+	info, err := f.Stat()
+	if err == nil {
+		_, err = f.Seek(max(offset, 0), io.SeekStart)
+	}
+	if err != nil {
+		f.Close()
+		return 0, nil, err
+	}
+	return info.Size() - max(offset, 0), f, nil
+}
+
+func (driver *fuzzDriver) PutFile(ctx *Context, p string, data io.Reader, offset int64) (int64, error) {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if offset >= 0 {
+		flags = os.O_WRONLY | os.O_CREATE
+	}
+	f, err := os.OpenFile(driver.realPath(p), flags, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	if offset >= 0 {
+		if err = f.Truncate(offset); err != nil {
+			return 0, err
+		}
+		if _, err = f.Seek(offset, io.SeekStart); err != nil {
+			return 0, err
+		}
+	}
+	return io.Copy(f, data)
+}
+
+// fuzzServe runs a session reading input until it ends.
+func fuzzServe(t *testing.T, opts *Options, input []byte) {
+	opts.Logger = &fuzzLogger{t: t}
+	opts.DisablePassive = true
+	opts.DisableActiveMode = true
+	s, err := NewServer(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.newSession(newSessionID(), newFuzzConn(input)).Serve()
+}
+
+var fuzzSeeds = []string{
+	"USER anonymous\r\nPASS guest\r\nSYST\r\nFEAT\r\nQUIT\r\n",
+	"USER admin\r\nPASS admin\r\nCWD /\r\nPWD\r\nLIST\r\nRETR a\r\n",
+	"\xff\xfb\x18\xff\xfd\x01NOOP\r\n\xff\xf4\xff\xf2ABOR\r\n",
+	"NOOP\rNOOP\nNOOP\r\nRNFR a\r\nRNTO b\r\n",
+	"OPTS UTF8 ON\r\nHELP SITE\r\nSITE HELP\r\nREST -1\r\nTYPE X\r\n",
+	"PORT 127,0,0,1,0,\r\nEPRT |9|::1|x|\r\nPASV\r\nEPSV ALL\r\n",
+	"CLNT \x00\r\nMODE Z\r\nSTRU R\r\nMDTM\r\nMFMT 2006 x\r\n",
+}
+
+func FuzzParseTelnet(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, line string) {
+		stripped, replies := parseTelnet(line)
+		if len(stripped) > len(line) {
+			t.Errorf("parseTelnet(%q) grew the line to %q", line, stripped)
+		}
+		if len(replies)%3 != 0 {
+			t.Fatalf("parseTelnet(%q) replied %x", line, replies)
+		}
+		for i := 0; i < len(replies); i += 3 {
+			if replies[i] != telnetIAC || (replies[i+1] != telnetWONT && replies[i+1] != telnetDONT) {
+				t.Errorf("parseTelnet(%q) replied %x, not a refusal", line, replies[i:i+3])
+			}
+		}
+	})
+}
+
+func FuzzParseLine(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		line, _, _ := strings.Cut(seed, "\n")
+		f.Add(line + "\n")
+	}
+
+	sess := &Session{}
+	f.Fuzz(func(t *testing.T, line string) {
+		command, param := sess.parseLine(line)
+		if strings.Contains(command, " ") {
+			t.Errorf("parseLine(%q) = %q, %q: space in the command", line, command, param)
+		}
+		if len(command)+len(param) > len(line) {
+			t.Errorf("parseLine(%q) = %q, %q: longer than the line", line, command, param)
+		}
+	})
+}
+
+func FuzzReadLine(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add([]byte(seed))
+	}
+
+	const maxLineLength = 64
+	s, err := NewServer(&Options{
+		Perm:          NewSimplePerm("test", "test"),
+		Logger:        new(DiscardLogger),
+		MaxLineLength: maxLineLength,
+	})
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	f.Fuzz(func(t *testing.T, input []byte) {
+		sess := s.newSession(newSessionID(), newFuzzConn(input))
+		read := 0
+		for {
+			line, err := sess.readLine()
+			read += len(line)
+			if err == errLineTooLong {
+				return
+			}
+			if len(line) > maxLineLength {
+				t.Fatalf("readLine returned %d bytes, more than %d", len(line), maxLineLength)
+			}
+			if i := strings.IndexByte(line, '\n'); i >= 0 && i != len(line)-1 {
+				t.Fatalf("readLine returned %q, going past its end", line)
+			}
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		if read > len(input) {
+			t.Errorf("readLine returned %d bytes out of %d", read, len(input))
+		}
+	})
+}
+
+// FuzzServe drives sessions which never log in, FuzzServeLogin logged in
+// ones.
+func FuzzServe(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, input []byte) {
+		fuzzServe(t, &Options{
+			Auth:               refuseAuth{},
+			Perm:               NewSimplePerm("test", "test"),
+			MaxLineLength:      512,
+			MaxPreAuthCommands: 64,
+		}, input)
+	})
+}
+
+const fuzzLogin = "USER admin\r\nPASS admin\r\n"
+
+// FuzzServeLogin drives logged in sessions with arbitrary command streams,
+// over files in a temporary directory.
+func FuzzServeLogin(f *testing.F) {
+	for _, seed := range []string{
+		"PWD\r\nMKD a\r\nCWD a\r\nCDUP\r\nRNFR a\r\nRNTO b\r\nRMD b\r\n",
+		"STOR a\r\nAPPE a\r\nRETR a\r\nSIZE a\r\nMDTM a\r\nDELE a\r\n",
+		"LIST -a\r\nNLST\r\nMLSD /\r\nMLST /\r\nSTAT /\r\n",
+		"REST 10\r\nTYPE I\r\nMODE S\r\nSTRU F\r\nOPTS UTF8 ON\r\n",
+		"SITE HELP\r\nSITE CHMOD 777 a\r\nSITE SYMLINK a b\r\nFEAT\r\nHELP\r\n",
+		"MFMT 20200102030405 a\r\nXCRC a\r\nHASH a\r\nABOR\r\nQUIT\r\n",
+		"CWD ..\\..\\..\r\nMKD %2e%2e%2fx\r\nLIST C:\\\r\n",
+	} {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, input []byte) {
+		fuzzServe(t, &Options{
+			Driver:        &fuzzDriver{root: t.TempDir()},
+			Auth:          &SimpleAuth{Name: "admin", Password: "admin"},
+			Perm:          NewSimplePerm("test", "test"),
+			MaxLineLength: 512,
+		}, append([]byte(fuzzLogin), input...))
+	})
+}
@@ -4,16 +4,15 @@ go 1.22
 
 require (
 	github.com/absfs/memfs v0.0.0-20230318170722-e8d59e67c8b1
-	github.com/jlaffaye/ftp v0.2.0
 	github.com/stretchr/testify v1.9.0
+	golang.org/x/crypto v0.24.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/absfs/absfs v0.0.0-20200602175035-e49edc9fef15 // indirect
 	github.com/absfs/inode v0.0.0-20190804195220-b7cd14cdd0dc // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/hashicorp/errwrap v1.1.0 // indirect
-	github.com/hashicorp/go-multierror v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
+	golang.org/x/sys v0.21.0 // indirect
 )
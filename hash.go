@@ -0,0 +1,144 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// HashDriver is implemented by drivers computing the checksums of files
+// themselves, for instance from digests their backend stores, which SITE
+// HASH and SITE CKSM then report instead of reading the files.
+type HashDriver interface {
+	// params  - path, algorithm as in Options.UploadChecksums, lower case
+	// returns - the hex digest of the file, or errors.ErrUnsupported to
+	//           have the server read the file and compute it
+	Hash(*Context, string, string) (string, error)
+}
+
+// hashFile returns the hex digest of the file at p, from the driver if it
+// is a HashDriver, reading the file otherwise when it is at most
+// Options.MaxHashSize bytes.
+func (sess *Session) hashFile(ctx *Context, algorithm, p string) (string, error) {
+	if driver, ok := sess.baseDriver().(HashDriver); ok {
+		sum, err := driver.Hash(ctx, sess.realPath(p), algorithm)
+		if !errors.Is(err, errors.ErrUnsupported) {
+			return sum, err
+		}
+	}
+
+	maxSize := sess.server.MaxHashSize
+	if maxSize <= 0 {
+		return "", &ReplyError{Code: 502, Message: "Computing digests is not enabled"}
+	}
+	info, err := sess.driver().Stat(ctx, p)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", errors.New("not a file")
+	}
+	if info.Size() > maxSize {
+		return "", &ReplyError{Code: 550, Message: fmt.Sprintf("File is larger than %d bytes", maxSize)}
+	}
+
+	size, data, err := sess.driver().GetFile(ctx, p, 0)
+	if err != nil {
+		return "", err
+	}
+	defer data.Close()
+	h := checksumAlgorithms[algorithm]()
+	if size > maxSize {
+		return "", &ReplyError{Code: 550, Message: fmt.Sprintf("File is larger than %d bytes", maxSize)}
+	}
+	if _, err = sess.server.copyBuffer(ctx, h, io.LimitReader(data, maxSize)); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// siteHash answers SITE HASH and SITE CKSM with the digest of the file at
+// param.
+func (sess *Session) siteHash(command, algorithm, param string) {
+	p := sess.buildPath(param)
+	ctx := Context{
+		Sess:  sess,
+		Cmd:   command,
+		Param: param,
+		Data:  NewStore(),
+	}
+	err := sess.server.notifiers.Intercept(&ctx, p)
+	sess.checkCanary(&ctx, p)
+	var sum string
+	if err == nil {
+		sum, err = sess.hashFile(&ctx, algorithm, p)
+	}
+	if err != nil {
+		sess.writeError(err, 550, fmt.Sprint("Action not taken: ", err))
+		return
+	}
+	sess.writeMessage(213, fmt.Sprintf("%s %s %s", strings.ToUpper(algorithm), sum, p))
+}
+
+// commandSiteHash responds to SITE HASH algorithm path with the digest of
+// the file, with one of the algorithms of Options.UploadChecksums.
+type commandSiteHash struct{}
+
+func (cmd commandSiteHash) IsExtend() bool {
+	return false
+}
+
+func (cmd commandSiteHash) RequireParam() bool {
+	return true
+}
+
+func (cmd commandSiteHash) RequireAuth() bool {
+	return true
+}
+
+func (cmd commandSiteHash) Help() string {
+	return "Syntax: SITE HASH <crc32|md5|sha1|sha256|sha512> <path>"
+}
+
+func (cmd commandSiteHash) Execute(sess *Session, param string) {
+	algorithm, p, _ := strings.Cut(param, " ")
+	algorithm = strings.ReplaceAll(strings.ToLower(algorithm), "-", "")
+	if p == "" {
+		sess.writeMessage(501, cmd.Help())
+		return
+	}
+	if _, ok := checksumAlgorithms[algorithm]; !ok {
+		sess.writeMessage(504, fmt.Sprintf("Unknown hash algorithm %s", algorithm))
+		return
+	}
+	sess.siteHash("SITE HASH", algorithm, p)
+}
+
+// commandSiteCksm responds to SITE CKSM path with the CRC-32 of the file
+type commandSiteCksm struct{}
+
+func (cmd commandSiteCksm) IsExtend() bool {
+	return false
+}
+
+func (cmd commandSiteCksm) RequireParam() bool {
+	return true
+}
+
+func (cmd commandSiteCksm) RequireAuth() bool {
+	return true
+}
+
+func (cmd commandSiteCksm) Help() string {
+	return "Syntax: SITE CKSM <path> (show the CRC-32 of the file)"
+}
+
+func (cmd commandSiteCksm) Execute(sess *Session, param string) {
+	sess.siteHash("SITE CKSM", "crc32", param)
+}
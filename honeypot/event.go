@@ -0,0 +1,87 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package honeypot
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/globalcyberalliance/ftp-go"
+)
+
+// EventType is the kind of an Event
+type EventType string
+
+// Event types
+const (
+	EventLogin      EventType = "login"
+	EventCommand    EventType = "command"
+	EventUpload     EventType = "upload"
+	EventDownload   EventType = "download"
+	EventCanary     EventType = "canary"
+	EventDisconnect EventType = "disconnect"
+)
+
+// Event is a structured record of something a client did, ready to be fed
+// to a threat-intel pipeline.
+type Event struct {
+	Time       time.Time `json:"time"`
+	Type       EventType `json:"type"`
+	SessionID  string    `json:"session_id"`
+	RemoteAddr string    `json:"remote_addr,omitempty"`
+	User       string    `json:"user,omitempty"`
+	// Fingerprint.Hash of the client so far, to cluster tools
+	FingerprintHash string `json:"fingerprint_hash,omitempty"`
+
+	// login
+	Password string `json:"password,omitempty"`
+	Accepted bool   `json:"accepted,omitempty"`
+
+	// command, the raw line as received
+	Line string `json:"line,omitempty"`
+
+	// upload, download and canary
+	Path      string            `json:"path,omitempty"`
+	Size      int64             `json:"size,omitempty"`
+	Checksums map[string]string `json:"checksums,omitempty"`
+	Error     string            `json:"error,omitempty"`
+
+	// disconnect
+	Fingerprint *ftp.Fingerprint `json:"fingerprint,omitempty"`
+}
+
+// Recorder receives the events of a Honeypot. Record may be called
+// concurrently.
+type Recorder interface {
+	Record(event *Event)
+}
+
+// RecorderFunc is a function used as Recorder
+type RecorderFunc func(event *Event)
+
+// Record implements Recorder
+func (f RecorderFunc) Record(event *Event) {
+	f(event)
+}
+
+// JSONRecorder writes events as JSON lines
+type JSONRecorder struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewJSONRecorder creates a JSONRecorder writing to w
+func NewJSONRecorder(w io.Writer) *JSONRecorder {
+	return &JSONRecorder{enc: json.NewEncoder(w)}
+}
+
+// Record implements Recorder, write errors are dropped.
+func (r *JSONRecorder) Record(event *Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_ = r.enc.Encode(event)
+}
@@ -0,0 +1,436 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package honeypot
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/globalcyberalliance/ftp-go"
+	"github.com/globalcyberalliance/ftp-go/quarantine"
+)
+
+var errDirNotEmpty = errors.New("honeypot: directory not empty")
+
+var _ ftp.Driver = &FS{}
+
+// fileTemplate describes generated files, {date} in the name makes a few
+// dated copies and {n} a few numbered ones.
+type fileTemplate struct {
+	name     string
+	min, max int64
+}
+
+// layout is the tree NewFS generates from.
+var layout = map[string][]fileTemplate{
+	"backup": {
+		{"db_backup_{date}.sql.gz", 20 << 20, 400 << 20},
+		{"site_{date}.tar.gz", 50 << 20, 900 << 20},
+	},
+	"config": {
+		{"settings.ini", 1 << 10, 6 << 10},
+		{"credentials.xml", 1 << 10, 4 << 10},
+		{"id_rsa.pub", 380, 750},
+	},
+	"logs": {
+		{"access.log.{n}", 1 << 20, 80 << 20},
+		{"error.log", 10 << 10, 2 << 20},
+	},
+	"pub": {
+		{"README.txt", 500, 3 << 10},
+		{"update_{date}.zip", 1 << 20, 60 << 20},
+	},
+	"www": {
+		{"index.html", 2 << 10, 30 << 10},
+		{"wp-config.php", 2 << 10, 4 << 10},
+		{".htaccess", 200, 2 << 10},
+		{"robots.txt", 30, 300},
+	},
+}
+
+// textExts are the extensions served as text rather than binary content.
+var textExts = map[string]bool{
+	".txt": true, ".log": true, ".ini": true, ".xml": true, ".php": true,
+	".html": true, ".htaccess": true, ".pub": true,
+}
+
+type node struct {
+	name     string
+	dir      bool
+	size     int64
+	modTime  time.Time
+	seed     int64
+	children map[string]*node
+}
+
+// FS is a driver serving a synthetic filesystem which looks like a
+// neglected web server. Clients may change it, the changes are shared by
+// all sessions but never reach the disk: downloads always get generated
+// content, uploads included, and the uploaded data is only kept in
+// Payloads.
+type FS struct {
+	// Payloads captures uploads, they are discarded when nil.
+	Payloads *quarantine.Store
+
+	mu   sync.RWMutex
+	rnd  *rand.Rand
+	root *node
+}
+
+// NewFS generates a filesystem from seed, the same seed gives the same tree
+// dated relative to now.
+func NewFS(seed int64) *FS {
+	fs := &FS{
+		rnd:  rand.New(rand.NewSource(seed)),
+		root: &node{name: "/", dir: true, modTime: time.Now(), children: make(map[string]*node)},
+	}
+
+	dirs := make([]string, 0, len(layout))
+	for dir := range layout {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		d := fs.newNode(dir, true, 0)
+		d.modTime = time.Time{}
+		fs.root.children[dir] = d
+		for _, tmpl := range layout[dir] {
+			fs.generate(d, tmpl)
+		}
+	}
+	return fs
+}
+
+func (fs *FS) newNode(name string, dir bool, size int64) *node {
+	n := &node{
+		name:    name,
+		dir:     dir,
+		size:    size,
+		modTime: time.Now().Add(-time.Duration(fs.rnd.Int63n(int64(2 * 365 * 24 * time.Hour)))).Truncate(time.Second),
+		seed:    fs.rnd.Int63(),
+	}
+	if dir {
+		n.children = make(map[string]*node)
+	}
+	return n
+}
+
+func (fs *FS) generate(dir *node, tmpl fileTemplate) {
+	count := 1
+	if strings.Contains(tmpl.name, "{date}") || strings.Contains(tmpl.name, "{n}") {
+		count = 2 + fs.rnd.Intn(4)
+	}
+	for i := 0; i < count; i++ {
+		n := fs.newNode("", false, tmpl.min+fs.rnd.Int63n(tmpl.max-tmpl.min))
+		n.name = strings.NewReplacer(
+			"{date}", n.modTime.Format("2006-01-02"),
+			"{n}", strconv.Itoa(i+1),
+		).Replace(tmpl.name)
+		dir.children[n.name] = n
+		if n.modTime.After(dir.modTime) {
+			dir.modTime = n.modTime
+		}
+	}
+}
+
+// lookup returns the node at p and its parent, fs.mu must be held.
+func (fs *FS) lookup(p string) (n, parent *node) {
+	n = fs.root
+	for _, name := range strings.Split(strings.Trim(path.Clean("/"+p), "/"), "/") {
+		if name == "" {
+			continue
+		}
+		if !n.dir {
+			return nil, nil
+		}
+		parent, n = n, n.children[name]
+		if n == nil {
+			return nil, parent
+		}
+	}
+	return n, parent
+}
+
+// parentDir returns the directory p is in, fs.mu must be held.
+func (fs *FS) parentDir(p string) (*node, error) {
+	dir, _ := fs.lookup(path.Dir(path.Clean("/" + p)))
+	if dir == nil {
+		return nil, ftp.ErrNotFound
+	}
+	if !dir.dir {
+		return nil, ftp.ErrNotDir
+	}
+	return dir, nil
+}
+
+// Stat implements ftp.Driver
+func (fs *FS) Stat(ctx *ftp.Context, p string) (os.FileInfo, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	n, _ := fs.lookup(p)
+	if n == nil {
+		return nil, ftp.ErrNotFound
+	}
+	return n.info(), nil
+}
+
+// ListDir implements ftp.Driver
+func (fs *FS) ListDir(ctx *ftp.Context, p string, callback func(os.FileInfo) error) error {
+	fs.mu.RLock()
+	n, _ := fs.lookup(p)
+	if n == nil {
+		fs.mu.RUnlock()
+		return ftp.ErrNotFound
+	}
+	if !n.dir {
+		fs.mu.RUnlock()
+		return ftp.ErrNotDir
+	}
+	infos := make([]os.FileInfo, 0, len(n.children))
+	for _, child := range n.children {
+		infos = append(infos, child.info())
+	}
+	fs.mu.RUnlock()
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name() < infos[j].Name() })
+	for _, info := range infos {
+		if err := callback(info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteDir implements ftp.Driver
+func (fs *FS) DeleteDir(ctx *ftp.Context, p string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	n, parent := fs.lookup(p)
+	switch {
+	case n == nil:
+		return ftp.ErrNotFound
+	case !n.dir:
+		return ftp.ErrNotDir
+	case n == fs.root:
+		return ftp.ErrPermissionDenied
+	case len(n.children) > 0:
+		return errDirNotEmpty
+	}
+	delete(parent.children, n.name)
+	return nil
+}
+
+// DeleteFile implements ftp.Driver
+func (fs *FS) DeleteFile(ctx *ftp.Context, p string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	n, parent := fs.lookup(p)
+	if n == nil {
+		return ftp.ErrNotFound
+	}
+	if n.dir {
+		return ftp.ErrIsDir
+	}
+	delete(parent.children, n.name)
+	return nil
+}
+
+// Rename implements ftp.Driver
+func (fs *FS) Rename(ctx *ftp.Context, fromPath string, toPath string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	n, parent := fs.lookup(fromPath)
+	if n == nil {
+		return ftp.ErrNotFound
+	}
+	if n == fs.root {
+		return ftp.ErrPermissionDenied
+	}
+	dir, err := fs.parentDir(toPath)
+	if err != nil {
+		return err
+	}
+	name := path.Base(path.Clean("/" + toPath))
+	if _, ok := dir.children[name]; ok {
+		return ftp.ErrExist
+	}
+	delete(parent.children, n.name)
+	n.name = name
+	dir.children[name] = n
+	return nil
+}
+
+// MakeDir implements ftp.Driver
+func (fs *FS) MakeDir(ctx *ftp.Context, p string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	dir, err := fs.parentDir(p)
+	if err != nil {
+		return err
+	}
+	name := path.Base(path.Clean("/" + p))
+	if _, ok := dir.children[name]; ok {
+		return ftp.ErrExist
+	}
+	n := fs.newNode(name, true, 0)
+	n.modTime = time.Now()
+	dir.children[name] = n
+	return nil
+}
+
+// GetFile implements ftp.Driver
+func (fs *FS) GetFile(ctx *ftp.Context, p string, offset int64) (int64, io.ReadCloser, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	n, _ := fs.lookup(p)
+	if n == nil {
+		return 0, nil, ftp.ErrNotFound
+	}
+	if n.dir {
+		return 0, nil, ftp.ErrIsDir
+	}
+	if offset > n.size {
+		offset = n.size
+	}
+	return n.size - offset, io.NopCloser(newContentReader(n, offset)), nil
+}
+
+// PutFile implements ftp.Driver, the data is captured in Payloads.
+func (fs *FS) PutFile(ctx *ftp.Context, destPath string, data io.Reader, offset int64) (int64, error) {
+	var (
+		size int64
+		err  error
+	)
+	if fs.Payloads != nil {
+		var entry *quarantine.Entry
+		if entry, err = fs.Payloads.Put(ctx, destPath, data, offset); entry != nil {
+			size = entry.Size
+		}
+	} else {
+		size, err = io.Copy(io.Discard, data)
+	}
+	if err != nil {
+		return size, err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	dir, err := fs.parentDir(destPath)
+	if err != nil {
+		return size, err
+	}
+	name := path.Base(path.Clean("/" + destPath))
+	n := dir.children[name]
+	if n != nil && n.dir {
+		return size, ftp.ErrIsDir
+	}
+	if n == nil || offset < 0 {
+		n = fs.newNode(name, false, 0)
+		dir.children[name] = n
+		offset = 0
+	}
+	if offset > n.size {
+		offset = n.size
+	}
+	n.size = offset + size
+	n.modTime = time.Now()
+	return size, nil
+}
+
+// info returns a snapshot of n, fs.mu must be held.
+func (n *node) info() os.FileInfo {
+	return &fileInfo{
+		name:    n.name,
+		dir:     n.dir,
+		size:    n.size,
+		modTime: n.modTime,
+	}
+}
+
+type fileInfo struct {
+	name    string
+	dir     bool
+	size    int64
+	modTime time.Time
+}
+
+func (f *fileInfo) Name() string       { return f.name }
+func (f *fileInfo) Size() int64        { return f.size }
+func (f *fileInfo) ModTime() time.Time { return f.modTime }
+func (f *fileInfo) IsDir() bool        { return f.dir }
+func (f *fileInfo) Sys() interface{}   { return nil }
+
+func (f *fileInfo) Mode() os.FileMode {
+	if f.dir {
+		return os.ModeDir | 0o755
+	}
+	return 0o644
+}
+
+const (
+	contentBlockSize = 4096
+	textAlphabet     = "etaoinshrdlucmfwypvbgkqjxz    \n"
+)
+
+// contentReader generates the content of a file block by block, so reading
+// from an offset does not generate what comes before it.
+type contentReader struct {
+	seed      int64
+	text      bool
+	pos, size int64
+	block     []byte
+}
+
+func newContentReader(n *node, offset int64) *contentReader {
+	return &contentReader{
+		seed: n.seed,
+		text: textExts[path.Ext(n.name)],
+		pos:  offset,
+		size: n.size,
+	}
+}
+
+func (r *contentReader) Read(p []byte) (int, error) {
+	if r.pos >= r.size {
+		return 0, io.EOF
+	}
+	if len(r.block) == 0 {
+		r.block = r.generate(r.pos / contentBlockSize)[r.pos%contentBlockSize:]
+	}
+	if remaining := r.size - r.pos; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	n := copy(p, r.block)
+	r.block = r.block[n:]
+	r.pos += int64(n)
+	return n, nil
+}
+
+func (r *contentReader) generate(index int64) []byte {
+	block := make([]byte, contentBlockSize)
+	rnd := rand.New(rand.NewSource(r.seed + index))
+	rnd.Read(block)
+	if r.text {
+		for i, b := range block {
+			block[i] = textAlphabet[int(b)%len(textAlphabet)]
+		}
+	}
+	return block
+}
@@ -0,0 +1,152 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package honeypot turns an ftp.Server into a honeypot: logins are accepted,
+// clients see a believable synthetic filesystem, their uploads are captured
+// and everything they do is recorded as structured events.
+//
+//	fs := honeypot.NewFS(time.Now().UnixNano())
+//	fs.Payloads, err = quarantine.NewStore("/var/lib/honeypot")
+//	hp := honeypot.New(honeypot.NewJSONRecorder(os.Stdout))
+//	server, err := ftp.NewServer(&ftp.Options{
+//		Driver: fs,
+//		Auth:   hp,
+//		Perm:   ftp.NewSimplePerm("ftp", "ftp"),
+//	})
+//	server.RegisterNotifier(hp)
+package honeypot
+
+import (
+	"sync"
+	"time"
+
+	"github.com/globalcyberalliance/ftp-go"
+)
+
+var (
+	_ ftp.Auth               = &Honeypot{}
+	_ ftp.CommandNotifier    = &Honeypot{}
+	_ ftp.TransferNotifier   = &Honeypot{}
+	_ ftp.CanaryNotifier     = &Honeypot{}
+	_ ftp.DisconnectNotifier = &Honeypot{}
+)
+
+// Honeypot captures the credentials, commands and transfers of clients and
+// hands them to its Recorder. Use it as Options.Auth and register it with
+// Server.RegisterNotifier.
+type Honeypot struct {
+	recorder Recorder
+
+	// Accept decides which logins succeed, all do when nil.
+	Accept func(user, password string) bool
+
+	mu    sync.Mutex
+	users map[string]string // logged in user by session id
+}
+
+// New creates a Honeypot recording to recorder
+func New(recorder Recorder) *Honeypot {
+	return &Honeypot{
+		recorder: recorder,
+		users:    make(map[string]string),
+	}
+}
+
+func (h *Honeypot) record(ctx *ftp.Context, event *Event) {
+	event.Time = time.Now()
+	if ctx != nil && ctx.Sess != nil {
+		event.SessionID = ctx.Sess.ID()
+		event.RemoteAddr = ctx.Sess.RemoteAddr().String()
+		event.FingerprintHash = ctx.Sess.Fingerprint().Hash()
+		if event.User == "" {
+			h.mu.Lock()
+			event.User = h.users[event.SessionID]
+			h.mu.Unlock()
+		}
+	}
+	h.recorder.Record(event)
+}
+
+// CheckPasswd implements ftp.Auth, it records the credentials tried.
+func (h *Honeypot) CheckPasswd(ctx *ftp.Context, user, password string) (bool, error) {
+	accepted := h.Accept == nil || h.Accept(user, password)
+	h.record(ctx, &Event{
+		Type:     EventLogin,
+		User:     user,
+		Password: password,
+		Accepted: accepted,
+	})
+
+	if accepted && ctx != nil && ctx.Sess != nil {
+		h.mu.Lock()
+		h.users[ctx.Sess.ID()] = user
+		h.mu.Unlock()
+	}
+	return accepted, nil
+}
+
+// BeforeCommand implements ftp.CommandNotifier
+func (h *Honeypot) BeforeCommand(ctx *ftp.Context, command string) {
+	h.record(ctx, &Event{
+		Type: EventCommand,
+		Line: command,
+	})
+}
+
+// BeforePutFile implements ftp.TransferNotifier
+func (h *Honeypot) BeforePutFile(ctx *ftp.Context, dstPath string) {
+}
+
+// AfterFilePut implements ftp.TransferNotifier
+func (h *Honeypot) AfterFilePut(ctx *ftp.Context, dstPath string, size int64, err error) {
+	h.record(ctx, &Event{
+		Type:      EventUpload,
+		Path:      dstPath,
+		Size:      size,
+		Checksums: ctx.Checksums,
+		Error:     errorString(err),
+	})
+}
+
+// BeforeDownloadFile implements ftp.TransferNotifier
+func (h *Honeypot) BeforeDownloadFile(ctx *ftp.Context, dstPath string) {
+}
+
+// AfterFileDownloaded implements ftp.TransferNotifier
+func (h *Honeypot) AfterFileDownloaded(ctx *ftp.Context, dstPath string, size int64, err error) {
+	h.record(ctx, &Event{
+		Type:  EventDownload,
+		Path:  dstPath,
+		Size:  size,
+		Error: errorString(err),
+	})
+}
+
+// OnCanary implements ftp.CanaryNotifier
+func (h *Honeypot) OnCanary(ctx *ftp.Context, event *ftp.CanaryEvent) {
+	h.record(ctx, &Event{
+		Type: EventCanary,
+		Path: event.Path,
+	})
+}
+
+// OnDisconnect implements ftp.DisconnectNotifier
+func (h *Honeypot) OnDisconnect(ctx *ftp.Context) {
+	fingerprint := ctx.Sess.Fingerprint()
+	h.record(ctx, &Event{
+		Type:        EventDisconnect,
+		Fingerprint: &fingerprint,
+	})
+
+	h.mu.Lock()
+	delete(h.users, ctx.Sess.ID())
+	h.mu.Unlock()
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
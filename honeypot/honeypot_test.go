@@ -0,0 +1,137 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package honeypot
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/globalcyberalliance/ftp-go"
+	"github.com/globalcyberalliance/ftp-go/quarantine"
+	"github.com/stretchr/testify/assert"
+)
+
+func listNames(t *testing.T, fs *FS, p string) []string {
+	var names []string
+	assert.NoError(t, fs.ListDir(&ftp.Context{}, p, func(info os.FileInfo) error {
+		names = append(names, info.Name())
+		return nil
+	}))
+	return names
+}
+
+func TestFS(t *testing.T) {
+	ctx := &ftp.Context{}
+	fs := NewFS(42)
+	assert.EqualValues(t, []string{"backup", "config", "logs", "pub", "www"}, listNames(t, fs, "/"))
+	assert.EqualValues(t, listNames(t, fs, "/backup"), listNames(t, NewFS(42), "/backup"))
+
+	info, err := fs.Stat(ctx, "/www/robots.txt")
+	assert.NoError(t, err)
+	assert.False(t, info.IsDir())
+
+	size, r, err := fs.GetFile(ctx, "/www/robots.txt", 0)
+	assert.NoError(t, err)
+	content, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.EqualValues(t, info.Size(), size)
+	assert.EqualValues(t, size, len(content))
+
+	_, r, err = fs.GetFile(ctx, "/www/robots.txt", 10)
+	assert.NoError(t, err)
+	tail, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.EqualValues(t, content[10:], tail)
+
+	_, err = fs.Stat(ctx, "/missing")
+	assert.ErrorIs(t, err, ftp.ErrNotFound)
+	assert.ErrorIs(t, fs.DeleteDir(ctx, "/www"), errDirNotEmpty)
+
+	fs.Payloads, err = quarantine.NewStore(t.TempDir())
+	assert.NoError(t, err)
+	size, err = fs.PutFile(ctx, "/pub/dropper.sh", strings.NewReader("payload"), -1)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 7, size)
+	info, err = fs.Stat(ctx, "/pub/dropper.sh")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 7, info.Size())
+
+	// The payload is captured, never served back.
+	entries, err := fs.Payloads.Entries()
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 1) {
+		assert.EqualValues(t, "/pub/dropper.sh", entries[0].Path)
+	}
+	_, r, err = fs.GetFile(ctx, "/pub/dropper.sh", 0)
+	assert.NoError(t, err)
+	served, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.NotEqualValues(t, "payload", string(served))
+
+	// A resumed upload records where it starts.
+	_, err = fs.PutFile(ctx, "/pub/dropper.sh", strings.NewReader("more"), 7)
+	assert.NoError(t, err)
+	info, err = fs.Stat(ctx, "/pub/dropper.sh")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 11, info.Size())
+	entries, err = fs.Payloads.Entries()
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 2) {
+		assert.EqualValues(t, 7, entries[1].Offset)
+	}
+
+	assert.NoError(t, fs.MakeDir(ctx, "/pub/x"))
+	assert.ErrorIs(t, fs.MakeDir(ctx, "/pub/x"), ftp.ErrExist)
+	assert.NoError(t, fs.Rename(ctx, "/pub/dropper.sh", "/pub/x/run.sh"))
+	assert.EqualValues(t, []string{"run.sh"}, listNames(t, fs, "/pub/x"))
+	assert.NoError(t, fs.DeleteFile(ctx, "/pub/x/run.sh"))
+	assert.NoError(t, fs.DeleteDir(ctx, "/pub/x"))
+}
+
+func TestHoneypot(t *testing.T) {
+	var buf bytes.Buffer
+	hp := New(NewJSONRecorder(&buf))
+	hp.Accept = func(user, password string) bool {
+		return user != "root"
+	}
+
+	ctx := &ftp.Context{}
+	ok, err := hp.CheckPasswd(ctx, "root", "toor")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = hp.CheckPasswd(ctx, "admin", "123456")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	hp.BeforeCommand(ctx, "STOR x.sh")
+	hp.AfterFilePut(&ftp.Context{Checksums: map[string]string{"sha256": "abc"}}, "/x.sh", 3, nil)
+
+	var events []Event
+	dec := json.NewDecoder(&buf)
+	for {
+		var event Event
+		if err := dec.Decode(&event); err == io.EOF {
+			break
+		} else if !assert.NoError(t, err) {
+			return
+		}
+		events = append(events, event)
+	}
+
+	if assert.Len(t, events, 4) {
+		assert.EqualValues(t, EventLogin, events[0].Type)
+		assert.EqualValues(t, "root", events[0].User)
+		assert.EqualValues(t, "toor", events[0].Password)
+		assert.False(t, events[0].Accepted)
+		assert.True(t, events[1].Accepted)
+		assert.EqualValues(t, "STOR x.sh", events[2].Line)
+		assert.EqualValues(t, EventUpload, events[3].Type)
+		assert.EqualValues(t, "/x.sh", events[3].Path)
+		assert.EqualValues(t, "abc", events[3].Checksums["sha256"])
+	}
+}
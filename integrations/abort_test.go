@@ -0,0 +1,138 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/globalcyberalliance/ftp-go"
+	"github.com/globalcyberalliance/ftp-go/driver/file"
+	"github.com/stretchr/testify/assert"
+)
+
+// ctxDriver records the context error a PutFile call returns with.
+type ctxDriver struct {
+	ftp.Driver
+	err chan error
+}
+
+func (driver *ctxDriver) PutFile(ctx *ftp.Context, path string, data io.Reader, offset int64) (int64, error) {
+	n, err := driver.Driver.PutFile(ctx, path, data, offset)
+	driver.err <- ctx.Err()
+	return n, err
+}
+
+func TestAbort(t *testing.T) {
+	assert.NoError(t, os.MkdirAll("./testdata/abort", os.ModePerm))
+	defer os.RemoveAll("./testdata/abort")
+
+	base, err := file.NewDriver("./testdata/abort")
+	assert.NoError(t, err)
+	driver := &ctxDriver{Driver: base, err: make(chan error, 1)}
+
+	opt := &ftp.Options{
+		Name:   "test ftpd",
+		Driver: driver,
+		Perm:   ftp.NewSimplePerm("test", "test"),
+		Port:   2126,
+		Auth: &ftp.SimpleAuth{
+			Name:     "admin",
+			Password: "admin",
+		},
+		Logger:      new(ftp.DiscardLogger),
+		IdleTimeout: 300 * time.Millisecond,
+	}
+
+	aborted := make(chan string, 1)
+	notifier := &ftp.NotifierFuncs{
+		AfterTransferAbortedFunc: func(ctx *ftp.Context, dstPath string, size int64) {
+			aborted <- dstPath
+		},
+	}
+
+	runServer(t, opt, []interface{}{notifier}, func() {
+		var client *textproto.Conn
+		for start := time.Now(); ; {
+			client, err = textproto.Dial("tcp", "localhost:2126")
+			if err == nil || time.Since(start) > 500*time.Millisecond {
+				break
+			}
+		}
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer client.Close()
+
+		expect := func(code int, format string, args ...interface{}) string {
+			if format != "" {
+				_, err := client.Cmd(format, args...)
+				assert.NoError(t, err)
+			}
+			_, msg, err := client.ReadResponse(code)
+			assert.NoError(t, err)
+			return msg
+		}
+
+		expect(220, "")
+		expect(331, "USER admin")
+		expect(230, "PASS admin")
+
+		msg := expect(229, "EPSV")
+		var port int
+		_, err := fmt.Sscanf(msg[strings.Index(msg, "(|||"):], "(|||%d|)", &port)
+		if !assert.NoError(t, err, msg) {
+			return
+		}
+		data, err := net.Dial("tcp", fmt.Sprintf("localhost:%d", port))
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer data.Close()
+
+		expect(150, "STOR big.txt")
+		_, err = data.Write([]byte("partial"))
+		assert.NoError(t, err)
+
+		// The transfer is still running, the ABOR is seen while it is, even
+		// after the control connection was quiet for longer than IdleTimeout.
+		time.Sleep(600 * time.Millisecond)
+		_, err = client.Cmd("\xff\xf4\xff\xf2ABOR")
+		assert.NoError(t, err)
+		expect(426, "")
+		expect(226, "")
+		assert.Equal(t, context.Canceled, <-driver.err)
+		assert.Equal(t, "/big.txt", <-aborted)
+
+		// ABOR ended by a CR alone
+		msg = expect(229, "EPSV")
+		_, err = fmt.Sscanf(msg[strings.Index(msg, "(|||"):], "(|||%d|)", &port)
+		if !assert.NoError(t, err, msg) {
+			return
+		}
+		data, err = net.Dial("tcp", fmt.Sprintf("localhost:%d", port))
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer data.Close()
+		expect(150, "STOR big.txt")
+		_, err = client.W.WriteString("ABOR\r")
+		assert.NoError(t, err)
+		assert.NoError(t, client.W.Flush())
+		expect(426, "")
+		expect(226, "")
+		assert.Equal(t, context.Canceled, <-driver.err)
+		assert.Equal(t, "/big.txt", <-aborted)
+
+		expect(221, "QUIT")
+	})
+}
@@ -0,0 +1,58 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"errors"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/globalcyberalliance/ftp-go"
+	"github.com/globalcyberalliance/ftp-go/driver/file"
+	"github.com/globalcyberalliance/ftp-go/ftptest"
+	"github.com/stretchr/testify/assert"
+)
+
+// allocDriver has room for uploads of up to max bytes
+type allocDriver struct {
+	ftp.Driver
+	max int64
+}
+
+func (driver *allocDriver) Allocate(ctx *ftp.Context, path string, size int64) error {
+	if size > driver.max {
+		return ftp.ErrInsufficientStorage
+	}
+	return nil
+}
+
+func TestAllocate(t *testing.T) {
+	base, err := file.NewDriver(t.TempDir())
+	assert.NoError(t, err)
+
+	addr, cleanup := ftptest.NewServer(&allocDriver{Driver: base, max: 8}, nil)
+	defer cleanup()
+
+	c, err := ftptest.Dial(addr)
+	assert.NoError(t, err)
+	defer c.Close()
+	assert.NoError(t, c.Login(ftptest.Username, ftptest.Password))
+
+	_, err = c.Cmd(200, "ALLO 16")
+	assert.NoError(t, err)
+	err = c.Stor("big.txt", strings.NewReader("too large for it"))
+	var protoErr *textproto.Error
+	if assert.True(t, errors.As(err, &protoErr), "%v", err) {
+		assert.EqualValues(t, 452, protoErr.Code)
+	}
+
+	_, err = c.Cmd(200, "ALLO 5")
+	assert.NoError(t, err)
+	assert.NoError(t, c.Stor("small.txt", strings.NewReader("small")))
+
+	// Without ALLO the driver is not asked
+	assert.NoError(t, c.Stor("big.txt", strings.NewReader("too large for it")))
+}
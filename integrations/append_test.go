@@ -0,0 +1,58 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/globalcyberalliance/ftp-go"
+	"github.com/globalcyberalliance/ftp-go/driver/file"
+	"github.com/globalcyberalliance/ftp-go/ftptest"
+	"github.com/stretchr/testify/assert"
+)
+
+// plainDriver hides the optional interfaces of the driver it wraps
+type plainDriver struct {
+	ftp.Driver
+}
+
+func TestAppend(t *testing.T) {
+	base, err := file.NewDriver(t.TempDir())
+	assert.NoError(t, err)
+
+	for name, driver := range map[string]ftp.Driver{
+		"AppendDriver": base,
+		"PutFile":      plainDriver{base},
+	} {
+		t.Run(name, func(t *testing.T) {
+			addr, cleanup := ftptest.NewServer(driver, nil)
+			defer cleanup()
+
+			c, err := ftptest.Dial(addr)
+			assert.NoError(t, err)
+			defer c.Close()
+			assert.NoError(t, c.Login(ftptest.Username, ftptest.Password))
+
+			p := "/" + name + ".txt"
+			assert.NoError(t, c.Appe(p, strings.NewReader("hello")))
+			assert.NoError(t, c.Appe(p, strings.NewReader(" world")))
+			content, err := c.Retr(p)
+			assert.NoError(t, err)
+			assert.EqualValues(t, "hello world", string(content))
+
+			// A resumed upload overwrites the file from the offset
+			assert.NoError(t, c.StorFrom(p, strings.NewReader(" there"), 5))
+			content, err = c.Retr(p)
+			assert.NoError(t, err)
+			assert.EqualValues(t, "hello there", string(content))
+
+			assert.NoError(t, c.StorFrom(p, strings.NewReader("!"), 5))
+			content, err = c.Retr(p)
+			assert.NoError(t, err)
+			assert.EqualValues(t, "hello!", string(content))
+		})
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/globalcyberalliance/ftp-go"
+	"github.com/globalcyberalliance/ftp-go/driver/file"
+	"github.com/globalcyberalliance/ftp-go/ftptest"
+	"github.com/stretchr/testify/assert"
+)
+
+// endlessDriver serves downloads which never end
+type endlessDriver struct {
+	ftp.Driver
+}
+
+func (driver endlessDriver) GetFile(ctx *ftp.Context, p string, offset int64) (int64, io.ReadCloser, error) {
+	return 1 << 40, io.NopCloser(endlessReader{}), nil
+}
+
+type endlessReader struct{}
+
+func (endlessReader) Read(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func TestCancelTransfer(t *testing.T) {
+	driver, err := file.NewDriver(t.TempDir())
+	assert.NoError(t, err)
+
+	sessions := make(chan *ftp.Session, 1)
+	addr, cleanup := ftptest.NewServer(endlessDriver{driver}, &ftp.Options{
+		SessionCallback: func(sess *ftp.Session) {
+			sessions <- sess
+		},
+	})
+	defer cleanup()
+	_, portStr, err := net.SplitHostPort(addr)
+	assert.NoError(t, err)
+	var port int
+	_, err = fmt.Sscan(portStr, &port)
+	assert.NoError(t, err)
+
+	client := dialControl(t, port)
+	defer client.Close()
+	client.expect(220, "")
+	sess := <-sessions
+	client.expect(331, "USER %s", ftptest.Username)
+	client.expect(230, "PASS %s", ftptest.Password)
+	msg := client.expect(229, "EPSV")
+	var dataPort int
+	_, err = fmt.Sscanf(msg[strings.Index(msg, "(|||"):], "(|||%d|)", &dataPort)
+	assert.NoError(t, err)
+	data, err := net.Dial("tcp", fmt.Sprintf("localhost:%d", dataPort))
+	assert.NoError(t, err)
+	defer data.Close()
+	client.expect(150, "RETR endless.bin")
+
+	// A middleware ends the download once it is under way
+	go func() {
+		for sess.Transfer() == nil {
+			time.Sleep(time.Millisecond)
+		}
+		_ = sess.DataConn().CancelTransfer()
+	}()
+	_, _ = io.Copy(io.Discard, data)
+	client.expect(426, "")
+	client.expect(221, "QUIT")
+}
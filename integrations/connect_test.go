@@ -0,0 +1,91 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/textproto"
+	"sync/atomic"
+	"testing"
+
+	"github.com/globalcyberalliance/ftp-go"
+	"github.com/globalcyberalliance/ftp-go/driver/file"
+	"github.com/globalcyberalliance/ftp-go/ftptest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnConnect(t *testing.T) {
+	driver, err := file.NewDriver(t.TempDir())
+	assert.NoError(t, err)
+
+	var connections atomic.Int32
+	addr, cleanup := ftptest.NewServer(driver, &ftp.Options{
+		OnConnect: func(sess *ftp.Session) error {
+			switch connections.Add(1) {
+			case 1:
+				sess.SetWelcomeMessage("Welcome, first client")
+				sess.SetRateLimit(1024)
+			case 2:
+				return errors.New("closed for maintenance")
+			case 3:
+				return &ftp.ReplyError{Code: 421, Message: "Too busy, try later"}
+			}
+			return nil
+		},
+	})
+	defer cleanup()
+
+	conn, err := textproto.Dial("tcp", addr)
+	assert.NoError(t, err)
+	defer conn.Close()
+	_, msg, err := conn.ReadResponse(220)
+	assert.NoError(t, err)
+	assert.Equal(t, "Welcome, first client", msg)
+
+	for _, message := range []string{"Service not available, closing control connection", "Too busy, try later"} {
+		_, err = ftptest.Dial(addr)
+		var protoErr *textproto.Error
+		if assert.ErrorAs(t, err, &protoErr) {
+			assert.Equal(t, 421, protoErr.Code)
+			assert.Equal(t, message, protoErr.Msg)
+		}
+	}
+}
+
+func TestConnCallbackImplicitTLS(t *testing.T) {
+	certFile, keyFile := writeCertificate(t, t.TempDir(), "default.example")
+	driver, err := file.NewDriver(t.TempDir())
+	assert.NoError(t, err)
+
+	server, err := ftp.NewServer(&ftp.Options{
+		Driver:   driver,
+		Auth:     &ftp.SimpleAuth{Name: ftptest.Username, Password: ftptest.Password},
+		Perm:     ftp.NewSimplePerm(ftptest.Username, ftptest.Username),
+		Logger:   new(ftp.DiscardLogger),
+		TLS:      true,
+		CertFile: certFile,
+		KeyFile:  keyFile,
+	})
+	assert.NoError(t, err)
+	wrapped := make(chan net.Conn, 1)
+	server.ConnCallback = func(ctx context.Context, conn net.Conn) net.Conn {
+		wrapped <- conn
+		return conn
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	go server.Serve(l)
+	defer server.Shutdown()
+
+	// The callback gets the TLS connection, as it did from a TLS listener
+	c, err := ftptest.DialTLS(l.Addr().String(), &tls.Config{InsecureSkipVerify: true})
+	assert.NoError(t, err)
+	defer c.Close()
+	assert.NoError(t, c.Login(ftptest.Username, ftptest.Password))
+	assert.IsType(t, &tls.Conn{}, <-wrapped)
+}
@@ -0,0 +1,74 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/globalcyberalliance/ftp-go"
+	"github.com/globalcyberalliance/ftp-go/driver/file"
+	"github.com/globalcyberalliance/ftp-go/ftptest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebug(t *testing.T) {
+	driver, err := file.NewDriver(t.TempDir())
+	assert.NoError(t, err)
+
+	// A free port for the debug endpoints
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	debugAddr := l.Addr().String()
+	l.Close()
+
+	addr, cleanup := ftptest.NewServer(driver, &ftp.Options{
+		DebugAddr:  debugAddr,
+		AdminUsers: []string{ftptest.Username},
+	})
+	defer cleanup()
+
+	c, err := ftptest.Dial(addr)
+	assert.NoError(t, err)
+	defer c.Close()
+	_, err = c.Cmd(530, "SITE DEBUG")
+	assert.NoError(t, err)
+	assert.NoError(t, c.Login(ftptest.Username, ftptest.Password))
+
+	msg, err := c.Cmd(211, "SITE DEBUG")
+	assert.NoError(t, err)
+	assert.Contains(t, msg, "Goroutines: ")
+	assert.Contains(t, msg, "Sessions: 1\n")
+	assert.Contains(t, msg, "Transfers: 0\n")
+
+	_, err = c.Cmd(200, "SITE DEBUG profile on")
+	assert.NoError(t, err)
+	_, err = c.Cmd(200, "SITE DEBUG PROFILE OFF")
+	assert.NoError(t, err)
+	_, err = c.Cmd(501, "SITE DEBUG PROFILE")
+	assert.NoError(t, err)
+
+	for _, path := range []string{"/debug/pprof/goroutine?debug=1", "/debug/vars"} {
+		resp, err := http.Get("http://" + debugAddr + path)
+		if assert.NoError(t, err) {
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			assert.Equal(t, http.StatusOK, resp.StatusCode, path)
+			assert.NotEmpty(t, body, path)
+		}
+	}
+
+	// Other users are refused
+	addr, cleanup = ftptest.NewServer(driver, &ftp.Options{AdminUsers: []string{"root"}})
+	defer cleanup()
+	c, err = ftptest.Dial(addr)
+	assert.NoError(t, err)
+	defer c.Close()
+	assert.NoError(t, c.Login(ftptest.Username, ftptest.Password))
+	_, err = c.Cmd(550, "SITE DEBUG")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,87 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"io"
+	"net"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/globalcyberalliance/ftp-go"
+	"github.com/globalcyberalliance/ftp-go/driver/file"
+	"github.com/globalcyberalliance/ftp-go/ftptest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShutdownDrain(t *testing.T) {
+	root := t.TempDir()
+	driver, err := file.NewDriver(root)
+	assert.NoError(t, err)
+
+	server, err := ftp.NewServer(&ftp.Options{
+		Driver:          driver,
+		Auth:            &ftp.SimpleAuth{Name: ftptest.Username, Password: ftptest.Password},
+		Perm:            ftp.NewSimplePerm(ftptest.Username, ftptest.Username),
+		Logger:          new(ftp.DiscardLogger),
+		MaxTransfers:    10,
+		ShutdownDrain:   5 * time.Second,
+		ShutdownMessage: "Maintenance, reconnect elsewhere",
+	})
+	assert.NoError(t, err)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	served := make(chan error, 1)
+	go func() { served <- server.Serve(l) }()
+
+	idle, err := textproto.Dial("tcp", l.Addr().String())
+	assert.NoError(t, err)
+	defer idle.Close()
+	_, _, err = idle.ReadResponse(220)
+	assert.NoError(t, err)
+
+	c, err := ftptest.Dial(l.Addr().String())
+	assert.NoError(t, err)
+	defer c.Close()
+	assert.NoError(t, c.Login(ftptest.Username, ftptest.Password))
+	pr, pw := io.Pipe()
+	stored := make(chan error, 1)
+	go func() { stored <- c.Stor("upload.txt", pr) }()
+	_, err = pw.Write([]byte("first "))
+	assert.NoError(t, err)
+	for server.ActiveTransfers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- server.Shutdown() }()
+
+	// Idle sessions are told at once, the upload goes on
+	_, msg, err := idle.ReadResponse(421)
+	assert.NoError(t, err)
+	assert.Equal(t, "Maintenance, reconnect elsewhere", msg)
+	assert.Equal(t, ftp.ErrServerClosed, <-served)
+	assert.True(t, server.Draining())
+	select {
+	case <-shutdown:
+		t.Fatal("Shutdown returned before the upload finished")
+	default:
+	}
+
+	_, err = pw.Write([]byte("second"))
+	assert.NoError(t, err)
+	assert.NoError(t, pw.Close())
+	assert.NoError(t, <-stored)
+	_, err = c.Cmd(421, "NOOP")
+	assert.NoError(t, err)
+	assert.NoError(t, <-shutdown)
+
+	content, err := os.ReadFile(filepath.Join(root, "upload.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "first second", string(content))
+}
@@ -0,0 +1,56 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/globalcyberalliance/ftp-go"
+	"github.com/globalcyberalliance/ftp-go/driver/file"
+	"github.com/stretchr/testify/assert"
+)
+
+// listDirDriver hides the EntryDriver implementation of the driver it wraps
+type listDirDriver struct {
+	ftp.Driver
+}
+
+func TestListEntries(t *testing.T) {
+	root := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "dir", "sub"), 0o700))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "dir", "a.txt"), nil, 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "dir", "sub", "b.txt"), nil, 0o600))
+	driver, err := file.NewDriver(root)
+	assert.NoError(t, err)
+
+	for _, test := range []struct {
+		name   string
+		driver ftp.Driver
+		dir    string
+		paths  []string
+	}{
+		{name: "EntryDriver", driver: driver, dir: "/dir", paths: []string{"/dir/a.txt", "/dir/sub"}},
+		{name: "ListDir", driver: listDirDriver{driver}, dir: "/dir/", paths: []string{"/dir/a.txt", "/dir/sub"}},
+		{
+			name:   "MultiDriver",
+			driver: ftp.NewMultiDriver(map[string]ftp.Driver{"/mnt": driver}),
+			dir:    "/mnt/dir/sub",
+			paths:  []string{"/mnt/dir/sub/b.txt"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var paths []string
+			err := ftp.ListEntries(&ftp.Context{}, test.driver, test.dir, func(entry ftp.Entry) error {
+				assert.Equal(t, filepath.Base(entry.Path), entry.Name())
+				paths = append(paths, entry.Path)
+				return nil
+			})
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, test.paths, paths)
+		})
+	}
+}
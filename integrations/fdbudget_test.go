@@ -0,0 +1,66 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/globalcyberalliance/ftp-go"
+	"github.com/globalcyberalliance/ftp-go/driver/file"
+	"github.com/globalcyberalliance/ftp-go/ftptest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxFileDescriptors(t *testing.T) {
+	driver, err := file.NewDriver(t.TempDir())
+	assert.NoError(t, err)
+
+	addr, cleanup := ftptest.NewServer(driver, &ftp.Options{MaxFileDescriptors: 2})
+	defer cleanup()
+	_, portStr, err := net.SplitHostPort(addr)
+	assert.NoError(t, err)
+	var port int
+	_, err = fmt.Sscan(portStr, &port)
+	assert.NoError(t, err)
+
+	waiting := dialControl(t, port)
+	defer waiting.Close()
+	waiting.expect(220, "")
+	waiting.expect(331, "USER %s", ftptest.Username)
+	waiting.expect(230, "PASS %s", ftptest.Password)
+
+	// An upload spending the budget with its data connection and file
+	uploading := dialControl(t, port)
+	defer uploading.Close()
+	uploading.expect(220, "")
+	uploading.expect(331, "USER %s", ftptest.Username)
+	uploading.expect(230, "PASS %s", ftptest.Password)
+	msg := uploading.expect(229, "EPSV")
+	var dataPort int
+	_, err = fmt.Sscanf(msg[strings.Index(msg, "(|||"):], "(|||%d|)", &dataPort)
+	assert.NoError(t, err)
+	data, err := net.Dial("tcp", fmt.Sprintf("localhost:%d", dataPort))
+	assert.NoError(t, err)
+	uploading.expect(150, "STOR first.txt")
+
+	waiting.expect(425, "EPSV")
+	refused := dialControl(t, port)
+	refused.expect(421, "")
+	refused.Close()
+
+	_, err = data.Write([]byte("first"))
+	assert.NoError(t, err)
+	assert.NoError(t, data.Close())
+	uploading.expect(226, "")
+
+	c, err := ftptest.Dial(addr)
+	assert.NoError(t, err)
+	defer c.Close()
+	assert.NoError(t, c.Login(ftptest.Username, ftptest.Password))
+	assert.NoError(t, c.Stor("second.txt", strings.NewReader("second")))
+}
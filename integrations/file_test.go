@@ -5,6 +5,7 @@
 package integrations
 
 import (
+	"io"
 	"io/ioutil"
 	"net"
 	"os"
@@ -14,7 +15,7 @@ import (
 
 	"github.com/globalcyberalliance/ftp-go"
 	"github.com/globalcyberalliance/ftp-go/driver/file"
-	ftpCli "github.com/jlaffaye/ftp"
+	"github.com/globalcyberalliance/ftp-go/ftptest"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -43,7 +44,7 @@ func TestFileDriver(t *testing.T) {
 		timeout := time.NewTimer(time.Millisecond * 500)
 
 		for {
-			f, err := ftpCli.Connect("localhost:2122")
+			f, err := ftptest.Dial("localhost:2122")
 			if err != nil && len(timeout.C) == 0 { // Retry errors
 				continue
 			}
@@ -55,7 +56,7 @@ func TestFileDriver(t *testing.T) {
 			content := `test`
 			assert.NoError(t, f.Stor("server_test.go", strings.NewReader(content)))
 
-			names, err := f.NameList("/")
+			names, err := f.Nlst("/")
 			assert.NoError(t, err)
 			assert.EqualValues(t, 1, len(names))
 			assert.EqualValues(t, "server_test.go", names[0])
@@ -64,64 +65,77 @@ func TestFileDriver(t *testing.T) {
 			assert.NoError(t, err)
 			assert.EqualValues(t, content, string(bs))
 
-			entries, err := f.List("/")
+			entries, err := f.Mlsd("/")
 			assert.NoError(t, err)
 			assert.EqualValues(t, 1, len(entries))
 			assert.EqualValues(t, "server_test.go", entries[0].Name)
-			assert.EqualValues(t, 4, entries[0].Size)
-			assert.EqualValues(t, ftpCli.EntryTypeFile, entries[0].Type)
+			assert.EqualValues(t, "4", entries[0].Facts["size"])
+			assert.EqualValues(t, "file", entries[0].Facts["type"])
 
-			curDir, err := f.CurrentDir()
+			list, err := f.List("/")
 			assert.NoError(t, err)
-			assert.EqualValues(t, "/", curDir)
+			lines := strings.Split(strings.TrimSpace(list), "\r\n")
+			if assert.Len(t, lines, 1) {
+				assert.Regexp(t, `^-[rwx-]{9} .* 4 \w{3} [ \d]\d +[\d:]{4,5} server_test\.go$`, lines[0])
+			}
 
-			size, err := f.FileSize("/server_test.go")
+			curDir, err := f.Pwd()
 			assert.NoError(t, err)
-			assert.EqualValues(t, 4, size)
+			assert.EqualValues(t, "/", curDir)
 
-			r, err := f.RetrFrom("/server_test.go", 2)
+			size, err := f.Cmd(213, "SIZE /server_test.go")
 			assert.NoError(t, err)
+			assert.EqualValues(t, "4", size)
 
-			buf, err := ioutil.ReadAll(r)
-			r.Close()
+			buf, err := f.RetrFrom("/server_test.go", 2)
 			assert.NoError(t, err)
 			assert.EqualValues(t, "st", string(buf))
 
 			err = f.Rename("/server_test.go", "/test.go")
 			assert.NoError(t, err)
 
-			err = f.MakeDir("/src")
+			err = f.Mkd("/src")
 			assert.NoError(t, err)
 
-			err = f.Delete("/test.go")
+			err = f.Dele("/test.go")
 			assert.NoError(t, err)
 
-			err = f.ChangeDir("/src")
+			err = f.Cwd("/src")
 			assert.NoError(t, err)
 
-			curDir, err = f.CurrentDir()
+			curDir, err = f.Pwd()
 			assert.NoError(t, err)
 			assert.EqualValues(t, "/src", curDir)
 
 			assert.NoError(t, f.Stor("server_test.go", strings.NewReader(content)))
 
-			r, err = f.Retr("/src/server_test.go")
+			buf, err = f.Retr("/src/server_test.go")
 			assert.NoError(t, err)
+			assert.EqualValues(t, "test", string(buf))
 
-			buf, err = ioutil.ReadAll(r)
-			r.Close()
+			assert.Error(t, f.Cwd("/missing"))
+			assert.Error(t, f.Cwd("server_test.go"))
+
+			curDir, err = f.Pwd()
 			assert.NoError(t, err)
-			assert.EqualValues(t, "test", string(buf))
+			assert.EqualValues(t, "/src", curDir)
+
+			_, err = f.Cmd(2, "CDUP")
+			assert.NoError(t, err)
+
+			curDir, err = f.Pwd()
+			assert.NoError(t, err)
+			assert.EqualValues(t, "/", curDir)
 
-			err = f.RemoveDir("/src")
+			err = f.Rmd("/src")
 			assert.NoError(t, err)
 
-			curDir, err = f.CurrentDir()
+			curDir, err = f.Pwd()
 			assert.NoError(t, err)
 			assert.EqualValues(t, "/", curDir)
 
 			assert.NoError(t, f.Stor(" file_name .test", strings.NewReader("tttt")))
-			assert.NoError(t, f.Delete(" file_name .test"))
+			assert.NoError(t, f.Dele(" file_name .test"))
 
 			err = f.Quit()
 			assert.NoError(t, err)
@@ -166,7 +180,7 @@ func TestLogin(t *testing.T) {
 	// Give server 0.5 seconds to get to the listening state
 	timeout := time.NewTimer(time.Millisecond * 500)
 	for {
-		f, err := ftpCli.Connect("localhost:2123")
+		f, err := ftptest.Dial("localhost:2123")
 		if err != nil && len(timeout.C) == 0 { // Retry errors
 			continue
 		}
@@ -181,3 +195,105 @@ func TestLogin(t *testing.T) {
 
 	assert.NoError(t, s.Shutdown())
 }
+
+// putPathDriver records the paths files are put to.
+type putPathDriver struct {
+	ftp.Driver
+	paths []string
+}
+
+func (driver *putPathDriver) PutFile(ctx *ftp.Context, path string, data io.Reader, offset int64) (int64, error) {
+	driver.paths = append(driver.paths, path)
+	return driver.Driver.PutFile(ctx, path, data, offset)
+}
+
+func TestAtomicUploads(t *testing.T) {
+	assert.NoError(t, os.MkdirAll("./testdata/atomic", os.ModePerm))
+	defer os.RemoveAll("./testdata/atomic")
+
+	base, err := file.NewDriver("./testdata/atomic")
+	assert.NoError(t, err)
+	driver := &putPathDriver{Driver: base}
+
+	opt := &ftp.Options{
+		Name:   "test ftpd",
+		Driver: driver,
+		Perm:   ftp.NewSimplePerm("test", "test"),
+		Port:   2128,
+		Auth: &ftp.SimpleAuth{
+			Name:     "admin",
+			Password: "admin",
+		},
+		Logger:        new(ftp.DiscardLogger),
+		AtomicUploads: true,
+		ListFilter:    ftp.HidePartialUploads | ftp.HideDotFiles,
+	}
+
+	runServer(t, opt, nil, func() {
+		// Give server 0.5 seconds to get to the listening state
+		timeout := time.NewTimer(time.Millisecond * 500)
+
+		for {
+			f, err := ftptest.Dial("localhost:2128")
+			if err != nil && len(timeout.C) == 0 { // Retry errors
+				continue
+			}
+			assert.NoError(t, err)
+
+			assert.NoError(t, f.Login("admin", "admin"))
+			assert.NoError(t, f.Stor("atomic.txt", strings.NewReader("test")))
+			// The partial name holds the session ID
+			if assert.Len(t, driver.paths, 1) {
+				assert.Regexp(t, `^/\.atomic\.txt\.[0-9a-f]+\.part$`, driver.paths[0])
+				_, err = os.Stat("./testdata/atomic" + driver.paths[0])
+				assert.True(t, os.IsNotExist(err))
+			}
+
+			bs, err := ioutil.ReadFile("./testdata/atomic/atomic.txt")
+			assert.NoError(t, err)
+			assert.EqualValues(t, "test", string(bs))
+
+			// Listings hide uploads in progress, and dotfiles unless asked.
+			assert.NoError(t, ioutil.WriteFile("./testdata/atomic/.big.txt.0123abcd.part", nil, os.ModePerm))
+			assert.NoError(t, ioutil.WriteFile("./testdata/atomic/.hidden", nil, os.ModePerm))
+
+			names, err := f.Nlst("/")
+			assert.NoError(t, err)
+			assert.EqualValues(t, []string{"atomic.txt"}, names)
+
+			names, err = f.Nlst("-a")
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, []string{".hidden", "atomic.txt"}, names)
+
+			assert.NoError(t, f.Quit())
+
+			break
+		}
+	})
+}
+
+func TestSizeMdtm(t *testing.T) {
+	root := t.TempDir()
+	assert.NoError(t, os.Mkdir(root+"/dir", 0o700))
+	assert.NoError(t, os.WriteFile(root+"/file.txt", []byte("hello"), 0o600))
+	driver, err := file.NewDriver(root)
+	assert.NoError(t, err)
+
+	addr, cleanup := ftptest.NewServer(driver, nil)
+	defer cleanup()
+	c, err := ftptest.Dial(addr)
+	assert.NoError(t, err)
+	defer c.Close()
+	assert.NoError(t, c.Login(ftptest.Username, ftptest.Password))
+
+	for _, cmd := range []string{"SIZE", "MDTM"} {
+		_, err = c.Cmd(213, "%s file.txt", cmd)
+		assert.NoError(t, err)
+		msg, err := c.Cmd(550, "%s dir", cmd)
+		assert.NoError(t, err)
+		assert.Equal(t, "Is a directory", msg)
+		msg, err = c.Cmd(550, "%s missing.txt", cmd)
+		assert.NoError(t, err)
+		assert.Equal(t, "No such file or directory", msg)
+	}
+}
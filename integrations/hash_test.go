@@ -0,0 +1,91 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/globalcyberalliance/ftp-go"
+	"github.com/globalcyberalliance/ftp-go/driver/file"
+	"github.com/globalcyberalliance/ftp-go/ftptest"
+	"github.com/stretchr/testify/assert"
+)
+
+// hashDriver knows the digest of stored.txt
+type hashDriver struct {
+	*file.Driver
+}
+
+func (driver hashDriver) Hash(ctx *ftp.Context, path, algorithm string) (string, error) {
+	if path == "/stored.txt" && algorithm == "sha256" {
+		return "cafe", nil
+	}
+	return "", errors.ErrUnsupported
+}
+
+func TestSiteHash(t *testing.T) {
+	root := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "hello world.txt"), []byte("hello"), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "stored.txt"), []byte("stored"), 0o600))
+	driver, err := file.NewDriver(root)
+	assert.NoError(t, err)
+
+	addr, cleanup := ftptest.NewServer(hashDriver{driver.(*file.Driver)}, &ftp.Options{MaxHashSize: 5})
+	defer cleanup()
+	c, err := ftptest.Dial(addr)
+	assert.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.Cmd(530, "SITE CKSM hello world.txt")
+	assert.NoError(t, err)
+	assert.NoError(t, c.Login(ftptest.Username, ftptest.Password))
+
+	msg, err := c.Cmd(213, "SITE HASH SHA-256 hello world.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "SHA256 2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824 /hello world.txt", msg)
+	msg, err = c.Cmd(213, "SITE CKSM hello world.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "CRC32 3610a686 /hello world.txt", msg)
+
+	// The driver's digest is used when it has one
+	msg, err = c.Cmd(213, "SITE HASH sha256 stored.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "SHA256 cafe /stored.txt", msg)
+	// stored.txt is larger than MaxHashSize
+	_, err = c.Cmd(550, "SITE HASH md5 stored.txt")
+	assert.NoError(t, err)
+
+	_, err = c.Cmd(504, "SITE HASH whirlpool stored.txt")
+	assert.NoError(t, err)
+	_, err = c.Cmd(501, "SITE HASH sha256")
+	assert.NoError(t, err)
+	_, err = c.Cmd(550, "SITE HASH sha256 missing.txt")
+	assert.NoError(t, err)
+}
+
+func TestSiteHashDisabled(t *testing.T) {
+	root := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "hello.txt"), []byte("hello"), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "stored.txt"), []byte("stored"), 0o600))
+	driver, err := file.NewDriver(root)
+	assert.NoError(t, err)
+
+	addr, cleanup := ftptest.NewServer(hashDriver{driver.(*file.Driver)}, nil)
+	defer cleanup()
+	c, err := ftptest.Dial(addr)
+	assert.NoError(t, err)
+	defer c.Close()
+	assert.NoError(t, c.Login(ftptest.Username, ftptest.Password))
+
+	// Files are only read with MaxHashSize, the driver's digests are served
+	_, err = c.Cmd(502, "SITE CKSM hello.txt")
+	assert.NoError(t, err)
+	msg, err := c.Cmd(213, "SITE HASH sha256 stored.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "SHA256 cafe /stored.txt", msg)
+}
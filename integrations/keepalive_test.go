@@ -0,0 +1,78 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/globalcyberalliance/ftp-go"
+	"github.com/globalcyberalliance/ftp-go/driver/file"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeepaliveDuringTransfer(t *testing.T) {
+	dir := t.TempDir()
+	driver, err := file.NewDriver(dir)
+	assert.NoError(t, err)
+
+	opt := &ftp.Options{
+		Name:   "test ftpd",
+		Driver: driver,
+		Perm:   ftp.NewSimplePerm("test", "test"),
+		Port:   2134,
+		Auth: &ftp.SimpleAuth{
+			Name:     "admin",
+			Password: "admin",
+		},
+		Logger: new(ftp.DiscardLogger),
+	}
+
+	runServer(t, opt, nil, func() {
+		client := dialControl(t, 2134)
+		defer client.Close()
+
+		client.expect(220, "")
+		client.expect(331, "USER admin")
+		client.expect(230, "PASS admin")
+
+		msg := client.expect(229, "EPSV")
+		var port int
+		_, err := fmt.Sscanf(msg[strings.Index(msg, "(|||"):], "(|||%d|)", &port)
+		if !assert.NoError(t, err, msg) {
+			return
+		}
+		data, err := net.Dial("tcp", fmt.Sprintf("localhost:%d", port))
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		client.expect(150, "STOR keepalive.txt")
+		_, err = data.Write([]byte("partial"))
+		assert.NoError(t, err)
+
+		// Answered while the upload is still running.
+		client.expect(200, "NOOP")
+		assert.Contains(t, client.expect(213, "STAT"), "STOR")
+		_, err = client.Cmd("QUIT")
+		assert.NoError(t, err)
+
+		_, err = data.Write([]byte(" upload"))
+		assert.NoError(t, err)
+		assert.NoError(t, data.Close())
+
+		// QUIT waits for the transfer to finish.
+		client.expect(226, "")
+		client.expect(221, "")
+
+		bs, err := ioutil.ReadFile(filepath.Join(dir, "keepalive.txt"))
+		assert.NoError(t, err)
+		assert.EqualValues(t, "partial upload", string(bs))
+	})
+}
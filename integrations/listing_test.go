@@ -0,0 +1,141 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/globalcyberalliance/ftp-go"
+	"github.com/globalcyberalliance/ftp-go/driver/file"
+	"github.com/globalcyberalliance/ftp-go/ftptest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxListEntries(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, fmt.Sprintf("%d.txt", i)), nil, os.ModePerm))
+	}
+	driver, err := file.NewDriver(dir)
+	assert.NoError(t, err)
+
+	opt := &ftp.Options{
+		Name:   "test ftpd",
+		Driver: driver,
+		Perm:   ftp.NewSimplePerm("test", "test"),
+		Port:   2133,
+		Auth: &ftp.SimpleAuth{
+			Name:     "admin",
+			Password: "admin",
+		},
+		Logger:         new(ftp.DiscardLogger),
+		MaxListEntries: 3,
+	}
+
+	listed := make(chan error, 1)
+	notifier := &ftp.NotifierFuncs{
+		AfterListDirFunc: func(ctx *ftp.Context, dirPath string, entries int, err error) {
+			listed <- err
+		},
+	}
+
+	runServer(t, opt, []interface{}{notifier}, func() {
+		client := dialControl(t, 2133)
+		defer client.Close()
+
+		client.expect(220, "")
+		client.expect(331, "USER admin")
+		client.expect(230, "PASS admin")
+
+		for _, command := range []string{"LIST /", "NLST /", "MLSD /"} {
+			lines := strings.Split(strings.TrimSpace(client.list(command)), "\n")
+			assert.Len(t, lines, 3, command)
+			assert.Equal(t, ftp.ErrListTruncated, <-listed, command)
+		}
+
+		msg := client.expect(213, "STAT /")
+		lines := strings.Split(msg, "\n")
+		assert.Equal(t, "Status of /:", lines[0])
+		assert.Len(t, lines, 5)
+		assert.Equal(t, "End of status, truncated at 3 entries", lines[4])
+
+		// Listings need a data connection
+		client.expect(425, "NLST /")
+		assert.Empty(t, listed)
+
+		msg = client.expect(229, "EPSV")
+		var port int
+		_, err := fmt.Sscanf(msg[strings.Index(msg, "(|||"):], "(|||%d|)", &port)
+		assert.NoError(t, err)
+		data, err := net.Dial("tcp", fmt.Sprintf("localhost:%d", port))
+		assert.NoError(t, err)
+		defer data.Close()
+		client.expect(550, "NLST /0.txt")
+		assert.Equal(t, ftp.ErrNotDir, <-listed)
+		client.expect(221, "QUIT")
+	})
+}
+
+func TestDOSListFormat(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "sub dir"), os.ModePerm))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "file.txt"), []byte("test"), os.ModePerm))
+	driver, err := file.NewDriver(dir)
+	assert.NoError(t, err)
+
+	opt := &ftp.Options{
+		Name:   "test ftpd",
+		Driver: driver,
+		Perm:   ftp.NewSimplePerm("test", "test"),
+		Port:   2133,
+		Auth: &ftp.SimpleAuth{
+			Name:     "admin",
+			Password: "admin",
+		},
+		Logger:     new(ftp.DiscardLogger),
+		ListFormat: ftp.ListFormatDOS,
+	}
+
+	runServer(t, opt, nil, func() {
+		// Give server 0.5 seconds to get to the listening state
+		timeout := time.NewTimer(time.Millisecond * 500)
+
+		for {
+			f, err := ftptest.Dial("localhost:2133")
+			if err != nil && len(timeout.C) == 0 { // Retry errors
+				continue
+			}
+			assert.NoError(t, err)
+
+			assert.NoError(t, f.Login("admin", "admin"))
+			list, err := f.List("/")
+			assert.NoError(t, err)
+			lines := strings.Split(strings.TrimSpace(list), "\r\n")
+			if assert.Len(t, lines, 2) {
+				assert.Regexp(t, `^\d\d-\d\d-\d\d  \d\d:\d\d[AP]M +4 file\.txt$`, lines[0])
+				assert.Regexp(t, `^\d\d-\d\d-\d\d  \d\d:\d\d[AP]M +<DIR> +sub dir$`, lines[1])
+			}
+
+			assert.NoError(t, f.Quit())
+			break
+		}
+	})
+}
+
+func TestParseListFormat(t *testing.T) {
+	format, err := ftp.ParseListFormat("DOS")
+	assert.NoError(t, err)
+	assert.EqualValues(t, ftp.ListFormatDOS, format)
+
+	_, err = ftp.ParseListFormat("vms")
+	assert.Error(t, err)
+}
@@ -0,0 +1,104 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/globalcyberalliance/ftp-go"
+	"github.com/globalcyberalliance/ftp-go/driver/file"
+	"github.com/globalcyberalliance/ftp-go/ftptest"
+	"github.com/stretchr/testify/assert"
+)
+
+// lockedBuffer is a bytes.Buffer safe for concurrent use
+type lockedBuffer struct {
+	lock sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.String()
+}
+
+func TestSessionLogger(t *testing.T) {
+	driver, err := file.NewDriver(t.TempDir())
+	assert.NoError(t, err)
+
+	var logs lockedBuffer
+	ids := make(chan string, 1)
+	addr, cleanup := ftptest.NewServer(driver, &ftp.Options{
+		SessionCallback: func(sess *ftp.Session) {
+			ids <- sess.ID()
+			sess.SetLogger(ftp.NewSlogLogger(slog.New(slog.NewJSONHandler(&logs, nil))))
+		},
+	})
+	defer cleanup()
+
+	c, err := ftptest.Dial(addr)
+	assert.NoError(t, err)
+	assert.NoError(t, c.Login(ftptest.Username, ftptest.Password))
+	assert.NoError(t, c.Quit())
+
+	id := <-ids
+	var loggedIn bool
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		var record map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(line), &record), line)
+		assert.Equal(t, id, record["session"], line)
+		assert.Equal(t, "127.0.0.1", record["remote_ip"], line)
+		if record["command"] == "PASS" {
+			assert.Equal(t, "****", record["params"], line)
+		}
+		if record["user"] == ftptest.Username {
+			loggedIn = true
+		}
+	}
+	assert.True(t, loggedIn, "no message logged with the user")
+}
+
+func TestSlowSessionCallback(t *testing.T) {
+	driver, err := file.NewDriver(t.TempDir())
+	assert.NoError(t, err)
+
+	var sessions atomic.Int32
+	release := make(chan struct{})
+	addr, cleanup := ftptest.NewServer(driver, &ftp.Options{
+		SessionCallback: func(sess *ftp.Session) {
+			if sessions.Add(1) == 1 {
+				<-release
+			}
+		},
+	})
+	defer cleanup()
+	defer close(release)
+
+	// The first session's callback doesn't hold up accepting the second
+	first, err := net.Dial("tcp", addr)
+	assert.NoError(t, err)
+	defer first.Close()
+	for sessions.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	c, err := ftptest.Dial(addr)
+	assert.NoError(t, err)
+	assert.NoError(t, c.Quit())
+}
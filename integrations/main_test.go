@@ -5,17 +5,23 @@
 package integrations
 
 import (
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/globalcyberalliance/ftp-go"
 	"github.com/stretchr/testify/assert"
 )
 
-func runServer(t *testing.T, opt *ftp.Options, notifiers []ftp.Notifier, execute func()) {
+func runServer(t *testing.T, opt *ftp.Options, notifiers []interface{}, execute func()) {
 	s, err := ftp.NewServer(opt)
 	assert.NoError(t, err)
 	for _, notifier := range notifiers {
-		s.RegisterNotifier(notifier)
+		assert.NoError(t, s.RegisterNotifier(notifier))
 	}
 
 	go func() {
@@ -27,3 +33,58 @@ func runServer(t *testing.T, opt *ftp.Options, notifiers []ftp.Notifier, execute
 
 	assert.NoError(t, s.Shutdown())
 }
+
+// controlConn drives a control connection command by command, for the
+// replies and listings the client library hides.
+type controlConn struct {
+	*textproto.Conn
+	t *testing.T
+}
+
+// dialControl connects to the server on port, retrying while it starts.
+func dialControl(t *testing.T, port int) *controlConn {
+	var conn *textproto.Conn
+	var err error
+	for start := time.Now(); ; {
+		conn, err = textproto.Dial("tcp", fmt.Sprintf("localhost:%d", port))
+		if err == nil || time.Since(start) > 500*time.Millisecond {
+			break
+		}
+	}
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return &controlConn{Conn: conn, t: t}
+}
+
+// expect sends the command, unless blank, and reads a reply with code.
+func (c *controlConn) expect(code int, format string, args ...interface{}) string {
+	if format != "" {
+		_, err := c.Cmd(format, args...)
+		assert.NoError(c.t, err)
+	}
+	_, msg, err := c.ReadResponse(code)
+	assert.NoError(c.t, err)
+	return msg
+}
+
+// list returns what a listing command sends over a passive connection.
+func (c *controlConn) list(command string) string {
+	msg := c.expect(229, "EPSV")
+	var port int
+	_, err := fmt.Sscanf(msg[strings.Index(msg, "(|||"):], "(|||%d|)", &port)
+	if !assert.NoError(c.t, err, msg) {
+		return ""
+	}
+	data, err := net.Dial("tcp", fmt.Sprintf("localhost:%d", port))
+	if !assert.NoError(c.t, err) {
+		return ""
+	}
+	defer data.Close()
+
+	c.expect(150, command)
+	bs, err := io.ReadAll(data)
+	assert.NoError(c.t, err)
+	c.expect(226, "")
+	return string(bs)
+}
@@ -6,6 +6,7 @@ package integrations
 
 import (
 	"io/ioutil"
+	"net/textproto"
 	"os"
 	"strings"
 	"sync"
@@ -14,20 +15,21 @@ import (
 
 	"github.com/globalcyberalliance/ftp-go"
 	"github.com/globalcyberalliance/ftp-go/driver/file"
-	ftpCli "github.com/jlaffaye/ftp"
+	"github.com/globalcyberalliance/ftp-go/ftptest"
 	"github.com/stretchr/testify/assert"
 )
 
 var _ ftp.Notifier = &mockNotifier{}
 
 type mockNotifier struct {
-	actions []string
-	lock    sync.Mutex
+	actions  []string
+	commands []string
+	lock     sync.Mutex
 }
 
 func (m *mockNotifier) BeforeCommand(ctx *ftp.Context, command string) {
 	m.lock.Lock()
-	m.actions = append(m.actions, "BeforeCommand")
+	m.commands = append(m.commands, command)
 	m.lock.Unlock()
 }
 
@@ -115,6 +117,26 @@ func (m *mockNotifier) AfterFileDownloaded(ctx *ftp.Context, dstPath string, siz
 	m.lock.Unlock()
 }
 
+// deleteNotifier only implements ftp.FileNotifier and ftp.Interceptor, it
+// refuses to delete or replace files named hold.go.
+type deleteNotifier struct {
+	deleted []string
+}
+
+func (d *deleteNotifier) Intercept(ctx *ftp.Context, path string) error {
+	if (ctx.Cmd == "DELE" || ctx.Cmd == "RNTO") && strings.HasSuffix(path, "/hold.go") {
+		return &ftp.ReplyError{Code: 450, Message: "File is on legal hold"}
+	}
+	return nil
+}
+
+func (d *deleteNotifier) BeforeDeleteFile(ctx *ftp.Context, dstPath string) {
+}
+
+func (d *deleteNotifier) AfterFileDeleted(ctx *ftp.Context, dstPath string, err error) {
+	d.deleted = append(d.deleted, dstPath)
+}
+
 func assetMockNotifier(t *testing.T, mock *mockNotifier, lastActions []string) {
 	if len(lastActions) == 0 {
 		return
@@ -140,18 +162,38 @@ func TestNotification(t *testing.T) {
 			Name:     "admin",
 			Password: "admin",
 		},
-		Perm:   perm,
-		Logger: new(ftp.DiscardLogger),
+		Perm:            perm,
+		Logger:          new(ftp.DiscardLogger),
+		UploadChecksums: []string{"sha256"},
 	}
 
 	mock := &mockNotifier{}
+	var uploaded, checksums, renamed, listed []string
+	disconnected := make(chan struct{})
+	funcs := &ftp.NotifierFuncs{
+		AfterFilePutFunc: func(ctx *ftp.Context, dstPath string, size int64, err error) {
+			uploaded = append(uploaded, dstPath)
+			checksums = append(checksums, ctx.Checksums["sha256"])
+		},
+		AfterRenameFunc: func(ctx *ftp.Context, fromPath, toPath string, err error) {
+			renamed = append(renamed, fromPath, toPath)
+		},
+		AfterListDirFunc: func(ctx *ftp.Context, dirPath string, entries int, err error) {
+			listed = append(listed, dirPath)
+		},
+		OnDisconnectFunc: func(ctx *ftp.Context) {
+			close(disconnected)
+		},
+	}
+
+	deletes := &deleteNotifier{}
 
-	runServer(t, opt, []ftp.Notifier{mock}, func() {
+	runServer(t, opt, []interface{}{mock, funcs, deletes}, func() {
 		// Give server 0.5 seconds to get to the listening state
 		timeout := time.NewTimer(time.Millisecond * 500)
 
 		for {
-			f, err := ftpCli.Connect("localhost:2121")
+			f, err := ftptest.Dial("localhost:2121")
 			if err != nil && len(timeout.C) == 0 { // Retry errors
 				continue
 			}
@@ -166,30 +208,199 @@ func TestNotification(t *testing.T) {
 			content := `test`
 			assert.NoError(t, f.Stor("server_test.go", strings.NewReader(content)))
 			assetMockNotifier(t, mock, []string{"BeforePutFile", "AfterFilePut"})
+			assert.EqualValues(t, []string{"/server_test.go"}, uploaded)
+			assert.EqualValues(t, []string{"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"}, checksums)
 
-			r, err := f.RetrFrom("/server_test.go", 2)
-			assert.NoError(t, err)
-
-			buf, err := ioutil.ReadAll(r)
-			r.Close()
+			buf, err := f.RetrFrom("/server_test.go", 2)
 			assert.NoError(t, err)
 			assert.EqualValues(t, "st", string(buf))
 			assetMockNotifier(t, mock, []string{"BeforeDownloadFile", "AfterFileDownloaded"})
 
 			assert.NoError(t, f.Rename("/server_test.go", "/test.go"))
+			assert.EqualValues(t, []string{"/server_test.go", "/test.go"}, renamed)
+
+			entries, err := f.Mlsd("/")
+			assert.NoError(t, err)
+			assert.EqualValues(t, 1, len(entries))
+			assert.EqualValues(t, []string{"/"}, listed)
 
-			assert.NoError(t, f.MakeDir("/src"))
+			assert.NoError(t, f.Mkd("/src"))
 			assetMockNotifier(t, mock, []string{"BeforeCreateDir", "AfterDirCreated"})
 
-			assert.NoError(t, f.Delete("/test.go"))
+			assert.NoError(t, f.Dele("/test.go"))
 			assetMockNotifier(t, mock, []string{"BeforeDeleteFile", "AfterFileDeleted"})
+			assert.EqualValues(t, []string{"/test.go"}, deletes.deleted)
+
+			assert.NoError(t, f.Stor("hold.go", strings.NewReader(content)))
+			err = f.Dele("/hold.go")
+			var replyErr *textproto.Error
+			if assert.ErrorAs(t, err, &replyErr) {
+				assert.EqualValues(t, 450, replyErr.Code)
+				assert.EqualValues(t, "File is on legal hold", replyErr.Msg)
+			}
+			assetMockNotifier(t, mock, []string{"BeforeDeleteFile", "AfterFileDeleted"})
+			// The new path of a rename is intercepted too
+			assert.NoError(t, f.Stor("free.go", strings.NewReader(content)))
+			err = f.Rename("/free.go", "/hold.go")
+			if assert.ErrorAs(t, err, &replyErr) {
+				assert.EqualValues(t, 450, replyErr.Code)
+			}
+			assert.NoError(t, os.Remove("./testdata/free.go"))
+			assert.NoError(t, os.Remove("./testdata/hold.go"))
 
-			assert.NoError(t, f.ChangeDir("/src"))
+			assert.NoError(t, f.Cwd("/src"))
 			assetMockNotifier(t, mock, []string{"BeforeChangeCurDir", "AfterCurDirChanged"})
 
-			assert.NoError(t, f.RemoveDir("/src"))
+			assert.NoError(t, f.Rmd("/src"))
 			assetMockNotifier(t, mock, []string{"BeforeDeleteDir", "AfterDirDeleted"})
 
+			assert.NoError(t, f.Quit())
+			<-disconnected
+
+			// BeforeCommand sees every command line, apart from the hooks
+			// of what the commands do
+			mock.lock.Lock()
+			var commands []string
+			for _, line := range mock.commands {
+				commands = append(commands, strings.TrimRight(line, "\r\n"))
+			}
+			assert.NotContains(t, mock.actions, "BeforeCommand")
+			mock.lock.Unlock()
+			assert.Contains(t, commands, "USER admin")
+			assert.Contains(t, commands, "STOR server_test.go")
+			assert.EqualValues(t, "QUIT", commands[len(commands)-1])
+
+			break
+		}
+	})
+}
+
+func TestAsyncNotification(t *testing.T) {
+	err := os.MkdirAll("./testdata/async", os.ModePerm)
+	assert.NoError(t, err)
+	defer os.RemoveAll("./testdata/async")
+
+	driver, err := file.NewDriver("./testdata/async")
+	assert.NoError(t, err)
+
+	opt := &ftp.Options{
+		Name:   "test ftpd",
+		Driver: driver,
+		Port:   2127,
+		Auth: &ftp.SimpleAuth{
+			Name:     "admin",
+			Password: "admin",
+		},
+		Perm:            ftp.NewSimplePerm("test", "test"),
+		Logger:          new(ftp.DiscardLogger),
+		NotifierWorkers: 1,
+	}
+
+	release := make(chan struct{})
+	var events []string
+	done := make(chan struct{})
+	funcs := &ftp.NotifierFuncs{
+		AfterFilePutFunc: func(ctx *ftp.Context, dstPath string, size int64, err error) {
+			// A slow notifier, the client must not wait for it.
+			<-release
+			events = append(events, "AfterFilePut")
+		},
+		AfterFileDeletedFunc: func(ctx *ftp.Context, dstPath string, err error) {
+			events = append(events, "AfterFileDeleted")
+			close(done)
+		},
+	}
+
+	runServer(t, opt, []interface{}{funcs}, func() {
+		// Give server 0.5 seconds to get to the listening state
+		timeout := time.NewTimer(time.Millisecond * 500)
+
+		for {
+			f, err := ftptest.Dial("localhost:2127")
+			if err != nil && len(timeout.C) == 0 { // Retry errors
+				continue
+			}
+			assert.NoError(t, err)
+
+			assert.NoError(t, f.Login("admin", "admin"))
+			assert.NoError(t, f.Stor("async.txt", strings.NewReader("test")))
+			assert.NoError(t, f.Dele("/async.txt"))
+
+			close(release)
+			<-done
+			assert.EqualValues(t, []string{"AfterFilePut", "AfterFileDeleted"}, events)
+
+			assert.NoError(t, f.Quit())
+
+			break
+		}
+	})
+}
+
+func TestCanaryNotification(t *testing.T) {
+	err := os.MkdirAll("./testdata/canary/secrets", os.ModePerm)
+	assert.NoError(t, err)
+	defer os.RemoveAll("./testdata/canary")
+	assert.NoError(t, ioutil.WriteFile("./testdata/canary/secrets/passwords.txt", []byte("test"), os.ModePerm))
+
+	driver, err := file.NewDriver("./testdata/canary")
+	assert.NoError(t, err)
+
+	opt := &ftp.Options{
+		Name:   "test ftpd",
+		Driver: driver,
+		Port:   2129,
+		Auth: &ftp.SimpleAuth{
+			Name:     "admin",
+			Password: "admin",
+		},
+		Perm:        ftp.NewSimplePerm("test", "test"),
+		Logger:      new(ftp.DiscardLogger),
+		CanaryPaths: []string{"/secrets", "/*.key"},
+	}
+
+	var events []ftp.CanaryEvent
+	funcs := &ftp.NotifierFuncs{
+		OnCanaryFunc: func(ctx *ftp.Context, event *ftp.CanaryEvent) {
+			events = append(events, *event)
+		},
+	}
+
+	runServer(t, opt, []interface{}{funcs}, func() {
+		// Give server 0.5 seconds to get to the listening state
+		timeout := time.NewTimer(time.Millisecond * 500)
+
+		for {
+			f, err := ftptest.Dial("localhost:2129")
+			if err != nil && len(timeout.C) == 0 { // Retry errors
+				continue
+			}
+			assert.NoError(t, err)
+
+			assert.NoError(t, f.Login("admin", "admin"))
+			assert.NoError(t, f.Stor("notes.txt", strings.NewReader("test")))
+			assert.Empty(t, events)
+
+			assert.NoError(t, f.Stor("server.key", strings.NewReader("test")))
+			if assert.Len(t, events, 1) {
+				assert.EqualValues(t, "STOR", events[0].Command)
+				assert.EqualValues(t, "/server.key", events[0].Path)
+				assert.EqualValues(t, "/*.key", events[0].Pattern)
+				assert.EqualValues(t, "admin", events[0].User)
+			}
+
+			_, err = f.Retr("/secrets/passwords.txt")
+			assert.NoError(t, err)
+			if assert.Len(t, events, 2) {
+				assert.EqualValues(t, "RETR", events[1].Command)
+				assert.EqualValues(t, "/secrets/passwords.txt", events[1].Path)
+				assert.EqualValues(t, "/secrets", events[1].Pattern)
+			}
+
+			_, err = f.Nlst("/secrets")
+			assert.NoError(t, err)
+			assert.Len(t, events, 4)
+
 			assert.NoError(t, f.Quit())
 
 			break
@@ -0,0 +1,90 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/globalcyberalliance/ftp-go"
+	"github.com/globalcyberalliance/ftp-go/driver/file"
+	"github.com/globalcyberalliance/ftp-go/ftptest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionOverrides(t *testing.T) {
+	root := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "tenant", "sub"), 0o700))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "secret.txt"), []byte("secret"), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "tenant", "hello.txt"), []byte("hello"), 0o600))
+	driver, err := file.NewDriver(root)
+	assert.NoError(t, err)
+
+	var connections atomic.Int32
+	addr, cleanup := ftptest.NewServer(driver, &ftp.Options{
+		OnConnect: func(sess *ftp.Session) error {
+			sess.SetRoot("/tenant")
+			if connections.Add(1) == 1 {
+				sess.SetReadOnly(true)
+			} else {
+				sess.SetIdleTimeout(50 * time.Millisecond)
+			}
+			return nil
+		},
+	})
+	defer cleanup()
+
+	c, err := ftptest.Dial(addr)
+	assert.NoError(t, err)
+	defer c.Close()
+	assert.NoError(t, c.Login(ftptest.Username, ftptest.Password))
+
+	names, err := c.Nlst("/")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"hello.txt", "sub"}, names)
+	data, err := c.Retr("/hello.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+	_, err = c.Retr("/../secret.txt")
+	assert.Error(t, err)
+	assert.NoError(t, c.Cwd("sub"))
+	dir, err := c.Pwd()
+	assert.NoError(t, err)
+	assert.Equal(t, "/sub", dir)
+
+	// Read-only sessions can't change files
+	assert.Error(t, c.Stor("/new.txt", strings.NewReader("new")))
+	_, err = c.Cmd(550, "DELE /hello.txt")
+	assert.NoError(t, err)
+	_, err = c.Cmd(550, "SITE SYMLINK hello.txt link.txt")
+	assert.NoError(t, err)
+	assert.NoFileExists(t, filepath.Join(root, "tenant", "new.txt"))
+
+	// The second session may write, and idles out quickly
+	c2, err := ftptest.Dial(addr)
+	assert.NoError(t, err)
+	defer c2.Close()
+	assert.NoError(t, c2.Login(ftptest.Username, ftptest.Password))
+	assert.NoError(t, c2.Stor("/new.txt", strings.NewReader("new")))
+	assert.FileExists(t, filepath.Join(root, "tenant", "new.txt"))
+	// Links cannot lead out of the root, nor disclose what is outside
+	_, err = c2.Cmd(200, "SITE SYMLINK ../../secret.txt /sub/escape.txt")
+	assert.NoError(t, err)
+	target, err := os.Readlink(filepath.Join(root, "tenant", "sub", "escape.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "tenant", "secret.txt"), target)
+	assert.NoError(t, os.Symlink(filepath.Join(root, "secret.txt"), filepath.Join(root, "tenant", "out.txt")))
+	listing, err := c2.List("/")
+	assert.NoError(t, err)
+	assert.Contains(t, listing, " out.txt\r\n")
+	assert.NotContains(t, listing, "secret")
+	time.Sleep(150 * time.Millisecond)
+	_, err = c2.Cmd(200, "NOOP")
+	assert.Error(t, err)
+}
@@ -0,0 +1,67 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"testing"
+
+	"github.com/globalcyberalliance/ftp-go"
+	"github.com/globalcyberalliance/ftp-go/driver/file"
+	"github.com/stretchr/testify/assert"
+)
+
+// ownerDriver reports every file as alice's, leaving the group to Perm.
+type ownerDriver struct {
+	ftp.Driver
+}
+
+func (driver *ownerDriver) FileOwner(ctx *ftp.Context, path string, info os.FileInfo) (string, string, os.FileMode, error) {
+	return "alice", "", 0o600, nil
+}
+
+func TestFileOwner(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "owned.txt"), []byte("test"), 0o640))
+	base, err := file.NewDriver(dir)
+	assert.NoError(t, err)
+
+	current, err := user.Current()
+	assert.NoError(t, err)
+
+	for _, test := range []struct {
+		driver ftp.Driver
+		prefix string
+	}{
+		{&ownerDriver{Driver: base}, "-rw------- 1 alice test "},
+		{base, "-rw-r----- 1 " + current.Username + " "},
+	} {
+		opt := &ftp.Options{
+			Name:   "test ftpd",
+			Driver: test.driver,
+			Perm:   ftp.NewSimplePerm("test", "test"),
+			Port:   2132,
+			Auth: &ftp.SimpleAuth{
+				Name:     "admin",
+				Password: "admin",
+			},
+			Logger: new(ftp.DiscardLogger),
+		}
+
+		runServer(t, opt, nil, func() {
+			client := dialControl(t, 2132)
+			defer client.Close()
+
+			client.expect(220, "")
+			client.expect(331, "USER admin")
+			client.expect(230, "PASS admin")
+			assert.Regexp(t, "^"+test.prefix, client.list("LIST /"))
+			client.expect(221, "QUIT")
+		})
+	}
+}
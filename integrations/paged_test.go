@@ -0,0 +1,84 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/globalcyberalliance/ftp-go"
+	"github.com/globalcyberalliance/ftp-go/driver/file"
+	"github.com/globalcyberalliance/ftp-go/ftptest"
+	"github.com/stretchr/testify/assert"
+)
+
+// pagedDriver lists directories a page at a time, with the index of the
+// next entry as token.
+type pagedDriver struct {
+	*file.Driver
+	pages atomic.Int32
+}
+
+func (driver *pagedDriver) ListDirPage(ctx *ftp.Context, dir, token string, limit int) ([]ftp.Entry, string, error) {
+	driver.pages.Add(1)
+	dirEntries, err := os.ReadDir(filepath.Join(driver.RootPath, dir))
+	if err != nil {
+		return nil, "", err
+	}
+	start, _ := strconv.Atoi(token)
+	end := min(start+limit, len(dirEntries))
+	var entries []ftp.Entry
+	for _, dirEntry := range dirEntries[start:end] {
+		info, err := dirEntry.Info()
+		if err != nil {
+			return nil, "", err
+		}
+		entries = append(entries, ftp.Entry{FileInfo: info, Path: path.Join(dir, info.Name())})
+	}
+	if end == len(dirEntries) {
+		return entries, "", nil
+	}
+	return entries, strconv.Itoa(end), nil
+}
+
+func TestListDirPage(t *testing.T) {
+	root := t.TempDir()
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, os.WriteFile(filepath.Join(root, fmt.Sprintf("%d.txt", i)), nil, 0o600))
+	}
+	base, err := file.NewDriver(root)
+	assert.NoError(t, err)
+	driver := &pagedDriver{Driver: base.(*file.Driver)}
+
+	for _, test := range []struct {
+		name       string
+		maxEntries int
+		names      int
+		pages      int32
+	}{
+		{name: "all", names: 5, pages: 3},
+		{name: "truncated", maxEntries: 3, names: 3, pages: 2},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			driver.pages.Store(0)
+			addr, cleanup := ftptest.NewServer(driver, &ftp.Options{ListPageSize: 2, MaxListEntries: test.maxEntries})
+			defer cleanup()
+			c, err := ftptest.Dial(addr)
+			assert.NoError(t, err)
+			defer c.Close()
+			assert.NoError(t, c.Login(ftptest.Username, ftptest.Password))
+
+			names, err := c.Nlst("/")
+			assert.NoError(t, err)
+			assert.Len(t, names, test.names)
+			assert.Equal(t, test.pages, driver.pages.Load())
+		})
+	}
+}
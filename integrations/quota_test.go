@@ -0,0 +1,98 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/globalcyberalliance/ftp-go"
+	"github.com/globalcyberalliance/ftp-go/driver/file"
+	"github.com/globalcyberalliance/ftp-go/ftptest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransferQuota(t *testing.T) {
+	assert.NoError(t, os.MkdirAll("./testdata/quota", os.ModePerm))
+	defer os.RemoveAll("./testdata/quota")
+
+	driver, err := file.NewDriver("./testdata/quota")
+	assert.NoError(t, err)
+
+	opt := &ftp.Options{
+		Name:   "test ftpd",
+		Driver: driver,
+		Perm:   ftp.NewSimplePerm("test", "test"),
+		Port:   2125,
+		Auth: &ftp.SimpleAuth{
+			Name:     "admin",
+			Password: "admin",
+		},
+		TransferQuota: ftp.TransferQuota{
+			Upload:   6,
+			Download: 6,
+		},
+		Logger: new(ftp.DiscardLogger),
+	}
+
+	runServer(t, opt, nil, func() {
+		// Give server 0.5 seconds to get to the listening state
+		timeout := time.NewTimer(time.Millisecond * 500)
+
+		for {
+			f, err := ftptest.Dial("localhost:2125")
+			if err != nil && len(timeout.C) == 0 { // Retry errors
+				continue
+			}
+			assert.NoError(t, err)
+			assert.NoError(t, f.Login("admin", "admin"))
+
+			assert.NoError(t, f.Stor("a.txt", strings.NewReader("test")))
+			assert.Error(t, f.Stor("b.txt", strings.NewReader("test")))
+			assert.Error(t, f.Stor("c.txt", strings.NewReader("test")))
+
+			buf, err := f.Retr("a.txt")
+			assert.NoError(t, err)
+			assert.EqualValues(t, "test", string(buf))
+
+			// Resuming is only charged from the offset
+			buf, err = f.RetrFrom("a.txt", 2)
+			assert.NoError(t, err)
+			assert.EqualValues(t, "st", string(buf))
+
+			_, err = f.RetrFrom("a.txt", 3)
+			assert.Error(t, err)
+			_, err = f.Cmd(257, "PWD")
+			assert.NoError(t, err)
+
+			assert.NoError(t, f.Quit())
+
+			// A refused upload closes its data connection
+			client := dialControl(t, 2125)
+			defer client.Close()
+			client.expect(220, "")
+			client.expect(331, "USER admin")
+			client.expect(230, "PASS admin")
+			msg := client.expect(229, "EPSV")
+			var port int
+			_, err = fmt.Sscanf(msg[strings.Index(msg, "(|||"):], "(|||%d|)", &port)
+			assert.NoError(t, err)
+			data, err := net.Dial("tcp", fmt.Sprintf("localhost:%d", port))
+			assert.NoError(t, err)
+			defer data.Close()
+			client.expect(552, "STOR d.txt")
+			assert.NoError(t, data.SetReadDeadline(time.Now().Add(5*time.Second)))
+			_, err = data.Read(make([]byte, 1))
+			assert.ErrorIs(t, err, io.EOF)
+
+			break
+		}
+	})
+}
@@ -0,0 +1,198 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/globalcyberalliance/ftp-go"
+	"github.com/globalcyberalliance/ftp-go/driver/file"
+	"github.com/globalcyberalliance/ftp-go/ftptest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenamePolicy(t *testing.T) {
+	for _, policy := range []ftp.RenamePolicy{ftp.RenameReject, ftp.RenameOverwrite, ftp.RenameVersion} {
+		t.Run(policy.String(), func(t *testing.T) {
+			dir := t.TempDir()
+			driver, err := file.NewDriver(dir)
+			assert.NoError(t, err)
+
+			opt := &ftp.Options{
+				Name:   "test ftpd",
+				Driver: driver,
+				Perm:   ftp.NewSimplePerm("test", "test"),
+				Port:   2130,
+				Auth: &ftp.SimpleAuth{
+					Name:     "admin",
+					Password: "admin",
+				},
+				Logger:       new(ftp.DiscardLogger),
+				RenamePolicy: policy,
+			}
+
+			conflicts := make(chan *ftp.RenameConflict, 1)
+			notifier := &ftp.NotifierFuncs{
+				OnRenameConflictFunc: func(ctx *ftp.Context, conflict *ftp.RenameConflict, err error) {
+					conflicts <- conflict
+				},
+			}
+
+			runServer(t, opt, []interface{}{notifier}, func() {
+				// Give server 0.5 seconds to get to the listening state
+				timeout := time.NewTimer(time.Millisecond * 500)
+
+				for {
+					f, err := ftptest.Dial("localhost:2130")
+					if err != nil && len(timeout.C) == 0 { // Retry errors
+						continue
+					}
+					assert.NoError(t, err)
+
+					assert.NoError(t, f.Login("admin", "admin"))
+					assert.NoError(t, f.Mkd("/dir"))
+					assert.NoError(t, f.Stor("/new.txt", strings.NewReader("new")))
+					assert.NoError(t, f.Stor("/dir/old.txt", strings.NewReader("old")))
+
+					err = f.Rename("/new.txt", "/dir/old.txt")
+					conflict := <-conflicts
+					assert.EqualValues(t, "/new.txt", conflict.FromPath)
+					assert.EqualValues(t, "/dir/old.txt", conflict.ToPath)
+					assert.EqualValues(t, policy, conflict.Policy)
+
+					bs, _ := ioutil.ReadFile(filepath.Join(dir, "dir", "old.txt"))
+					switch policy {
+					case ftp.RenameReject:
+						assert.Error(t, err)
+						assert.EqualValues(t, "old", string(bs))
+						_, err = os.Stat(filepath.Join(dir, "new.txt"))
+						assert.NoError(t, err)
+					case ftp.RenameOverwrite:
+						assert.NoError(t, err)
+						assert.EqualValues(t, "new", string(bs))
+					case ftp.RenameVersion:
+						assert.NoError(t, err)
+						assert.EqualValues(t, "new", string(bs))
+						assert.EqualValues(t, "/dir/old.txt.1", conflict.VersionPath)
+						bs, err = ioutil.ReadFile(filepath.Join(dir, "dir", "old.txt.1"))
+						assert.NoError(t, err)
+						assert.EqualValues(t, "old", string(bs))
+					}
+
+					assert.NoError(t, f.Quit())
+					break
+				}
+			})
+		})
+	}
+}
+
+func TestParseRenamePolicy(t *testing.T) {
+	policy, err := ftp.ParseRenamePolicy(" Version ")
+	assert.NoError(t, err)
+	assert.EqualValues(t, ftp.RenameVersion, policy)
+
+	_, err = ftp.ParseRenamePolicy("clobber")
+	assert.Error(t, err)
+}
+
+func TestRenameSequence(t *testing.T) {
+	root := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "a.txt"), nil, 0o600))
+	driver, err := file.NewDriver(root)
+	assert.NoError(t, err)
+
+	addr, cleanup := ftptest.NewServer(driver, &ftp.Options{RenameTimeout: 50 * time.Millisecond})
+	defer cleanup()
+	c, err := ftptest.Dial(addr)
+	assert.NoError(t, err)
+	defer c.Close()
+	assert.NoError(t, c.Login(ftptest.Username, ftptest.Password))
+
+	_, err = c.Cmd(503, "RNTO b.txt")
+	assert.NoError(t, err)
+
+	// Commands which are not executed keep RNFR pending, another command
+	// in between drops it
+	_, err = c.Cmd(350, "RNFR a.txt")
+	assert.NoError(t, err)
+	_, err = c.Cmd(500, "BOGUS")
+	assert.NoError(t, err)
+	_, err = c.Cmd(553, "DELE")
+	assert.NoError(t, err)
+	assert.NoError(t, c.Rename("a.txt", "b.txt"))
+	assert.NoError(t, c.Rename("b.txt", "a.txt"))
+	_, err = c.Cmd(350, "RNFR a.txt")
+	assert.NoError(t, err)
+	_, err = c.Cmd(200, "NOOP")
+	assert.NoError(t, err)
+	_, err = c.Cmd(503, "RNTO b.txt")
+	assert.NoError(t, err)
+
+	_, err = c.Cmd(350, "RNFR a.txt")
+	assert.NoError(t, err)
+	time.Sleep(100 * time.Millisecond)
+	_, err = c.Cmd(503, "RNTO b.txt")
+	assert.NoError(t, err)
+	assert.FileExists(t, filepath.Join(root, "a.txt"))
+
+	assert.NoError(t, c.Rename("a.txt", "b.txt"))
+	assert.FileExists(t, filepath.Join(root, "b.txt"))
+	_, err = c.Cmd(503, "RNTO c.txt")
+	assert.NoError(t, err)
+}
+
+// failingRenameDriver fails to rename its source file
+type failingRenameDriver struct {
+	ftp.Driver
+	source string
+}
+
+func (driver *failingRenameDriver) Rename(ctx *ftp.Context, fromPath, toPath string) error {
+	if fromPath == driver.source {
+		return ftp.ErrUnavailable
+	}
+	return driver.Driver.Rename(ctx, fromPath, toPath)
+}
+
+func TestRenameOverwriteFailure(t *testing.T) {
+	root := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "a.txt"), []byte("a"), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "busy.txt"), []byte("busy"), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "b.txt"), []byte("b"), 0o600))
+	base, err := file.NewDriver(root)
+	assert.NoError(t, err)
+
+	addr, cleanup := ftptest.NewServer(&failingRenameDriver{Driver: base, source: "/busy.txt"}, &ftp.Options{RenamePolicy: ftp.RenameOverwrite})
+	defer cleanup()
+	c, err := ftptest.Dial(addr)
+	assert.NoError(t, err)
+	defer c.Close()
+	assert.NoError(t, c.Login(ftptest.Username, ftptest.Password))
+
+	// Renaming a file onto itself leaves it be
+	assert.NoError(t, c.Rename("a.txt", "a.txt"))
+	bs, err := os.ReadFile(filepath.Join(root, "a.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "a", string(bs))
+
+	// The target is kept when the rename fails
+	assert.Error(t, c.Rename("busy.txt", "b.txt"))
+	bs, err = os.ReadFile(filepath.Join(root, "b.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "b", string(bs))
+	assert.NoFileExists(t, filepath.Join(root, "b.txt.1"))
+
+	assert.NoError(t, c.Rename("a.txt", "b.txt"))
+	bs, err = os.ReadFile(filepath.Join(root, "b.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "a", string(bs))
+	assert.NoFileExists(t, filepath.Join(root, "b.txt.1"))
+}
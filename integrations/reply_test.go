@@ -0,0 +1,70 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/globalcyberalliance/ftp-go"
+	"github.com/globalcyberalliance/ftp-go/driver/file"
+	"github.com/globalcyberalliance/ftp-go/ftptest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnReply(t *testing.T) {
+	driver, err := file.NewDriver(t.TempDir())
+	assert.NoError(t, err)
+
+	var mu sync.Mutex
+	var commands []string
+	addr, cleanup := ftptest.NewServer(driver, &ftp.Options{
+		OnReply: func(sess *ftp.Session, reply *ftp.Reply) {
+			mu.Lock()
+			commands = append(commands, reply.Command)
+			mu.Unlock()
+			switch {
+			case reply.Code == 220:
+				reply.Message = "Example Corp. file transfer\nHello"
+			case reply.Code >= 500 && reply.Command != "":
+				reply.Message = "Request failed"
+			case reply.Command == "FEAT":
+				reply.Message = strings.Replace(reply.Message, "\nEnd", "\nEnd of features", 1)
+			case reply.Command == "NOOP":
+				reply.Code = 1000
+			}
+		},
+	})
+	defer cleanup()
+
+	conn, err := textproto.Dial("tcp", addr)
+	assert.NoError(t, err)
+	_, msg, err := conn.ReadResponse(220)
+	assert.NoError(t, err)
+	assert.Equal(t, "Example Corp. file transfer\nHello", msg)
+	conn.Close()
+
+	c, err := ftptest.Dial(addr)
+	assert.NoError(t, err)
+	defer c.Close()
+	assert.NoError(t, c.Login(ftptest.Username, ftptest.Password))
+
+	msg, err = c.Cmd(550, "SIZE /missing.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "Request failed", msg)
+	msg, err = c.Cmd(211, "FEAT")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasSuffix(msg, "\nEnd of features"), msg)
+	// Invalid codes are ignored
+	_, err = c.Cmd(200, "NOOP")
+	assert.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Contains(t, commands, "")
+	assert.Contains(t, commands, "SIZE")
+}
@@ -0,0 +1,138 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/globalcyberalliance/ftp-go"
+	"github.com/globalcyberalliance/ftp-go/driver/file"
+	"github.com/globalcyberalliance/ftp-go/ftptest"
+	"github.com/stretchr/testify/assert"
+)
+
+// ticket returns the ticket of a SITE TICKET or SITE RESUME reply
+func ticket(msg string) string {
+	fields := strings.Fields(msg)
+	for i, field := range fields {
+		if strings.EqualFold(strings.TrimSuffix(field, ","), "ticket") && i+1 < len(fields) {
+			return fields[i+1]
+		}
+	}
+	return ""
+}
+
+func TestResumeTicket(t *testing.T) {
+	root := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(root, "dir"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "dir", "hello.txt"), []byte("hello"), 0o644))
+	driver, err := file.NewDriver(root)
+	assert.NoError(t, err)
+
+	addr, cleanup := ftptest.NewServer(driver, &ftp.Options{ResumeTicketTTL: time.Minute})
+	defer cleanup()
+
+	c, err := ftptest.Dial(addr)
+	assert.NoError(t, err)
+	_, err = c.Cmd(530, "SITE TICKET")
+	assert.NoError(t, err)
+	assert.NoError(t, c.Login(ftptest.Username, ftptest.Password))
+	assert.NoError(t, c.Cwd("/dir"))
+	msg, err := c.Cmd(200, "SITE TICKET")
+	assert.NoError(t, err)
+	token := ticket(msg)
+	assert.NotEmpty(t, token)
+	// The connection drops between REST and RETR
+	_, err = c.Cmd(350, "REST 3")
+	assert.NoError(t, err)
+	assert.NoError(t, c.Close())
+
+	c, err = ftptest.Dial(addr)
+	assert.NoError(t, err)
+	defer c.Close()
+	_, err = c.Cmd(530, "SITE RESUME wrong")
+	assert.NoError(t, err)
+	msg, err = c.Cmd(230, "SITE RESUME %s", token)
+	assert.NoError(t, err)
+	next := ticket(msg)
+	assert.NotEmpty(t, next)
+	assert.NotEqual(t, token, next)
+
+	data, err := c.Retr("hello.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "lo", string(data))
+	pwd, err := c.Pwd()
+	assert.NoError(t, err)
+	assert.Equal(t, "/dir", pwd)
+
+	// Tickets are used once, and revoked by QUIT
+	other, err := ftptest.Dial(addr)
+	assert.NoError(t, err)
+	defer other.Close()
+	_, err = other.Cmd(530, "SITE RESUME %s", token)
+	assert.NoError(t, err)
+	assert.NoError(t, c.Quit())
+	_, err = other.Cmd(530, "SITE RESUME %s", next)
+	assert.NoError(t, err)
+}
+
+func TestResumeTicketChecks(t *testing.T) {
+	root := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(root, "dir"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "dir", "hello.txt"), []byte("hello"), 0o644))
+	driver, err := file.NewDriver(root)
+	assert.NoError(t, err)
+
+	var connections atomic.Int32
+	var reject atomic.Bool
+	addr, cleanup := ftptest.NewServer(driver, &ftp.Options{
+		ResumeTicketTTL: time.Minute,
+		OnConnect: func(sess *ftp.Session) error {
+			if connections.Add(1) == 1 {
+				sess.SetRoot("/dir")
+				sess.SetReadOnly(true)
+			}
+			if reject.Load() && sess.IsLogin() {
+				return &ftp.ReplyError{Code: 421, Message: "Go away"}
+			}
+			return nil
+		},
+	})
+	defer cleanup()
+
+	c, err := ftptest.Dial(addr)
+	assert.NoError(t, err)
+	assert.NoError(t, c.Login(ftptest.Username, ftptest.Password))
+	msg, err := c.Cmd(200, "SITE TICKET")
+	assert.NoError(t, err)
+	assert.NoError(t, c.Close())
+
+	// The root and read-only state are resumed, not those of the new
+	// connection
+	c, err = ftptest.Dial(addr)
+	assert.NoError(t, err)
+	msg, err = c.Cmd(230, "SITE RESUME %s", ticket(msg))
+	assert.NoError(t, err)
+	names, err := c.Nlst("/")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"hello.txt"}, names)
+	assert.Error(t, c.Stor("/new.txt", strings.NewReader("new")))
+	msg, err = c.Cmd(200, "SITE TICKET")
+	assert.NoError(t, err)
+	assert.NoError(t, c.Close())
+
+	// OnConnect may refuse the resumed session
+	reject.Store(true)
+	c, err = ftptest.Dial(addr)
+	assert.NoError(t, err)
+	defer c.Close()
+	_, err = c.Cmd(421, "SITE RESUME %s", ticket(msg))
+	assert.NoError(t, err)
+}
@@ -0,0 +1,168 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/globalcyberalliance/ftp-go"
+	"github.com/globalcyberalliance/ftp-go/driver/file"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShutdownEndsSessions(t *testing.T) {
+	driver, err := file.NewDriver(t.TempDir())
+	assert.NoError(t, err)
+
+	s, err := ftp.NewServer(&ftp.Options{
+		Name:   "test ftpd",
+		Driver: driver,
+		Perm:   ftp.NewSimplePerm("test", "test"),
+		Port:   2135,
+		Auth: &ftp.SimpleAuth{
+			Name:     "admin",
+			Password: "admin",
+		},
+		Logger: new(ftp.DiscardLogger),
+	})
+	assert.NoError(t, err)
+
+	var disconnected int32
+	s.RegisterNotifier(&ftp.NotifierFuncs{
+		OnDisconnectFunc: func(ctx *ftp.Context) {
+			atomic.AddInt32(&disconnected, 1)
+		},
+	})
+
+	served := make(chan error, 1)
+	go func() {
+		served <- s.ListenAndServe()
+	}()
+
+	idle := dialControl(t, 2135)
+	defer idle.Close()
+	idle.expect(220, "")
+
+	// An upload the client never finishes
+	uploading := dialControl(t, 2135)
+	defer uploading.Close()
+	uploading.expect(220, "")
+	uploading.expect(331, "USER admin")
+	uploading.expect(230, "PASS admin")
+	msg := uploading.expect(229, "EPSV")
+	var port int
+	_, err = fmt.Sscanf(msg[strings.Index(msg, "(|||"):], "(|||%d|)", &port)
+	assert.NoError(t, err)
+	data, err := net.Dial("tcp", fmt.Sprintf("localhost:%d", port))
+	assert.NoError(t, err)
+	defer data.Close()
+	uploading.expect(150, "STOR never.txt")
+
+	assert.NoError(t, s.Shutdown())
+	assert.EqualValues(t, 2, atomic.LoadInt32(&disconnected))
+	assert.EqualError(t, <-served, ftp.ErrServerClosed.Error())
+
+	for _, client := range []*controlConn{idle, uploading} {
+		_, err = io.ReadAll(client.R)
+		assert.NoError(t, err)
+	}
+}
+
+func TestShutdownPendingPassive(t *testing.T) {
+	driver, err := file.NewDriver(t.TempDir())
+	assert.NoError(t, err)
+
+	s, err := ftp.NewServer(&ftp.Options{
+		Name:   "test ftpd",
+		Driver: driver,
+		Perm:   ftp.NewSimplePerm("test", "test"),
+		Port:   2136,
+		Auth: &ftp.SimpleAuth{
+			Name:     "admin",
+			Password: "admin",
+		},
+		Logger: new(ftp.DiscardLogger),
+	})
+	assert.NoError(t, err)
+
+	served := make(chan error, 1)
+	go func() {
+		served <- s.ListenAndServe()
+	}()
+
+	// A passive socket no client ever connects to
+	client := dialControl(t, 2136)
+	defer client.Close()
+	client.expect(220, "")
+	client.expect(331, "USER admin")
+	client.expect(230, "PASS admin")
+	client.expect(229, "EPSV")
+
+	shutdown := make(chan error, 1)
+	go func() {
+		shutdown <- s.Shutdown()
+	}()
+	select {
+	case err = <-shutdown:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Shutdown waited for the pending passive socket")
+	}
+	assert.EqualError(t, <-served, ftp.ErrServerClosed.Error())
+}
+
+func TestServeErrServerClosed(t *testing.T) {
+	driver, err := file.NewDriver(t.TempDir())
+	assert.NoError(t, err)
+	opts := &ftp.Options{
+		Driver: driver,
+		Perm:   ftp.NewSimplePerm("test", "test"),
+		Auth:   &ftp.SimpleAuth{Name: "admin", Password: "admin"},
+		Logger: new(ftp.DiscardLogger),
+	}
+
+	// Closed by Shutdown
+	s, err := ftp.NewServer(opts)
+	assert.NoError(t, err)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	served := make(chan error, 1)
+	go func() {
+		served <- s.Serve(l)
+	}()
+	// Once greeted, Serve is accepting
+	c, err := net.Dial("tcp", l.Addr().String())
+	assert.NoError(t, err)
+	_, err = bufio.NewReader(c).ReadString('\n')
+	assert.NoError(t, err)
+	c.Close()
+	assert.NoError(t, s.Shutdown())
+	assert.ErrorIs(t, <-served, ftp.ErrServerClosed)
+
+	// Closed by someone else
+	s, err = ftp.NewServer(opts)
+	assert.NoError(t, err)
+	l, err = net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	go func() {
+		served <- s.Serve(l)
+	}()
+	c, err = net.Dial("tcp", l.Addr().String())
+	assert.NoError(t, err)
+	_, err = bufio.NewReader(c).ReadString('\n')
+	assert.NoError(t, err)
+	c.Close()
+	l.Close()
+	err = <-served
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ftp.ErrServerClosed)
+}
@@ -0,0 +1,137 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/globalcyberalliance/ftp-go"
+	"github.com/globalcyberalliance/ftp-go/driver/file"
+	"github.com/globalcyberalliance/ftp-go/ftptest"
+	"github.com/stretchr/testify/assert"
+)
+
+// progressReader sends hello, then waits for the session to have received
+// it before ending the upload.
+type progressReader struct {
+	sess  *ftp.Session
+	sent  bool
+	state *ftp.TransferState
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	if !r.sent {
+		r.sent = true
+		return copy(p, "hello"), nil
+	}
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if state := r.sess.Transfer(); state != nil && state.Bytes == 5 {
+			r.state = state
+			break
+		}
+	}
+	return 0, io.EOF
+}
+
+func TestStats(t *testing.T) {
+	driver, err := file.NewDriver(t.TempDir())
+	assert.NoError(t, err)
+
+	sessions := make(chan *ftp.Session, 2)
+	addr, cleanup := ftptest.NewServer(driver, &ftp.Options{
+		SessionCallback: func(sess *ftp.Session) {
+			sessions <- sess
+		},
+	})
+	defer cleanup()
+
+	var sess *ftp.Session
+	for i := 0; i < 2; i++ {
+		c, err := ftptest.Dial(addr)
+		assert.NoError(t, err)
+		assert.NoError(t, c.Login(ftptest.Username, ftptest.Password))
+		sess = <-sessions
+
+		upload := &progressReader{sess: sess}
+		assert.NoError(t, c.Stor("/file.txt", upload))
+		if assert.NotNil(t, upload.state) {
+			assert.Equal(t, ftp.TransferUpload, upload.state.Direction)
+			assert.Equal(t, "/file.txt", upload.state.Path)
+			assert.False(t, upload.state.Start.IsZero())
+		}
+		_, err = c.Retr("/file.txt")
+		assert.NoError(t, err)
+		assert.NoError(t, c.Quit())
+
+		assert.Equal(t, ftp.TransferStats{Uploaded: 5, Downloaded: 5}, sess.Stats())
+		assert.Nil(t, sess.Transfer())
+	}
+
+	c, err := ftptest.Dial(addr)
+	assert.NoError(t, err)
+	defer c.Close()
+	_, err = c.Cmd(331, "USER %s", ftptest.Username)
+	assert.NoError(t, err)
+	_, err = c.Cmd(530, "PASS wrong")
+	assert.NoError(t, err)
+	assert.NoError(t, c.Login(ftptest.Username, ftptest.Password))
+	_, err = c.Cmd(211, "SITE USAGE")
+	assert.NoError(t, err)
+	_, err = c.Cmd(502, "SITE UNKNOWN")
+	assert.NoError(t, err)
+
+	stats := sess.Server().Stats()
+	assert.Equal(t, ftp.TransferStats{Uploaded: 10, Downloaded: 10}, stats.TransferStats)
+	assert.Equal(t, map[string]ftp.TransferStats{
+		ftptest.Username: {Uploaded: 10, Downloaded: 10},
+	}, stats.Users)
+	assert.Equal(t, int64(3), stats.Connections)
+	assert.GreaterOrEqual(t, stats.ActiveSessions, 1)
+	assert.Equal(t, int64(3), stats.Logins)
+	assert.Equal(t, int64(1), stats.FailedLogins)
+	assert.Equal(t, int64(2), stats.Commands["STOR"])
+	assert.Equal(t, int64(4), stats.Commands["PASS"])
+	assert.Equal(t, int64(1), stats.Commands["SITE USAGE"])
+	assert.Equal(t, int64(1), stats.Commands["SITE"])
+	assert.Positive(t, stats.Uptime)
+	assert.False(t, stats.Start.IsZero())
+}
+
+// failingDriver reads uploads and then fails them without saying how much
+// it got.
+type failingDriver struct {
+	ftp.Driver
+}
+
+func (driver failingDriver) PutFile(ctx *ftp.Context, p string, data io.Reader, offset int64) (int64, error) {
+	_, _ = io.Copy(io.Discard, data)
+	return 0, errors.New("disk on fire")
+}
+
+func TestStatsFailedUpload(t *testing.T) {
+	base, err := file.NewDriver(t.TempDir())
+	assert.NoError(t, err)
+
+	sessions := make(chan *ftp.Session, 1)
+	addr, cleanup := ftptest.NewServer(failingDriver{base}, &ftp.Options{
+		SessionCallback: func(sess *ftp.Session) {
+			sessions <- sess
+		},
+	})
+	defer cleanup()
+
+	c, err := ftptest.Dial(addr)
+	assert.NoError(t, err)
+	defer c.Close()
+	assert.NoError(t, c.Login(ftptest.Username, ftptest.Password))
+	sess := <-sessions
+
+	assert.Error(t, c.Stor("/file.txt", strings.NewReader("hello")))
+	assert.Equal(t, ftp.TransferStats{Uploaded: 5}, sess.Stats())
+}
@@ -0,0 +1,73 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/globalcyberalliance/ftp-go"
+	"github.com/globalcyberalliance/ftp-go/driver/file"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), os.ModePerm))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "target.txt"), []byte("test"), os.ModePerm))
+
+	driver, err := file.NewDriver(dir)
+	assert.NoError(t, err)
+
+	opt := &ftp.Options{
+		Name:   "test ftpd",
+		Driver: driver,
+		Perm:   ftp.NewSimplePerm("test", "test"),
+		Port:   2131,
+		Auth: &ftp.SimpleAuth{
+			Name:     "admin",
+			Password: "admin",
+		},
+		Logger: new(ftp.DiscardLogger),
+	}
+
+	runServer(t, opt, nil, func() {
+		client := dialControl(t, 2131)
+		defer client.Close()
+		expect, list := client.expect, client.list
+
+		expect(220, "")
+		expect(331, "USER admin")
+		expect(230, "PASS admin")
+
+		expect(200, "SITE SYMLINK ../target.txt /sub/link.txt")
+		expect(501, "SITE SYMLINK target.txt")
+
+		// The link is stored absolute, it does not break once moved.
+		expect(350, "RNFR /sub/link.txt")
+		expect(250, "RNTO /link.txt")
+		bs, err := ioutil.ReadFile(filepath.Join(dir, "link.txt"))
+		assert.NoError(t, err)
+		assert.EqualValues(t, "test", string(bs))
+
+		listing := list("LIST /")
+		assert.Contains(t, listing, " link.txt -> /target.txt\r\n")
+		for _, line := range strings.Split(listing, "\r\n") {
+			if strings.Contains(line, "link.txt") {
+				assert.True(t, strings.HasPrefix(line, "l"), line)
+			}
+		}
+		assert.Contains(t, list("MLSD /"), "Type=OS.unix=slink:/target.txt;")
+
+		// Links leading out of the root are not disclosed.
+		assert.NoError(t, os.Symlink(os.TempDir(), filepath.Join(dir, "out")))
+		assert.Contains(t, list("LIST /"), " out\r\n")
+
+		expect(221, "QUIT")
+	})
+}
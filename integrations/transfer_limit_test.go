@@ -0,0 +1,64 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/globalcyberalliance/ftp-go"
+	"github.com/globalcyberalliance/ftp-go/driver/file"
+	"github.com/globalcyberalliance/ftp-go/ftptest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxTransfers(t *testing.T) {
+	driver, err := file.NewDriver(t.TempDir())
+	assert.NoError(t, err)
+
+	addr, cleanup := ftptest.NewServer(driver, &ftp.Options{MaxTransfers: 1})
+	defer cleanup()
+	_, portStr, err := net.SplitHostPort(addr)
+	assert.NoError(t, err)
+	var port int
+	_, err = fmt.Sscan(portStr, &port)
+	assert.NoError(t, err)
+
+	// An upload taking the only slot until its data connection is closed
+	uploading := dialControl(t, port)
+	defer uploading.Close()
+	uploading.expect(220, "")
+	uploading.expect(331, "USER %s", ftptest.Username)
+	uploading.expect(230, "PASS %s", ftptest.Password)
+	msg := uploading.expect(229, "EPSV")
+	var dataPort int
+	_, err = fmt.Sscanf(msg[strings.Index(msg, "(|||"):], "(|||%d|)", &dataPort)
+	assert.NoError(t, err)
+	data, err := net.Dial("tcp", fmt.Sprintf("localhost:%d", dataPort))
+	assert.NoError(t, err)
+	uploading.expect(150, "STOR first.txt")
+
+	c, err := ftptest.Dial(addr)
+	assert.NoError(t, err)
+	defer c.Close()
+	assert.NoError(t, c.Login(ftptest.Username, ftptest.Password))
+
+	err = c.Stor("second.txt", strings.NewReader("second"))
+	var protoErr *textproto.Error
+	if assert.True(t, errors.As(err, &protoErr), "%v", err) {
+		assert.EqualValues(t, 450, protoErr.Code)
+	}
+
+	_, err = data.Write([]byte("first"))
+	assert.NoError(t, err)
+	assert.NoError(t, data.Close())
+	uploading.expect(226, "")
+
+	assert.NoError(t, c.Stor("second.txt", strings.NewReader("second")))
+}
@@ -0,0 +1,112 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/globalcyberalliance/ftp-go"
+	"github.com/globalcyberalliance/ftp-go/driver/file"
+	"github.com/globalcyberalliance/ftp-go/ftptest"
+	"github.com/stretchr/testify/assert"
+)
+
+// upperReader upper cases what it reads
+type upperReader struct {
+	r io.Reader
+}
+
+func (r *upperReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	copy(p, bytes.ToUpper(p[:n]))
+	return n, err
+}
+
+// scanReader rejects content holding "VIRUS" once read to the end
+type scanReader struct {
+	r       io.Reader
+	content bytes.Buffer
+	closed  *atomic.Int32
+}
+
+func (r *scanReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.content.Write(p[:n])
+	if err == io.EOF && strings.Contains(r.content.String(), "VIRUS") {
+		return n, &ftp.ReplyError{Code: 552, Message: "Infected file rejected"}
+	}
+	return n, err
+}
+
+func (r *scanReader) Close() error {
+	r.closed.Add(1)
+	return nil
+}
+
+func TestTransferStages(t *testing.T) {
+	root := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "forbidden.txt"), []byte("forbidden"), 0o600))
+	driver, err := file.NewDriver(root)
+	assert.NoError(t, err)
+
+	server, err := ftp.NewServer(&ftp.Options{
+		Driver: driver,
+		Auth:   &ftp.SimpleAuth{Name: ftptest.Username, Password: ftptest.Password},
+		Perm:   ftp.NewSimplePerm(ftptest.Username, ftptest.Username),
+		Logger: new(ftp.DiscardLogger),
+	})
+	assert.NoError(t, err)
+
+	// Uploads are upper cased before they are scanned, downloads are scanned
+	// as read from the driver.
+	server.RegisterTransferStage(ftp.TransferStageFunc(func(ctx *ftp.Context, direction ftp.TransferDirection, path string, r io.Reader) (io.Reader, error) {
+		if path == "/forbidden.txt" {
+			return nil, &ftp.ReplyError{Code: 553, Message: "Transfer refused"}
+		}
+		if direction == ftp.TransferUpload {
+			return &upperReader{r: r}, nil
+		}
+		return r, nil
+	}))
+	var closed atomic.Int32
+	server.RegisterTransferStage(ftp.TransferStageFunc(func(ctx *ftp.Context, direction ftp.TransferDirection, path string, r io.Reader) (io.Reader, error) {
+		return &scanReader{r: r, closed: &closed}, nil
+	}))
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	go server.Serve(l)
+	defer server.Shutdown()
+
+	c, err := ftptest.Dial(l.Addr().String())
+	assert.NoError(t, err)
+	defer c.Close()
+	assert.NoError(t, c.Login(ftptest.Username, ftptest.Password))
+
+	assert.NoError(t, c.Stor("clean.txt", strings.NewReader("clean")))
+	content, err := c.Retr("clean.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "CLEAN", string(content))
+
+	var protoErr *textproto.Error
+	err = c.Stor("infected.txt", strings.NewReader("a virus"))
+	if assert.True(t, errors.As(err, &protoErr), "%v", err) {
+		assert.EqualValues(t, 552, protoErr.Code)
+	}
+	_, err = c.Retr("forbidden.txt")
+	if assert.True(t, errors.As(err, &protoErr), "%v", err) {
+		assert.EqualValues(t, 553, protoErr.Code)
+	}
+	assert.EqualValues(t, 3, closed.Load())
+}
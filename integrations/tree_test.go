@@ -0,0 +1,165 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/globalcyberalliance/ftp-go"
+	"github.com/globalcyberalliance/ftp-go/driver/file"
+	"github.com/globalcyberalliance/ftp-go/ftptest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSiteTree(t *testing.T) {
+	root := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "tree", "a", "b"), 0o700))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "tree", "one.txt"), []byte("1"), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "tree", "a", "b", "two.txt"), []byte("2"), 0o600))
+	driver, err := file.NewDriver(root)
+	assert.NoError(t, err)
+
+	addr, cleanup := ftptest.NewServer(driver, &ftp.Options{RecursiveCommands: true})
+	defer cleanup()
+	c, err := ftptest.Dial(addr)
+	assert.NoError(t, err)
+	defer c.Close()
+	assert.NoError(t, c.Login(ftptest.Username, ftptest.Password))
+
+	_, err = c.Cmd(501, "SITE RMDIR tree")
+	assert.NoError(t, err)
+	_, err = c.Cmd(550, "SITE RMDIR -R -Y /")
+	assert.NoError(t, err)
+	_, err = c.Cmd(553, "SITE MVDIR tree tree/a/moved")
+	assert.NoError(t, err)
+
+	_, err = c.Cmd(250, "SITE MVDIR tree moved")
+	assert.NoError(t, err)
+	assert.NoDirExists(t, filepath.Join(root, "tree"))
+	assert.FileExists(t, filepath.Join(root, "moved", "a", "b", "two.txt"))
+
+	// Nothing is removed until confirmed
+	_, err = c.Cmd(250, "CWD moved/a")
+	assert.NoError(t, err)
+	msg, err := c.Cmd(200, "SITE RMDIR -R /moved")
+	assert.NoError(t, err)
+	assert.Contains(t, msg, "2 files and 2 directories")
+	assert.DirExists(t, filepath.Join(root, "moved"))
+
+	_, err = c.Cmd(250, "SITE RMDIR -R -Y /moved")
+	assert.NoError(t, err)
+	assert.NoDirExists(t, filepath.Join(root, "moved"))
+	msg, err = c.Cmd(257, "PWD")
+	assert.NoError(t, err)
+	assert.Contains(t, msg, `"/"`)
+	_, err = c.Cmd(550, "SITE RMDIR -R -Y /moved")
+	assert.NoError(t, err)
+}
+
+func TestSiteTreeDisabled(t *testing.T) {
+	driver, err := file.NewDriver(t.TempDir())
+	assert.NoError(t, err)
+
+	addr, cleanup := ftptest.NewServer(driver, nil)
+	defer cleanup()
+	c, err := ftptest.Dial(addr)
+	assert.NoError(t, err)
+	defer c.Close()
+	assert.NoError(t, c.Login(ftptest.Username, ftptest.Password))
+
+	_, err = c.Cmd(502, "SITE RMDIR -R -Y /")
+	assert.NoError(t, err)
+	_, err = c.Cmd(502, "SITE MVDIR a b")
+	assert.NoError(t, err)
+}
+
+// treeDriver removes trees with a single call
+type treeDriver struct {
+	ftp.Driver
+	root    string
+	deleted []string
+}
+
+func (driver *treeDriver) DeleteTree(ctx *ftp.Context, p string) error {
+	driver.deleted = append(driver.deleted, p)
+	return os.RemoveAll(filepath.Join(driver.root, p))
+}
+
+func (driver *treeDriver) MoveTree(ctx *ftp.Context, fromPath, toPath string) error {
+	return os.Rename(filepath.Join(driver.root, fromPath), filepath.Join(driver.root, toPath))
+}
+
+func TestSiteTreeEntries(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"fast", "slow"} {
+		assert.NoError(t, os.MkdirAll(filepath.Join(root, dir, "sub"), 0o700))
+		assert.NoError(t, os.WriteFile(filepath.Join(root, dir, "one.txt"), []byte("1"), 0o600))
+		assert.NoError(t, os.WriteFile(filepath.Join(root, dir, "sub", "keep.txt"), []byte("2"), 0o600))
+	}
+	base, err := file.NewDriver(root)
+	assert.NoError(t, err)
+	driver := &treeDriver{Driver: base, root: root}
+
+	// Without notifiers the tree goes at once, and leaves the cache
+	addr, cleanup := ftptest.NewServer(driver, &ftp.Options{RecursiveCommands: true, StatCacheTTL: time.Minute})
+	defer cleanup()
+	c, err := ftptest.Dial(addr)
+	assert.NoError(t, err)
+	defer c.Close()
+	assert.NoError(t, c.Login(ftptest.Username, ftptest.Password))
+	_, err = c.Cmd(213, "SIZE /fast/one.txt")
+	assert.NoError(t, err)
+	_, err = c.Cmd(250, "SITE RMDIR -R -Y /fast")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/fast"}, driver.deleted)
+	_, err = c.Cmd(550, "SIZE /fast/one.txt")
+	assert.NoError(t, err)
+
+	// Notifiers see every entry, and may keep some
+	server, err := ftp.NewServer(&ftp.Options{
+		Driver:            driver,
+		Auth:              &ftp.SimpleAuth{Name: ftptest.Username, Password: ftptest.Password},
+		Perm:              ftp.NewSimplePerm("test", "test"),
+		Logger:            new(ftp.DiscardLogger),
+		RecursiveCommands: true,
+	})
+	assert.NoError(t, err)
+	var deleted []string
+	server.RegisterNotifier(&ftp.NotifierFuncs{
+		AfterFileDeletedFunc: func(ctx *ftp.Context, dstPath string, err error) {
+			deleted = append(deleted, dstPath)
+		},
+	})
+	server.RegisterNotifier(&keepInterceptor{})
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	go server.Serve(l)
+	defer server.Shutdown()
+
+	c2, err := ftptest.Dial(l.Addr().String())
+	assert.NoError(t, err)
+	defer c2.Close()
+	assert.NoError(t, c2.Login(ftptest.Username, ftptest.Password))
+	_, err = c2.Cmd(450, "SITE RMDIR -R -Y /slow")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/fast"}, driver.deleted)
+	assert.ElementsMatch(t, []string{"/slow/one.txt", "/slow/sub/keep.txt"}, deleted)
+	assert.FileExists(t, filepath.Join(root, "slow", "sub", "keep.txt"))
+}
+
+// keepInterceptor refuses to delete files named keep.txt
+type keepInterceptor struct{}
+
+func (keepInterceptor) Intercept(ctx *ftp.Context, path string) error {
+	if strings.HasSuffix(path, "/keep.txt") {
+		return &ftp.ReplyError{Code: 450, Message: "Kept"}
+	}
+	return nil
+}
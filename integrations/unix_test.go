@@ -0,0 +1,79 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/globalcyberalliance/ftp-go"
+	"github.com/globalcyberalliance/ftp-go/driver/file"
+	"github.com/globalcyberalliance/ftp-go/ftptest"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingTransport counts the passive listeners it opens
+type countingTransport struct {
+	ftp.TCPDataTransport
+	listens atomic.Int32
+}
+
+func (transport *countingTransport) ListenPassive(sess *ftp.Session, laddr *net.TCPAddr) (net.Listener, error) {
+	transport.listens.Add(1)
+	return transport.TCPDataTransport.ListenPassive(sess, laddr)
+}
+
+func TestUnixControl(t *testing.T) {
+	driver, err := file.NewDriver(t.TempDir())
+	assert.NoError(t, err)
+
+	transport := new(countingTransport)
+	server, err := ftp.NewServer(&ftp.Options{
+		Driver:        driver,
+		Auth:          &ftp.SimpleAuth{Name: ftptest.Username, Password: ftptest.Password},
+		Perm:          ftp.NewSimplePerm(ftptest.Username, ftptest.Username),
+		Logger:        new(ftp.DiscardLogger),
+		DataTransport: transport,
+	})
+	assert.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "ftp.sock")
+	l, err := net.Listen("unix", path)
+	assert.NoError(t, err)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = server.Serve(l)
+	}()
+	defer func() {
+		assert.NoError(t, server.Shutdown())
+		<-done
+	}()
+
+	c, err := ftptest.DialUnix(path)
+	assert.NoError(t, err)
+	defer c.Close()
+	assert.NoError(t, c.Login(ftptest.Username, ftptest.Password))
+
+	assert.NoError(t, c.Stor("/file.txt", strings.NewReader("hello")))
+	content, err := c.Retr("/file.txt")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "hello", string(content))
+
+	// Passive connections are announced on the loopback address
+	msg, err := c.Cmd(227, "PASV")
+	assert.NoError(t, err)
+	var p1, p2 int
+	_, err = fmt.Sscanf(msg[strings.Index(msg, "("):], "(127,0,0,1,%d,%d)", &p1, &p2)
+	assert.NoError(t, err)
+	data, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", p1*256+p2))
+	assert.NoError(t, err)
+	assert.NoError(t, data.Close())
+	assert.EqualValues(t, 3, transport.listens.Load())
+}
@@ -0,0 +1,185 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/globalcyberalliance/ftp-go"
+	"github.com/globalcyberalliance/ftp-go/driver/file"
+	"github.com/globalcyberalliance/ftp-go/ftptest"
+	"github.com/globalcyberalliance/ftp-go/users"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVirtualUsers(t *testing.T) {
+	assert.NoError(t, os.MkdirAll("./testdata/users/alice", os.ModePerm))
+	assert.NoError(t, os.MkdirAll("./testdata/users/bob", os.ModePerm))
+	defer os.RemoveAll("./testdata/users")
+
+	base, err := file.NewDriver("./testdata/users")
+	assert.NoError(t, err)
+
+	aliceHash, err := users.HashPassword("alice")
+	assert.NoError(t, err)
+	bobHash, err := users.HashPassword("bob")
+	assert.NoError(t, err)
+
+	store := users.NewMapStore(
+		&users.User{Name: "alice", Password: aliceHash, Home: "/alice", Perms: users.PermAll, Quota: 8},
+		&users.User{Name: "bob", Password: bobHash, Home: "/bob", Perms: users.PermReadOnly},
+	)
+
+	driver := users.NewDriver(store, base)
+	opt := &ftp.Options{
+		Name:   "test ftpd",
+		Driver: driver,
+		Perm:   users.NewPerm("ftp", "ftp"),
+		Port:   2124,
+		Logger: new(ftp.DiscardLogger),
+	}
+
+	runServer(t, opt, nil, func() {
+		// Give server 0.5 seconds to get to the listening state
+		timeout := time.NewTimer(time.Millisecond * 500)
+
+		for {
+			f, err := ftptest.Dial("localhost:2124")
+			if err != nil && len(timeout.C) == 0 { // Retry errors
+				continue
+			}
+			assert.NoError(t, err)
+
+			assert.Error(t, f.Login("alice", "bob"))
+			assert.NoError(t, f.Login("alice", "alice"))
+			assert.NoError(t, f.Stor("hello.txt", strings.NewReader("hello")))
+
+			msg, err := f.Cmd(211, "SITE QUOTA")
+			assert.NoError(t, err)
+			assert.Contains(t, msg, "Storage: 3 of 8 bytes remaining")
+			msg, err = f.Cmd(211, "SITE USAGE")
+			assert.NoError(t, err)
+			assert.Contains(t, msg, "Upload: 5 bytes")
+			assert.Contains(t, msg, "Storage: 5 bytes used")
+			_, err = f.Cmd(552, "ALLO 4")
+			assert.NoError(t, err)
+
+			assert.Error(t, f.Stor("big.txt", strings.NewReader("too large")))
+
+			bs, err := ioutil.ReadFile("./testdata/users/alice/hello.txt")
+			assert.NoError(t, err)
+			assert.EqualValues(t, "hello", string(bs))
+
+			// Usage follows deletes and overwrites
+			assert.NoError(t, f.Dele("big.txt"))
+			assert.NoError(t, f.Stor("hello.txt", strings.NewReader("hi")))
+			msg, err = f.Cmd(211, "SITE QUOTA")
+			assert.NoError(t, err)
+			assert.Contains(t, msg, "Storage: 6 of 8 bytes remaining")
+			assert.NoError(t, f.Dele("hello.txt"))
+			msg, err = f.Cmd(211, "SITE QUOTA")
+			assert.NoError(t, err)
+			assert.Contains(t, msg, "Storage: 8 of 8 bytes remaining")
+
+			// Files grown outside of the driver may take usage over quota
+			assert.NoError(t, ioutil.WriteFile("./testdata/users/alice/outside.txt", []byte("0123456789"), os.ModePerm))
+			driver.ResetUsage("alice")
+			msg, err = f.Cmd(211, "SITE QUOTA")
+			assert.NoError(t, err)
+			assert.Contains(t, msg, "Storage: 0 of 10 bytes remaining")
+			assert.Error(t, f.Stor("more.txt", strings.NewReader("x")))
+			_, err = os.Stat("./testdata/users/alice/more.txt")
+			assert.True(t, os.IsNotExist(err))
+			assert.NoError(t, f.Dele("outside.txt"))
+			assert.NoError(t, f.Quit())
+
+			f, err = ftptest.Dial("localhost:2124")
+			assert.NoError(t, err)
+			assert.NoError(t, f.Login("bob", "bob"))
+			assert.Error(t, f.Stor("hello.txt", strings.NewReader("hello")))
+
+			names, err := f.Nlst("/")
+			assert.NoError(t, err)
+			assert.Empty(t, names)
+			assert.NoError(t, f.Quit())
+
+			break
+		}
+	})
+}
+
+func TestExpiredUsers(t *testing.T) {
+	base, err := file.NewDriver(t.TempDir())
+	assert.NoError(t, err)
+	hash, err := users.HashPassword("secret")
+	assert.NoError(t, err)
+	store := users.NewMapStore(
+		&users.User{Name: "expired", Password: hash, Perms: users.PermAll, ExpiresAt: time.Now().Add(-time.Hour)},
+		&users.User{Name: "expiring", Password: hash, Perms: users.PermAll, ExpiresAt: time.Now().Add(time.Hour)},
+		&users.User{Name: "changing", Password: hash, Perms: users.PermAll, MustChangePassword: true},
+	)
+
+	server, err := ftp.NewServer(&ftp.Options{
+		Driver: users.NewDriver(store, base),
+		Perm:   users.NewPerm("ftp", "ftp"),
+		Logger: new(ftp.DiscardLogger),
+
+		ResumeTicketTTL: time.Minute,
+	})
+	assert.NoError(t, err)
+	expired := make(chan string, 1)
+	server.RegisterNotifier(&ftp.NotifierFuncs{
+		OnAccountExpiredFunc: func(ctx *ftp.Context, userName string) {
+			expired <- userName
+		},
+	})
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	go server.Serve(l)
+	defer server.Shutdown()
+
+	c, err := ftptest.Dial(l.Addr().String())
+	assert.NoError(t, err)
+	defer c.Close()
+
+	// A wrong password does not tell the account expired
+	_, err = c.Cmd(331, "USER expired")
+	assert.NoError(t, err)
+	_, err = c.Cmd(530, "PASS wrong")
+	assert.NoError(t, err)
+	assert.Empty(t, expired)
+
+	_, err = c.Cmd(331, "USER expired")
+	assert.NoError(t, err)
+	msg, err := c.Cmd(530, "PASS secret")
+	assert.NoError(t, err)
+	assert.Contains(t, msg, "Account expired")
+	assert.Equal(t, "expired", <-expired)
+
+	_, err = c.Cmd(331, "USER changing")
+	assert.NoError(t, err)
+	_, err = c.Cmd(532, "PASS secret")
+	assert.NoError(t, err)
+
+	assert.NoError(t, c.Login("expiring", "secret"))
+
+	// Resuming the session checks the account again
+	msg, err = c.Cmd(200, "SITE TICKET")
+	assert.NoError(t, err)
+	assert.NoError(t, c.Close())
+	store.Add(&users.User{Name: "expiring", Password: hash, Perms: users.PermAll, ExpiresAt: time.Now().Add(-time.Minute)})
+	c, err = ftptest.Dial(l.Addr().String())
+	assert.NoError(t, err)
+	defer c.Close()
+	msg, err = c.Cmd(530, "SITE RESUME %s", strings.Fields(msg)[1])
+	assert.NoError(t, err)
+	assert.Contains(t, msg, "Account expired")
+	assert.Equal(t, "expiring", <-expired)
+}
@@ -0,0 +1,125 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/globalcyberalliance/ftp-go"
+	"github.com/globalcyberalliance/ftp-go/driver/file"
+	"github.com/globalcyberalliance/ftp-go/ftptest"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeCertificate writes a self-signed certificate for name and its key
+// to dir.
+func writeCertificate(t *testing.T, dir, name string) (certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	certFile = filepath.Join(dir, name+".pem")
+	keyFile = filepath.Join(dir, name+".key")
+	assert.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	assert.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+func TestVirtualHosts(t *testing.T) {
+	certs := t.TempDir()
+	certFile, keyFile := writeCertificate(t, certs, "default.example")
+	tenantCert, tenantKey := writeCertificate(t, certs, "tenant.example")
+
+	driver, err := file.NewDriver(t.TempDir())
+	assert.NoError(t, err)
+	tenantRoot := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(tenantRoot, "tenant.txt"), []byte("tenant"), 0o600))
+	tenantDriver, err := file.NewDriver(tenantRoot)
+	assert.NoError(t, err)
+
+	addr, cleanup := ftptest.NewServer(driver, &ftp.Options{
+		TLS:          true,
+		ExplicitFTPS: true,
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		VirtualHosts: map[string]ftp.VirtualHost{
+			"Tenant.example": {Driver: tenantDriver, CertFile: tenantCert, KeyFile: tenantKey},
+		},
+	})
+	defer cleanup()
+
+	for _, test := range []struct {
+		name       string
+		host       string
+		serverName string
+		cert       string
+		files      []string
+	}{
+		{name: "default", cert: "default.example", files: nil},
+		{name: "HOST", host: "tenant.example", cert: "tenant.example", files: []string{"tenant.txt"}},
+		{name: "SNI", serverName: "tenant.example", cert: "tenant.example", files: []string{"tenant.txt"}},
+		{name: "unknown SNI", serverName: "other.example", cert: "default.example", files: nil},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			c, err := ftptest.Dial(addr)
+			assert.NoError(t, err)
+			defer c.Close()
+
+			if test.host != "" {
+				_, err = c.Cmd(220, "HOST %s", test.host)
+				assert.NoError(t, err)
+			}
+			var cert string
+			assert.NoError(t, c.AuthTLS(&tls.Config{
+				ServerName:         test.serverName,
+				InsecureSkipVerify: true,
+				VerifyConnection: func(state tls.ConnectionState) error {
+					cert = state.PeerCertificates[0].Subject.CommonName
+					return nil
+				},
+			}))
+			assert.Equal(t, test.cert, cert)
+
+			assert.NoError(t, c.Login(ftptest.Username, ftptest.Password))
+			files, err := c.Nlst("/")
+			assert.NoError(t, err)
+			assert.Equal(t, test.files, files)
+		})
+	}
+
+	c, err := ftptest.Dial(addr)
+	assert.NoError(t, err)
+	defer c.Close()
+	_, err = c.Cmd(504, "HOST unknown.example")
+	assert.NoError(t, err)
+	_, err = c.Cmd(331, "USER %s", ftptest.Username)
+	assert.NoError(t, err)
+	_, err = c.Cmd(503, "HOST tenant.example")
+	assert.NoError(t, err)
+}
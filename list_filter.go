@@ -0,0 +1,115 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ListFilter selects the entries hidden from directory listings.
+type ListFilter uint8
+
+const (
+	// HidePartialUploads hides the ".name.<session ID>.part" files of
+	// uploads in progress, see Options.AtomicUploads.
+	HidePartialUploads ListFilter = 1 << iota
+	// HideDotFiles hides names starting with a dot, unless the client
+	// asks for them with LIST -a.
+	HideDotFiles
+)
+
+var listFilterNames = []struct {
+	filter ListFilter
+	name   string
+}{
+	{HidePartialUploads, "partial"},
+	{HideDotFiles, "dotfiles"},
+}
+
+// hides reports whether an entry called name is left out of a listing,
+// all is set when the client listed with -a.
+func (f ListFilter) hides(name string, all bool) bool {
+	if f&HidePartialUploads != 0 && isPartialUpload(name) {
+		return true
+	}
+	return f&HideDotFiles != 0 && !all && strings.HasPrefix(name, ".")
+}
+
+// String returns the comma separated names of the filters, "partial" and
+// "dotfiles".
+func (f ListFilter) String() string {
+	var names []string
+	for _, n := range listFilterNames {
+		if f&n.filter != 0 {
+			names = append(names, n.name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// ParseListFilter parses a comma separated list of filter names, see
+// ListFilter.String.
+func ParseListFilter(s string) (ListFilter, error) {
+	var f ListFilter
+	for _, field := range strings.Split(s, ",") {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if field == "" {
+			continue
+		}
+
+		found := false
+		for _, n := range listFilterNames {
+			if n.name == field {
+				f |= n.filter
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("ftp: unknown list filter %q", field)
+		}
+	}
+	return f, nil
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (f ListFilter) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (f *ListFilter) UnmarshalText(text []byte) error {
+	parsed, err := ParseListFilter(string(text))
+	if err != nil {
+		return err
+	}
+	*f = parsed
+	return nil
+}
+
+// SetListFilter changes the entries hidden from the session's listings
+func (sess *Session) SetListFilter(filter ListFilter) {
+	sess.listFilter = filter
+}
+
+// ListFilter returns the entries hidden from the session's listings
+func (sess *Session) ListFilter() ListFilter {
+	return sess.listFilter
+}
+
+// listAll reports whether the flags in front of a LIST parameter ask for
+// all entries, with -a or -A.
+func listAll(param string) bool {
+	for _, field := range strings.Fields(param) {
+		if !strings.HasPrefix(field, "-") {
+			break
+		}
+		if strings.ContainsAny(field, "aA") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ListFormat selects how LIST formats its entries.
+type ListFormat uint8
+
+const (
+	// ListFormatUnix lists entries as ls -l does.
+	ListFormatUnix ListFormat = iota
+	// ListFormatDOS lists entries as MS-DOS dir and IIS do, for legacy
+	// Windows clients and appliances that only parse that format.
+	ListFormatDOS
+)
+
+var listFormatNames = []string{
+	ListFormatUnix: "unix",
+	ListFormatDOS:  "dos",
+}
+
+// String returns the name of the format, "unix" or "dos".
+func (f ListFormat) String() string {
+	if int(f) < len(listFormatNames) {
+		return listFormatNames[f]
+	}
+	return "ListFormat(" + strconv.Itoa(int(f)) + ")"
+}
+
+// ParseListFormat parses a format name, see ListFormat.String.
+func ParseListFormat(s string) (ListFormat, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for f, name := range listFormatNames {
+		if name == s {
+			return ListFormat(f), nil
+		}
+	}
+	return 0, fmt.Errorf("ftp: unknown list format %q", s)
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (f ListFormat) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (f *ListFormat) UnmarshalText(text []byte) error {
+	parsed, err := ParseListFormat(string(text))
+	if err != nil {
+		return err
+	}
+	*f = parsed
+	return nil
+}
+
+// writer returns the function writing a LIST entry in the format.
+func (f ListFormat) writer(style listStyle) func(io.Writer, FileInfo) error {
+	if f == ListFormatDOS {
+		return style.writeDOS
+	}
+	return style.writeDetailed
+}
+
+// listFormat returns the format sessions start with, Options.ListFormat
+// unless the profile lists otherwise.
+func (server *Server) listFormat() ListFormat {
+	if server.ListFormat == ListFormatUnix && server.Profile != nil {
+		return server.Profile.ListFormat
+	}
+	return server.ListFormat
+}
+
+// SetListFormat changes how the session's LIST formats entries
+func (sess *Session) SetListFormat(format ListFormat) {
+	sess.listFormat = format
+}
+
+// ListFormat returns how the session's LIST formats entries
+func (sess *Session) ListFormat() ListFormat {
+	return sess.listFormat
+}
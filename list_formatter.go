@@ -7,39 +7,122 @@ package ftp
 import (
 	"bytes"
 	"fmt"
+	"io"
+	"os"
 	"strconv"
 	"strings"
 	"time"
 )
 
+// defaultListRecentPeriod is the default Options.ListRecentPeriod.
+const defaultListRecentPeriod = 365 * 24 * time.Hour
+
 type listFormatter []FileInfo
 
-// Short returns a string that lists the collection of files by name only,
-// one per line
-func (formatter listFormatter) Short() []byte {
+// Detailed returns a string that lists the collection of files with extra
+// detail, one per line
+func (formatter listFormatter) Detailed(style listStyle) []byte {
 	var buf bytes.Buffer
 	for _, file := range formatter {
-		fmt.Fprintf(&buf, "%s\r\n", file.Name())
+		_ = style.writeDetailed(&buf, file)
 	}
 	return buf.Bytes()
 }
 
-// Detailed returns a string that lists the collection of files with extra
-// detail, one per line
-func (formatter listFormatter) Detailed() []byte {
+// listStyle is how listings and MDTM show times, see Options.TimeLocation.
+type listStyle struct {
+	loc        *time.Location
+	recent     time.Time // LIST shows the year of older times
+	alwaysYear bool
+}
+
+// listStyle returns the style of the session's listings
+func (sess *Session) listStyle() listStyle {
+	return listStyle{
+		loc:        sess.server.TimeLocation,
+		recent:     time.Now().Add(-sess.server.ListRecentPeriod),
+		alwaysYear: sess.server.ListAlwaysYear,
+	}
+}
+
+// time returns t in the time zone of the style.
+func (style listStyle) time(t time.Time) time.Time {
+	if style.loc != nil {
+		return t.In(style.loc)
+	}
+	return t
+}
+
+// writeShort writes the name of file on a line, as NLST lists it.
+func writeShort(w io.Writer, file FileInfo) error {
+	_, err := fmt.Fprintf(w, "%s\r\n", file.Name())
+	return err
+}
+
+// writeDetailed writes file on a line as ls -l does, as LIST lists it.
+func (style listStyle) writeDetailed(w io.Writer, file FileInfo) error {
 	var buf bytes.Buffer
-	for _, file := range formatter {
-		fmt.Fprint(&buf, file.Mode().String())
-		fmt.Fprintf(&buf, " 1 %s %s ", file.Owner(), file.Group())
-		fmt.Fprint(&buf, lpad(strconv.FormatInt(file.Size(), 10), 12))
-		if file.ModTime().Before(time.Now().AddDate(-1, 0, 0)) {
-			fmt.Fprint(&buf, file.ModTime().Format(" Jan _2  2006 "))
-		} else {
-			fmt.Fprint(&buf, file.ModTime().Format(" Jan _2 15:04 "))
+	fmt.Fprint(&buf, unixMode(file.Mode()))
+	fmt.Fprintf(&buf, " 1 %s %s ", file.Owner(), file.Group())
+	fmt.Fprint(&buf, lpad(strconv.FormatInt(file.Size(), 10), 12))
+	modTime := style.time(file.ModTime())
+	if style.alwaysYear || modTime.Before(style.recent) {
+		fmt.Fprint(&buf, modTime.Format(" Jan _2  2006 "))
+	} else {
+		fmt.Fprint(&buf, modTime.Format(" Jan _2 15:04 "))
+	}
+	fmt.Fprint(&buf, file.Name())
+	if target := linkTarget(file); target != "" {
+		fmt.Fprintf(&buf, " -> %s", target)
+	}
+	fmt.Fprint(&buf, "\r\n")
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// writeDOS writes file on a line as MS-DOS dir does.
+func (style listStyle) writeDOS(w io.Writer, file FileInfo) error {
+	size := "      <DIR>         "
+	if !file.IsDir() {
+		size = lpad(strconv.FormatInt(file.Size(), 10), 20)
+	}
+	_, err := fmt.Fprintf(w, "%s %s %s\r\n", style.time(file.ModTime()).Format("01-02-06  03:04PM"), size, file.Name())
+	return err
+}
+
+// writeMLSD writes the facts and name of file on a line, as MLSD lists it.
+func (style listStyle) writeMLSD(w io.Writer, file FileInfo) error {
+	fileType := "file"
+	if file.IsDir() {
+		fileType = "dir"
+	} else if file.Mode()&os.ModeSymlink != 0 {
+		fileType = "OS.unix=symlink"
+		if target := linkTarget(file); target != "" {
+			fileType = "OS.unix=slink:" + target
 		}
-		fmt.Fprintf(&buf, "%s\r\n", file.Name())
 	}
-	return buf.Bytes()
+	/*Possible facts "Size" / "Modify" / "Create" /
+			  "Type" / "Unique" / "Perm" /
+			  "Lang" / "Media-Type" / "CharSet"
+			  TODO: Perm pvals        = "a" / "c" / "d" / "e" / "f" /
+	                     "l" / "m" / "p" / "r" / "w"
+	*/
+	_, err := fmt.Fprintf(w,
+		"Type=%s;Modify=%s;Size=%d; %s\n",
+		fileType,
+		style.time(file.ModTime()).Format("20060102150405"),
+		file.Size(),
+		file.Name(),
+	)
+	return err
+}
+
+// unixMode formats mode as ls does, Go spells symbolic links "L".
+func unixMode(mode os.FileMode) string {
+	if mode&os.ModeSymlink != 0 {
+		return "l" + mode.Perm().String()[1:]
+	}
+	return mode.String()
 }
 
 func lpad(input string, length int) (result string) {
@@ -0,0 +1,52 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestListStyle(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "file.txt")
+	if err := os.WriteFile(p, []byte("test"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Now().Add(-2 * time.Hour).In(time.FixedZone("UTC+5", 5*3600))
+	if err := os.Chtimes(p, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := &fileInfo{FileInfo: info, mode: 0o644, owner: "test", group: "test"}
+
+	for _, test := range []struct {
+		style listStyle
+		want  string
+	}{
+		{listStyle{loc: time.UTC, recent: time.Now().Add(-time.Hour)}, modTime.UTC().Format(" Jan _2  2006 ")},
+		{listStyle{loc: time.UTC, recent: time.Now().Add(-24 * time.Hour)}, modTime.UTC().Format(" Jan _2 15:04 ")},
+		{listStyle{loc: time.UTC, recent: time.Now().Add(-24 * time.Hour), alwaysYear: true}, modTime.UTC().Format(" Jan _2  2006 ")},
+	} {
+		var b strings.Builder
+		if err := test.style.writeDetailed(&b, file); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(b.String(), test.want+"file.txt\r\n") {
+			t.Errorf("listed %q, want the time %q", b.String(), test.want)
+		}
+	}
+
+	var b strings.Builder
+	_ = listStyle{loc: time.UTC}.writeMLSD(&b, file)
+	if want := "Modify=" + modTime.UTC().Format("20060102150405") + ";"; !strings.Contains(b.String(), want) {
+		t.Errorf("listed %q, want %q", b.String(), want)
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Bounds of the delay Serve retries accepting connections after a temporary
+// error, doubled at each failure in a row.
+const (
+	minAcceptRetryDelay = 5 * time.Millisecond
+	maxAcceptRetryDelay = time.Second
+)
+
+// isTemporaryAcceptError reports whether accepting connections may succeed
+// again after err, as when the process ran out of file descriptors.
+func isTemporaryAcceptError(err error) bool {
+	var temp interface{ Temporary() bool }
+	return errors.As(err, &temp) && temp.Temporary()
+}
+
+// listen listens on Options.Hostname. A DNS name is resolved and listened
+// on at every address, so that clients reach the server over IPv4 and IPv6
+// alike. Addresses which can't be listened on, as IPv6 ones on hosts
+// without IPv6, are skipped as long as one can.
+func (server *Server) listen() (net.Listener, error) {
+	host := server.Hostname
+	if host == "" || net.ParseIP(host) != nil {
+		return net.Listen("tcp", server.listenTo)
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(context.Background(), host)
+	if err != nil {
+		return nil, err
+	}
+
+	port := server.Port
+	seen := make(map[string]bool)
+	var listeners []net.Listener
+	var errs []error
+	for _, addr := range addrs {
+		if seen[addr.String()] {
+			continue
+		}
+		seen[addr.String()] = true
+
+		l, err := net.Listen("tcp", net.JoinHostPort(addr.String(), strconv.Itoa(port)))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		// A port picked for the first address is used for all
+		if port == 0 {
+			port = l.Addr().(*net.TCPAddr).Port
+		}
+		listeners = append(listeners, l)
+	}
+
+	if len(listeners) == 0 {
+		if len(errs) == 0 {
+			return nil, &net.DNSError{Err: "no addresses", Name: host}
+		}
+		return nil, errors.Join(errs...)
+	}
+	for _, err := range errs {
+		server.logger.Printf("", "not listening: %v", err)
+	}
+	if len(listeners) == 1 {
+		return listeners[0], nil
+	}
+	return newMultiListener(listeners), nil
+}
+
+// multiListener accepts the connections of several listeners
+type multiListener struct {
+	listeners []net.Listener
+	conns     chan net.Conn
+	errs      chan error
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newMultiListener(listeners []net.Listener) *multiListener {
+	l := &multiListener{
+		listeners: listeners,
+		conns:     make(chan net.Conn),
+		errs:      make(chan error),
+		done:      make(chan struct{}),
+	}
+	for _, listener := range listeners {
+		go l.accept(listener)
+	}
+	return l
+}
+
+// accept forwards the connections of listener until it is closed. Other
+// errors are forwarded too, and Accept is retried after a delay doubled at
+// each failure in a row, as Server.Serve does.
+func (l *multiListener) accept(listener net.Listener) {
+	var tempDelay time.Duration
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			select {
+			case l.errs <- err:
+			case <-l.done:
+				return
+			}
+			if tempDelay == 0 {
+				tempDelay = minAcceptRetryDelay
+			} else if tempDelay *= 2; tempDelay > maxAcceptRetryDelay {
+				tempDelay = maxAcceptRetryDelay
+			}
+			timer := time.NewTimer(tempDelay)
+			select {
+			case <-timer.C:
+			case <-l.done:
+				timer.Stop()
+				return
+			}
+			continue
+		}
+		tempDelay = 0
+		select {
+		case l.conns <- conn:
+		case <-l.done:
+			conn.Close()
+			return
+		}
+	}
+}
+
+// Accept implements net.Listener
+func (l *multiListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case err := <-l.errs:
+		return nil, err
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+// Close implements net.Listener, closing every listener
+func (l *multiListener) Close() error {
+	err := net.ErrClosed
+	l.closeOnce.Do(func() {
+		close(l.done)
+		var errs []error
+		for _, listener := range l.listeners {
+			errs = append(errs, listener.Close())
+		}
+		err = errors.Join(errs...)
+	})
+	return err
+}
+
+// Addr implements net.Listener, returning the address of the first listener
+func (l *multiListener) Addr() net.Addr {
+	return l.listeners[0].Addr()
+}
@@ -0,0 +1,143 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"errors"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestListenHostname(t *testing.T) {
+	server := &Server{
+		Options: &Options{Hostname: "localhost"},
+		logger:  new(DiscardLogger),
+	}
+	l, err := server.listen()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	// Every address localhost resolves to is listened on, at the same port
+	port := l.Addr().(*net.TCPAddr).Port
+	addrs, err := net.LookupIP("localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ip := range addrs {
+		addr := &net.TCPAddr{IP: ip, Port: port}
+		conn, err := net.DialTCP("tcp", nil, addr)
+		if err != nil {
+			// No IPv6 on this host
+			continue
+		}
+		accepted, err := l.Accept()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !accepted.LocalAddr().(*net.TCPAddr).IP.Equal(ip) {
+			t.Errorf("connection to %s accepted on %s", addr, accepted.LocalAddr())
+		}
+		accepted.Close()
+		conn.Close()
+	}
+
+	if err = l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = l.Accept(); !errors.Is(err, net.ErrClosed) {
+		t.Fatalf("expected net.ErrClosed once closed, got %v", err)
+	}
+}
+
+// failingListener fails to accept temporarily failures times, then for good
+type failingListener struct {
+	net.Listener
+	failures int
+}
+
+func (l *failingListener) Accept() (net.Conn, error) {
+	if l.failures == 0 {
+		return nil, errors.New("listener broken")
+	}
+	l.failures--
+	return nil, &net.OpError{Op: "accept", Net: "tcp", Err: os.NewSyscallError("accept", syscall.EMFILE)}
+}
+
+func TestServeAcceptBackoff(t *testing.T) {
+	var persistent []error
+	s, err := NewServer(&Options{
+		Perm:   NewSimplePerm("test", "test"),
+		Logger: new(DiscardLogger),
+		AcceptErrorCallback: func(err error) {
+			persistent = append(persistent, err)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	// Retrying backs off from 5ms to a second over 9 failures in a row, only
+	// the last of which is persistent
+	err = s.Serve(&failingListener{Listener: l, failures: 9})
+	if err == nil || err.Error() != "listener broken" {
+		t.Fatalf("Serve returned %v, want the error which isn't temporary", err)
+	}
+	if len(persistent) != 1 || !errors.Is(persistent[0], syscall.EMFILE) {
+		t.Errorf("persistent errors %v, want a single EMFILE", persistent)
+	}
+}
+
+// flakyListener fails to accept temporarily failures times, then accepts
+type flakyListener struct {
+	net.Listener
+	failures int
+}
+
+func (l *flakyListener) Accept() (net.Conn, error) {
+	if l.failures > 0 {
+		l.failures--
+		return nil, &net.OpError{Op: "accept", Net: "tcp", Err: os.NewSyscallError("accept", syscall.EMFILE)}
+	}
+	return l.Listener.Accept()
+}
+
+func TestMultiListenerRetries(t *testing.T) {
+	var listeners []net.Listener
+	for i := 0; i < 2; i++ {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		listeners = append(listeners, l)
+	}
+	l := newMultiListener([]net.Listener{&flakyListener{Listener: listeners[0], failures: 2}, listeners[1]})
+	defer l.Close()
+
+	// The errors are passed on, and the listener still accepts afterwards
+	for i := 0; i < 2; i++ {
+		if _, err := l.Accept(); !errors.Is(err, syscall.EMFILE) {
+			t.Fatalf("got %v, want EMFILE", err)
+		}
+	}
+	conn, err := net.Dial("tcp", listeners[0].Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	accepted, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	accepted.Close()
+}
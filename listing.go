@@ -0,0 +1,138 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// ErrListTruncated is passed to ListNotifier.AfterListDir when a listing
+// was cut at Options.MaxListEntries. It ends the listing early when
+// returned by the callback handed to Driver.ListDir.
+var ErrListTruncated = errors.New("ftp: listing truncated")
+
+// listing describes a directory listing sent over the data connection.
+type listing struct {
+	cmd     string
+	path    string
+	param   string
+	all     bool // include the entries the list filter hides with -a
+	dirOnly bool // refuse to list a file, as NLST does
+	detail  bool // entries need their owner, group and mode
+	format  func(io.Writer, FileInfo) error
+}
+
+// listDir calls fn with the entries of dir the list filter shows, firing
+// the canaries they touch. It stops with ErrListTruncated once fn accepted
+// Options.MaxListEntries of them.
+func (sess *Session) listDir(ctx *Context, dir string, all bool, fn func(Entry) error) error {
+	listed := 0
+	return ListEntries(ctx, sess.driver(), dir, func(entry Entry) error {
+		if sess.listFilter.hides(entry.Name(), all) {
+			return nil
+		}
+		if max := sess.server.MaxListEntries; max > 0 && listed >= max {
+			return ErrListTruncated
+		}
+		sess.checkCanary(ctx, entry.Path)
+		if err := fn(entry); err != nil {
+			return err
+		}
+		listed++
+		return nil
+	})
+}
+
+// sendListing streams the entries of a directory, or a single file, to the
+// data connection as the driver yields them, so listings of huge
+// directories are never held in memory.
+func (sess *Session) sendListing(l listing) {
+	ctx := &Context{
+		Sess:  sess,
+		Cmd:   l.cmd,
+		Param: l.param,
+		Data:  NewStore(),
+	}
+	entries := 0
+	if sess.dataConn == nil {
+		sess.writeMessage(425, "Can't open data connection")
+		return
+	}
+
+	sess.checkCanary(ctx, l.path)
+	info, err := sess.driver().Stat(ctx, l.path)
+	if err != nil {
+		sess.dataConn.Close()
+		sess.setDataConn(nil)
+		sess.server.notifiers.AfterListDir(ctx, l.path, entries, err)
+		sess.writeError(err, 550, err.Error())
+		return
+	}
+	if info != nil && l.dirOnly && !info.IsDir() {
+		sess.dataConn.Close()
+		sess.setDataConn(nil)
+		sess.server.notifiers.AfterListDir(ctx, l.path, entries, ErrNotDir)
+		sess.writeMessage(550, l.param+" is not a directory")
+		return
+	}
+
+	sess.writeMessage(150, "Opening ASCII mode data connection for file list")
+	w := bufio.NewWriter(sess.dataConn)
+
+	var writeErr error
+	emit := func(f os.FileInfo, p string) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var file FileInfo = &fileInfo{FileInfo: f, mode: f.Mode()}
+		if l.detail {
+			var err error
+			if file, err = convertFileInfo(sess, ctx, f, p); err != nil {
+				return err
+			}
+		}
+		if writeErr = l.format(w, file); writeErr != nil {
+			return writeErr
+		}
+		entries++
+		return nil
+	}
+
+	switch {
+	case info == nil:
+		sess.logf("%s: no such file or directory.\n", l.path)
+	case info.IsDir():
+		err = sess.listDir(ctx, l.path, l.all, func(entry Entry) error {
+			return emit(entry.FileInfo, entry.Path)
+		})
+	default:
+		err = emit(info, l.path)
+	}
+	if err == nil || errors.Is(err, ErrListTruncated) {
+		if flushErr := w.Flush(); flushErr != nil {
+			writeErr, err = flushErr, flushErr
+		}
+	}
+	sess.dataConn.Close()
+	sess.setDataConn(nil)
+	sess.server.notifiers.AfterListDir(ctx, l.path, entries, err)
+
+	switch {
+	case err == nil:
+		sess.writeMessage(226, "Closing data connection, sent "+strconv.Itoa(entries)+" entries")
+	case errors.Is(err, ErrListTruncated):
+		sess.writeMessage(226, fmt.Sprintf("Closing data connection, listing truncated at %d entries", entries))
+	case writeErr != nil:
+		sess.writeMessage(426, "Connection closed; transfer aborted")
+	default:
+		sess.writeError(err, 451, err.Error())
+	}
+}
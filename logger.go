@@ -7,6 +7,8 @@ package ftp
 import (
 	"fmt"
 	"log"
+	"log/slog"
+	"strings"
 )
 
 // Logger represents an interface to record all ftp information and command
@@ -17,6 +19,21 @@ type Logger interface {
 	PrintResponse(sessionID string, code int, message string)
 }
 
+// LogFields identify the session a message is logged for
+type LogFields struct {
+	SessionID string
+	User      string // empty until logged in
+	RemoteIP  string
+}
+
+// FieldLogger is a Logger which takes the fields of the session along with
+// its messages. Sessions call WithFields for every message and log it to
+// the Logger returned, so that the user is current.
+type FieldLogger interface {
+	Logger
+	WithFields(fields LogFields) Logger
+}
+
 // StdLogger use an instance of this to log in a standard format
 type StdLogger struct{}
 
@@ -58,3 +75,55 @@ func (logger *DiscardLogger) PrintCommand(sessionID string, command string, para
 
 // PrintResponse implements Logger
 func (logger *DiscardLogger) PrintResponse(sessionID string, code int, message string) {}
+
+// SlogLogger logs to a slog.Logger, with the fields of sessions as
+// attributes
+type SlogLogger struct {
+	Logger *slog.Logger
+	// the fields were added to Logger, see WithFields
+	fields bool
+}
+
+// NewSlogLogger returns a SlogLogger logging to logger
+func NewSlogLogger(logger *slog.Logger) *SlogLogger {
+	return &SlogLogger{Logger: logger}
+}
+
+// WithFields implements FieldLogger
+func (logger *SlogLogger) WithFields(fields LogFields) Logger {
+	attrs := []any{slog.String("session", fields.SessionID), slog.String("remote_ip", fields.RemoteIP)}
+	if fields.User != "" {
+		attrs = append(attrs, slog.String("user", fields.User))
+	}
+	return &SlogLogger{Logger: logger.Logger.With(attrs...), fields: true}
+}
+
+func (logger *SlogLogger) log(sessionID string, message string, attrs ...any) {
+	if !logger.fields && sessionID != "" {
+		attrs = append(attrs, slog.String("session", sessionID))
+	}
+	logger.Logger.Info(message, attrs...)
+}
+
+// Print implements Logger
+func (logger *SlogLogger) Print(sessionID string, message interface{}) {
+	logger.log(sessionID, fmt.Sprint(message))
+}
+
+// Printf implements Logger
+func (logger *SlogLogger) Printf(sessionID string, format string, v ...interface{}) {
+	logger.log(sessionID, fmt.Sprintf(format, v...))
+}
+
+// PrintCommand implements Logger
+func (logger *SlogLogger) PrintCommand(sessionID string, command string, params string) {
+	if strings.EqualFold(command, "PASS") {
+		params = "****"
+	}
+	logger.log(sessionID, "command", slog.String("command", command), slog.String("params", params))
+}
+
+// PrintResponse implements Logger
+func (logger *SlogLogger) PrintResponse(sessionID string, code int, message string) {
+	logger.log(sessionID, "response", slog.Int("code", code), slog.String("message", message))
+}
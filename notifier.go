@@ -4,116 +4,369 @@
 
 package ftp
 
-// Notifier represents a notification operator interface
+// Notifier represents a notification operator interface. Server.RegisterNotifier
+// also accepts a value implementing only some of the interfaces below, embed
+// NullNotifier or use NotifierFuncs to implement only the hooks you need.
+// RenameNotifier, RenameConflictNotifier, ListNotifier, AbortNotifier,
+// DisconnectNotifier, CanaryNotifier, PanicNotifier and
+// AccountExpiredNotifier are not part of Notifier and are only called when
+// implemented.
 type Notifier interface {
-	BeforeCommand(ctx *Context, command string)
-	BeforeLoginUser(ctx *Context, userName string)
-	BeforePutFile(ctx *Context, dstPath string)
-	BeforeDeleteFile(ctx *Context, dstPath string)
-	BeforeChangeCurDir(ctx *Context, oldCurDir, newCurDir string)
-	BeforeCreateDir(ctx *Context, dstPath string)
-	BeforeDeleteDir(ctx *Context, dstPath string)
-	BeforeDownloadFile(ctx *Context, dstPath string)
-	AfterUserLogin(ctx *Context, userName, password string, passMatched bool, err error)
-	AfterFilePut(ctx *Context, dstPath string, size int64, err error)
-	AfterFileDeleted(ctx *Context, dstPath string, err error)
-	AfterFileDownloaded(ctx *Context, dstPath string, size int64, err error)
-	AfterCurDirChanged(ctx *Context, oldCurDir, newCurDir string, err error)
-	AfterDirCreated(ctx *Context, dstPath string, err error)
-	AfterDirDeleted(ctx *Context, dstPath string, err error)
+	CommandNotifier
+	LoginNotifier
+	TransferNotifier
+	FileNotifier
+	DirNotifier
 }
 
-type notifierList []Notifier
+type (
+	// CommandNotifier is notified of every command received.
+	CommandNotifier interface {
+		BeforeCommand(ctx *Context, command string)
+	}
 
-var _ Notifier = notifierList{}
+	// LoginNotifier is notified of login attempts.
+	LoginNotifier interface {
+		BeforeLoginUser(ctx *Context, userName string)
+		AfterUserLogin(ctx *Context, userName, password string, passMatched bool, err error)
+	}
 
-func (notifiers notifierList) BeforeCommand(ctx *Context, command string) {
-	for _, notifier := range notifiers {
-		notifier.BeforeCommand(ctx, command)
+	// TransferNotifier is notified of uploads and downloads.
+	TransferNotifier interface {
+		BeforePutFile(ctx *Context, dstPath string)
+		AfterFilePut(ctx *Context, dstPath string, size int64, err error)
+		BeforeDownloadFile(ctx *Context, dstPath string)
+		AfterFileDownloaded(ctx *Context, dstPath string, size int64, err error)
 	}
-}
 
-func (notifiers notifierList) BeforeLoginUser(ctx *Context, userName string) {
-	for _, notifier := range notifiers {
-		notifier.BeforeLoginUser(ctx, userName)
+	// FileNotifier is notified of deleted files.
+	FileNotifier interface {
+		BeforeDeleteFile(ctx *Context, dstPath string)
+		AfterFileDeleted(ctx *Context, dstPath string, err error)
 	}
-}
 
-func (notifiers notifierList) BeforePutFile(ctx *Context, dstPath string) {
-	for _, notifier := range notifiers {
-		notifier.BeforePutFile(ctx, dstPath)
+	// DirNotifier is notified of directory changes, creations and deletions.
+	DirNotifier interface {
+		BeforeChangeCurDir(ctx *Context, oldCurDir, newCurDir string)
+		AfterCurDirChanged(ctx *Context, oldCurDir, newCurDir string, err error)
+		BeforeCreateDir(ctx *Context, dstPath string)
+		AfterDirCreated(ctx *Context, dstPath string, err error)
+		BeforeDeleteDir(ctx *Context, dstPath string)
+		AfterDirDeleted(ctx *Context, dstPath string, err error)
 	}
-}
 
-func (notifiers notifierList) BeforeDeleteFile(ctx *Context, dstPath string) {
-	for _, notifier := range notifiers {
-		notifier.BeforeDeleteFile(ctx, dstPath)
+	// RenameNotifier is notified of renamed files and directories.
+	RenameNotifier interface {
+		BeforeRename(ctx *Context, fromPath, toPath string)
+		AfterRename(ctx *Context, fromPath, toPath string, err error)
 	}
-}
 
-func (notifiers notifierList) BeforeChangeCurDir(ctx *Context, oldCurDir, newCurDir string) {
-	for _, notifier := range notifiers {
-		notifier.BeforeChangeCurDir(ctx, oldCurDir, newCurDir)
+	// RenameConflictNotifier is notified when the target of RNTO exists
+	// and Options.RenamePolicy rejected, overwrote or versioned it. err is
+	// the reason the rename is refused, if it is.
+	RenameConflictNotifier interface {
+		OnRenameConflict(ctx *Context, conflict *RenameConflict, err error)
 	}
-}
 
-func (notifiers notifierList) BeforeCreateDir(ctx *Context, dstPath string) {
-	for _, notifier := range notifiers {
-		notifier.BeforeCreateDir(ctx, dstPath)
+	// ListNotifier is notified of directory listings sent with LIST, NLST
+	// and MLSD, entries is the number of entries listed.
+	ListNotifier interface {
+		AfterListDir(ctx *Context, dirPath string, entries int, err error)
 	}
-}
 
-func (notifiers notifierList) BeforeDeleteDir(ctx *Context, dstPath string) {
-	for _, notifier := range notifiers {
-		notifier.BeforeDeleteDir(ctx, dstPath)
+	// AbortNotifier is notified of transfers aborted by the client with
+	// ABOR, size is the number of bytes transferred until then.
+	AbortNotifier interface {
+		AfterTransferAborted(ctx *Context, dstPath string, size int64)
 	}
-}
 
-func (notifiers notifierList) BeforeDownloadFile(ctx *Context, dstPath string) {
-	for _, notifier := range notifiers {
-		notifier.BeforeDownloadFile(ctx, dstPath)
+	// DisconnectNotifier is notified when a control connection is closed.
+	DisconnectNotifier interface {
+		OnDisconnect(ctx *Context)
+	}
+
+	// CanaryNotifier is notified when RETR, STOR, APPE or a listing touches
+	// one of Options.CanaryPaths. It is always called synchronously, before
+	// the command goes on.
+	CanaryNotifier interface {
+		OnCanary(ctx *Context, event *CanaryEvent)
 	}
-}
 
-func (notifiers notifierList) AfterUserLogin(ctx *Context, userName, password string, passMatched bool, err error) {
-	for _, notifier := range notifiers {
-		notifier.AfterUserLogin(ctx, userName, password, passMatched, err)
+	// PanicNotifier is notified of the panics recovered while serving a
+	// session, by a command or outside of one. It is always called
+	// synchronously, before the client is answered.
+	PanicNotifier interface {
+		OnPanic(ctx *Context, event *PanicEvent)
 	}
+
+	// AccountExpiredNotifier is notified when a user whose account expired
+	// tries to log in, that is when Auth returned ErrAccountExpired.
+	AccountExpiredNotifier interface {
+		OnAccountExpired(ctx *Context, userName string)
+	}
+)
+
+// Interceptor may veto the file operations announced by the Before* hooks.
+// Intercept is called right after them with the same path, for the CWD,
+// CDUP, DELE, MKD, RMD, RETR, STOR and APPE commands, for RNTO with the
+// path being renamed and then its new path, for SITE SYMLINK with the link,
+// for SITE HASH and SITE CKSM with the file hashed, for SITE RMDIR with the
+// directory removed and for SITE MVDIR with the directory moved and then
+// its new path, ctx.Cmd tells which.
+// A non-nil error aborts the command: a *ReplyError chooses the reply sent
+// to the client, other errors are answered like driver errors. The After*
+// hook is still called, with that error.
+type Interceptor interface {
+	Intercept(ctx *Context, path string) error
 }
 
-func (notifiers notifierList) AfterFilePut(ctx *Context, dstPath string, size int64, err error) {
-	for _, notifier := range notifiers {
-		notifier.AfterFilePut(ctx, dstPath, size, err)
+// isNotifier reports whether notifier implements at least one of the
+// interfaces above.
+func isNotifier(notifier interface{}) bool {
+	switch notifier.(type) {
+	case CommandNotifier, LoginNotifier, TransferNotifier, FileNotifier, DirNotifier,
+		RenameNotifier, RenameConflictNotifier, ListNotifier, AbortNotifier,
+		DisconnectNotifier, CanaryNotifier, PanicNotifier, AccountExpiredNotifier,
+		Interceptor:
+		return true
 	}
+	return false
+}
+
+// notifierList dispatches each hook to the registered notifiers
+// implementing it.
+type notifierList struct {
+	list []interface{}
+	pool *notifierPool // nil calls the hooks synchronously
 }
 
-func (notifiers notifierList) AfterFileDeleted(ctx *Context, dstPath string, err error) {
-	for _, notifier := range notifiers {
-		notifier.AfterFileDeleted(ctx, dstPath, err)
+var _ Notifier = &notifierList{}
+
+// dispatch calls fn with every registered notifier, on the session's
+// worker when notifications are asynchronous. Asynchronous hooks get a
+// snapshot of ctx, as the session goes on with the next commands.
+func (notifiers *notifierList) dispatch(ctx *Context, fn func(ctx *Context, notifier interface{})) {
+	if notifiers.pool != nil {
+		snapshot := ctx.snapshot()
+		if notifiers.pool.enqueue(ctx, func() { notifiers.each(snapshot, fn) }) {
+			return
+		}
 	}
+	notifiers.each(ctx, fn)
 }
 
-func (notifiers notifierList) AfterFileDownloaded(ctx *Context, dstPath string, size int64, err error) {
-	for _, notifier := range notifiers {
-		notifier.AfterFileDownloaded(ctx, dstPath, size, err)
+// each calls fn with every registered notifier, synchronously. The Before*
+// hooks always are, as they announce what the command is about to do.
+func (notifiers *notifierList) each(ctx *Context, fn func(ctx *Context, notifier interface{})) {
+	for _, notifier := range notifiers.list {
+		fn(ctx, notifier)
 	}
 }
 
-func (notifiers notifierList) AfterCurDirChanged(ctx *Context, oldCurDir, newCurDir string, err error) {
-	for _, notifier := range notifiers {
-		notifier.AfterCurDirChanged(ctx, oldCurDir, newCurDir, err)
+func (notifiers *notifierList) BeforeCommand(ctx *Context, command string) {
+	notifiers.each(ctx, func(ctx *Context, notifier interface{}) {
+		if notifier, ok := notifier.(CommandNotifier); ok {
+			notifier.BeforeCommand(ctx, command)
+		}
+	})
+}
+
+func (notifiers *notifierList) BeforeLoginUser(ctx *Context, userName string) {
+	notifiers.each(ctx, func(ctx *Context, notifier interface{}) {
+		if notifier, ok := notifier.(LoginNotifier); ok {
+			notifier.BeforeLoginUser(ctx, userName)
+		}
+	})
+}
+
+func (notifiers *notifierList) AfterUserLogin(ctx *Context, userName, password string, passMatched bool, err error) {
+	notifiers.dispatch(ctx, func(ctx *Context, notifier interface{}) {
+		if notifier, ok := notifier.(LoginNotifier); ok {
+			notifier.AfterUserLogin(ctx, userName, password, passMatched, err)
+		}
+	})
+}
+
+func (notifiers *notifierList) BeforePutFile(ctx *Context, dstPath string) {
+	notifiers.each(ctx, func(ctx *Context, notifier interface{}) {
+		if notifier, ok := notifier.(TransferNotifier); ok {
+			notifier.BeforePutFile(ctx, dstPath)
+		}
+	})
+}
+
+func (notifiers *notifierList) AfterFilePut(ctx *Context, dstPath string, size int64, err error) {
+	notifiers.dispatch(ctx, func(ctx *Context, notifier interface{}) {
+		if notifier, ok := notifier.(TransferNotifier); ok {
+			notifier.AfterFilePut(ctx, dstPath, size, err)
+		}
+	})
+}
+
+func (notifiers *notifierList) BeforeDownloadFile(ctx *Context, dstPath string) {
+	notifiers.each(ctx, func(ctx *Context, notifier interface{}) {
+		if notifier, ok := notifier.(TransferNotifier); ok {
+			notifier.BeforeDownloadFile(ctx, dstPath)
+		}
+	})
+}
+
+func (notifiers *notifierList) AfterFileDownloaded(ctx *Context, dstPath string, size int64, err error) {
+	notifiers.dispatch(ctx, func(ctx *Context, notifier interface{}) {
+		if notifier, ok := notifier.(TransferNotifier); ok {
+			notifier.AfterFileDownloaded(ctx, dstPath, size, err)
+		}
+	})
+}
+
+func (notifiers *notifierList) BeforeDeleteFile(ctx *Context, dstPath string) {
+	notifiers.each(ctx, func(ctx *Context, notifier interface{}) {
+		if notifier, ok := notifier.(FileNotifier); ok {
+			notifier.BeforeDeleteFile(ctx, dstPath)
+		}
+	})
+}
+
+func (notifiers *notifierList) AfterFileDeleted(ctx *Context, dstPath string, err error) {
+	notifiers.dispatch(ctx, func(ctx *Context, notifier interface{}) {
+		if notifier, ok := notifier.(FileNotifier); ok {
+			notifier.AfterFileDeleted(ctx, dstPath, err)
+		}
+	})
+}
+
+func (notifiers *notifierList) BeforeChangeCurDir(ctx *Context, oldCurDir, newCurDir string) {
+	notifiers.each(ctx, func(ctx *Context, notifier interface{}) {
+		if notifier, ok := notifier.(DirNotifier); ok {
+			notifier.BeforeChangeCurDir(ctx, oldCurDir, newCurDir)
+		}
+	})
+}
+
+func (notifiers *notifierList) AfterCurDirChanged(ctx *Context, oldCurDir, newCurDir string, err error) {
+	notifiers.dispatch(ctx, func(ctx *Context, notifier interface{}) {
+		if notifier, ok := notifier.(DirNotifier); ok {
+			notifier.AfterCurDirChanged(ctx, oldCurDir, newCurDir, err)
+		}
+	})
+}
+
+func (notifiers *notifierList) BeforeCreateDir(ctx *Context, dstPath string) {
+	notifiers.each(ctx, func(ctx *Context, notifier interface{}) {
+		if notifier, ok := notifier.(DirNotifier); ok {
+			notifier.BeforeCreateDir(ctx, dstPath)
+		}
+	})
+}
+
+func (notifiers *notifierList) AfterDirCreated(ctx *Context, dstPath string, err error) {
+	notifiers.dispatch(ctx, func(ctx *Context, notifier interface{}) {
+		if notifier, ok := notifier.(DirNotifier); ok {
+			notifier.AfterDirCreated(ctx, dstPath, err)
+		}
+	})
+}
+
+func (notifiers *notifierList) BeforeDeleteDir(ctx *Context, dstPath string) {
+	notifiers.each(ctx, func(ctx *Context, notifier interface{}) {
+		if notifier, ok := notifier.(DirNotifier); ok {
+			notifier.BeforeDeleteDir(ctx, dstPath)
+		}
+	})
+}
+
+func (notifiers *notifierList) AfterDirDeleted(ctx *Context, dstPath string, err error) {
+	notifiers.dispatch(ctx, func(ctx *Context, notifier interface{}) {
+		if notifier, ok := notifier.(DirNotifier); ok {
+			notifier.AfterDirDeleted(ctx, dstPath, err)
+		}
+	})
+}
+
+func (notifiers *notifierList) BeforeRename(ctx *Context, fromPath, toPath string) {
+	notifiers.each(ctx, func(ctx *Context, notifier interface{}) {
+		if notifier, ok := notifier.(RenameNotifier); ok {
+			notifier.BeforeRename(ctx, fromPath, toPath)
+		}
+	})
+}
+
+func (notifiers *notifierList) AfterRename(ctx *Context, fromPath, toPath string, err error) {
+	notifiers.dispatch(ctx, func(ctx *Context, notifier interface{}) {
+		if notifier, ok := notifier.(RenameNotifier); ok {
+			notifier.AfterRename(ctx, fromPath, toPath, err)
+		}
+	})
+}
+
+func (notifiers *notifierList) AfterListDir(ctx *Context, dirPath string, entries int, err error) {
+	notifiers.dispatch(ctx, func(ctx *Context, notifier interface{}) {
+		if notifier, ok := notifier.(ListNotifier); ok {
+			notifier.AfterListDir(ctx, dirPath, entries, err)
+		}
+	})
+}
+
+func (notifiers *notifierList) AfterTransferAborted(ctx *Context, dstPath string, size int64) {
+	notifiers.dispatch(ctx, func(ctx *Context, notifier interface{}) {
+		if notifier, ok := notifier.(AbortNotifier); ok {
+			notifier.AfterTransferAborted(ctx, dstPath, size)
+		}
+	})
+}
+
+func (notifiers *notifierList) OnDisconnect(ctx *Context) {
+	notifiers.dispatch(ctx, func(ctx *Context, notifier interface{}) {
+		if notifier, ok := notifier.(DisconnectNotifier); ok {
+			notifier.OnDisconnect(ctx)
+		}
+	})
+}
+
+func (notifiers *notifierList) OnAccountExpired(ctx *Context, userName string) {
+	notifiers.dispatch(ctx, func(ctx *Context, notifier interface{}) {
+		if notifier, ok := notifier.(AccountExpiredNotifier); ok {
+			notifier.OnAccountExpired(ctx, userName)
+		}
+	})
+}
+
+func (notifiers *notifierList) OnRenameConflict(ctx *Context, conflict *RenameConflict, err error) {
+	notifiers.dispatch(ctx, func(ctx *Context, notifier interface{}) {
+		if notifier, ok := notifier.(RenameConflictNotifier); ok {
+			notifier.OnRenameConflict(ctx, conflict, err)
+		}
+	})
+}
+
+// Intercept returns the first error of the registered Interceptors, they
+// are always called synchronously.
+func (notifiers *notifierList) Intercept(ctx *Context, path string) error {
+	for _, notifier := range notifiers.list {
+		if interceptor, ok := notifier.(Interceptor); ok {
+			if err := interceptor.Intercept(ctx, path); err != nil {
+				return err
+			}
+		}
 	}
+	return nil
 }
 
-func (notifiers notifierList) AfterDirCreated(ctx *Context, dstPath string, err error) {
-	for _, notifier := range notifiers {
-		notifier.AfterDirCreated(ctx, dstPath, err)
+// OnCanary calls the registered CanaryNotifiers.
+func (notifiers *notifierList) OnCanary(ctx *Context, event *CanaryEvent) {
+	for _, notifier := range notifiers.list {
+		if notifier, ok := notifier.(CanaryNotifier); ok {
+			notifier.OnCanary(ctx, event)
+		}
 	}
 }
 
-func (notifiers notifierList) AfterDirDeleted(ctx *Context, dstPath string, err error) {
-	for _, notifier := range notifiers {
-		notifier.AfterDirDeleted(ctx, dstPath, err)
+// OnPanic calls the registered PanicNotifiers.
+func (notifiers *notifierList) OnPanic(ctx *Context, event *PanicEvent) {
+	for _, notifier := range notifiers.list {
+		if notifier, ok := notifier.(PanicNotifier); ok {
+			notifier.OnPanic(ctx, event)
+		}
 	}
 }
 
@@ -130,54 +383,289 @@ func (NullNotifier) BeforeCommand(ctx *Context, command string) {
 func (NullNotifier) BeforeLoginUser(ctx *Context, userName string) {
 }
 
+// AfterUserLogin implements Notifier
+func (NullNotifier) AfterUserLogin(ctx *Context, userName, password string, passMatched bool, err error) {
+}
+
 // BeforePutFile implements Notifier
 func (NullNotifier) BeforePutFile(ctx *Context, dstPath string) {
 }
 
+// AfterFilePut implements Notifier
+func (NullNotifier) AfterFilePut(ctx *Context, dstPath string, size int64, err error) {
+}
+
+// BeforeDownloadFile implements Notifier
+func (NullNotifier) BeforeDownloadFile(ctx *Context, dstPath string) {
+}
+
+// AfterFileDownloaded implements Notifier
+func (NullNotifier) AfterFileDownloaded(ctx *Context, dstPath string, size int64, err error) {
+}
+
 // BeforeDeleteFile implements Notifier
 func (NullNotifier) BeforeDeleteFile(ctx *Context, dstPath string) {
 }
 
+// AfterFileDeleted implements Notifier
+func (NullNotifier) AfterFileDeleted(ctx *Context, dstPath string, err error) {
+}
+
 // BeforeChangeCurDir implements Notifier
 func (NullNotifier) BeforeChangeCurDir(ctx *Context, oldCurDir, newCurDir string) {
 }
 
+// AfterCurDirChanged implements Notifier
+func (NullNotifier) AfterCurDirChanged(ctx *Context, oldCurDir, newCurDir string, err error) {
+}
+
 // BeforeCreateDir implements Notifier
 func (NullNotifier) BeforeCreateDir(ctx *Context, dstPath string) {
 }
 
+// AfterDirCreated implements Notifier
+func (NullNotifier) AfterDirCreated(ctx *Context, dstPath string, err error) {
+}
+
 // BeforeDeleteDir implements Notifier
 func (NullNotifier) BeforeDeleteDir(ctx *Context, dstPath string) {
 }
 
-// BeforeDownloadFile implements Notifier
-func (NullNotifier) BeforeDownloadFile(ctx *Context, dstPath string) {
+// AfterDirDeleted implements Notifier
+func (NullNotifier) AfterDirDeleted(ctx *Context, dstPath string, err error) {
+}
+
+// BeforeRename implements RenameNotifier
+func (NullNotifier) BeforeRename(ctx *Context, fromPath, toPath string) {
+}
+
+// AfterRename implements RenameNotifier
+func (NullNotifier) AfterRename(ctx *Context, fromPath, toPath string, err error) {
+}
+
+// AfterListDir implements ListNotifier
+func (NullNotifier) AfterListDir(ctx *Context, dirPath string, entries int, err error) {
+}
+
+// AfterTransferAborted implements AbortNotifier
+func (NullNotifier) AfterTransferAborted(ctx *Context, dstPath string, size int64) {
+}
+
+// OnDisconnect implements DisconnectNotifier
+func (NullNotifier) OnDisconnect(ctx *Context) {
+}
+
+// OnCanary implements CanaryNotifier
+func (NullNotifier) OnCanary(ctx *Context, event *CanaryEvent) {
+}
+
+// OnRenameConflict implements RenameConflictNotifier
+func (NullNotifier) OnRenameConflict(ctx *Context, conflict *RenameConflict, err error) {
+}
+
+// OnPanic implements PanicNotifier
+func (NullNotifier) OnPanic(ctx *Context, event *PanicEvent) {
+}
+
+// OnAccountExpired implements AccountExpiredNotifier
+func (NullNotifier) OnAccountExpired(ctx *Context, userName string) {
+}
+
+// NotifierFuncs implements Notifier with a function per hook, the ones left
+// nil are not called.
+type NotifierFuncs struct {
+	BeforeCommandFunc        func(ctx *Context, command string)
+	BeforeLoginUserFunc      func(ctx *Context, userName string)
+	AfterUserLoginFunc       func(ctx *Context, userName, password string, passMatched bool, err error)
+	BeforePutFileFunc        func(ctx *Context, dstPath string)
+	AfterFilePutFunc         func(ctx *Context, dstPath string, size int64, err error)
+	BeforeDownloadFileFunc   func(ctx *Context, dstPath string)
+	AfterFileDownloadedFunc  func(ctx *Context, dstPath string, size int64, err error)
+	BeforeDeleteFileFunc     func(ctx *Context, dstPath string)
+	AfterFileDeletedFunc     func(ctx *Context, dstPath string, err error)
+	BeforeChangeCurDirFunc   func(ctx *Context, oldCurDir, newCurDir string)
+	AfterCurDirChangedFunc   func(ctx *Context, oldCurDir, newCurDir string, err error)
+	BeforeCreateDirFunc      func(ctx *Context, dstPath string)
+	AfterDirCreatedFunc      func(ctx *Context, dstPath string, err error)
+	BeforeDeleteDirFunc      func(ctx *Context, dstPath string)
+	AfterDirDeletedFunc      func(ctx *Context, dstPath string, err error)
+	BeforeRenameFunc         func(ctx *Context, fromPath, toPath string)
+	AfterRenameFunc          func(ctx *Context, fromPath, toPath string, err error)
+	AfterListDirFunc         func(ctx *Context, dirPath string, entries int, err error)
+	AfterTransferAbortedFunc func(ctx *Context, dstPath string, size int64)
+	OnDisconnectFunc         func(ctx *Context)
+	OnCanaryFunc             func(ctx *Context, event *CanaryEvent)
+	OnRenameConflictFunc     func(ctx *Context, conflict *RenameConflict, err error)
+	OnPanicFunc              func(ctx *Context, event *PanicEvent)
+	OnAccountExpiredFunc     func(ctx *Context, userName string)
+}
+
+var _ Notifier = &NotifierFuncs{}
+
+// BeforeCommand implements Notifier
+func (funcs *NotifierFuncs) BeforeCommand(ctx *Context, command string) {
+	if funcs.BeforeCommandFunc != nil {
+		funcs.BeforeCommandFunc(ctx, command)
+	}
+}
+
+// BeforeLoginUser implements Notifier
+func (funcs *NotifierFuncs) BeforeLoginUser(ctx *Context, userName string) {
+	if funcs.BeforeLoginUserFunc != nil {
+		funcs.BeforeLoginUserFunc(ctx, userName)
+	}
 }
 
 // AfterUserLogin implements Notifier
-func (NullNotifier) AfterUserLogin(ctx *Context, userName, password string, passMatched bool, err error) {
+func (funcs *NotifierFuncs) AfterUserLogin(ctx *Context, userName, password string, passMatched bool, err error) {
+	if funcs.AfterUserLoginFunc != nil {
+		funcs.AfterUserLoginFunc(ctx, userName, password, passMatched, err)
+	}
+}
+
+// BeforePutFile implements Notifier
+func (funcs *NotifierFuncs) BeforePutFile(ctx *Context, dstPath string) {
+	if funcs.BeforePutFileFunc != nil {
+		funcs.BeforePutFileFunc(ctx, dstPath)
+	}
 }
 
 // AfterFilePut implements Notifier
-func (NullNotifier) AfterFilePut(ctx *Context, dstPath string, size int64, err error) {
+func (funcs *NotifierFuncs) AfterFilePut(ctx *Context, dstPath string, size int64, err error) {
+	if funcs.AfterFilePutFunc != nil {
+		funcs.AfterFilePutFunc(ctx, dstPath, size, err)
+	}
 }
 
-// AfterFileDeleted implements Notifier
-func (NullNotifier) AfterFileDeleted(ctx *Context, dstPath string, err error) {
+// BeforeDownloadFile implements Notifier
+func (funcs *NotifierFuncs) BeforeDownloadFile(ctx *Context, dstPath string) {
+	if funcs.BeforeDownloadFileFunc != nil {
+		funcs.BeforeDownloadFileFunc(ctx, dstPath)
+	}
 }
 
 // AfterFileDownloaded implements Notifier
-func (NullNotifier) AfterFileDownloaded(ctx *Context, dstPath string, size int64, err error) {
+func (funcs *NotifierFuncs) AfterFileDownloaded(ctx *Context, dstPath string, size int64, err error) {
+	if funcs.AfterFileDownloadedFunc != nil {
+		funcs.AfterFileDownloadedFunc(ctx, dstPath, size, err)
+	}
+}
+
+// BeforeDeleteFile implements Notifier
+func (funcs *NotifierFuncs) BeforeDeleteFile(ctx *Context, dstPath string) {
+	if funcs.BeforeDeleteFileFunc != nil {
+		funcs.BeforeDeleteFileFunc(ctx, dstPath)
+	}
+}
+
+// AfterFileDeleted implements Notifier
+func (funcs *NotifierFuncs) AfterFileDeleted(ctx *Context, dstPath string, err error) {
+	if funcs.AfterFileDeletedFunc != nil {
+		funcs.AfterFileDeletedFunc(ctx, dstPath, err)
+	}
+}
+
+// BeforeChangeCurDir implements Notifier
+func (funcs *NotifierFuncs) BeforeChangeCurDir(ctx *Context, oldCurDir, newCurDir string) {
+	if funcs.BeforeChangeCurDirFunc != nil {
+		funcs.BeforeChangeCurDirFunc(ctx, oldCurDir, newCurDir)
+	}
 }
 
 // AfterCurDirChanged implements Notifier
-func (NullNotifier) AfterCurDirChanged(ctx *Context, oldCurDir, newCurDir string, err error) {
+func (funcs *NotifierFuncs) AfterCurDirChanged(ctx *Context, oldCurDir, newCurDir string, err error) {
+	if funcs.AfterCurDirChangedFunc != nil {
+		funcs.AfterCurDirChangedFunc(ctx, oldCurDir, newCurDir, err)
+	}
+}
+
+// BeforeCreateDir implements Notifier
+func (funcs *NotifierFuncs) BeforeCreateDir(ctx *Context, dstPath string) {
+	if funcs.BeforeCreateDirFunc != nil {
+		funcs.BeforeCreateDirFunc(ctx, dstPath)
+	}
 }
 
 // AfterDirCreated implements Notifier
-func (NullNotifier) AfterDirCreated(ctx *Context, dstPath string, err error) {
+func (funcs *NotifierFuncs) AfterDirCreated(ctx *Context, dstPath string, err error) {
+	if funcs.AfterDirCreatedFunc != nil {
+		funcs.AfterDirCreatedFunc(ctx, dstPath, err)
+	}
+}
+
+// BeforeDeleteDir implements Notifier
+func (funcs *NotifierFuncs) BeforeDeleteDir(ctx *Context, dstPath string) {
+	if funcs.BeforeDeleteDirFunc != nil {
+		funcs.BeforeDeleteDirFunc(ctx, dstPath)
+	}
 }
 
 // AfterDirDeleted implements Notifier
-func (NullNotifier) AfterDirDeleted(ctx *Context, dstPath string, err error) {
+func (funcs *NotifierFuncs) AfterDirDeleted(ctx *Context, dstPath string, err error) {
+	if funcs.AfterDirDeletedFunc != nil {
+		funcs.AfterDirDeletedFunc(ctx, dstPath, err)
+	}
+}
+
+// BeforeRename implements RenameNotifier
+func (funcs *NotifierFuncs) BeforeRename(ctx *Context, fromPath, toPath string) {
+	if funcs.BeforeRenameFunc != nil {
+		funcs.BeforeRenameFunc(ctx, fromPath, toPath)
+	}
+}
+
+// AfterRename implements RenameNotifier
+func (funcs *NotifierFuncs) AfterRename(ctx *Context, fromPath, toPath string, err error) {
+	if funcs.AfterRenameFunc != nil {
+		funcs.AfterRenameFunc(ctx, fromPath, toPath, err)
+	}
+}
+
+// AfterListDir implements ListNotifier
+func (funcs *NotifierFuncs) AfterListDir(ctx *Context, dirPath string, entries int, err error) {
+	if funcs.AfterListDirFunc != nil {
+		funcs.AfterListDirFunc(ctx, dirPath, entries, err)
+	}
+}
+
+// AfterTransferAborted implements AbortNotifier
+func (funcs *NotifierFuncs) AfterTransferAborted(ctx *Context, dstPath string, size int64) {
+	if funcs.AfterTransferAbortedFunc != nil {
+		funcs.AfterTransferAbortedFunc(ctx, dstPath, size)
+	}
+}
+
+// OnDisconnect implements DisconnectNotifier
+func (funcs *NotifierFuncs) OnDisconnect(ctx *Context) {
+	if funcs.OnDisconnectFunc != nil {
+		funcs.OnDisconnectFunc(ctx)
+	}
+}
+
+// OnCanary implements CanaryNotifier
+func (funcs *NotifierFuncs) OnCanary(ctx *Context, event *CanaryEvent) {
+	if funcs.OnCanaryFunc != nil {
+		funcs.OnCanaryFunc(ctx, event)
+	}
+}
+
+// OnRenameConflict implements RenameConflictNotifier
+func (funcs *NotifierFuncs) OnRenameConflict(ctx *Context, conflict *RenameConflict, err error) {
+	if funcs.OnRenameConflictFunc != nil {
+		funcs.OnRenameConflictFunc(ctx, conflict, err)
+	}
+}
+
+// OnPanic implements PanicNotifier
+func (funcs *NotifierFuncs) OnPanic(ctx *Context, event *PanicEvent) {
+	if funcs.OnPanicFunc != nil {
+		funcs.OnPanicFunc(ctx, event)
+	}
+}
+
+// OnAccountExpired implements AccountExpiredNotifier
+func (funcs *NotifierFuncs) OnAccountExpired(ctx *Context, userName string) {
+	if funcs.OnAccountExpiredFunc != nil {
+		funcs.OnAccountExpiredFunc(ctx, userName)
+	}
 }
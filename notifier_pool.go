@@ -0,0 +1,163 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+)
+
+const defaultNotifierQueueSize = 64
+
+// notifierPool runs notifier hooks on a fixed number of workers. Each session
+// sticks to one worker, so its hooks are still called in order.
+type notifierPool struct {
+	logger Logger
+	queues []chan func()
+	// closed by close, the queues never are so that enqueue needs no lock
+	done      chan struct{}
+	closeOnce sync.Once
+	// hooks dropped because their worker's queue was full
+	dropped atomic.Int64
+}
+
+func newNotifierPool(logger Logger, workers, queueSize int) *notifierPool {
+	pool := &notifierPool{
+		logger: logger,
+		queues: make([]chan func(), workers),
+		done:   make(chan struct{}),
+	}
+	for i := range pool.queues {
+		queue := make(chan func(), queueSize)
+		pool.queues[i] = queue
+		go pool.work(queue)
+	}
+	return pool
+}
+
+func (pool *notifierPool) work(queue chan func()) {
+	for {
+		select {
+		case notify := <-queue:
+			pool.run(notify)
+		case <-pool.done:
+			// Run what was queued before close
+			for {
+				select {
+				case notify := <-queue:
+					pool.run(notify)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// run calls notify, a panicking notifier must not take the worker down.
+func (pool *notifierPool) run(notify func()) {
+	defer func() {
+		if recovery := recover(); recovery != nil {
+			pool.logger.Printf("", "notifier panicked: %v\n%s", recovery, debug.Stack())
+		}
+	}()
+	notify()
+}
+
+// enqueue queues notify on the worker of the session without waiting: when
+// its queue is full the hook is dropped and counted. It returns false once
+// the pool is closed.
+func (pool *notifierPool) enqueue(ctx *Context, notify func()) bool {
+	select {
+	case <-pool.done:
+		return false
+	default:
+	}
+
+	var worker uint32
+	var sessionID string
+	if ctx != nil && ctx.Sess != nil {
+		worker = ctx.Sess.notifyWorker % uint32(len(pool.queues))
+		sessionID = ctx.Sess.id
+	}
+	select {
+	case pool.queues[worker] <- notify:
+	default:
+		if pool.dropped.Add(1) == 1 {
+			pool.logger.Printf(sessionID, "notifier queue full, dropping hooks, see Options.NotifierQueueSize")
+		}
+	}
+	return true
+}
+
+// close stops the workers once they have run the hooks already queued.
+// Hooks dispatched afterwards are called synchronously.
+func (pool *notifierPool) close() {
+	pool.closeOnce.Do(func() {
+		close(pool.done)
+	})
+}
+
+// snapshot copies ctx for a hook run asynchronously, so that the hook sees
+// the session as it was when dispatched and does not race with the
+// commands that follow. Its Sess only carries the session's state: it
+// shares the control connection and Data with the session, but changing
+// it does not change the session.
+func (ctx *Context) snapshot() *Context {
+	if ctx == nil {
+		return nil
+	}
+	copied := &Context{
+		Cmd:   ctx.Cmd,
+		Param: ctx.Param,
+	}
+	if ctx.Data != nil {
+		copied.Data = ctx.Data.clone()
+	}
+	if ctx.Checksums != nil {
+		copied.Checksums = make(map[string]string, len(ctx.Checksums))
+		for algorithm, sum := range ctx.Checksums {
+			copied.Checksums[algorithm] = sum
+		}
+	}
+	if sess := ctx.Sess; sess != nil {
+		copied.Sess = &Session{
+			Conn:            sess.Conn,
+			Ctx:             sess.Ctx,
+			rawConn:         sess.rawConn,
+			server:          sess.server,
+			Data:            sess.Data,
+			id:              sess.id,
+			curDir:          sess.curDir,
+			reqUser:         sess.reqUser,
+			user:            sess.user,
+			clientSoft:      sess.clientSoft,
+			closed:          sess.closed,
+			tls:             sess.tls,
+			transferQuota:   sess.transferQuota,
+			listFilter:      sess.listFilter,
+			listFormat:      sess.listFormat,
+			quirks:          sess.quirks,
+			readOnly:        sess.readOnly,
+			root:            sess.root,
+			command:         sess.command,
+			notifyWorker:    sess.notifyWorker,
+			logger:          sess.logger,
+			vhost:           sess.vhost,
+			fingerprint:     sess.Fingerprint(),
+			cancel:          sess.cancel,
+			uploadLimiter:   sess.uploadLimiter,
+			downloadLimiter: sess.downloadLimiter,
+		}
+		if class := sess.bandwidthClass.Load(); class != nil {
+			copied.Sess.bandwidthClass.Store(class)
+		}
+		copied.Sess.uploaded.Store(sess.uploaded.Load())
+		copied.Sess.downloaded.Store(sess.downloaded.Load())
+		copied.Sess.transfer.Store(sess.transfer.Load())
+	}
+	return copied
+}
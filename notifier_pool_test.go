@@ -0,0 +1,86 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"testing"
+)
+
+func TestNotifierPoolFull(t *testing.T) {
+	pool := newNotifierPool(new(DiscardLogger), 1, 1)
+
+	running := make(chan struct{})
+	release := make(chan struct{})
+	ran := make(chan string, 3)
+	pool.enqueue(nil, func() {
+		close(running)
+		<-release
+		ran <- "first"
+	})
+	<-running
+	// The worker is busy: one hook is queued, the next one dropped rather
+	// than blocking the session.
+	pool.enqueue(nil, func() { ran <- "second" })
+	pool.enqueue(nil, func() { ran <- "third" })
+	if dropped := pool.dropped.Load(); dropped != 1 {
+		t.Errorf("got %d dropped hooks, want 1", dropped)
+	}
+
+	pool.close()
+	if pool.enqueue(nil, func() {}) {
+		t.Error("enqueue succeeded on a closed pool")
+	}
+	close(release)
+	for _, want := range []string{"first", "second"} {
+		if got := <-ran; got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+}
+
+func TestContextSnapshot(t *testing.T) {
+	sess := &Session{id: "1", curDir: "/a", user: "admin", Data: NewStore()}
+	ctx := &Context{Sess: sess, Data: NewStore(), Cmd: "STOR", Param: "f"}
+	ctx.Data.Set("key", "before")
+
+	snapshot := ctx.snapshot()
+	sess.curDir = "/b"
+	sess.user = ""
+	ctx.Data.Set("key", "after")
+
+	if snapshot.Sess == sess {
+		t.Fatal("snapshot shares the session")
+	}
+	if snapshot.Sess.curDir != "/a" || snapshot.Sess.LoginUser() != "admin" || snapshot.Sess.ID() != "1" {
+		t.Errorf("got %q %q %q", snapshot.Sess.curDir, snapshot.Sess.LoginUser(), snapshot.Sess.ID())
+	}
+	if value, _ := snapshot.Data.Get("key"); value != "before" {
+		t.Errorf("got %v, want before", value)
+	}
+	if snapshot.Cmd != "STOR" || snapshot.Param != "f" {
+		t.Errorf("got %q %q", snapshot.Cmd, snapshot.Param)
+	}
+}
+
+func TestRegisterNotifier(t *testing.T) {
+	s, err := NewServer(&Options{
+		Perm:   NewSimplePerm("test", "test"),
+		Logger: new(DiscardLogger),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.RegisterNotifier(&NotifierFuncs{}); err != nil {
+		t.Errorf("registering NotifierFuncs: %v", err)
+	}
+	// A value with no hooks at all is a mistake, such as passing a
+	// notifier by value when its methods have a pointer receiver
+	if err = s.RegisterNotifier(struct{}{}); err == nil {
+		t.Error("registering a value without hooks succeeded")
+	}
+	if len(s.notifiers.list) != 1 {
+		t.Errorf("got %d notifiers, want 1", len(s.notifiers.list))
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import "time"
+
+// Option configures the server New creates. Any function changing Options
+// is one, for the settings without a With helper.
+type Option func(*Options)
+
+// New creates a server for driver configured by options, the Options
+// struct equivalent being NewServer:
+//
+//	server, err := ftp.New(driver,
+//		ftp.WithAuth(auth),
+//		ftp.WithPerm(ftp.NewSimplePerm("ftp", "ftp")),
+//		ftp.WithPassivePorts("30000-30100"),
+//	)
+func New(driver Driver, options ...Option) (*Server, error) {
+	opts := &Options{Driver: driver}
+	for _, option := range options {
+		option(opts)
+	}
+	return NewServer(opts)
+}
+
+// WithAuth sets Options.Auth
+func WithAuth(auth Auth) Option {
+	return func(opts *Options) {
+		opts.Auth = auth
+	}
+}
+
+// WithPerm sets Options.Perm
+func WithPerm(perm Perm) Option {
+	return func(opts *Options) {
+		opts.Perm = perm
+	}
+}
+
+// WithLogger sets Options.Logger
+func WithLogger(logger Logger) Option {
+	return func(opts *Options) {
+		opts.Logger = logger
+	}
+}
+
+// WithAddress sets Options.Hostname and Options.Port
+func WithAddress(hostname string, port int) Option {
+	return func(opts *Options) {
+		opts.Hostname = hostname
+		opts.Port = port
+	}
+}
+
+// WithTLS enables TLS with the given certificate and key files, implicit
+// unless explicit is set.
+func WithTLS(certFile, keyFile string, explicit bool) Option {
+	return func(opts *Options) {
+		opts.TLS = true
+		opts.CertFile = certFile
+		opts.KeyFile = keyFile
+		opts.ExplicitFTPS = explicit
+	}
+}
+
+// WithPassivePorts sets Options.PassivePorts, a range such as "30000-30100"
+func WithPassivePorts(ports string) Option {
+	return func(opts *Options) {
+		opts.PassivePorts = ports
+	}
+}
+
+// Timeouts groups the timeouts of the server, zero values leave the
+// defaults.
+type Timeouts struct {
+	Session       time.Duration // Options.Timeout
+	Login         time.Duration // Options.LoginTimeout
+	Idle          time.Duration // Options.IdleTimeout
+	Write         time.Duration // Options.WriteTimeout
+	TransferStall time.Duration // Options.TransferStallTimeout
+	Driver        time.Duration // Options.DriverTimeout
+}
+
+// WithTimeouts sets the timeouts that are not zero in timeouts
+func WithTimeouts(timeouts Timeouts) Option {
+	return func(opts *Options) {
+		setDuration(&opts.Timeout, timeouts.Session)
+		setDuration(&opts.LoginTimeout, timeouts.Login)
+		setDuration(&opts.IdleTimeout, timeouts.Idle)
+		setDuration(&opts.WriteTimeout, timeouts.Write)
+		setDuration(&opts.TransferStallTimeout, timeouts.TransferStall)
+		setDuration(&opts.DriverTimeout, timeouts.Driver)
+	}
+}
+
+func setDuration(dst *time.Duration, d time.Duration) {
+	if d != 0 {
+		*dst = d
+	}
+}
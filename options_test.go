@@ -0,0 +1,104 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	s, err := New(nil,
+		WithPerm(NewSimplePerm("test", "test")),
+		WithAddress("127.0.0.1", 2100),
+		WithPassivePorts("30000-30100"),
+		WithTimeouts(Timeouts{Login: time.Second, Idle: time.Minute}),
+		func(opts *Options) { opts.DisablePassive = true },
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Port != 2100 || s.PassivePorts != "30000-30100" {
+		t.Fatalf("unexpected port %d and passive ports %q", s.Port, s.PassivePorts)
+	}
+	if s.LoginTimeout != time.Second || s.IdleTimeout != time.Minute || s.Timeout != 60*time.Second {
+		t.Fatalf("unexpected timeouts %v, %v, %v", s.LoginTimeout, s.IdleTimeout, s.Timeout)
+	}
+
+	// Each server has its own commands.
+	if _, ok := s.Commands["PASV"]; ok {
+		t.Fatal("expected PASV to be disabled")
+	}
+	if _, ok := defaultCommands["PASV"]; !ok {
+		t.Fatal("disabling PASV changed the default commands")
+	}
+	delete(s.SiteCommands, "HASH")
+	if _, ok := defaultSiteCommands["HASH"]; !ok {
+		t.Fatal("removing SITE HASH changed the default SITE commands")
+	}
+	other, err := New(nil, WithPerm(NewSimplePerm("test", "test")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := other.SiteCommands["HASH"]; !ok {
+		t.Fatal("removing SITE HASH changed another server's SITE commands")
+	}
+
+	if _, err := New(nil); err == nil {
+		t.Fatal("expected an error without a perm")
+	}
+}
+
+func TestOptionsFromEnv(t *testing.T) {
+	password := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(password, []byte("secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("FTP_PORT", "2121")
+	t.Setenv("FTP_PASSIVE_PORTS", "30000-30009")
+	t.Setenv("NODE_IP", "192.0.2.1")
+	t.Setenv("FTP_PUBLIC_IP_FROM", "NODE_IP")
+	t.Setenv("FTP_TLS_CERT_FILE", "/tls/tls.crt")
+	t.Setenv("FTP_TLS_KEY_FILE", "/tls/tls.key")
+	t.Setenv("FTP_FORCE_TLS", "true")
+	t.Setenv("FTP_USER", "admin")
+	t.Setenv("FTP_PASSWORD_FILE", password)
+
+	opts, err := OptionsFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.Port != 2121 || opts.PassivePorts != "30000-30009" || opts.PublicIP != "192.0.2.1" {
+		t.Errorf("unexpected port %d, passive ports %q and public IP %q", opts.Port, opts.PassivePorts, opts.PublicIP)
+	}
+	if !opts.TLS || !opts.ExplicitFTPS || !opts.ForceTLS || opts.CertFile != "/tls/tls.crt" || opts.KeyFile != "/tls/tls.key" {
+		t.Errorf("unexpected TLS options %+v", opts)
+	}
+	if auth, ok := opts.Auth.(*SimpleAuth); !ok || auth.Name != "admin" || auth.Password != "secret" {
+		t.Errorf("unexpected auth %#v", opts.Auth)
+	}
+
+	// A service link of a service named ftp is no port
+	t.Setenv("FTP_PORT", "tcp://10.0.0.1:21")
+	if opts, err = OptionsFromEnv(); err != nil || opts.Port != 0 {
+		t.Errorf("service link gave port %d, %v", opts.Port, err)
+	}
+
+	for name, value := range map[string]string{
+		"FTP_PORT":           "ftp",
+		"FTP_FORCE_TLS":      "maybe",
+		"FTP_PUBLIC_IP_FROM": "UNSET_IP",
+		"FTP_PASSWORD_FILE":  filepath.Join(t.TempDir(), "missing"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(name, value)
+			if _, err := OptionsFromEnv(); err == nil {
+				t.Errorf("%s=%s accepted", name, value)
+			}
+		})
+	}
+}
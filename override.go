@@ -0,0 +1,182 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// writeCommands are the commands, and SITE subcommands, refused to
+// read-only sessions, see Session.SetReadOnly.
+var writeCommands = map[string]bool{
+	"APPE":         true,
+	"DELE":         true,
+	"MKD":          true,
+	"RMD":          true,
+	"RNFR":         true,
+	"RNTO":         true,
+	"STOR":         true,
+	"XMKD":         true,
+	"XRMD":         true,
+	"SITE MVDIR":   true,
+	"SITE RMDIR":   true,
+	"SITE SYMLINK": true,
+}
+
+// SetReadOnly makes the session read-only, refusing the commands changing
+// files with 550, or lets it write again. Options.OnConnect or Auth
+// implementations may call it.
+func (sess *Session) SetReadOnly(readOnly bool) {
+	sess.readOnly = readOnly
+}
+
+// ReadOnly reports whether the session is read-only, see SetReadOnly.
+func (sess *Session) ReadOnly() bool {
+	return sess.readOnly
+}
+
+// deniesWrite reports whether the session is read-only and cmd changes
+// files.
+func (sess *Session) deniesWrite(cmd string) bool {
+	return sess.readOnly && writeCommands[cmd]
+}
+
+// SetIdleTimeout overrides Options.IdleTimeout for the session, 0 disables
+// it. It applies from the next command read.
+func (sess *Session) SetIdleTimeout(timeout time.Duration) {
+	sess.idleTimeout = &timeout
+}
+
+// sessionIdleTimeout returns the idle timeout of the session
+func (sess *Session) sessionIdleTimeout() time.Duration {
+	if sess.idleTimeout != nil {
+		return *sess.idleTimeout
+	}
+	return sess.server.IdleTimeout
+}
+
+// SetTransferStallTimeout overrides Options.TransferStallTimeout for the
+// session, 0 or a negative value disables it. It applies from the next
+// data connection.
+func (sess *Session) SetTransferStallTimeout(timeout time.Duration) {
+	sess.stallTimeout = &timeout
+}
+
+// transferStallTimeout returns the transfer stall timeout of the session
+func (sess *Session) transferStallTimeout() time.Duration {
+	if sess.stallTimeout != nil {
+		return *sess.stallTimeout
+	}
+	return sess.server.TransferStallTimeout
+}
+
+// SetRoot confines the session to the directory root of its driver, which
+// it then sees as "/", and moves it there. Options.OnConnect or Auth
+// implementations may call it, "" lifts the confinement.
+func (sess *Session) SetRoot(root string) {
+	sess.root = ""
+	if root = path.Clean("/" + root); root != "/" {
+		sess.root = root
+	}
+	sess.curDir = "/"
+}
+
+// Root returns the directory the session is confined to, see SetRoot, ""
+// if none.
+func (sess *Session) Root() string {
+	return sess.root
+}
+
+// realPath returns the path of the driver for p, a path of the session
+func (sess *Session) realPath(p string) string {
+	if sess.root == "" {
+		return p
+	}
+	return path.Join(sess.root, p)
+}
+
+// sessionPath returns the path of the session for p, a path of the driver
+// below its root.
+func (sess *Session) sessionPath(p string) string {
+	return rootedPath(sess.root, p)
+}
+
+func rootedPath(root, p string) string {
+	if root == "" {
+		return p
+	}
+	return path.Join("/", strings.TrimPrefix(p, root))
+}
+
+var (
+	_ Driver       = &rootedDriver{}
+	_ AppendDriver = &rootedDriver{}
+	_ EntryDriver  = &rootedDriver{}
+)
+
+// rootedDriver confines a driver to its directory root, see
+// Session.SetRoot.
+type rootedDriver struct {
+	Driver
+	root string
+}
+
+// Stat implements Driver
+func (driver *rootedDriver) Stat(ctx *Context, p string) (os.FileInfo, error) {
+	return driver.Driver.Stat(ctx, path.Join(driver.root, p))
+}
+
+// ListDir implements Driver
+func (driver *rootedDriver) ListDir(ctx *Context, p string, callback func(os.FileInfo) error) error {
+	return driver.Driver.ListDir(ctx, path.Join(driver.root, p), callback)
+}
+
+// ListEntries implements EntryDriver, with the paths of the entries below
+// the root.
+func (driver *rootedDriver) ListEntries(ctx *Context, p string, callback func(Entry) error) error {
+	return ListEntries(ctx, driver.Driver, path.Join(driver.root, p), func(entry Entry) error {
+		entry.Path = rootedPath(driver.root, entry.Path)
+		return callback(entry)
+	})
+}
+
+// DeleteDir implements Driver
+func (driver *rootedDriver) DeleteDir(ctx *Context, p string) error {
+	return driver.Driver.DeleteDir(ctx, path.Join(driver.root, p))
+}
+
+// DeleteFile implements Driver
+func (driver *rootedDriver) DeleteFile(ctx *Context, p string) error {
+	return driver.Driver.DeleteFile(ctx, path.Join(driver.root, p))
+}
+
+// Rename implements Driver
+func (driver *rootedDriver) Rename(ctx *Context, fromPath, toPath string) error {
+	return driver.Driver.Rename(ctx, path.Join(driver.root, fromPath), path.Join(driver.root, toPath))
+}
+
+// MakeDir implements Driver
+func (driver *rootedDriver) MakeDir(ctx *Context, p string) error {
+	return driver.Driver.MakeDir(ctx, path.Join(driver.root, p))
+}
+
+// GetFile implements Driver
+func (driver *rootedDriver) GetFile(ctx *Context, p string, offset int64) (int64, io.ReadCloser, error) {
+	return driver.Driver.GetFile(ctx, path.Join(driver.root, p), offset)
+}
+
+// PutFile implements Driver
+func (driver *rootedDriver) PutFile(ctx *Context, p string, data io.Reader, offset int64) (int64, error) {
+	return driver.Driver.PutFile(ctx, path.Join(driver.root, p), data, offset)
+}
+
+// AppendFile implements AppendDriver
+func (driver *rootedDriver) AppendFile(ctx *Context, p string, data io.Reader) (int64, error) {
+	return appendFile(ctx, driver.Driver, path.Join(driver.root, p), data)
+}
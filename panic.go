@@ -0,0 +1,83 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import "runtime/debug"
+
+// PanicEvent describes a panic recovered while serving a session, see
+// PanicNotifier.
+type PanicEvent struct {
+	// Value panic was called with
+	Value interface{}
+	// Stack of the panicking goroutine
+	Stack []byte
+	// Command being handled, upper-cased, and its parameter. Command is
+	// blank for panics outside of commands, which end the session.
+	Command string
+	Param   string
+	// Whether the session is closed because of the panic, see
+	// Options.DisconnectOnPanic
+	Disconnect bool
+}
+
+// PanicCount returns the number of panics recovered while serving sessions
+func (server *Server) PanicCount() uint64 {
+	return server.panics.Load()
+}
+
+// recoverPanic accounts for a panic recovered while serving the session,
+// logging it and notifying the PanicNotifiers.
+func (sess *Session) recoverPanic(command, param string, value interface{}, disconnect bool) {
+	sess.server.panics.Add(1)
+	event := &PanicEvent{
+		Value:      value,
+		Stack:      debug.Stack(),
+		Command:    command,
+		Param:      param,
+		Disconnect: disconnect,
+	}
+	if command == "" {
+		sess.logf("recovered from handle panic; recovered=%v; stack=%s", value, event.Stack)
+	} else {
+		sess.logf("handler crashed with error:%v\n%s", value, event.Stack)
+	}
+	sess.server.notifiers.OnPanic(&Context{
+		Sess:  sess,
+		Cmd:   command,
+		Param: param,
+		Data:  NewStore(),
+	}, event)
+}
+
+// abortPanickedCommand cleans up after a command which panicked: its
+// transfer is ended and the client told, the session going on unless
+// Options.DisconnectOnPanic is set.
+func (sess *Session) abortPanickedCommand(command, param string, value interface{}) {
+	disconnect := sess.server.DisconnectOnPanic
+	sess.recoverPanic(command, param, value, disconnect)
+
+	if sess.stopWatching != nil {
+		sess.stopWatching()
+	}
+	if sess.cmdCancel != nil {
+		sess.cmdCancel()
+		sess.cmdMu.Lock()
+		sess.cmdCtx, sess.cmdCancel = nil, nil
+		sess.cmdMu.Unlock()
+	}
+	if sess.dataConn != nil {
+		sess.dataConn.Close()
+		sess.setDataConn(nil)
+	}
+	sess.command = ""
+	sess.lastFilePos = -1
+
+	if disconnect {
+		sess.writeMessage(421, "Internal error, closing control connection")
+		sess.Close()
+		return
+	}
+	sess.writeMessage(451, "Requested action aborted: local error in processing")
+}
@@ -0,0 +1,81 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"net/textproto"
+	"testing"
+)
+
+// commandPanic panics, standing for a buggy command or driver.
+type commandPanic struct{}
+
+func (cmd commandPanic) IsExtend() bool     { return false }
+func (cmd commandPanic) RequireParam() bool { return false }
+func (cmd commandPanic) RequireAuth() bool  { return false }
+
+func (cmd commandPanic) Execute(sess *Session, param string) {
+	panic("boom")
+}
+
+func TestCommandPanic(t *testing.T) {
+	for _, disconnect := range []bool{false, true} {
+		commands := map[string]Command{"PANIC": commandPanic{}}
+		for name, cmd := range defaultCommands {
+			commands[name] = cmd
+		}
+		s, err := NewServer(&Options{
+			Perm:              NewSimplePerm("test", "test"),
+			Logger:            new(DiscardLogger),
+			Commands:          commands,
+			DisconnectOnPanic: disconnect,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var events []*PanicEvent
+		s.RegisterNotifier(&NotifierFuncs{
+			OnPanicFunc: func(ctx *Context, event *PanicEvent) {
+				events = append(events, event)
+			},
+		})
+
+		serverConn, clientConn := net.Pipe()
+		sess := s.newSession(newSessionID(), serverConn)
+		go sess.Serve()
+		client := textproto.NewConn(clientConn)
+		if _, _, err = client.ReadResponse(220); err != nil {
+			t.Fatal(err)
+		}
+
+		if disconnect {
+			expectCode(t, client, 421, "PANIC now")
+			if _, err = client.ReadLine(); err != io.EOF {
+				t.Errorf("session still open after a panic: %v", err)
+			}
+		} else {
+			expectCode(t, client, 451, "PANIC now")
+			expectCode(t, client, 200, "NOOP")
+		}
+		client.Close()
+
+		if s.PanicCount() != 1 {
+			t.Errorf("PanicCount() = %d, want 1", s.PanicCount())
+		}
+		if len(events) != 1 {
+			t.Fatalf("%d panic events, want 1", len(events))
+		}
+		event := events[0]
+		if event.Value != "boom" || event.Command != "PANIC" || event.Param != "now" || event.Disconnect != disconnect {
+			t.Errorf("panic event %+v", event)
+		}
+		if !bytes.Contains(event.Stack, []byte("commandPanic.Execute")) {
+			t.Errorf("panic stack without the panicking command:\n%s", event.Stack)
+		}
+	}
+}
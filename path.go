@@ -0,0 +1,36 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"path"
+	"strings"
+)
+
+// CleanPath returns the absolute, clean and slash separated form of name,
+// taken relative to dir unless absolute, which no ".." leads out of the
+// root. Backslashes are taken as separators, as Windows clients send them.
+// Session paths are cleaned with it, drivers may use it for paths they
+// build themselves.
+func CleanPath(dir, name string) string {
+	name = strings.ReplaceAll(name, `\`, "/")
+	if !strings.HasPrefix(name, "/") {
+		name = dir + "/" + name
+	}
+	return path.Clean("/" + name)
+}
+
+// stripDriveLetter drops the drive letter in front of an absolute Windows
+// path, as in "C:\dir" or "C:/dir", see QuirkDriveLetters.
+func stripDriveLetter(name string) string {
+	if len(name) >= 3 && isASCIILetter(name[0]) && name[1] == ':' && (name[2] == '\\' || name[2] == '/') {
+		return name[2:]
+	}
+	return name
+}
+
+func isASCIILetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
@@ -0,0 +1,38 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"path"
+	"strings"
+	"testing"
+)
+
+func FuzzCleanPath(f *testing.F) {
+	for _, seed := range []string{
+		"", "/", "one.txt", "../../etc/passwd", `..\..\win.ini`, `C:\temp`,
+		"a/./b/../../..",
+	} {
+		f.Add("/home/user", seed)
+	}
+
+	f.Fuzz(func(t *testing.T, dir, name string) {
+		p := CleanPath(CleanPath("/", dir), name)
+		if !strings.HasPrefix(p, "/") {
+			t.Fatalf("CleanPath(%q, %q) = %q, not absolute", dir, name, p)
+		}
+		if path.Clean(p) != p {
+			t.Fatalf("CleanPath(%q, %q) = %q, not clean", dir, name, p)
+		}
+		if strings.Contains(p, `\`) {
+			t.Fatalf("CleanPath(%q, %q) = %q, has a backslash", dir, name, p)
+		}
+		for _, segment := range strings.Split(p, "/") {
+			if segment == ".." {
+				t.Fatalf("CleanPath(%q, %q) = %q, leads out of the root", dir, name, p)
+			}
+		}
+	})
+}
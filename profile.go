@@ -0,0 +1,148 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"strconv"
+)
+
+// Profile makes the server pass for another FTP server, for honeypots and
+// for clients that change their behavior by server. See Options.Profile.
+type Profile struct {
+	Name string
+
+	// Welcome message used when Options.WelcomeMessage is not set, a
+	// text/template executed with BannerData.
+	WelcomeMessage string
+
+	// SYST reply
+	System string
+
+	// FEAT reply, the first line and the features each on a line of
+	// their own starting with a space, and the closing line. The server's
+	// features are sent when blank.
+	Features    string
+	FeaturesEnd string
+
+	// How LIST formats entries when Options.ListFormat is not set
+	ListFormat ListFormat
+
+	// Replies replaces the text of replies. Keys are "CMD CODE" for the
+	// replies to a command, "* CODE" for any command and "CODE" for replies
+	// sent outside a command, such as 500 to an unknown command.
+	Replies map[string]string
+}
+
+var (
+	// ProfileVsftpd mimics vsftpd 3.0.3
+	ProfileVsftpd = &Profile{
+		Name:           "vsftpd",
+		WelcomeMessage: "(vsFTPd 3.0.3)",
+		System:         "UNIX Type: L8",
+		Features:       "Features:\n EPRT\n EPSV\n MDTM\n PASV\n REST STREAM\n SIZE\n TVFS\n UTF8",
+		FeaturesEnd:    "End",
+		Replies: map[string]string{
+			"500":      "Unknown command.",
+			"* 530":    "Please login with USER and PASS.",
+			"USER 331": "Please specify the password.",
+			"PASS 230": "Login successful.",
+			"PASS 530": "Login incorrect.",
+			"CWD 250":  "Directory successfully changed.",
+			"CWD 550":  "Failed to change directory.",
+			"RETR 550": "Failed to open file.",
+			"STOR 553": "Could not create file.",
+			"DELE 250": "Delete operation successful.",
+			"DELE 550": "Delete operation failed.",
+			"MKD 550":  "Create directory operation failed.",
+			"RMD 250":  "Remove directory operation successful.",
+			"RMD 550":  "Remove directory operation failed.",
+			"QUIT 221": "Goodbye.",
+		},
+	}
+
+	// ProfileProFTPD mimics ProFTPD 1.3.5
+	ProfileProFTPD = &Profile{
+		Name:           "proftpd",
+		WelcomeMessage: "ProFTPD 1.3.5 Server (ProFTPD Default Installation) [{{.ServerIP}}]",
+		System:         "UNIX Type: L8",
+		Features:       "Features:\n LANG en-US*\n MDTM\n MFMT\n TVFS\n UTF8\n MLST modify*;perm*;size*;type*;unique*;UNIX.group*;UNIX.mode*;UNIX.owner*;\n REST STREAM\n SIZE",
+		FeaturesEnd:    "End",
+		Replies: map[string]string{
+			"* 530":    "Please login with USER and PASS",
+			"PASS 530": "Login incorrect.",
+			"QUIT 221": "Goodbye.",
+			"CWD 250":  "CWD command successful",
+			"DELE 250": "DELE command successful",
+			"RMD 250":  "RMD command successful",
+		},
+	}
+
+	// ProfileIIS mimics Microsoft IIS FTP
+	ProfileIIS = &Profile{
+		Name:           "iis",
+		WelcomeMessage: "Microsoft FTP Service",
+		System:         "Windows_NT",
+		Features:       "Extended features supported:\n LANG EN*\n UTF8\n AUTH TLS;TLS-C;SSL;TLS-P;\n PBSZ\n PROT C;P;\n CCC\n HOST\n SIZE\n MDTM\n REST STREAM",
+		FeaturesEnd:    "END",
+		ListFormat:     ListFormatDOS,
+		Replies: map[string]string{
+			"500":      "Command not understood.",
+			"* 530":    "User cannot log in.",
+			"USER 331": "Password required",
+			"PASS 230": "User logged in.",
+			"CWD 250":  "CWD command successful.",
+			"CWD 550":  "The system cannot find the file specified.",
+			"RETR 550": "The system cannot find the file specified.",
+			"DELE 250": "DELE command successful.",
+			"RMD 250":  "RMD command successful.",
+			"QUIT 221": "Goodbye.",
+		},
+	}
+
+	// Profiles are the built-in profiles by name
+	Profiles = map[string]*Profile{
+		ProfileVsftpd.Name:  ProfileVsftpd,
+		ProfileProFTPD.Name: ProfileProFTPD,
+		ProfileIIS.Name:     ProfileIIS,
+	}
+)
+
+// phrase returns the text of a reply, as the profile words it.
+func (sess *Session) phrase(code int, message string) string {
+	profile := sess.server.Profile
+	if profile == nil || len(profile.Replies) == 0 {
+		return message
+	}
+
+	c := strconv.Itoa(code)
+	key := c
+	if sess.command != "" {
+		key = sess.command + " " + c
+	}
+	if text, ok := profile.Replies[key]; ok {
+		return text
+	}
+	if text, ok := profile.Replies["* "+c]; ok {
+		return text
+	}
+	return message
+}
+
+// system returns the SYST reply.
+func (sess *Session) system() string {
+	if profile := sess.server.Profile; profile != nil && profile.System != "" {
+		return profile.System
+	}
+	return "UNIX Type: L8"
+}
+
+// writeFeatures sends the FEAT reply.
+func (sess *Session) writeFeatures() {
+	if profile := sess.server.Profile; profile != nil && profile.Features != "" {
+		sess.writeMessageMultiline(211, sess.quirks.feats(profile.Features), profile.FeaturesEnd)
+		return
+	}
+	sess.writeMessageMultiline(211, sess.quirks.feats(sess.server.feats), "End")
+}
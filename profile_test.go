@@ -0,0 +1,58 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+func TestProfile(t *testing.T) {
+	s, err := NewServer(&Options{
+		Perm:    NewSimplePerm("test", "test"),
+		Logger:  new(DiscardLogger),
+		Auth:    &SimpleAuth{Name: "admin", Password: "admin"},
+		Profile: ProfileVsftpd,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serverConn, clientConn := net.Pipe()
+	sess := s.newSession(newSessionID(), serverConn)
+	go sess.Serve()
+
+	client := textproto.NewConn(clientConn)
+	defer client.Close()
+	if _, msg, err := client.ReadResponse(220); err != nil || msg != "(vsFTPd 3.0.3)" {
+		t.Fatalf("expected the vsftpd banner, got %q (%v)", msg, err)
+	}
+
+	replies := []struct {
+		cmd  string
+		code int
+		msg  string
+	}{
+		{"XYZZY", 500, "Unknown command."},
+		{"SYST", 530, "Please login with USER and PASS."},
+		{"USER admin", 331, "Please specify the password."},
+		{"PASS nope", 530, "Login incorrect."},
+		{"USER admin", 331, "Please specify the password."},
+		{"PASS admin", 230, "Login successful."},
+		{"SYST", 215, "UNIX Type: L8"},
+	}
+	for _, r := range replies {
+		if msg := expectCode(t, client, r.code, r.cmd); msg != r.msg {
+			t.Fatalf("%s: expected %q, got %q", r.cmd, r.msg, msg)
+		}
+	}
+
+	feats := expectCode(t, client, 211, "FEAT")
+	if !strings.HasPrefix(feats, "Features:\n EPRT\n") || !strings.HasSuffix(feats, "\nEnd") {
+		t.Fatalf("expected the vsftpd features, got %q", feats)
+	}
+}
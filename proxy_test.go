@@ -0,0 +1,154 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+)
+
+// listen serves every connection accepted on a loopback port with serve
+func listen(t *testing.T, serve func(conn net.Conn)) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				serve(conn)
+			}()
+		}
+	}()
+	return l.Addr().String()
+}
+
+// relay connects the client of a proxy to addr
+func relay(client io.ReadWriter, addr string) {
+	target, err := net.Dial("tcp", addr)
+	if err != nil {
+		return
+	}
+	defer target.Close()
+	go func() { _, _ = io.Copy(target, client) }()
+	_, _ = io.Copy(client, target)
+}
+
+// serveSOCKS5 is a SOCKS5 proxy for IPv4 addresses, expecting user:secret
+func serveSOCKS5(conn net.Conn) {
+	buf := make([]byte, 3)
+	if _, err := io.ReadFull(conn, buf); err != nil || buf[2] != socks5PasswordAuth {
+		_, _ = conn.Write([]byte{socks5Version, 0xff})
+		return
+	}
+	_, _ = conn.Write([]byte{socks5Version, socks5PasswordAuth})
+
+	r := bufio.NewReader(conn)
+	read := func() string {
+		size, _ := r.ReadByte()
+		b := make([]byte, size)
+		_, _ = io.ReadFull(r, b)
+		return string(b)
+	}
+	_, _ = r.ReadByte()
+	if read() != "user" || read() != "secret" {
+		_, _ = conn.Write([]byte{1, 1})
+		return
+	}
+	_, _ = conn.Write([]byte{1, 0})
+
+	req := make([]byte, 10)
+	if _, err := io.ReadFull(r, req); err != nil || req[3] != socks5IPv4 {
+		return
+	}
+	addr := &net.TCPAddr{IP: net.IP(req[4:8]), Port: int(req[8])<<8 | int(req[9])}
+	_, _ = conn.Write([]byte{socks5Version, 0, 0, socks5IPv4, 0, 0, 0, 0, 0, 0})
+	relay(struct {
+		io.Reader
+		io.Writer
+	}{r, conn}, addr.String())
+}
+
+// serveHTTP is an HTTP CONNECT proxy, expecting user:secret
+func serveHTTP(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	req, err := http.ReadRequest(r)
+	if err != nil {
+		return
+	}
+	if req.Method != http.MethodConnect ||
+		req.Header.Get("Proxy-Authorization") != "Basic "+base64.StdEncoding.EncodeToString([]byte("user:secret")) {
+		_, _ = io.WriteString(conn, "HTTP/1.1 407 Proxy Authentication Required\r\n\r\n")
+		return
+	}
+	target, err := net.Dial("tcp", req.Host)
+	if err != nil {
+		return
+	}
+	defer target.Close()
+
+	// What the target sent first directly follows the response
+	greeting := make([]byte, len("hello"))
+	if _, err = io.ReadFull(target, greeting); err != nil {
+		return
+	}
+	_, _ = io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n"+string(greeting))
+	go func() { _, _ = io.Copy(target, r) }()
+	_, _ = io.Copy(conn, target)
+}
+
+func TestProxyDialer(t *testing.T) {
+	target := listen(t, func(conn net.Conn) {
+		_, _ = io.WriteString(conn, "hello")
+	})
+
+	for name, serve := range map[string]func(net.Conn){
+		"socks5": serveSOCKS5,
+		"http":   serveHTTP,
+	} {
+		t.Run(name, func(t *testing.T) {
+			proxy := listen(t, serve)
+
+			dialer, err := newProxyDialer(name + "://user:secret@" + proxy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			conn, err := dialer.dial(context.Background(), target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+			content, _ := io.ReadAll(io.LimitReader(conn, int64(len("hello"))))
+			if string(content) != "hello" {
+				t.Fatalf("expected hello, got %q", content)
+			}
+
+			dialer, err = newProxyDialer(name + "://user:wrong@" + proxy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err = dialer.dial(context.Background(), target); err == nil {
+				t.Fatal("expected the proxy to reject the credentials")
+			}
+		})
+	}
+
+	if _, err := newProxyDialer("ftp://proxy"); err == nil {
+		t.Fatal("expected an unknown proxy scheme to be rejected")
+	}
+}
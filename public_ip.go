@@ -0,0 +1,247 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPublicIPRefresh = 10 * time.Minute
+	publicIPLookupTimeout  = 10 * time.Second
+)
+
+// checkPublicIP returns an error unless publicIP is blank, an IP address or
+// an IPv4 and an IPv6 address separated by a comma.
+func checkPublicIP(publicIP string) error {
+	if publicIP == "" {
+		return nil
+	}
+	ips := strings.Split(publicIP, ",")
+	if len(ips) > 2 {
+		return fmt.Errorf("ftp: invalid public IP %q, expected at most an IPv4 and an IPv6 address", publicIP)
+	}
+	var families [2]bool
+	for _, s := range ips {
+		ip := net.ParseIP(strings.TrimSpace(s))
+		if ip == nil {
+			return fmt.Errorf("ftp: invalid public IP %q", s)
+		}
+		v6 := 0
+		if ip.To4() == nil {
+			v6 = 1
+		}
+		if families[v6] {
+			return fmt.Errorf("ftp: invalid public IP %q, expected at most an IPv4 and an IPv6 address", publicIP)
+		}
+		families[v6] = true
+	}
+	return nil
+}
+
+// publicIPv4 returns the IPv4 address of publicIP, nil if it has none
+func publicIPv4(publicIP string) net.IP {
+	for _, s := range strings.Split(publicIP, ",") {
+		if ip := net.ParseIP(strings.TrimSpace(s)).To4(); ip != nil {
+			return ip
+		}
+	}
+	return nil
+}
+
+// publicIP returns the configured public IP, or the discovered one.
+func (server *Server) publicIP() string {
+	if server.PublicIP != "" {
+		return server.PublicIP
+	}
+	if ip, ok := server.discoveredIP.Load().(string); ok {
+		return ip
+	}
+	return ""
+}
+
+// discoverPublicIP refreshes the discovered public IP until ctx is done.
+func (server *Server) discoverPublicIP(ctx context.Context) {
+	ticker := time.NewTicker(server.PublicIPRefresh)
+	defer ticker.Stop()
+
+	for {
+		ip, err := lookupPublicIP(ctx, server.PublicIPDiscovery)
+		if err != nil {
+			server.logger.Printf("", "public IP discovery failed: %v", err)
+		} else if old, _ := server.discoveredIP.Load().(string); old != ip {
+			server.logger.Printf("", "public IP discovered: %s", ip)
+			server.discoveredIP.Store(ip)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// lookupPublicIP asks the service for the address this host is seen with. The
+// service is either an HTTP(S) URL answering with the address as plain text,
+// or a STUN server given as "stun:host:port".
+func lookupPublicIP(ctx context.Context, service string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, publicIPLookupTimeout)
+	defer cancel()
+
+	var (
+		ip  net.IP
+		err error
+	)
+	if addr, ok := strings.CutPrefix(service, "stun:"); ok {
+		ip, err = lookupSTUN(ctx, addr)
+	} else {
+		ip, err = lookupHTTP(ctx, service)
+	}
+	if err != nil {
+		return "", err
+	}
+	return ip.String(), nil
+}
+
+func lookupHTTP(ctx context.Context, url string) (net.IP, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s from %s", resp.Status, url)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 256))
+	if err != nil {
+		return nil, err
+	}
+
+	ip := net.ParseIP(strings.TrimSpace(string(body)))
+	if ip == nil {
+		return nil, fmt.Errorf("invalid address %q from %s", body, url)
+	}
+	return ip, nil
+}
+
+const (
+	stunMagicCookie       = 0x2112A442
+	stunBindingRequest    = 0x0001
+	stunBindingSuccess    = 0x0101
+	stunMappedAddress     = 0x0001
+	stunXorMappedAddress  = 0x0020
+	stunHeaderSize        = 20
+	stunTransactionIDSize = 12
+)
+
+// lookupSTUN sends a RFC 5389 binding request and returns the mapped address.
+func lookupSTUN(ctx context.Context, addr string) (net.IP, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	req := make([]byte, stunHeaderSize)
+	binary.BigEndian.PutUint16(req[0:], stunBindingRequest)
+	binary.BigEndian.PutUint32(req[4:], stunMagicCookie)
+	if _, err = rand.Read(req[8:stunHeaderSize]); err != nil {
+		return nil, err
+	}
+	if _, err = conn.Write(req); err != nil {
+		return nil, err
+	}
+
+	resp := make([]byte, 512)
+	n, err := conn.Read(resp)
+	if err != nil {
+		return nil, err
+	}
+	resp = resp[:n]
+
+	if len(resp) < stunHeaderSize ||
+		binary.BigEndian.Uint16(resp[0:]) != stunBindingSuccess ||
+		string(resp[8:stunHeaderSize]) != string(req[8:stunHeaderSize]) {
+		return nil, errors.New("invalid STUN response")
+	}
+
+	attrs := resp[stunHeaderSize:]
+	for len(attrs) >= 4 {
+		attrType := binary.BigEndian.Uint16(attrs[0:])
+		attrLen := int(binary.BigEndian.Uint16(attrs[2:]))
+		if len(attrs) < 4+attrLen {
+			break
+		}
+		value := attrs[4 : 4+attrLen]
+
+		switch attrType {
+		case stunXorMappedAddress:
+			return parseSTUNAddress(value, resp[4:stunHeaderSize])
+		case stunMappedAddress:
+			return parseSTUNAddress(value, nil)
+		}
+
+		// Attributes are padded to a multiple of 4 bytes, a last one may
+		// come unpadded from sloppy servers.
+		padded := 4 + (attrLen+3)&^3
+		if padded > len(attrs) {
+			break
+		}
+		attrs = attrs[padded:]
+	}
+	return nil, errors.New("no mapped address in STUN response")
+}
+
+// parseSTUNAddress decodes a (XOR-)MAPPED-ADDRESS value, xor holds the magic
+// cookie and transaction ID for the XOR variant.
+func parseSTUNAddress(value, xor []byte) (net.IP, error) {
+	if len(value) < 4 {
+		return nil, errors.New("short STUN address")
+	}
+
+	var size int
+	switch value[1] {
+	case 0x01:
+		size = net.IPv4len
+	case 0x02:
+		size = net.IPv6len
+	default:
+		return nil, errors.New("unknown STUN address family")
+	}
+	if len(value) < 4+size {
+		return nil, errors.New("short STUN address")
+	}
+
+	ip := make(net.IP, size)
+	copy(ip, value[4:4+size])
+	if xor != nil {
+		for i := range ip {
+			ip[i] ^= xor[i]
+		}
+	}
+	return ip, nil
+}
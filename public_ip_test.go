@@ -0,0 +1,73 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLookupPublicIP(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("203.0.113.7\n"))
+	}))
+	defer ts.Close()
+
+	ip, err := lookupPublicIP(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ip != "203.0.113.7" {
+		t.Fatalf("expected 203.0.113.7, got %s", ip)
+	}
+}
+
+func TestParseSTUNAddress(t *testing.T) {
+	// 203.0.113.7 xored with the magic cookie 0x2112A442.
+	value := []byte{0x00, 0x01, 0x00, 0x00, 203 ^ 0x21, 0 ^ 0x12, 113 ^ 0xA4, 7 ^ 0x42}
+	xor := []byte{0x21, 0x12, 0xA4, 0x42}
+
+	ip, err := parseSTUNAddress(value, xor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ip.Equal(net.IPv4(203, 0, 113, 7)) {
+		t.Fatalf("expected 203.0.113.7, got %s", ip)
+	}
+}
+
+func TestLookupSTUNUnpadded(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	go func() {
+		req := make([]byte, 512)
+		n, addr, err := conn.ReadFrom(req)
+		if err != nil || n < stunHeaderSize {
+			return
+		}
+		// A success response ending with an unpadded 5 bytes attribute
+		resp := make([]byte, stunHeaderSize, stunHeaderSize+9)
+		binary.BigEndian.PutUint16(resp[0:], stunBindingSuccess)
+		binary.BigEndian.PutUint16(resp[2:], 9)
+		copy(resp[4:], req[4:stunHeaderSize])
+		resp = append(resp, 0x80, 0x22, 0x00, 0x05, 'g', 'o', 'f', 't', 'p')
+		_, _ = conn.WriteTo(resp, addr)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := lookupSTUN(ctx, conn.LocalAddr().String()); err == nil {
+		t.Fatal("expected an error without a mapped address")
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quarantine
+
+import (
+	"io"
+
+	"github.com/globalcyberalliance/ftp-go"
+)
+
+var _ ftp.Driver = &Driver{}
+
+// Driver wraps another ftp.Driver and diverts every upload into a Store,
+// uploads never reach the wrapped driver and so are never listed or served.
+// Everything else is passed through.
+type Driver struct {
+	ftp.Driver
+	store *Store
+}
+
+// NewDriver creates a Driver quarantining the uploads to base in store
+func NewDriver(store *Store, base ftp.Driver) *Driver {
+	return &Driver{
+		Driver: base,
+		store:  store,
+	}
+}
+
+// PutFile implements ftp.Driver
+func (driver *Driver) PutFile(ctx *ftp.Context, p string, data io.Reader, offset int64) (int64, error) {
+	entry, err := driver.store.Put(ctx, p, data, offset)
+	if entry == nil {
+		return 0, err
+	}
+	return entry.Size, err
+}
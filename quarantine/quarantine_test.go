@@ -0,0 +1,83 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quarantine
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/globalcyberalliance/ftp-go"
+	"github.com/globalcyberalliance/ftp-go/driver/file"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDriver(t *testing.T) {
+	dir := t.TempDir()
+	base, err := file.NewDriver(dir)
+	assert.NoError(t, err)
+
+	store, err := NewStore(t.TempDir())
+	assert.NoError(t, err)
+	driver := NewDriver(store, base)
+	ctx := &ftp.Context{}
+
+	size, err := driver.PutFile(ctx, "/evil.sh", strings.NewReader("payload"), -1)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 7, size)
+	_, err = driver.PutFile(ctx, "/again.sh", strings.NewReader("payload"), -1)
+	assert.NoError(t, err)
+
+	// Nothing reaches the visible tree.
+	_, err = os.Stat(filepath.Join(dir, "evil.sh"))
+	assert.True(t, os.IsNotExist(err))
+
+	entries, err := store.Entries()
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 2) {
+		assert.EqualValues(t, "/evil.sh", entries[0].Path)
+		assert.EqualValues(t, "/again.sh", entries[1].Path)
+		assert.EqualValues(t, entries[0].SHA256, entries[1].SHA256)
+		assert.EqualValues(t, 7, entries[0].Size)
+
+		f, err := store.Open(entries[0].SHA256)
+		if assert.NoError(t, err) {
+			content, err := io.ReadAll(f)
+			f.Close()
+			assert.NoError(t, err)
+			assert.EqualValues(t, "payload", string(content))
+		}
+	}
+
+	_, err = store.Open("../index.jsonl")
+	assert.ErrorIs(t, err, ErrInvalidDigest)
+}
+
+func TestStoreMaxSize(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	assert.NoError(t, err)
+	store.MaxSize = 4
+
+	entry, err := store.Put(&ftp.Context{}, "/big.bin", strings.NewReader("0123456789"), 3)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 10, entry.Size)
+	assert.EqualValues(t, 3, entry.Offset)
+	assert.True(t, entry.Truncated)
+
+	f, err := store.Open(entry.SHA256)
+	if assert.NoError(t, err) {
+		content, err := io.ReadAll(f)
+		f.Close()
+		assert.NoError(t, err)
+		assert.EqualValues(t, "0123", string(content))
+	}
+
+	entry, err = store.Put(&ftp.Context{}, "/small.bin", strings.NewReader("0123"), -1)
+	assert.NoError(t, err)
+	assert.False(t, entry.Truncated)
+	assert.EqualValues(t, 0, entry.Offset)
+}
@@ -0,0 +1,172 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package quarantine diverts uploads into an append-only store where they
+// can be analyzed without ever being served back to clients.
+package quarantine
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/globalcyberalliance/ftp-go"
+)
+
+const indexFile = "index.jsonl"
+
+// DefaultMaxSize is the MaxSize set by NewStore.
+const DefaultMaxSize = 64 << 20
+
+// ErrInvalidDigest is returned by Open for names that are not a sha256
+// digest.
+var ErrInvalidDigest = errors.New("quarantine: invalid digest")
+
+// Entry records a quarantined upload
+type Entry struct {
+	Time       time.Time `json:"time"`
+	SHA256     string    `json:"sha256"`
+	Size       int64     `json:"size"`
+	Offset     int64     `json:"offset,omitempty"`    // the REST offset of a resumed upload
+	Truncated  bool      `json:"truncated,omitempty"` // only the first MaxSize bytes were kept
+	Path       string    `json:"path"`                // the path the client uploaded to
+	User       string    `json:"user,omitempty"`
+	SessionID  string    `json:"session_id,omitempty"`
+	RemoteAddr string    `json:"remote_addr,omitempty"`
+}
+
+// Store is an append-only quarantine directory. Payloads are kept under
+// payloads/ named after their sha256 digest, read-only and never
+// overwritten, and every upload appends an Entry to index.jsonl.
+type Store struct {
+	// MaxSize caps the bytes kept of each upload, the rest is read and
+	// discarded. 0 keeps everything.
+	MaxSize int64
+
+	dir string
+	mu  sync.Mutex // serializes index appends
+}
+
+// NewStore opens the store in dir, creating it if needed
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Join(dir, "payloads"), 0o700); err != nil {
+		return nil, err
+	}
+	return &Store{MaxSize: DefaultMaxSize, dir: dir}, nil
+}
+
+// Put copies r into the store and records it as uploaded to p at offset,
+// a negative offset being a new upload. Size is the full upload size even
+// when the payload was cut to MaxSize.
+func (s *Store) Put(ctx *ftp.Context, p string, r io.Reader, offset int64) (*Entry, error) {
+	f, err := os.CreateTemp(s.dir, ".upload-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+
+	h := sha256.New()
+	var kept io.Reader = r
+	if s.MaxSize > 0 {
+		kept = io.LimitReader(r, s.MaxSize)
+	}
+	size, err := io.Copy(io.MultiWriter(f, h), kept)
+	truncated := false
+	if err == nil && s.MaxSize > 0 && size == s.MaxSize {
+		var rest int64
+		rest, err = io.Copy(io.Discard, r)
+		size += rest
+		truncated = rest > 0
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	entry := &Entry{
+		Time:      time.Now(),
+		SHA256:    hex.EncodeToString(h.Sum(nil)),
+		Size:      size,
+		Offset:    max(offset, 0),
+		Truncated: truncated,
+		Path:      p,
+	}
+	if err != nil {
+		return entry, err
+	}
+	if ctx != nil && ctx.Sess != nil {
+		entry.User = ctx.Sess.LoginUser()
+		entry.SessionID = ctx.Sess.ID()
+		entry.RemoteAddr = ctx.Sess.RemoteAddr().String()
+	}
+
+	// A link never replaces an existing payload, the same digest means the
+	// same content anyway.
+	if err := os.Chmod(f.Name(), 0o400); err != nil {
+		return entry, err
+	}
+	if err := os.Link(f.Name(), s.payloadPath(entry.SHA256)); err != nil && !os.IsExist(err) {
+		return entry, err
+	}
+	return entry, s.appendEntry(entry)
+}
+
+func (s *Store) payloadPath(sum string) string {
+	return filepath.Join(s.dir, "payloads", sum)
+}
+
+func (s *Store) appendEntry(entry *Entry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(filepath.Join(s.dir, indexFile), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Entries returns the entries recorded so far, oldest first
+func (s *Store) Entries() ([]Entry, error) {
+	f, err := os.Open(filepath.Join(s.dir, indexFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return entries, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// Open opens the payload with the sha256 digest sum for reading
+func (s *Store) Open(sum string) (*os.File, error) {
+	if b, err := hex.DecodeString(sum); err != nil || len(b) != sha256.Size {
+		return nil, ErrInvalidDigest
+	}
+	return os.Open(s.payloadPath(sum))
+}
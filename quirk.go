@@ -0,0 +1,198 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Quirk selects compatibility workarounds for clients that mishandle some
+// features. The commands of a quirk are left out of FEAT and refused with
+// 502, so the client falls back to what it handles.
+type Quirk uint8
+
+const (
+	// QuirkNoEPSV refuses EPSV, for clients that cannot fall back to PASV
+	// once EPSV failed to connect.
+	QuirkNoEPSV Quirk = 1 << iota
+	// QuirkNoMLSD refuses MLSD and MLST, for clients that cannot parse
+	// machine listings.
+	QuirkNoMLSD
+	// QuirkNoUTF8 hides UTF8 from FEAT, for clients that mangle file names
+	// once it is announced.
+	QuirkNoUTF8
+	// QuirkDriveLetters drops the drive letter in front of absolute paths,
+	// as in "C:\dir", for Windows clients that send them.
+	QuirkDriveLetters
+)
+
+var quirkNames = []struct {
+	quirk    Quirk
+	name     string
+	commands []string // refused commands and hidden features
+}{
+	{QuirkNoEPSV, "noepsv", []string{"EPSV"}},
+	{QuirkNoMLSD, "nomlsd", []string{"MLSD", "MLST"}},
+	{QuirkNoUTF8, "noutf8", []string{"UTF8"}},
+	{QuirkDriveLetters, "driveletters", nil},
+}
+
+// ClientQuirk applies Quirks to the clients it matches, see
+// Options.ClientQuirks.
+type ClientQuirk struct {
+	// Regular expression matched against what the client announced with
+	// CLNT.
+	Client string `json:"client,omitempty" yaml:"client,omitempty"`
+	// Regular expression matched against the password of anonymous logins,
+	// which browsers and some other clients identify themselves with.
+	Password string `json:"password,omitempty" yaml:"password,omitempty"`
+	Quirks   Quirk  `json:"quirks" yaml:"quirks"`
+}
+
+// DefaultClientQuirks is used when Options.ClientQuirks is nil.
+var DefaultClientQuirks = []ClientQuirk{
+	// Browser FTP clients only know PASV and LIST.
+	{Password: `(?i)^(IEUser|mozilla|chrome)@`, Quirks: QuirkNoEPSV | QuirkNoMLSD},
+}
+
+// clientQuirk is a ClientQuirk with its expressions compiled.
+type clientQuirk struct {
+	client   *regexp.Regexp
+	password *regexp.Regexp
+	quirks   Quirk
+}
+
+func compileClientQuirks(quirks []ClientQuirk) ([]clientQuirk, error) {
+	compiled := make([]clientQuirk, 0, len(quirks))
+	for _, q := range quirks {
+		c := clientQuirk{quirks: q.Quirks}
+		var err error
+		if q.Client != "" {
+			if c.client, err = regexp.Compile(q.Client); err != nil {
+				return nil, fmt.Errorf("ftp: client quirk %q: %w", q.Client, err)
+			}
+		}
+		if q.Password != "" {
+			if c.password, err = regexp.Compile(q.Password); err != nil {
+				return nil, fmt.Errorf("ftp: client quirk %q: %w", q.Password, err)
+			}
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled, nil
+}
+
+// disables reports whether the quirks refuse command.
+func (q Quirk) disables(command string) bool {
+	for _, n := range quirkNames {
+		if q&n.quirk == 0 {
+			continue
+		}
+		for _, c := range n.commands {
+			if c == command {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// feats returns the FEAT reply without the features the quirks hide.
+func (q Quirk) feats(feats string) string {
+	if q == 0 {
+		return feats
+	}
+	lines := strings.Split(feats, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if strings.HasPrefix(line, " ") && len(fields) > 0 && q.disables(fields[0]) {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.Join(kept, "\n")
+}
+
+// String returns the comma separated names of the quirks, "noepsv",
+// "nomlsd", "noutf8" and "driveletters".
+func (q Quirk) String() string {
+	var names []string
+	for _, n := range quirkNames {
+		if q&n.quirk != 0 {
+			names = append(names, n.name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// ParseQuirk parses a comma separated list of quirk names, see
+// Quirk.String.
+func ParseQuirk(s string) (Quirk, error) {
+	var q Quirk
+	for _, field := range strings.Split(s, ",") {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if field == "" {
+			continue
+		}
+
+		found := false
+		for _, n := range quirkNames {
+			if n.name == field {
+				q |= n.quirk
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("ftp: unknown quirk %q", field)
+		}
+	}
+	return q, nil
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (q Quirk) MarshalText() ([]byte, error) {
+	return []byte(q.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (q *Quirk) UnmarshalText(text []byte) error {
+	parsed, err := ParseQuirk(string(text))
+	if err != nil {
+		return err
+	}
+	*q = parsed
+	return nil
+}
+
+// SetQuirks changes the compatibility quirks applied to the session
+func (sess *Session) SetQuirks(quirks Quirk) {
+	sess.quirks = quirks
+}
+
+// Quirks returns the compatibility quirks applied to the session
+func (sess *Session) Quirks() Quirk {
+	return sess.quirks
+}
+
+// isAnonymousUser reports whether name is one of the conventional
+// anonymous login names.
+func isAnonymousUser(name string) bool {
+	return strings.EqualFold(name, "anonymous") || strings.EqualFold(name, "ftp")
+}
+
+// detectQuirks adds the quirks of the client quirks matching the CLNT
+// announcement or the anonymous password, either may be blank.
+func (sess *Session) detectQuirks(client, password string) {
+	for _, q := range sess.server.clientQuirks {
+		if (client != "" && q.client != nil && q.client.MatchString(client)) ||
+			(password != "" && q.password != nil && q.password.MatchString(password)) {
+			sess.quirks |= q.quirks
+		}
+	}
+}
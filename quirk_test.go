@@ -0,0 +1,45 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseQuirk(t *testing.T) {
+	q, err := ParseQuirk("noepsv, NoMLSD")
+	if err != nil || q != QuirkNoEPSV|QuirkNoMLSD {
+		t.Fatalf("expected noepsv,nomlsd, got %v (%v)", q, err)
+	}
+	if q.String() != "noepsv,nomlsd" {
+		t.Fatalf("expected noepsv,nomlsd, got %s", q)
+	}
+	if _, err := ParseQuirk("nopasv"); err == nil {
+		t.Fatal("expected an error for an unknown quirk")
+	}
+}
+
+func TestClientQuirks(t *testing.T) {
+	client := newPipeSession(t, &Options{
+		ClientQuirks: []ClientQuirk{
+			{Client: `^Broken `, Quirks: QuirkNoEPSV | QuirkNoUTF8},
+		},
+	})
+
+	if feats := expectCode(t, client, 211, "FEAT"); !strings.Contains(feats, "EPSV") {
+		t.Fatalf("expected EPSV in FEAT, got %q", feats)
+	}
+
+	expectCode(t, client, 200, "CLNT Broken 1.0")
+	feats := expectCode(t, client, 211, "FEAT")
+	if strings.Contains(feats, "EPSV") || strings.Contains(feats, "UTF8") {
+		t.Fatalf("expected EPSV and UTF8 hidden, got %q", feats)
+	}
+	if !strings.Contains(feats, "MLSD") {
+		t.Fatalf("expected MLSD in FEAT, got %q", feats)
+	}
+	expectCode(t, client, 502, "EPSV")
+}
@@ -0,0 +1,214 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrQuotaExceeded is returned when a transfer would exceed the user's transfer quota.
+var ErrQuotaExceeded = errors.New("ftp: transfer quota exceeded")
+
+// QuotaPeriod is the period after which transfer quotas reset.
+type QuotaPeriod int
+
+const (
+	// QuotaDaily quotas reset at midnight UTC.
+	QuotaDaily QuotaPeriod = iota
+	// QuotaMonthly quotas reset on the first of each month, midnight UTC.
+	QuotaMonthly
+)
+
+// Start returns the start of the period containing t.
+func (p QuotaPeriod) Start(t time.Time) time.Time {
+	t = t.UTC()
+	if p == QuotaMonthly {
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// String implements fmt.Stringer
+func (p QuotaPeriod) String() string {
+	if p == QuotaMonthly {
+		return "monthly"
+	}
+	return "daily"
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (p QuotaPeriod) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (p *QuotaPeriod) UnmarshalText(text []byte) error {
+	switch strings.ToLower(string(text)) {
+	case "daily", "":
+		*p = QuotaDaily
+	case "monthly":
+		*p = QuotaMonthly
+	default:
+		return fmt.Errorf("ftp: unknown quota period %q", text)
+	}
+	return nil
+}
+
+// TransferQuota limits the number of bytes a user may transfer per period.
+type TransferQuota struct {
+	Period QuotaPeriod `json:"period" yaml:"period"`
+
+	// Bytes that may be uploaded per period, 0 means no limit
+	Upload int64 `json:"upload" yaml:"upload"`
+
+	// Bytes that may be downloaded per period, 0 means no limit
+	Download int64 `json:"download" yaml:"download"`
+}
+
+// QuotaStore keeps track of the bytes transferred by each user and period.
+// Implementations backed by a shared database allow quotas to be enforced
+// across several servers.
+type QuotaStore interface {
+	// Usage returns the bytes uploaded and downloaded by user in the period
+	// starting at start.
+	Usage(user string, start time.Time) (up, down int64, err error)
+
+	// AddUsage records bytes uploaded and downloaded by user in the period
+	// starting at start.
+	AddUsage(user string, start time.Time, up, down int64) error
+}
+
+var _ QuotaStore = &MemoryQuotaStore{}
+
+// MemoryQuotaStore is an in-memory QuotaStore, usage is lost on restart.
+type MemoryQuotaStore struct {
+	usage map[string]*quotaUsage
+	lock  sync.Mutex
+}
+
+type quotaUsage struct {
+	start    time.Time
+	up, down int64
+}
+
+// NewMemoryQuotaStore creates a MemoryQuotaStore
+func NewMemoryQuotaStore() *MemoryQuotaStore {
+	return &MemoryQuotaStore{
+		usage: make(map[string]*quotaUsage),
+	}
+}
+
+// Usage implements QuotaStore
+func (store *MemoryQuotaStore) Usage(user string, start time.Time) (int64, int64, error) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	usage, ok := store.usage[user]
+	if !ok || !usage.start.Equal(start) {
+		return 0, 0, nil
+	}
+	return usage.up, usage.down, nil
+}
+
+// AddUsage implements QuotaStore
+func (store *MemoryQuotaStore) AddUsage(user string, start time.Time, up, down int64) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	// Only the current period is kept per user.
+	usage, ok := store.usage[user]
+	if !ok || !usage.start.Equal(start) {
+		usage = &quotaUsage{start: start}
+		store.usage[user] = usage
+	}
+	usage.up += up
+	usage.down += down
+	return nil
+}
+
+// SetTransferQuota changes the transfer quota of the session's user
+func (sess *Session) SetTransferQuota(quota TransferQuota) {
+	sess.transferQuota = quota
+}
+
+// TransferQuota returns the transfer quota of the session's user
+func (sess *Session) TransferQuota() TransferQuota {
+	return sess.transferQuota
+}
+
+// QuotaUsage returns the bytes the session's user uploaded and downloaded
+// in the current period of their transfer quota.
+func (sess *Session) QuotaUsage() (up, down int64, err error) {
+	return sess.server.QuotaStore.Usage(sess.user, sess.transferQuota.Period.Start(time.Now()))
+}
+
+// QuotaRemaining returns the bytes the session's user may still upload and
+// download in the current period, -1 means no limit.
+func (sess *Session) QuotaRemaining() (up, down int64, err error) {
+	quota := sess.transferQuota
+	if quota.Upload <= 0 && quota.Download <= 0 {
+		return -1, -1, nil
+	}
+
+	usedUp, usedDown, err := sess.QuotaUsage()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	up, down = -1, -1
+	if quota.Upload > 0 {
+		up = max(quota.Upload-usedUp, 0)
+	}
+	if quota.Download > 0 {
+		down = max(quota.Download-usedDown, 0)
+	}
+	return up, down, nil
+}
+
+// addQuotaUsage records transferred bytes against the session's quota.
+func (sess *Session) addQuotaUsage(up, down int64) {
+	if up == 0 && down == 0 {
+		return
+	}
+
+	start := sess.transferQuota.Period.Start(time.Now())
+	if err := sess.server.QuotaStore.AddUsage(sess.user, start, up, down); err != nil {
+		sess.logf("recording transfer quota usage failed: %v", err)
+	}
+}
+
+// NewQuotaReader returns a reader passing on at most remaining bytes of r,
+// which fails with err once r has more. A negative remaining is taken as 0.
+// Drivers enforcing storage quotas wrap the data of PutFile with it.
+func NewQuotaReader(r io.Reader, remaining int64, err error) io.Reader {
+	return &quotaReader{
+		r:         r,
+		remaining: max(remaining, 0),
+		err:       err,
+	}
+}
+
+// quotaReader fails with err once more than remaining bytes are read,
+// passing on at most remaining bytes.
+type quotaReader struct {
+	r         io.Reader
+	remaining int64
+	err       error
+}
+
+func (q *quotaReader) Read(p []byte) (int, error) {
+	n, err := q.r.Read(p)
+	if int64(n) > q.remaining {
+		n = int(q.remaining)
+		err = q.err
+	}
+	q.remaining -= int64(n)
+	return n, err
+}
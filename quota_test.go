@@ -0,0 +1,27 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestQuotaReader(t *testing.T) {
+	errQuota := errors.New("quota exceeded")
+
+	content, err := io.ReadAll(NewQuotaReader(strings.NewReader("hello"), 3, errQuota))
+	if string(content) != "hel" || !errors.Is(err, errQuota) {
+		t.Fatalf("got %q, %v", content, err)
+	}
+
+	// Usage already over quota allows nothing
+	content, err = io.ReadAll(NewQuotaReader(strings.NewReader("hello"), -2, errQuota))
+	if len(content) != 0 || !errors.Is(err, errQuota) {
+		t.Fatalf("got %q, %v", content, err)
+	}
+}
@@ -5,34 +5,132 @@
 package ratelimit
 
 import (
+	"sync"
 	"time"
 )
 
-// Limiter represents a rate limiter
+const (
+	// minBurst is the smallest default burst, so small rates still move
+	// reasonably sized chunks.
+	minBurst = 4 << 10
+)
+
+// Limiter represents a token bucket rate limiter. The bucket fills at rate
+// bytes per second up to burst bytes, and every transferred byte takes one
+// token. When the bucket runs dry callers are paced so the average never
+// exceeds the rate, while short bursts of up to burst bytes pass immediately.
+//
+// It is safe for concurrent use, so one limiter can be shared by several
+// readers and writers.
 type Limiter struct {
-	t     time.Time
-	rate  time.Duration
-	count int64
+	last   time.Time
+	rate   int64
+	burst  int64
+	tokens float64
+	lock   sync.Mutex
+
+	// replaced in tests
+	now   func() time.Time
+	sleep func(time.Duration)
 }
 
 // New create a limiter for transfer speed, parameter rate means bytes per second
-// 0 means don't limit
+// 0 means don't limit. The burst defaults to a tenth of a second worth of
+// bytes.
 func New(rate int64) *Limiter {
-	return &Limiter{
-		rate:  time.Duration(rate),
-		count: 0,
-		t:     time.Now(),
+	return NewWithBurst(rate, 0)
+}
+
+// NewWithBurst creates a limiter for rate bytes per second allowing bursts of
+// up to burst bytes, 0 means the default burst.
+func NewWithBurst(rate, burst int64) *Limiter {
+	l := &Limiter{
+		now:   time.Now,
+		sleep: time.Sleep,
 	}
+	l.last = l.now()
+	l.set(rate, burst)
+	return l
 }
 
-// Wait sleep when write count bytes
+// set updates the rate and burst, l.lock must be held or l unshared.
+func (l *Limiter) set(rate, burst int64) {
+	if burst <= 0 {
+		burst = defaultBurst(rate)
+	}
+	l.rate = rate
+	l.burst = burst
+	l.tokens = float64(burst)
+	l.last = l.now()
+}
+
+// Rate returns the current limit in bytes per second
+func (l *Limiter) Rate() int64 {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.rate
+}
+
+// Burst returns the current burst size in bytes
+func (l *Limiter) Burst() int64 {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.burst
+}
+
+// SetRate changes the limit in bytes per second, 0 means don't limit. It takes
+// effect for readers and writers already using the limiter. An explicitly
+// configured burst is kept, a default one follows the new rate.
+func (l *Limiter) SetRate(rate int64) {
+	l.lock.Lock()
+	burst := l.burst
+	if defaultBurst(l.rate) == burst {
+		burst = 0
+	}
+	l.set(rate, burst)
+	l.lock.Unlock()
+}
+
+// SetBurst changes the burst size in bytes, 0 means the default burst.
+func (l *Limiter) SetBurst(burst int64) {
+	l.lock.Lock()
+	l.set(l.rate, burst)
+	l.lock.Unlock()
+}
+
+func defaultBurst(rate int64) int64 {
+	if rate/10 < minBurst {
+		return minBurst
+	}
+	return rate / 10
+}
+
+// Wait takes count tokens from the bucket, sleeping until the debt is paid
+// off if there were not enough.
 func (l *Limiter) Wait(count int) {
+	l.lock.Lock()
 	if l.rate == 0 {
+		l.lock.Unlock()
 		return
 	}
-	l.count += int64(count)
-	t := time.Duration(l.count)*time.Second/l.rate - time.Since(l.t)
-	if t > 0 {
-		time.Sleep(t)
+
+	now := l.now()
+	l.tokens += now.Sub(l.last).Seconds() * float64(l.rate)
+	if l.tokens > float64(l.burst) {
+		l.tokens = float64(l.burst)
+	}
+	l.last = now
+
+	// Take the tokens even when going into debt, so concurrent callers
+	// queue up behind each other instead of all waking at once.
+	l.tokens -= float64(count)
+	var wait time.Duration
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / float64(l.rate) * float64(time.Second))
+	}
+	l.lock.Unlock()
+
+	if wait > 0 {
+		l.sleep(wait)
 	}
 }
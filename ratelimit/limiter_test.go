@@ -0,0 +1,82 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock advances only when the limiter sleeps.
+type fakeClock struct {
+	t     time.Time
+	slept time.Duration
+}
+
+func (c *fakeClock) now() time.Time {
+	return c.t
+}
+
+func (c *fakeClock) sleep(d time.Duration) {
+	c.t = c.t.Add(d)
+	c.slept += d
+}
+
+func newTestLimiter(rate, burst int64) (*Limiter, *fakeClock) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	l := &Limiter{
+		now:   clock.now,
+		sleep: clock.sleep,
+	}
+	l.set(rate, burst)
+	return l, clock
+}
+
+func TestLimiterBurst(t *testing.T) {
+	l, clock := newTestLimiter(1000, 500)
+
+	// The full bucket passes without waiting.
+	l.Wait(500)
+	if clock.slept != 0 {
+		t.Fatalf("expected no wait within the burst, waited %v", clock.slept)
+	}
+
+	// The next 1000 bytes take a second.
+	for i := 0; i < 10; i++ {
+		l.Wait(100)
+	}
+	if clock.slept != time.Second {
+		t.Fatalf("expected to wait 1s, waited %v", clock.slept)
+	}
+}
+
+func TestLimiterRefill(t *testing.T) {
+	l, clock := newTestLimiter(1000, 500)
+	l.Wait(500)
+
+	// An idle period refills the bucket, but never beyond the burst.
+	clock.t = clock.t.Add(time.Hour)
+	l.Wait(1000)
+	if clock.slept != 500*time.Millisecond {
+		t.Fatalf("expected to wait 500ms, waited %v", clock.slept)
+	}
+}
+
+func TestLimiterSetRate(t *testing.T) {
+	l, clock := newTestLimiter(0, 0)
+	l.Wait(1 << 20)
+	if clock.slept != 0 {
+		t.Fatalf("expected no wait without limit, waited %v", clock.slept)
+	}
+
+	l.SetRate(1 << 20)
+	if l.Burst() != (1<<20)/10 {
+		t.Fatalf("expected default burst to follow the rate, got %d", l.Burst())
+	}
+	l.Wait(1<<20 + int(l.Burst()))
+	if clock.slept != time.Second {
+		t.Fatalf("expected to wait 1s, waited %v", clock.slept)
+	}
+}
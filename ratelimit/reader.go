@@ -7,21 +7,33 @@ package ratelimit
 import "io"
 
 type reader struct {
-	r io.Reader
-	l *Limiter
+	r  io.Reader
+	ls []*Limiter
 }
 
 // Read Read
 func (r *reader) Read(buf []byte) (int, error) {
 	n, err := r.r.Read(buf)
-	r.l.Wait(n)
+	for _, l := range r.ls {
+		l.Wait(n)
+	}
 	return n, err
 }
 
-// Reader returns a reader with limiter
-func Reader(r io.Reader, l *Limiter) io.Reader {
+// Reader returns a reader limited by all the given limiters, nil limiters are ignored
+func Reader(r io.Reader, limiters ...*Limiter) io.Reader {
 	return &reader{
-		r: r,
-		l: l,
+		r:  r,
+		ls: nonNil(limiters),
+	}
+}
+
+func nonNil(limiters []*Limiter) []*Limiter {
+	var ls []*Limiter
+	for _, l := range limiters {
+		if l != nil {
+			ls = append(ls, l)
+		}
 	}
+	return ls
 }
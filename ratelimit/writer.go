@@ -7,20 +7,22 @@ package ratelimit
 import "io"
 
 type writer struct {
-	w io.Writer
-	l *Limiter
+	w  io.Writer
+	ls []*Limiter
 }
 
 // Write Write
 func (w *writer) Write(buf []byte) (int, error) {
-	w.l.Wait(len(buf))
+	for _, l := range w.ls {
+		l.Wait(len(buf))
+	}
 	return w.w.Write(buf)
 }
 
-// Writer returns a writer with limiter
-func Writer(w io.Writer, l *Limiter) io.Writer {
+// Writer returns a writer limited by all the given limiters, nil limiters are ignored
+func Writer(w io.Writer, limiters ...*Limiter) io.Writer {
 	return &writer{
-		w: w,
-		l: l,
+		w:  w,
+		ls: nonNil(limiters),
 	}
 }
@@ -0,0 +1,297 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package redis shares the state several servers must agree on through a
+// Redis database: a Store is the ftp.BanList, ftp.LoginFailureStore and
+// ftp.QuotaStore of every server of a cluster, so that a source banned or
+// slowed down by one is by all, and transfer quotas count what users
+// transfer through any of them.
+//
+//	store, err := redis.NewStore(&redis.Options{Addr: "redis:6379"})
+//	defer store.Close()
+//	server, err := ftp.NewServer(&ftp.Options{
+//		MaxLoginFailures:  10,
+//		BanList:           store,
+//		LoginFailureStore: store,
+//		QuotaStore:        store,
+//		...
+//	})
+package redis
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultPrefix   = "ftp:"
+	defaultPoolSize = 4
+	defaultTimeout  = 5 * time.Second
+	defaultQuotaTTL = 32 * 24 * time.Hour
+)
+
+// ErrClosed is returned using a closed Store
+var ErrClosed = errors.New("redis: store closed")
+
+// Error is an error reply of the database
+type Error string
+
+// Error implements error
+func (e Error) Error() string {
+	return "redis: " + string(e)
+}
+
+// Options configure a Store
+type Options struct {
+	// Address of the database, "host:port"
+	Addr string
+
+	// Credentials, for AUTH. Optional.
+	Username string
+	Password string
+
+	// Database selected with SELECT. Optional, defaults to 0.
+	DB int
+
+	// TLS configuration connections are protected with. Optional, they
+	// are in the clear without.
+	TLSConfig *tls.Config
+
+	// Prefix of the keys of the store. Optional, defaults to "ftp:".
+	Prefix string
+
+	// Number of idle connections kept for later requests. Optional,
+	// defaults to 4.
+	PoolSize int
+
+	// Bound of connecting and of each request. Optional, defaults to 5
+	// seconds.
+	Timeout time.Duration
+
+	// How long the transfer usage of a quota period is kept after it was
+	// last updated, longer than the longest period. Optional, defaults to
+	// 32 days.
+	QuotaTTL time.Duration
+}
+
+// Store is an ftp.BanList, ftp.LoginFailureStore and ftp.QuotaStore kept in
+// a Redis database, see NewStore. It is safe for concurrent use.
+type Store struct {
+	opts Options
+
+	mu     sync.Mutex
+	idle   []*conn
+	closed bool
+}
+
+// NewStore creates a Store of the database at opts.Addr, connecting on
+// first use.
+func NewStore(opts *Options) (*Store, error) {
+	if opts.Addr == "" {
+		return nil, errors.New("redis: no address")
+	}
+	s := &Store{opts: *opts}
+	if s.opts.Prefix == "" {
+		s.opts.Prefix = defaultPrefix
+	}
+	if s.opts.PoolSize <= 0 {
+		s.opts.PoolSize = defaultPoolSize
+	}
+	if s.opts.Timeout <= 0 {
+		s.opts.Timeout = defaultTimeout
+	}
+	if s.opts.QuotaTTL <= 0 {
+		s.opts.QuotaTTL = defaultQuotaTTL
+	}
+	return s, nil
+}
+
+// Close closes the connections of the store
+func (s *Store) Close() error {
+	s.mu.Lock()
+	idle := s.idle
+	s.idle, s.closed = nil, true
+	s.mu.Unlock()
+
+	var errs []error
+	for _, c := range idle {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// Do sends commands to the database in a single round trip and returns
+// their replies: nil, int64, string, []interface{} or Error.
+func (s *Store) Do(commands ...[]string) ([]interface{}, error) {
+	c, err := s.get()
+	if err != nil {
+		return nil, err
+	}
+	replies, err := c.do(s.opts.Timeout, commands...)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	s.put(c)
+	return replies, nil
+}
+
+// get returns an idle connection or a new one
+func (s *Store) get() (*conn, error) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil, ErrClosed
+	}
+	if n := len(s.idle); n > 0 {
+		c := s.idle[n-1]
+		s.idle = s.idle[:n-1]
+		s.mu.Unlock()
+		return c, nil
+	}
+	s.mu.Unlock()
+	return s.dial()
+}
+
+// put keeps c for later requests, or closes it
+func (s *Store) put(c *conn) {
+	s.mu.Lock()
+	if !s.closed && len(s.idle) < s.opts.PoolSize {
+		s.idle = append(s.idle, c)
+		c = nil
+	}
+	s.mu.Unlock()
+	if c != nil {
+		c.Close()
+	}
+}
+
+// dial connects to the database, authenticates and selects the database
+func (s *Store) dial() (*conn, error) {
+	dialer := &net.Dialer{Timeout: s.opts.Timeout}
+	var nc net.Conn
+	var err error
+	if s.opts.TLSConfig != nil {
+		nc, err = tls.DialWithDialer(dialer, "tcp", s.opts.Addr, s.opts.TLSConfig)
+	} else {
+		nc, err = dialer.Dial("tcp", s.opts.Addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c := &conn{Conn: nc, r: bufio.NewReader(nc), w: bufio.NewWriter(nc)}
+
+	var setup [][]string
+	switch {
+	case s.opts.Username != "":
+		setup = append(setup, []string{"AUTH", s.opts.Username, s.opts.Password})
+	case s.opts.Password != "":
+		setup = append(setup, []string{"AUTH", s.opts.Password})
+	}
+	if s.opts.DB != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(s.opts.DB)})
+	}
+	if len(setup) > 0 {
+		replies, err := c.do(s.opts.Timeout, setup...)
+		if err == nil {
+			err = replyErr(replies)
+		}
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// conn is a connection speaking RESP, the protocol of Redis
+type conn struct {
+	net.Conn
+	r *bufio.Reader
+	w *bufio.Writer
+}
+
+// do writes commands and reads their replies
+func (c *conn) do(timeout time.Duration, commands ...[]string) ([]interface{}, error) {
+	_ = c.SetDeadline(time.Now().Add(timeout))
+	for _, args := range commands {
+		fmt.Fprintf(c.w, "*%d\r\n", len(args))
+		for _, arg := range args {
+			fmt.Fprintf(c.w, "$%d\r\n%s\r\n", len(arg), arg)
+		}
+	}
+	if err := c.w.Flush(); err != nil {
+		return nil, err
+	}
+
+	replies := make([]interface{}, len(commands))
+	for i := range replies {
+		reply, err := readReply(c.r)
+		if err != nil {
+			return nil, err
+		}
+		replies[i] = reply
+	}
+	return replies, nil
+}
+
+// readReply reads a RESP reply
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+	kind, line := line[0], line[1:len(line)-2]
+	switch kind {
+	case '+':
+		return line, nil
+	case '-':
+		return Error(line), nil
+	case ':':
+		return strconv.ParseInt(line, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		if _, err = io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		array := make([]interface{}, n)
+		for i := range array {
+			if array[i], err = readReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return array, nil
+	}
+	return nil, fmt.Errorf("redis: malformed reply %q", line)
+}
+
+// replyErr returns the first error reply of replies
+func replyErr(replies []interface{}) error {
+	for _, reply := range replies {
+		if err, ok := reply.(Error); ok {
+			return err
+		}
+	}
+	return nil
+}
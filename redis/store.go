@@ -0,0 +1,130 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package redis
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/globalcyberalliance/ftp-go"
+)
+
+var (
+	_ ftp.BanList           = &Store{}
+	_ ftp.LoginFailureStore = &Store{}
+	_ ftp.QuotaStore        = &Store{}
+)
+
+// milliseconds formats d for PX and PEXPIRE, which refuse 0
+func milliseconds(d time.Duration) string {
+	return strconv.FormatInt(max(d.Milliseconds(), 1), 10)
+}
+
+// Banned implements ftp.BanList
+func (s *Store) Banned(ip string) (bool, error) {
+	replies, err := s.Do([]string{"EXISTS", s.opts.Prefix + "ban:" + ip})
+	if err != nil {
+		return false, err
+	}
+	if err = replyErr(replies); err != nil {
+		return false, err
+	}
+	return replies[0] == int64(1), nil
+}
+
+// Ban implements ftp.BanList, the key of the ban expiring with it
+func (s *Store) Ban(ip string, d time.Duration) error {
+	replies, err := s.Do([]string{"SET", s.opts.Prefix + "ban:" + ip, "1", "PX", milliseconds(d)})
+	if err != nil {
+		return err
+	}
+	return replyErr(replies)
+}
+
+// Unban implements ftp.BanList
+func (s *Store) Unban(ip string) error {
+	replies, err := s.Do([]string{"DEL", s.opts.Prefix + "ban:" + ip})
+	if err != nil {
+		return err
+	}
+	return replyErr(replies)
+}
+
+// AddLoginFailure implements ftp.LoginFailureStore, the counter expiring
+// window after the last failure.
+func (s *Store) AddLoginFailure(ip string, window time.Duration) (int, error) {
+	key := s.opts.Prefix + "fail:" + ip
+	replies, err := s.Do(
+		[]string{"INCR", key},
+		[]string{"PEXPIRE", key, milliseconds(window)},
+	)
+	if err != nil {
+		return 0, err
+	}
+	if err = replyErr(replies); err != nil {
+		return 0, err
+	}
+	failures, ok := replies[0].(int64)
+	if !ok {
+		return 0, fmt.Errorf("redis: unexpected INCR reply %v", replies[0])
+	}
+	return int(failures), nil
+}
+
+// ResetLoginFailures implements ftp.LoginFailureStore
+func (s *Store) ResetLoginFailures(ip string) error {
+	replies, err := s.Do([]string{"DEL", s.opts.Prefix + "fail:" + ip})
+	if err != nil {
+		return err
+	}
+	return replyErr(replies)
+}
+
+// quotaKey returns the key of the usage of user in the period starting at
+// start, a hash of its "up" and "down" bytes.
+func (s *Store) quotaKey(user string, start time.Time) string {
+	return s.opts.Prefix + "quota:" + user + ":" + strconv.FormatInt(start.Unix(), 10)
+}
+
+// Usage implements ftp.QuotaStore
+func (s *Store) Usage(user string, start time.Time) (int64, int64, error) {
+	replies, err := s.Do([]string{"HMGET", s.quotaKey(user, start), "up", "down"})
+	if err != nil {
+		return 0, 0, err
+	}
+	if err = replyErr(replies); err != nil {
+		return 0, 0, err
+	}
+	fields, ok := replies[0].([]interface{})
+	if !ok || len(fields) != 2 {
+		return 0, 0, fmt.Errorf("redis: unexpected HMGET reply %v", replies[0])
+	}
+	var usage [2]int64
+	for i, field := range fields {
+		if field == nil {
+			continue
+		}
+		if usage[i], err = strconv.ParseInt(fmt.Sprint(field), 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("redis: usage of %s: %w", user, err)
+		}
+	}
+	return usage[0], usage[1], nil
+}
+
+// AddUsage implements ftp.QuotaStore, the usage expiring Options.QuotaTTL
+// after it was last updated.
+func (s *Store) AddUsage(user string, start time.Time, up, down int64) error {
+	key := s.quotaKey(user, start)
+	replies, err := s.Do(
+		[]string{"HINCRBY", key, "up", strconv.FormatInt(up, 10)},
+		[]string{"HINCRBY", key, "down", strconv.FormatInt(down, 10)},
+		[]string{"PEXPIRE", key, milliseconds(s.opts.QuotaTTL)},
+	)
+	if err != nil {
+		return err
+	}
+	return replyErr(replies)
+}
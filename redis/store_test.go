@@ -0,0 +1,267 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package redis
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/globalcyberalliance/ftp-go"
+	"github.com/globalcyberalliance/ftp-go/driver/file"
+	"github.com/globalcyberalliance/ftp-go/ftptest"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRedis answers the commands of Store from memory, expiring nothing
+type fakeRedis struct {
+	mu     sync.Mutex
+	values map[string]string
+	hashes map[string]map[string]int64
+	ttls   map[string]string
+	auth   []string
+}
+
+// listen serves fake redis on a local port until the test ends
+func listen(t *testing.T) (*fakeRedis, string) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	db := &fakeRedis{
+		values: make(map[string]string),
+		hashes: make(map[string]map[string]int64),
+		ttls:   make(map[string]string),
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go db.serve(conn)
+		}
+	}()
+	return db, l.Addr().String()
+}
+
+func (db *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		reply, err := readReply(r)
+		if err != nil {
+			return
+		}
+		var args []string
+		for _, arg := range reply.([]interface{}) {
+			args = append(args, arg.(string))
+		}
+		if _, err = io.WriteString(conn, db.exec(args)); err != nil {
+			return
+		}
+	}
+}
+
+func (db *fakeRedis) exec(args []string) string {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	key := ""
+	if len(args) > 1 {
+		key = args[1]
+	}
+	switch strings.ToUpper(args[0]) {
+	case "AUTH":
+		db.auth = args[1:]
+		return "+OK\r\n"
+	case "SET":
+		db.values[key] = args[2]
+		if len(args) == 5 {
+			db.ttls[key] = args[3] + " " + args[4]
+		}
+		return "+OK\r\n"
+	case "EXISTS":
+		_, value := db.values[key]
+		_, hash := db.hashes[key]
+		if value || hash {
+			return ":1\r\n"
+		}
+		return ":0\r\n"
+	case "DEL":
+		_, value := db.values[key]
+		_, hash := db.hashes[key]
+		delete(db.values, key)
+		delete(db.hashes, key)
+		if value || hash {
+			return ":1\r\n"
+		}
+		return ":0\r\n"
+	case "INCR":
+		n, _ := strconv.Atoi(db.values[key])
+		db.values[key] = strconv.Itoa(n + 1)
+		return fmt.Sprintf(":%d\r\n", n+1)
+	case "PEXPIRE":
+		db.ttls[key] = "PX " + args[2]
+		return ":1\r\n"
+	case "HINCRBY":
+		if db.hashes[key] == nil {
+			db.hashes[key] = make(map[string]int64)
+		}
+		n, _ := strconv.ParseInt(args[3], 10, 64)
+		db.hashes[key][args[2]] += n
+		return fmt.Sprintf(":%d\r\n", db.hashes[key][args[2]])
+	case "HMGET":
+		reply := fmt.Sprintf("*%d\r\n", len(args)-2)
+		for _, field := range args[2:] {
+			if n, ok := db.hashes[key][field]; ok {
+				s := strconv.FormatInt(n, 10)
+				reply += fmt.Sprintf("$%d\r\n%s\r\n", len(s), s)
+			} else {
+				reply += "$-1\r\n"
+			}
+		}
+		return reply
+	}
+	return "-ERR unknown command '" + args[0] + "'\r\n"
+}
+
+func (db *fakeRedis) ttl(key string) string {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.ttls[key]
+}
+
+func newStore(t *testing.T) (*fakeRedis, *Store) {
+	t.Helper()
+	db, addr := listen(t)
+	store, err := NewStore(&Options{Addr: addr, Password: "secret", Prefix: "test:"})
+	assert.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+	return db, store
+}
+
+func TestBanList(t *testing.T) {
+	db, store := newStore(t)
+
+	banned, err := store.Banned("192.0.2.1")
+	assert.NoError(t, err)
+	assert.False(t, banned)
+
+	assert.NoError(t, store.Ban("192.0.2.1", time.Minute))
+	assert.Equal(t, "PX 60000", db.ttl("test:ban:192.0.2.1"))
+	banned, err = store.Banned("192.0.2.1")
+	assert.NoError(t, err)
+	assert.True(t, banned)
+	assert.Equal(t, []string{"secret"}, db.auth)
+
+	assert.NoError(t, store.Unban("192.0.2.1"))
+	banned, err = store.Banned("192.0.2.1")
+	assert.NoError(t, err)
+	assert.False(t, banned)
+}
+
+func TestLoginFailures(t *testing.T) {
+	db, store := newStore(t)
+
+	for want := 1; want <= 3; want++ {
+		failures, err := store.AddLoginFailure("192.0.2.1", time.Second)
+		assert.NoError(t, err)
+		assert.Equal(t, want, failures)
+	}
+	assert.Equal(t, "PX 1000", db.ttl("test:fail:192.0.2.1"))
+
+	assert.NoError(t, store.ResetLoginFailures("192.0.2.1"))
+	failures, err := store.AddLoginFailure("192.0.2.1", time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, failures)
+}
+
+func TestQuotaUsage(t *testing.T) {
+	db, store := newStore(t)
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	up, down, err := store.Usage("alice", start)
+	assert.NoError(t, err)
+	assert.Zero(t, up)
+	assert.Zero(t, down)
+
+	assert.NoError(t, store.AddUsage("alice", start, 100, 0))
+	assert.NoError(t, store.AddUsage("alice", start, 20, 300))
+	up, down, err = store.Usage("alice", start)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(120), up)
+	assert.Equal(t, int64(300), down)
+	key := fmt.Sprintf("test:quota:alice:%d", start.Unix())
+	assert.Equal(t, "PX "+strconv.FormatInt(defaultQuotaTTL.Milliseconds(), 10), db.ttl(key))
+
+	up, _, err = store.Usage("alice", start.AddDate(0, 1, 0))
+	assert.NoError(t, err)
+	assert.Zero(t, up)
+}
+
+func TestErrors(t *testing.T) {
+	_, store := newStore(t)
+
+	replies, err := store.Do([]string{"NOPE"})
+	assert.NoError(t, err)
+	assert.Equal(t, Error("ERR unknown command 'NOPE'"), replies[0])
+
+	assert.NoError(t, store.Close())
+	_, err = store.Banned("192.0.2.1")
+	assert.ErrorIs(t, err, ErrClosed)
+
+	_, err = NewStore(&Options{})
+	assert.Error(t, err)
+}
+
+// TestSharedBans checks that failing to log in to one server bans the
+// source from another sharing the store.
+func TestSharedBans(t *testing.T) {
+	_, store := newStore(t)
+
+	var addrs []string
+	for i := 0; i < 2; i++ {
+		driver, err := file.NewDriver(t.TempDir())
+		assert.NoError(t, err)
+		addr, cleanup := ftptest.NewServer(driver, &ftp.Options{
+			MaxLoginFailures:  2,
+			BanList:           store,
+			LoginFailureStore: store,
+		})
+		t.Cleanup(cleanup)
+		addrs = append(addrs, addr)
+	}
+
+	// One failure on each server reaches the limit
+	for i, addr := range addrs {
+		c, err := ftptest.Dial(addr)
+		assert.NoError(t, err)
+		_, err = c.Cmd(331, "USER %s", ftptest.Username)
+		assert.NoError(t, err)
+		code := 530
+		if i == len(addrs)-1 {
+			code = 421
+		}
+		_, err = c.Cmd(code, "PASS wrong")
+		assert.NoError(t, err)
+		c.Close()
+	}
+
+	for _, addr := range addrs {
+		c, err := ftptest.Dial(addr)
+		if err == nil {
+			c.Close()
+		}
+		assert.Error(t, err)
+	}
+}
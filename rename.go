@@ -0,0 +1,156 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// RenamePolicy decides what RNTO does when its target exists, see
+// Options.RenamePolicy.
+type RenamePolicy uint8
+
+const (
+	// RenameDriver leaves an existing target to Driver.Rename, which may
+	// replace it or fail depending on the backend.
+	RenameDriver RenamePolicy = iota
+	// RenameReject refuses to rename onto an existing target.
+	RenameReject
+	// RenameOverwrite replaces an existing target file: it is moved aside
+	// and deleted once the rename succeeded. A directory is never
+	// overwritten.
+	RenameOverwrite
+	// RenameVersion moves an existing target aside to the first free
+	// "name.N" before renaming.
+	RenameVersion
+)
+
+// maxRenameVersions bounds the versions RenameVersion looks through.
+const maxRenameVersions = 1000
+
+var renamePolicyNames = []string{
+	RenameDriver:    "driver",
+	RenameReject:    "reject",
+	RenameOverwrite: "overwrite",
+	RenameVersion:   "version",
+}
+
+// RenameConflict describes an RNTO whose target existed, see
+// RenameConflictNotifier.
+type RenameConflict struct {
+	FromPath string
+	ToPath   string
+	Policy   RenamePolicy
+	// Where the target was moved with RenameVersion
+	VersionPath string
+}
+
+// String returns the name of the policy, "driver", "reject", "overwrite" or
+// "version".
+func (p RenamePolicy) String() string {
+	if int(p) < len(renamePolicyNames) {
+		return renamePolicyNames[p]
+	}
+	return "RenamePolicy(" + strconv.Itoa(int(p)) + ")"
+}
+
+// ParseRenamePolicy parses a policy name, see RenamePolicy.String.
+func ParseRenamePolicy(s string) (RenamePolicy, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for p, name := range renamePolicyNames {
+		if name == s {
+			return RenamePolicy(p), nil
+		}
+	}
+	return 0, fmt.Errorf("ftp: unknown rename policy %q", s)
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (p RenamePolicy) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (p *RenamePolicy) UnmarshalText(text []byte) error {
+	parsed, err := ParseRenamePolicy(string(text))
+	if err != nil {
+		return err
+	}
+	*p = parsed
+	return nil
+}
+
+// rename renames fromPath to toPath, enforcing Options.RenamePolicy when
+// toPath exists. A target moved aside is moved back if the rename fails.
+func (sess *Session) rename(ctx *Context, fromPath, toPath string) error {
+	if fromPath == toPath {
+		return nil
+	}
+	policy := sess.server.RenamePolicy
+	if policy == RenameDriver {
+		return sess.driver().Rename(ctx, fromPath, toPath)
+	}
+	info, err := sess.driver().Stat(ctx, toPath)
+	if isNotExist(err) {
+		// nothing to replace
+		return sess.driver().Rename(ctx, fromPath, toPath)
+	}
+	if err != nil {
+		return err
+	}
+
+	conflict := &RenameConflict{
+		FromPath: fromPath,
+		ToPath:   toPath,
+		Policy:   policy,
+	}
+	switch policy {
+	case RenameOverwrite, RenameVersion:
+		if policy == RenameOverwrite && info.IsDir() {
+			err = ErrExist
+			break
+		}
+		var aside string
+		aside, err = sess.versionPath(ctx, toPath)
+		if err == nil {
+			err = sess.driver().Rename(ctx, toPath, aside)
+		}
+		if err != nil {
+			break
+		}
+		if err = sess.driver().Rename(ctx, fromPath, toPath); err != nil {
+			if restoreErr := sess.driver().Rename(ctx, aside, toPath); restoreErr != nil {
+				sess.logf("moving %s back to %s: %v", aside, toPath, restoreErr)
+			}
+			break
+		}
+		if policy == RenameVersion {
+			conflict.VersionPath = aside
+		} else if deleteErr := sess.driver().DeleteFile(ctx, aside); deleteErr != nil {
+			sess.logf("deleting overwritten %s: %v", aside, deleteErr)
+		}
+	default:
+		err = ErrExist
+	}
+	sess.server.notifiers.OnRenameConflict(ctx, conflict, err)
+	return err
+}
+
+// versionPath returns the first "p.N" which does not exist.
+func (sess *Session) versionPath(ctx *Context, p string) (string, error) {
+	for i := 1; i <= maxRenameVersions; i++ {
+		version := p + "." + strconv.Itoa(i)
+		_, err := sess.driver().Stat(ctx, version)
+		if isNotExist(err) {
+			return version, nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return "", ErrExist
+}
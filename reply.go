@@ -0,0 +1,77 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Reply is a reply about to be sent to a client, see Options.OnReply
+type Reply struct {
+	// Command being executed, "" for replies sent outside a command such
+	// as the greeting
+	Command string
+
+	Code int
+
+	// Text of the reply, the lines of multiline replies separated by "\n",
+	// the closing line last
+	Message string
+}
+
+// rewriteReply passes a reply through Options.OnReply, body being the lines
+// before the closing line last. Codes out of the 100 to 599 range it sets
+// are ignored.
+func (sess *Session) rewriteReply(code int, body, last string) (int, string, string) {
+	if sess.server.OnReply == nil {
+		return code, body, last
+	}
+	reply := &Reply{Command: sess.command, Code: code, Message: last}
+	if body != "" {
+		reply.Message = body + "\n" + last
+	}
+	sess.server.OnReply(sess, reply)
+
+	if reply.Code >= 100 && reply.Code < 600 {
+		code = reply.Code
+	}
+	message := strings.TrimRight(strings.ReplaceAll(reply.Message, "\r\n", "\n"), "\n")
+	if i := strings.LastIndex(message, "\n"); i >= 0 {
+		return code, message[:i], message[i+1:]
+	}
+	return code, "", message
+}
+
+// writeReply sends a reply, body being the lines before the closing line
+// last, if any. Each line of body is prefixed with the code and a dash, or
+// only the first one when indent is set, the others being indented when
+// they start with a digit so that none passes for the closing line.
+func (sess *Session) writeReply(code int, body, last string, indent bool) {
+	code, body, last = sess.rewriteReply(code, body, last)
+
+	sess.controlMu.Lock()
+	defer sess.controlMu.Unlock()
+	if body == "" {
+		sess.sessionLogger().PrintResponse(sess.id, code, last)
+	} else {
+		sess.sessionLogger().PrintResponse(sess.id, code, body+"\n"+last)
+	}
+	sess.setWriteDeadline()
+	if body != "" {
+		for i, line := range strings.Split(body, "\n") {
+			switch {
+			case i == 0 || !indent:
+				_, _ = fmt.Fprintf(sess.controlWriter, "%d-%s\r\n", code, line)
+			case line != "" && line[0] >= '0' && line[0] <= '9':
+				_, _ = fmt.Fprintf(sess.controlWriter, " %s\r\n", line)
+			default:
+				_, _ = fmt.Fprintf(sess.controlWriter, "%s\r\n", line)
+			}
+		}
+	}
+	_, _ = fmt.Fprintf(sess.controlWriter, "%d %s\r\n", code, last)
+	sess.controlWriter.Flush()
+}
@@ -0,0 +1,309 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// resumeTicket is the state a session resumed with SITE RESUME gets back
+type resumeTicket struct {
+	expires  time.Time
+	remoteIP string
+	tls      bool
+	vhost    *virtualHost
+
+	user           string
+	root           string
+	readOnly       bool
+	curDir         string
+	preCommand     string
+	lastFilePos    int64
+	data           *Store
+	uploadRate     int64
+	downloadRate   int64
+	bandwidthClass string
+	transferQuota  TransferQuota
+	listFilter     ListFilter
+	listFormat     ListFormat
+	quirks         Quirk
+	motd           string
+}
+
+// issueTicket issues a resumption ticket for the session, replacing the
+// one it had.
+func (sess *Session) issueTicket() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+
+	server := sess.server
+	now := time.Now()
+	server.ticketsMu.Lock()
+	defer server.ticketsMu.Unlock()
+	if server.tickets == nil {
+		server.tickets = make(map[string]*resumeTicket)
+	}
+	for t, ticket := range server.tickets {
+		if now.After(ticket.expires) {
+			delete(server.tickets, t)
+		}
+	}
+	delete(server.tickets, sess.ticket)
+
+	sess.ticket = token
+	server.tickets[token] = &resumeTicket{
+		expires:  now.Add(server.ResumeTicketTTL),
+		remoteIP: addrIP(sess.RemoteAddr()),
+		tls:      sess.tls,
+		vhost:    sess.vhost,
+	}
+	sess.saveTicket()
+	return token, nil
+}
+
+// saveTicket records the state of the session in its ticket, so that it is
+// resumed as it was after the last command, a pending REST included. The caller holds ticketsMu.
+func (sess *Session) saveTicket() {
+	ticket, ok := sess.server.tickets[sess.ticket]
+	if !ok {
+		sess.ticket = ""
+		return
+	}
+	ticket.user = sess.user
+	ticket.root = sess.root
+	ticket.readOnly = sess.readOnly
+	ticket.curDir = sess.curDir
+	ticket.preCommand = sess.preCommand
+	ticket.lastFilePos = sess.lastFilePos
+	// A copy, the session may go on changing its own
+	ticket.data = sess.Data.clone()
+	ticket.uploadRate, ticket.downloadRate = sess.RateLimit()
+	ticket.bandwidthClass = sess.BandwidthClass()
+	ticket.transferQuota = sess.transferQuota
+	ticket.listFilter = sess.listFilter
+	ticket.listFormat = sess.listFormat
+	ticket.quirks = sess.quirks
+	ticket.motd = sess.motd
+}
+
+// updateTicket records the state of the session in its ticket, if it has
+// one.
+func (sess *Session) updateTicket() {
+	if sess.ticket == "" {
+		return
+	}
+	sess.server.ticketsMu.Lock()
+	sess.saveTicket()
+	sess.server.ticketsMu.Unlock()
+}
+
+// keepsResumedRest reports whether command keeps a REST restored by SITE
+// RESUME pending, as clients open a new data connection before the
+// transfer it applies to.
+func keepsResumedRest(command string) bool {
+	switch command {
+	case "SITE", "PASV", "EPSV", "PORT", "EPRT", "LPRT", "TYPE", "MODE":
+		return true
+	}
+	return false
+}
+
+// revokeTicket revokes the ticket of the session, if it has one
+func (sess *Session) revokeTicket() {
+	if sess.ticket == "" {
+		return
+	}
+	sess.server.ticketsMu.Lock()
+	delete(sess.server.tickets, sess.ticket)
+	sess.server.ticketsMu.Unlock()
+	sess.ticket = ""
+}
+
+// takeTicket returns the ticket of token and revokes it, nil if it is not
+// valid for the session. Tickets are used once, and only from the address
+// and over the security they were issued with.
+func (sess *Session) takeTicket(token string) *resumeTicket {
+	server := sess.server
+	server.ticketsMu.Lock()
+	ticket, ok := server.tickets[token]
+	delete(server.tickets, token)
+	server.ticketsMu.Unlock()
+
+	if !ok || time.Now().After(ticket.expires) ||
+		ticket.remoteIP != addrIP(sess.RemoteAddr()) ||
+		ticket.tls && !sess.tls ||
+		sess.vhost != nil && sess.vhost != ticket.vhost {
+		return nil
+	}
+	return ticket
+}
+
+// restoreTicket restores the session a ticket was issued to.
+func (sess *Session) restoreTicket(ticket *resumeTicket) {
+	sess.vhost = ticket.vhost
+	sess.user = ticket.user
+	sess.reqUser = ""
+	sess.root = ticket.root
+	sess.readOnly = ticket.readOnly
+	sess.curDir = ticket.curDir
+	sess.lastFilePos = ticket.lastFilePos
+	sess.resumedRest = ticket.preCommand == "REST"
+	sess.Data = ticket.data
+	sess.uploadLimiter.SetRate(ticket.uploadRate)
+	sess.downloadLimiter.SetRate(ticket.downloadRate)
+	if err := sess.SetBandwidthClass(ticket.bandwidthClass); err != nil {
+		sess.logf("resuming bandwidth class: %v", err)
+	}
+	sess.transferQuota = ticket.transferQuota
+	sess.listFilter = ticket.listFilter
+	sess.listFormat = ticket.listFormat
+	sess.quirks = ticket.quirks
+	sess.motd = ticket.motd
+}
+
+// redeemTicket logs the session in with a ticket, as PASS does with a
+// password: the account must still be allowed to log in, see
+// AccountChecker, and failures count towards the login tarpit. It returns
+// the user the ticket was issued to.
+func (sess *Session) redeemTicket(ctx *Context, token string) (string, error) {
+	ticket := sess.takeTicket(token)
+	if ticket == nil {
+		return "", errInvalidTicket
+	}
+
+	auth := sess.server.Auth
+	if driverAuth, found := sess.baseDriver().(Auth); found {
+		auth = driverAuth
+	}
+	if checker, ok := auth.(AccountChecker); ok {
+		// Checked against the restored Data, where Auths keep the user
+		previous := sess.Data
+		sess.Data = ticket.data
+		if err := checker.CheckAccount(ctx, ticket.user); err != nil {
+			sess.Data = previous
+			return ticket.user, err
+		}
+	}
+	sess.restoreTicket(ticket)
+	return ticket.user, nil
+}
+
+// errInvalidTicket is returned by redeemTicket for unknown, expired or
+// misused tickets.
+var errInvalidTicket = &ReplyError{Code: 530, Message: "Invalid or expired ticket, not logged in"}
+
+// commandSiteTicket responds to SITE TICKET with a ticket SITE RESUME
+// logs in with, to resume the session after the connection dropped. It is
+// valid for Options.ResumeTicketTTL.
+type commandSiteTicket struct{}
+
+func (cmd commandSiteTicket) IsExtend() bool {
+	return false
+}
+
+func (cmd commandSiteTicket) RequireParam() bool {
+	return false
+}
+
+func (cmd commandSiteTicket) RequireAuth() bool {
+	return true
+}
+
+func (cmd commandSiteTicket) Help() string {
+	return "Syntax: SITE TICKET (get a ticket to resume the session with)"
+}
+
+func (cmd commandSiteTicket) Execute(sess *Session, param string) {
+	if sess.server.ResumeTicketTTL <= 0 {
+		sess.writeMessage(502, "SITE TICKET not implemented")
+		return
+	}
+	token, err := sess.issueTicket()
+	if err != nil {
+		sess.logf("issuing resumption ticket: %v", err)
+		sess.writeMessage(451, "Issuing ticket failed")
+		return
+	}
+	sess.writeMessage(200, fmt.Sprintf("Ticket %s valid for %s", token, sess.server.ResumeTicketTTL))
+}
+
+// commandSiteResume responds to SITE RESUME by logging in with a ticket of
+// SITE TICKET, in place of USER and PASS. The session gets back the
+// directory and settings it had, and a new ticket. A REST pending when the
+// connection dropped applies to the next transfer.
+type commandSiteResume struct{}
+
+func (cmd commandSiteResume) IsExtend() bool {
+	return false
+}
+
+func (cmd commandSiteResume) RequireParam() bool {
+	return true
+}
+
+func (cmd commandSiteResume) RequireAuth() bool {
+	return false
+}
+
+func (cmd commandSiteResume) Help() string {
+	return "Syntax: SITE RESUME <ticket> (log in again after a disconnection)"
+}
+
+func (cmd commandSiteResume) Execute(sess *Session, param string) {
+	if sess.server.ResumeTicketTTL <= 0 {
+		sess.writeMessage(502, "SITE RESUME not implemented")
+		return
+	}
+	if sess.IsLogin() {
+		sess.writeMessage(503, "Already logged in")
+		return
+	}
+
+	ctx := Context{
+		Sess:  sess,
+		Cmd:   "SITE",
+		Param: "RESUME",
+		Data:  NewStore(),
+	}
+	user, err := sess.redeemTicket(&ctx, param)
+	sess.server.notifiers.AfterUserLogin(&ctx, user, "", err == nil, err)
+	if errors.Is(err, ErrAccountExpired) {
+		sess.server.notifiers.OnAccountExpired(&ctx, user)
+	}
+	if err != nil {
+		sess.logf("resuming session: %v", err)
+		sess.server.failedLogins.Add(1)
+		if sess.tarpitFailedLogin() {
+			sess.writeMessage(421, "Too many failed logins, closing control connection")
+			sess.Close()
+			return
+		}
+		sess.writeError(err, 530, "Resuming session refused, not logged in")
+		return
+	}
+	// OnConnect accepted the connection, it now sees who resumed it
+	if !sess.onConnect() {
+		sess.user = ""
+		sess.Close()
+		return
+	}
+	sess.server.logins.Add(1)
+	sess.tarpitLogin()
+
+	token, err := sess.issueTicket()
+	if err != nil {
+		sess.logf("issuing resumption ticket: %v", err)
+		sess.writeMessage(230, "Session resumed")
+		return
+	}
+	sess.writeMessage(230, fmt.Sprintf("Session resumed, ticket %s", token))
+}
@@ -0,0 +1,109 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// scrubMask replaces the secrets a Scrubber masks
+const scrubMask = "****"
+
+// secretCommands have parameters which are secrets: passwords, accounts,
+// the security data of RFC 2228 and resumption tickets.
+var secretCommands = map[string]bool{
+	"PASS":        true,
+	"ACCT":        true,
+	"ADAT":        true,
+	"SITE RESUME": true,
+}
+
+// ticketPattern matches the resumption tickets of SITE TICKET replies
+var ticketPattern = regexp.MustCompile(`([Tt]icket )[0-9a-f]{48}`)
+
+// Scrubber masks secrets in what sessions log and notifiers export: the
+// parameters of PASS, ACCT, ADAT and SITE RESUME, the resumption tickets
+// of replies and the matches of Options.LogScrubPatterns. See
+// Server.Scrubber.
+type Scrubber struct {
+	patterns []*regexp.Regexp
+}
+
+// NewScrubber creates a Scrubber masking the matches of patterns, in the
+// syntax of the regexp package, besides the secrets it always masks.
+func NewScrubber(patterns ...string) (*Scrubber, error) {
+	scrubber := &Scrubber{}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("ftp: log scrub pattern %q: %w", pattern, err)
+		}
+		scrubber.patterns = append(scrubber.patterns, re)
+	}
+	return scrubber, nil
+}
+
+// Command returns the parameters of command masked, entirely for commands
+// whose parameters are secrets.
+func (scrubber *Scrubber) Command(command, params string) string {
+	if params == "" {
+		return ""
+	}
+	command = strings.ToUpper(command)
+	if secretCommands[command] {
+		return scrubMask
+	}
+	if name, _, ok := strings.Cut(params, " "); ok && command == "SITE" && secretCommands["SITE "+strings.ToUpper(name)] {
+		return name + " " + scrubMask
+	}
+	return scrubber.String(params)
+}
+
+// String returns s with the resumption tickets and the matches of the
+// patterns masked.
+func (scrubber *Scrubber) String(s string) string {
+	s = ticketPattern.ReplaceAllString(s, "${1}"+scrubMask)
+	if scrubber == nil {
+		return s
+	}
+	for _, re := range scrubber.patterns {
+		s = re.ReplaceAllLiteralString(s, scrubMask)
+	}
+	return s
+}
+
+// Scrubber returns the Scrubber of Options.LogScrubPatterns, for loggers
+// and notifiers logging or exporting commands and replies.
+func (server *Server) Scrubber() *Scrubber {
+	return server.scrubber
+}
+
+// scrubbingLogger masks secrets before they reach Logger
+type scrubbingLogger struct {
+	Logger
+	scrubber *Scrubber
+}
+
+// Print implements Logger
+func (logger *scrubbingLogger) Print(sessionID string, message interface{}) {
+	logger.Logger.Print(sessionID, logger.scrubber.String(fmt.Sprint(message)))
+}
+
+// Printf implements Logger
+func (logger *scrubbingLogger) Printf(sessionID string, format string, v ...interface{}) {
+	logger.Logger.Printf(sessionID, "%s", logger.scrubber.String(fmt.Sprintf(format, v...)))
+}
+
+// PrintCommand implements Logger
+func (logger *scrubbingLogger) PrintCommand(sessionID string, command string, params string) {
+	logger.Logger.PrintCommand(sessionID, command, logger.scrubber.Command(command, params))
+}
+
+// PrintResponse implements Logger
+func (logger *scrubbingLogger) PrintResponse(sessionID string, code int, message string) {
+	logger.Logger.PrintResponse(sessionID, code, logger.scrubber.String(message))
+}
@@ -0,0 +1,88 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestScrubber(t *testing.T) {
+	scrubber, err := NewScrubber(`token=\S+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		command, params, want string
+	}{
+		{"PASS", "secret", "****"},
+		{"pass", "secret", "****"},
+		{"ADAT", "c2VjcmV0", "****"},
+		{"PASS", "", ""},
+		{"SITE", "RESUME 0123", "RESUME ****"},
+		{"SITE", "resume 0123", "resume ****"},
+		{"SITE", "CHMOD 644 /a", "CHMOD 644 /a"},
+		{"RETR", "/files?token=abc", "/files?****"},
+	} {
+		if params := scrubber.Command(test.command, test.params); params != test.want {
+			t.Errorf("%s %s: got %q, want %q", test.command, test.params, params, test.want)
+		}
+	}
+
+	ticket := strings.Repeat("0f", 24)
+	if s := scrubber.String("Ticket " + ticket + " valid for 1h0m0s"); s != "Ticket **** valid for 1h0m0s" {
+		t.Errorf("ticket not masked: %q", s)
+	}
+
+	if _, err := NewScrubber("("); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
+
+// recordingLogger records what it is given
+type recordingLogger struct {
+	DiscardLogger
+	mu    sync.Mutex
+	lines []string
+}
+
+func (logger *recordingLogger) PrintCommand(sessionID string, command string, params string) {
+	logger.mu.Lock()
+	logger.lines = append(logger.lines, command+" "+params)
+	logger.mu.Unlock()
+}
+
+func (logger *recordingLogger) PrintResponse(sessionID string, code int, message string) {
+	logger.mu.Lock()
+	logger.lines = append(logger.lines, fmt.Sprintf("%d %s", code, message))
+	logger.mu.Unlock()
+}
+
+func TestSessionLogScrubbing(t *testing.T) {
+	logger := &recordingLogger{}
+	client := newPipeSession(t, &Options{
+		Auth:             &SimpleAuth{Name: "user", Password: "secret"},
+		Logger:           logger,
+		LogScrubPatterns: []string{`token=\S+`},
+	})
+	expectCode(t, client, 331, "USER user")
+	expectCode(t, client, 230, "PASS secret")
+	expectCode(t, client, 200, "NOOP token=secret")
+
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	for _, line := range logger.lines {
+		if strings.Contains(line, "secret") {
+			t.Errorf("logged %q", line)
+		}
+	}
+	if want := "PASS ****"; !slices.Contains(logger.lines, want) {
+		t.Errorf("%q not logged in %q", want, logger.lines)
+	}
+}
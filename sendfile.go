@@ -0,0 +1,60 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"io"
+	"net"
+	"os"
+)
+
+// sendFileChunk is how much is handed to the kernel at once, so that every
+// chunk gets a fresh deadline when stall detection is on, the transfer's
+// progress is counted and a rate limit set meanwhile applies.
+const sendFileChunk = 256 << 10
+
+// sendFile copies r to conn through the kernel's zero-copy path (sendfile(2)
+// on Linux) when r is a file, conn a plain TCP connection and no download
+// rate limit is active. ok is false when the fast path can't be used and the
+// caller must copy the data itself. The file is sent in chunks, once a rate
+// limit is set the rest is copied to limited, conn's rate limited writer.
+func (sess *Session) sendFile(conn *stallConn, limited io.Writer, r io.Reader) (n int64, ok bool, err error) {
+	counted := r
+	var transfer *activeTransfer
+	if reader, isCounted := r.(*transferReader); isCounted {
+		r, transfer = reader.r, reader.transfer
+	}
+	f, isFile := r.(*os.File)
+	tcpConn, isTCP := conn.Conn.(*net.TCPConn)
+	if !isFile || !isTCP || sess.rateLimited() {
+		return 0, false, nil
+	}
+
+	for {
+		if sess.rateLimited() {
+			copied, err := sess.server.copyBuffer(sess.commandContext(), limited, counted)
+			return n + copied, true, err
+		}
+		if conn.timeout > 0 {
+			_ = tcpConn.SetWriteDeadline(conn.next())
+		}
+		written, err := tcpConn.ReadFrom(io.LimitReader(f, sendFileChunk))
+		n += written
+		if transfer != nil {
+			transfer.bytes.Add(written)
+		}
+		if err != nil {
+			return n, true, conn.error(err)
+		}
+		if written < sendFileChunk {
+			return n, true, nil
+		}
+	}
+}
+
+// rateLimited reports whether downloads of the session are rate limited
+func (sess *Session) rateLimited() bool {
+	return sess.downloadLimiter.Rate() != 0 || sess.server.rateLimiter.Rate() != 0
+}
@@ -8,11 +8,16 @@ import (
 	"bufio"
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net"
 	"strconv"
 	"sync"
+	"sync/atomic"
+	"text/template"
 	"time"
 
 	"github.com/globalcyberalliance/ftp-go/ratelimit"
@@ -37,9 +42,67 @@ type (
 		// How to handle the perm controls
 		Perm Perm
 
-		// A logger implementation, if nil the StdLogger is used
+		// A logger implementation, if nil the StdLogger is used. A
+		// FieldLogger is also given the fields of the session logging.
 		Logger Logger
 
+		// Regular expressions whose matches are masked with "****" in the
+		// commands, replies and messages sessions log, besides the
+		// parameters of PASS, ACCT, ADAT and SITE RESUME and the tickets of
+		// SITE TICKET which always are. Notifiers exporting commands mask
+		// them with Server.Scrubber. Optional.
+		LogScrubPatterns []string
+
+		// Called with every new session in its goroutine before it is
+		// served, for instance to give it its own logger with
+		// Session.SetLogger, so that the session logs nothing through the
+		// server's. Unlike OnConnect, it can't reject the connection and is
+		// not called again for SITE RESUME. Optional.
+		SessionCallback func(sess *Session)
+
+		// Called with every new session right before the welcome message is
+		// sent, to inspect it and adjust it, as with Session.SetRateLimit
+		// and Session.SetWelcomeMessage. A non-nil error rejects the
+		// connection with 421, or the reply of a *ReplyError, and closes it.
+		// It is called again once SITE RESUME restored a session, where an
+		// error refuses the login. Optional.
+		OnConnect func(sess *Session) error
+
+		// Called with every reply before it is sent, after Profile
+		// rephrased it, to rewrite its code or text, as to mask the details
+		// of internal errors or phrase replies for a deployment. Changing
+		// the code changes what clients do next, usually only the text is
+		// rewritten. Optional.
+		OnReply func(sess *Session, reply *Reply)
+
+		// Called when accepting connections keeps failing with a temporary
+		// error, as EMFILE once out of file descriptors, after Serve backed
+		// off to retrying every second. Serve keeps retrying, errors which
+		// aren't temporary end it. Optional.
+		AcceptErrorCallback func(err error)
+
+		// How long Shutdown lets the commands in progress, transfers
+		// included, finish before ending their sessions. Meanwhile no
+		// connection is accepted, no transfer started, and sessions are sent
+		// ShutdownMessage and closed once idle, so that clients reconnect to
+		// other instances. Optional, 0 ends the sessions at once.
+		ShutdownDrain time.Duration
+
+		// Text of the 421 reply sessions ended by ShutdownDrain are sent.
+		// Optional, defaults to "Service shutting down, closing control
+		// connection".
+		ShutdownMessage string
+
+		// Address pprof and expvar are served on over HTTP, under
+		// /debug/pprof/ and /debug/vars, such as "127.0.0.1:6060". It must
+		// not be reachable from untrusted networks. Optional, disabled when
+		// blank.
+		DebugAddr string
+
+		// Users allowed the administrative SITE commands, SITE DEBUG.
+		// Optional.
+		AdminUsers []string
+
 		// This server supported commands, if blank, it will be defaultCommands
 		// So that users could override the Commands
 		Commands map[string]Command
@@ -48,39 +111,380 @@ type (
 		Name string
 
 		// The hostname that the FTP server should listen on. Optional, defaults to
-		// "::", which means all hostnames on ipv4 and ipv6.
+		// "::", which means all hostnames on ipv4 and ipv6. A DNS name is
+		// listened on at all its A and AAAA addresses.
 		Hostname string
 
-		// Public IP of the server
+		// Public IP of the server. Dual-stack hosts may give an IPv4 and an
+		// IPv6 address separated by a comma. PASV announces the IPv4 one,
+		// EPSV announces none as clients connect to the control address.
 		PublicIP string
 
+		// Service used to discover the public IP when PublicIP is empty. Either
+		// an HTTPS URL answering with the caller's address as plain text, or a
+		// STUN server as "stun:host:port". Discovery is disabled when blank.
+		PublicIPDiscovery string
+
+		// How often the discovered public IP is refreshed, defaults to 10 minutes
+		PublicIPRefresh time.Duration
+
 		// Disable use of passive ports
 		DisablePassive bool
 
+		// Disable active mode, PORT, EPRT and LPRT are rejected with 502
+		DisableActiveMode bool
+
+		// How the source of passive data connections is verified against the
+		// control connection, defaults to DataSourceCheckNone
+		DataSourceCheck DataSourceCheck
+
+		// Originate active mode data connections from local port 20 (ftp-data),
+		// as some legacy firewalls and clients expect. Binding port 20 usually
+		// requires elevated privileges.
+		ActiveModePort20 bool
+
+		// Proxy active mode data connections are dialed through, as
+		// "socks5://[user:password@]host[:port]" or
+		// "http://[user:password@]host[:port]" for HTTP CONNECT. They then
+		// leave from the proxy, ActiveModePort20 and DataBindAddress don't
+		// apply. Optional, used by TCPDataTransport.
+		ActiveProxy string
+
 		// Passive ports
 		PassivePorts string
 
+		// Local address data connections are bound to, either an IP address
+		// or the name of a network interface (e.g. a dedicated data NIC).
+		// Passive listeners listen on all addresses when blank.
+		DataBindAddress string
+
 		// if tls used, cert file is required
 		CertFile string
 
 		// if tls used, key file is required
 		KeyFile string
 
+		// Whether TLS clients are asked for a certificate, see
+		// Context.PeerCertificates. Optional, defaults to tls.NoClientCert.
+		ClientAuth tls.ClientAuthType
+
+		// PEM file of the CAs client certificates are verified against.
+		// Optional, defaults to the system roots.
+		ClientCAFile string
+
+		// Greeting sent with 220, it may span several lines. Optional,
+		// defaults to "Welcome to the Go FTP Server".
 		WelcomeMessage string
 
+		// If true, WelcomeMessage is a text/template executed with
+		// BannerData, as in "Welcome to {{.ServerName}}". Optional, it is
+		// sent as is by default.
+		WelcomeTemplate bool
+
+		// Policy banner, such as a legal notice, the 220 greeting starts
+		// with before the welcome message. It may be of any length, each of
+		// its lines is sent as a "220-" continuation line. Optional.
+		PolicyBanner string
+
+		// File read on every connection for the policy banner when
+		// PolicyBanner is not set, so that it can be changed without a
+		// restart. Optional.
+		PolicyBannerFile string
+
+		// Makes the server pass for another one, with its welcome message,
+		// SYST and FEAT replies and reply texts, such as ProfileVsftpd.
+		// Optional.
+		Profile *Profile
+
+		// Message of the day sent with the 230 reply after login, unless
+		// the session has one set with Session.SetMOTD. Optional.
+		MOTD func(ctx *Context) string
+
+		// File read on every login for the message of the day when MOTD is
+		// not set. Optional.
+		MOTDFile string
+
 		// The port that the FTP should listen on. Optional, defaults to 3000. In
 		// a production environment you will probably want to change this to 21.
 		Port int
 
-		// Rate Limit per connection bytes per second, 0 means no limit
+		// Rate Limit per connection bytes per second, 0 means no limit. It can
+		// be changed for a live session with Session.SetRateLimit.
 		RateLimit int64
 
+		// Rate limits per connection for uploads (STOR, APPE) and downloads
+		// (RETR, listings) in bytes per second, 0 means RateLimit applies
+		RateLimitUp   int64
+		RateLimitDown int64
+
+		// Rate limit in bytes per second shared by all connections, 0 means no
+		// limit. It can be changed at runtime with Server.SetGlobalRateLimit.
+		GlobalRateLimit int64
+
+		// Number of bytes a rate limited transfer may move at once before being
+		// paced, 0 means a tenth of a second worth of the rate.
+		RateLimitBurst int64
+
+		// Rate limits by class name, which sessions are put in with
+		// Session.SetBandwidthClass, such as from Auth.CheckPasswd. Their
+		// windows are applied as time passes. Optional.
+		BandwidthClasses map[string]BandwidthClass
+
+		// Transfer quota applied to every user, it can be changed per session
+		// with Session.SetTransferQuota
+		TransferQuota TransferQuota
+
+		// Where transfer quota usage is tracked, defaults to a MemoryQuotaStore
+		QuotaStore QuotaStore
+
+		// Maximum number of file transfers (RETR, STOR, APPE) running at
+		// once across all sessions, so that a burst of clients does not
+		// overwhelm the driver's backend. Optional, 0 means no limit.
+		MaxTransfers int
+
+		// How long a transfer waits for one of the MaxTransfers to finish
+		// before it is refused with 450. Optional, 0 refuses it at once.
+		TransferQueueTimeout time.Duration
+
+		// Maximum number of file descriptors data connections, passive
+		// listeners and the files of transfers may hold at once. Data
+		// connections over it are refused with 425, and new control
+		// connections with 421 while it is spent, instead of failing with
+		// EMFILE. A negative value is taken off the process's limit of open
+		// files (RLIMIT_NOFILE), keeping that many for control connections
+		// and the rest of the process. Optional, 0 disables it.
+		MaxFileDescriptors int
+
+		// Whether SITE RMDIR -R and SITE MVDIR remove and move whole
+		// directory trees, see TreeDriver. Optional, they are refused with
+		// 502 by default.
+		RecursiveCommands bool
+
+		// Subcommands of the SITE command, if nil, it will be DefaultSiteCommands.
+		// Each server gets its own copy.
+		SiteCommands map[string]Command
+
+		// Size in bytes of the buffers used to copy transfers, which are pooled
+		// and shared between sessions. Optional, defaults to 32KiB, larger
+		// sizes than 4MiB are lowered to it.
+		TransferBufferSize int
+
+		// Maximum number of bytes of transfer buffers in use at once across
+		// all sessions. Transfers wait for a buffer to be given back once it
+		// is reached instead of allocating more, so that thousands of
+		// concurrent uploads can't balloon memory. Uploads are streamed
+		// from the data connection to the driver through a single buffer.
+		// Optional, 0 means no limit.
+		TransferBufferMemory int
+
+		// If true, STOR uploads are written to a hidden file named
+		// ".name.<session ID>.part" and renamed to their final name with
+		// Driver.Rename once complete, so partial files are never seen
+		// under the final name. Failed uploads are deleted.
+		AtomicUploads bool
+
+		// Entries hidden from directory listings, it can be changed per
+		// session with Session.SetListFilter
+		ListFilter ListFilter
+
+		// How LIST formats entries, it can be changed per session with
+		// Session.SetListFormat. Optional, defaults to ListFormatUnix or
+		// the format of the Profile.
+		ListFormat ListFormat
+
+		// Time zone of the times LIST, MLSD and MDTM send, set time.UTC
+		// for clients following RFC 3659. Optional, times are sent as the
+		// driver returns them when nil.
+		TimeLocation *time.Location
+
+		// LIST shows the time of day of entries modified within this
+		// period and the year of older ones. Optional, defaults to a year.
+		ListRecentPeriod time.Duration
+
+		// If true, LIST always shows the year instead of the time of day
+		ListAlwaysYear bool
+
+		// Canary paths, as path.Match patterns of absolute paths. A pattern
+		// also covers everything below a matching directory. RETR, STOR,
+		// APPE and listings touching them fire CanaryNotifier.OnCanary.
+		CanaryPaths []string
+
+		// What RNTO does when its target exists. Optional, defaults to
+		// RenameDriver, leaving it to Driver.Rename.
+		RenamePolicy RenamePolicy
+
+		// Compatibility quirks applied to the clients matching them, on CLNT
+		// and anonymous logins. Optional, defaults to DefaultClientQuirks,
+		// an empty slice applies none.
+		ClientQuirks []ClientQuirk
+
+		// Hash algorithms computed over every upload as it streams to the
+		// driver, among crc32, md5, sha1, sha256 and sha512. The digests are
+		// handed to AfterFilePut in Context.Checksums, they only cover the
+		// bytes received, not a resumed file's earlier part. Optional, no
+		// checksums are computed when empty.
+		UploadChecksums []string
+
+		// Largest file in bytes SITE HASH and SITE CKSM read to compute a
+		// digest the driver doesn't provide as a HashDriver. Optional, 0
+		// refuses reading files with 502, only digests from the driver are
+		// served.
+		MaxHashSize int64
+
+		// Data transfers that move no bytes for this long are aborted with 426.
+		// Optional, 0 or a negative value disables it.
+		TransferStallTimeout time.Duration
+
+		// How long RNTO may follow RNFR, later it is refused with 503 and
+		// RNFR must be sent again. Optional, defaults to 60 seconds, a
+		// negative value disables it.
+		RenameTimeout time.Duration
+
+		// Maximum number of commands a session may send per second, clients
+		// exceeding it are disconnected with 421. Optional, 0 disables it.
+		MaxCommandRate int
+
+		// Maximum number of connections accepted per second from an IP,
+		// which may be below 1. Connections over it are closed as soon as
+		// accepted, before any session is started. Optional, 0 disables it.
+		MaxConnectionRate float64
+
+		// Number of connections an IP may open at once within
+		// MaxConnectionRate. Optional, defaults to twice MaxConnectionRate,
+		// at least 1.
+		ConnectionBurst int
+
+		// Maximum length in bytes of a command line, longer lines get the
+		// client disconnected with 421. Optional, defaults to 4096.
+		MaxLineLength int
+
+		// Maximum number of commands a session may send before logging in,
+		// after which it is disconnected with 421. Optional, 0 disables it.
+		MaxPreAuthCommands int
+
+		// Number of entries LIST, NLST, MLSD and STAT send at most, longer
+		// listings are cut. Optional, 0 lists every entry.
+		MaxListEntries int
+
+		// Number of entries listings ask a PagedDriver for at once.
+		// Optional, defaults to 1000.
+		ListPageSize int
+
+		// Closes the session of a command which panicked. Optional, by
+		// default only the command's transfer is ended and it is answered
+		// with 451. Panics are counted by Server.PanicCount and reported to
+		// PanicNotifiers either way.
+		DisconnectOnPanic bool
+
+		// Connections that have not logged in within this duration are
+		// disconnected with 421. Optional, 0 disables it.
+		LoginTimeout time.Duration
+
+		// Delay before answering a failed login with 530, doubled for each
+		// login which failed from the same IP within LoginTarpitWindow, up
+		// to LoginTarpitMax. It slows credential stuffing down without
+		// banning anyone. Optional, 0 disables it.
+		LoginTarpitDelay time.Duration
+
+		// Longest delay of failed logins. Optional, defaults to 30 seconds.
+		LoginTarpitMax time.Duration
+
+		// How long failed logins from an IP are remembered after the last
+		// one. Optional, defaults to 15 minutes.
+		LoginTarpitWindow time.Duration
+
+		// Number of logins failing from an IP within LoginTarpitWindow
+		// after which it is put in BanList for LoginBanDuration, and its
+		// connections refused. Optional, 0 disables it.
+		MaxLoginFailures int
+
+		// How long MaxLoginFailures bans an IP. Optional, defaults to an
+		// hour.
+		LoginBanDuration time.Duration
+
+		// Where the IPs whose connections are refused are kept, sharing
+		// them between the servers of a cluster when backed by a shared
+		// database. Optional, defaults to an in-memory list with
+		// MaxLoginFailures.
+		BanList BanList
+
+		// Where failed logins are counted for LoginTarpitDelay and
+		// MaxLoginFailures, sharing them between the servers of a cluster
+		// when backed by a shared database. Optional, defaults to counting
+		// them in memory.
+		LoginFailureStore LoginFailureStore
+
+		// Control connections idle for this long between commands are
+		// disconnected with 421. Optional, 0 disables it. Data connections
+		// are covered by TransferStallTimeout.
+		IdleTimeout time.Duration
+
+		// Deadline for writing a reply on the control connection. Optional,
+		// 0 disables it.
+		WriteTimeout time.Duration
+
+		// Driver calls making no progress for this long are given up and
+		// answered with 451, so that a hung backend does not hold sessions
+		// until Timeout. Listings and uploads progress with every entry
+		// and read, reading downloaded files is not covered. Optional, 0
+		// disables it.
+		DriverTimeout time.Duration
+
+		// Number of driver calls in a row failing, timeouts included, after
+		// which driver calls are answered with 451 without calling the
+		// driver until DriverBreakerCooldown passed. Errors such as
+		// ErrNotFound are not failures. Optional, 0 disables it.
+		DriverFailureThreshold int
+
+		// How long driver calls are refused once DriverFailureThreshold is
+		// reached, before one is let through to probe the backend.
+		// Optional, defaults to 30 seconds.
+		DriverBreakerCooldown time.Duration
+
+		// How long the results of Driver.Stat are cached, so that clients
+		// sending SIZE, MDTM or MLST for every file do not reach a slow
+		// driver each time. Listings fill the cache too, uploads, renames
+		// and deletions clear it. Changes made by others than the server
+		// show after at most this long. Optional, 0 disables the cache.
+		StatCacheTTL time.Duration
+
+		// Maximum number of cached Stat results. Optional, defaults to
+		// 10000.
+		StatCacheSize int
+
+		// TCP keepalive period for control and data connections, so idle
+		// control connections survive NAT timeouts. Optional, 0 keeps the
+		// system default and a negative value disables keepalives.
+		TCPKeepAlive time.Duration
+
+		// If true, Nagle's algorithm is left enabled on control and data
+		// connections.
+		DisableNoDelay bool
+
+		// Opens data connections. Optional, defaults to TCPDataTransport.
+		DataTransport DataTransport
+
+		// SocketOptions is called with every control and data TCP connection
+		// before use, to set any further socket options. An error closes the
+		// connection.
+		SocketOptions func(conn *net.TCPConn, kind SocketKind) error
+
+		// Number of goroutines notifier hooks are run on, so a slow notifier
+		// does not hold up the client. Hooks of one session are still called
+		// in order, the Before* hooks and Interceptors are always called
+		// synchronously. As the others may run after the command returned,
+		// they get a snapshot of their Context, which they should not use as
+		// a context.Context. Optional, 0 calls the hooks synchronously.
+		NotifierWorkers int
+
+		// Number of hooks each notifier worker may have pending, once full
+		// further hooks are dropped and counted in
+		// ServerStats.DroppedNotifications. Optional, defaults to 64.
+		NotifierQueueSize int
+
 		// Timeout is used to restrict the total length of a session
 		Timeout time.Duration
 
-		// CommandsMu controls access to the Commands map
-		CommandsMu sync.RWMutex
-
 		// use tls, default is false
 		TLS bool
 
@@ -89,6 +493,18 @@ type (
 
 		// If true, client must upgrade to TLS before sending any other command
 		ForceTLS bool
+
+		// Hosts served besides the default one, by name. Clients select them
+		// with HOST (RFC 7151) or the server name of their TLS handshake,
+		// and get their certificate and driver. HOST naming another host is
+		// rejected with 504. Optional.
+		VirtualHosts map[string]VirtualHost
+
+		// How long tickets of SITE TICKET are valid. Clients whose
+		// connection dropped log in again with SITE RESUME and the ticket,
+		// getting back their directory and pending REST. Optional, 0
+		// disables session resumption.
+		ResumeTicketTTL time.Duration
 	}
 
 	// Server is the root of your FTP application. You should instantiate one
@@ -101,13 +517,68 @@ type (
 		ctx      context.Context
 		*Options
 		tlsConfig *tls.Config
-		cancel    context.CancelFunc
-		// rate limiter per connection
+		// CommandsMu controls access to the Commands map
+		CommandsMu sync.RWMutex
+		// implicit FTPS, Serve does the TLS handshakes
+		implicitTLS bool
+		cancel      context.CancelFunc
+		// rate limiter shared by all connections
 		rateLimiter  *ratelimit.Limiter
-		ConnCallback func(ctx context.Context, conn net.Conn) net.Conn // optional callback for wrapping net.Conn before handling
+		ConnCallback func(ctx context.Context, conn net.Conn) net.Conn // optional callback wrapping the control connection, the *tls.Conn with implicit FTPS
 		listenTo     string
 		feats        string
-		notifiers    notifierList
+		clientQuirks []clientQuirk
+		// Options.WelcomeMessage parsed, nil unless Options.WelcomeTemplate
+		welcomeTemplate *template.Template
+		notifiers       notifierList
+		discoveredIP    atomic.Value
+		bufferPool      sync.Pool
+		// sessions created so far, spreads them over the notifier workers
+		sessionCount uint32
+		// sessions being served, which Shutdown ends and waits for
+		sessionsMu sync.Mutex
+		sessions   map[*Session]struct{}
+		sessionsWG sync.WaitGroup
+		closing    bool
+		// panics recovered while serving sessions
+		panics atomic.Uint64
+		// slots of Options.MaxTransfers, nil without a limit
+		transferSlots chan struct{}
+		// Options.Driver, guarded by Options.DriverTimeout and the circuit
+		// breaker and cached by Options.StatCacheTTL when set
+		driver Driver
+		// bytes transferred and commands executed, see Stats
+		statsMu      sync.Mutex
+		stats        TransferStats
+		userStats    map[string]TransferStats
+		commandStats map[string]int64
+		// when NewServer created the server
+		start time.Time
+		// sessions served and logins, see Stats
+		connections  atomic.Int64
+		logins       atomic.Int64
+		failedLogins atomic.Int64
+		// masks secrets in session logs, see Options.LogScrubPatterns
+		scrubber *Scrubber
+		// Options.ActiveProxy parsed, nil without one
+		activeProxy *proxyDialer
+		// Options.VirtualHosts by lower case name
+		virtualHosts map[string]*virtualHost
+		// resumption tickets by token, see SITE TICKET
+		ticketsMu sync.Mutex
+		tickets   map[string]*resumeTicket
+		// failed logins by source, see Options.LoginTarpitDelay
+		tarpit loginTarpit
+		// nil without Options.MaxConnectionRate
+		connLimiter *connLimiter
+		// nil without Options.MaxFileDescriptors
+		fds *fdBudget
+		// buffers of Options.TransferBufferMemory, nil without a limit
+		bufferSlots chan struct{}
+		// see RegisterTransferStage
+		transferStages []TransferStage
+		// set by Shutdown for Options.ShutdownDrain
+		draining atomic.Bool
 	}
 
 	// serverConn is used to wrap a handle with context.
@@ -146,7 +617,12 @@ func optsWithDefaults(opts *Options) *Options {
 		newOpts.Name = opts.Name
 	}
 
-	if opts.WelcomeMessage == "" {
+	newOpts.Profile = opts.Profile
+	newOpts.WelcomeTemplate = opts.WelcomeTemplate
+	if opts.WelcomeMessage == "" && opts.Profile != nil && opts.Profile.WelcomeMessage != "" {
+		newOpts.WelcomeMessage = opts.Profile.WelcomeMessage
+		newOpts.WelcomeTemplate = true
+	} else if opts.WelcomeMessage == "" {
 		newOpts.WelcomeMessage = defaultWelcomeMessage
 	} else {
 		newOpts.WelcomeMessage = opts.WelcomeMessage
@@ -161,17 +637,40 @@ func optsWithDefaults(opts *Options) *Options {
 	} else {
 		newOpts.Logger = &StdLogger{}
 	}
+	newOpts.LogScrubPatterns = opts.LogScrubPatterns
+	newOpts.SessionCallback = opts.SessionCallback
+	newOpts.OnConnect = opts.OnConnect
+	newOpts.OnReply = opts.OnReply
+	newOpts.AcceptErrorCallback = opts.AcceptErrorCallback
+	newOpts.ShutdownDrain = opts.ShutdownDrain
+	newOpts.ShutdownMessage = opts.ShutdownMessage
+	if newOpts.ShutdownMessage == "" {
+		newOpts.ShutdownMessage = defaultShutdownMessage
+	}
+	newOpts.DebugAddr = opts.DebugAddr
+	newOpts.AdminUsers = opts.AdminUsers
 
-	if opts.Commands == nil {
-		newOpts.Commands = defaultCommands
-	} else {
-		newOpts.Commands = opts.Commands
+	// Copied, so that changes to a server's commands stay its own.
+	commands := opts.Commands
+	if commands == nil {
+		commands = defaultCommands
+	}
+	newOpts.Commands = make(map[string]Command, len(commands))
+	for name, cmd := range commands {
+		newOpts.Commands[name] = cmd
+	}
+
+	siteCommands := opts.SiteCommands
+	if siteCommands == nil {
+		siteCommands = defaultSiteCommands
+	}
+	newOpts.SiteCommands = make(map[string]Command, len(siteCommands))
+	for name, cmd := range siteCommands {
+		newOpts.SiteCommands[name] = cmd
 	}
 
 	if opts.DisablePassive {
-		if _, ok := newOpts.Commands["PASV"]; ok {
-			delete(newOpts.Commands, "PASV")
-		}
+		delete(newOpts.Commands, "PASV")
 	}
 
 	if opts.Timeout.Seconds() <= 0 {
@@ -180,15 +679,149 @@ func optsWithDefaults(opts *Options) *Options {
 		newOpts.Timeout = opts.Timeout
 	}
 
+	newOpts.TransferBufferMemory = opts.TransferBufferMemory
+	if opts.TransferBufferSize <= 0 {
+		newOpts.TransferBufferSize = defaultTransferBufferSize
+	} else {
+		newOpts.TransferBufferSize = min(opts.TransferBufferSize, maxTransferBufferSize)
+	}
+
+	newOpts.UploadChecksums = opts.UploadChecksums
+	newOpts.MaxHashSize = opts.MaxHashSize
+	newOpts.AtomicUploads = opts.AtomicUploads
+	newOpts.ListFilter = opts.ListFilter
+	newOpts.CanaryPaths = opts.CanaryPaths
+	newOpts.RenamePolicy = opts.RenamePolicy
+	newOpts.ListFormat = opts.ListFormat
+	newOpts.TimeLocation = opts.TimeLocation
+	if opts.ListRecentPeriod <= 0 {
+		newOpts.ListRecentPeriod = defaultListRecentPeriod
+	} else {
+		newOpts.ListRecentPeriod = opts.ListRecentPeriod
+	}
+	newOpts.ListAlwaysYear = opts.ListAlwaysYear
+	newOpts.PolicyBanner = opts.PolicyBanner
+	newOpts.PolicyBannerFile = opts.PolicyBannerFile
+	newOpts.MOTD = opts.MOTD
+	newOpts.MOTDFile = opts.MOTDFile
+	newOpts.ClientQuirks = opts.ClientQuirks
+	if newOpts.ClientQuirks == nil {
+		newOpts.ClientQuirks = DefaultClientQuirks
+	}
+
+	newOpts.TransferStallTimeout = opts.TransferStallTimeout
+
+	if opts.RenameTimeout == 0 {
+		newOpts.RenameTimeout = 60 * time.Second
+	} else {
+		newOpts.RenameTimeout = opts.RenameTimeout
+	}
+
+	if opts.MaxLineLength <= 0 {
+		newOpts.MaxLineLength = defaultMaxLineLength
+	} else {
+		newOpts.MaxLineLength = opts.MaxLineLength
+	}
+
+	newOpts.NotifierWorkers = opts.NotifierWorkers
+	if opts.NotifierQueueSize <= 0 {
+		newOpts.NotifierQueueSize = defaultNotifierQueueSize
+	} else {
+		newOpts.NotifierQueueSize = opts.NotifierQueueSize
+	}
+
+	newOpts.LoginTimeout = opts.LoginTimeout
+	newOpts.LoginTarpitDelay = opts.LoginTarpitDelay
+	if opts.LoginTarpitMax <= 0 {
+		newOpts.LoginTarpitMax = defaultLoginTarpitMax
+	} else {
+		newOpts.LoginTarpitMax = opts.LoginTarpitMax
+	}
+	if opts.LoginTarpitWindow <= 0 {
+		newOpts.LoginTarpitWindow = defaultLoginTarpitWindow
+	} else {
+		newOpts.LoginTarpitWindow = opts.LoginTarpitWindow
+	}
+	newOpts.MaxLoginFailures = opts.MaxLoginFailures
+	if opts.LoginBanDuration <= 0 {
+		newOpts.LoginBanDuration = defaultLoginBanDuration
+	} else {
+		newOpts.LoginBanDuration = opts.LoginBanDuration
+	}
+	newOpts.BanList = opts.BanList
+	if newOpts.BanList == nil && opts.MaxLoginFailures > 0 {
+		newOpts.BanList = NewMemoryBanList()
+	}
+	newOpts.LoginFailureStore = opts.LoginFailureStore
+	newOpts.IdleTimeout = opts.IdleTimeout
+	newOpts.WriteTimeout = opts.WriteTimeout
+	newOpts.DriverTimeout = opts.DriverTimeout
+	newOpts.DriverFailureThreshold = opts.DriverFailureThreshold
+	if opts.DriverBreakerCooldown <= 0 {
+		newOpts.DriverBreakerCooldown = defaultDriverBreakerCooldown
+	} else {
+		newOpts.DriverBreakerCooldown = opts.DriverBreakerCooldown
+	}
+	newOpts.StatCacheTTL = opts.StatCacheTTL
+	if opts.StatCacheSize <= 0 {
+		newOpts.StatCacheSize = defaultStatCacheSize
+	} else {
+		newOpts.StatCacheSize = opts.StatCacheSize
+	}
+	newOpts.TCPKeepAlive = opts.TCPKeepAlive
+	newOpts.DisableNoDelay = opts.DisableNoDelay
+	newOpts.SocketOptions = opts.SocketOptions
+	if opts.DataTransport == nil {
+		newOpts.DataTransport = TCPDataTransport{}
+	} else {
+		newOpts.DataTransport = opts.DataTransport
+	}
+	newOpts.MaxCommandRate = opts.MaxCommandRate
+	newOpts.MaxConnectionRate = opts.MaxConnectionRate
+	newOpts.ConnectionBurst = opts.ConnectionBurst
+	newOpts.MaxPreAuthCommands = opts.MaxPreAuthCommands
+	newOpts.MaxListEntries = opts.MaxListEntries
+	newOpts.ListPageSize = opts.ListPageSize
+	newOpts.DisconnectOnPanic = opts.DisconnectOnPanic
 	newOpts.DisablePassive = opts.DisablePassive
+	newOpts.DisableActiveMode = opts.DisableActiveMode
+	newOpts.ActiveModePort20 = opts.ActiveModePort20
+	newOpts.ActiveProxy = opts.ActiveProxy
+	newOpts.VirtualHosts = opts.VirtualHosts
+	newOpts.ResumeTicketTTL = opts.ResumeTicketTTL
+	newOpts.DataSourceCheck = opts.DataSourceCheck
 	newOpts.Perm = opts.Perm
 	newOpts.TLS = opts.TLS
 	newOpts.KeyFile = opts.KeyFile
 	newOpts.CertFile = opts.CertFile
+	newOpts.ClientAuth = opts.ClientAuth
+	newOpts.ClientCAFile = opts.ClientCAFile
 	newOpts.ExplicitFTPS = opts.ExplicitFTPS
 	newOpts.PublicIP = opts.PublicIP
+	newOpts.PublicIPDiscovery = opts.PublicIPDiscovery
+	if opts.PublicIPRefresh <= 0 {
+		newOpts.PublicIPRefresh = defaultPublicIPRefresh
+	} else {
+		newOpts.PublicIPRefresh = opts.PublicIPRefresh
+	}
 	newOpts.PassivePorts = opts.PassivePorts
+	newOpts.DataBindAddress = opts.DataBindAddress
 	newOpts.RateLimit = opts.RateLimit
+	newOpts.RateLimitUp = opts.RateLimitUp
+	newOpts.RateLimitDown = opts.RateLimitDown
+	newOpts.GlobalRateLimit = opts.GlobalRateLimit
+	newOpts.RateLimitBurst = opts.RateLimitBurst
+	newOpts.BandwidthClasses = opts.BandwidthClasses
+	newOpts.TransferQuota = opts.TransferQuota
+	if opts.QuotaStore == nil {
+		newOpts.QuotaStore = NewMemoryQuotaStore()
+	} else {
+		newOpts.QuotaStore = opts.QuotaStore
+	}
+	newOpts.MaxTransfers = opts.MaxTransfers
+	newOpts.MaxFileDescriptors = opts.MaxFileDescriptors
+	newOpts.TransferQueueTimeout = opts.TransferQueueTimeout
+	newOpts.RecursiveCommands = opts.RecursiveCommands
 
 	return &newOpts
 }
@@ -211,17 +844,65 @@ func NewServer(opts *Options) (*Server, error) {
 	if opts.Perm == nil {
 		return nil, errors.New("No perm implementation")
 	}
+	if err := checkChecksumAlgorithms(opts.UploadChecksums); err != nil {
+		return nil, err
+	}
+	if int(opts.ListFormat) >= len(listFormatNames) {
+		return nil, fmt.Errorf("ftp: invalid list format %s", opts.ListFormat)
+	}
+	if int(opts.RenamePolicy) >= len(renamePolicyNames) {
+		return nil, fmt.Errorf("ftp: invalid rename policy %s", opts.RenamePolicy)
+	}
+	if err := checkCanaryPatterns(opts.CanaryPaths); err != nil {
+		return nil, err
+	}
+	if err := checkBandwidthClasses(opts.BandwidthClasses); err != nil {
+		return nil, err
+	}
+	if err := checkPublicIP(opts.PublicIP); err != nil {
+		return nil, err
+	}
+	var activeProxy *proxyDialer
+	if opts.ActiveProxy != "" {
+		var err error
+		if activeProxy, err = newProxyDialer(opts.ActiveProxy); err != nil {
+			return nil, err
+		}
+	}
+	clientQuirks, err := compileClientQuirks(opts.ClientQuirks)
+	if err != nil {
+		return nil, err
+	}
+	var welcomeTemplate *template.Template
+	if opts.WelcomeTemplate {
+		if welcomeTemplate, err = parseWelcomeMessage(opts.WelcomeMessage); err != nil {
+			return nil, err
+		}
+	}
+	scrubber, err := NewScrubber(opts.LogScrubPatterns...)
+	if err != nil {
+		return nil, err
+	}
 
 	s := &Server{
-		Options:  opts,
-		listenTo: net.JoinHostPort(opts.Hostname, strconv.Itoa(opts.Port)),
-		logger:   opts.Logger,
+		Options:         opts,
+		driver:          opts.Driver,
+		listenTo:        net.JoinHostPort(opts.Hostname, strconv.Itoa(opts.Port)),
+		logger:          opts.Logger,
+		clientQuirks:    clientQuirks,
+		welcomeTemplate: welcomeTemplate,
+		activeProxy:     activeProxy,
+		scrubber:        scrubber,
+		start:           time.Now(),
 	}
 
 	feats := "Extensions supported:\n%s"
 	featCmds := " UTF8\n"
 
 	for k, v := range s.Commands {
+		if opts.DisableActiveMode && isActiveModeCommand(k) {
+			continue
+		}
 		if v.IsExtend() {
 			featCmds = featCmds + " " + k + "\n"
 		}
@@ -232,37 +913,105 @@ func NewServer(opts *Options) (*Server, error) {
 	}
 
 	s.feats = fmt.Sprintf(feats, featCmds)
-	s.rateLimiter = ratelimit.New(opts.RateLimit)
+	s.rateLimiter = ratelimit.NewWithBurst(opts.GlobalRateLimit, opts.RateLimitBurst)
+	if opts.MaxTransfers > 0 {
+		s.transferSlots = make(chan struct{}, opts.MaxTransfers)
+	}
+	if opts.TransferBufferMemory > 0 {
+		s.bufferSlots = make(chan struct{}, max(1, opts.TransferBufferMemory/opts.TransferBufferSize))
+	}
+	if s.fds, err = newFDBudget(opts.MaxFileDescriptors); err != nil {
+		return nil, err
+	}
+	if opts.MaxConnectionRate > 0 {
+		s.connLimiter = newConnLimiter(opts.MaxConnectionRate, opts.ConnectionBurst)
+	}
+	s.driver = wrapDriver(opts.Driver, opts)
+	if s.virtualHosts, err = newVirtualHosts(opts, func(driver Driver) Driver {
+		return wrapDriver(driver, opts)
+	}); err != nil {
+		return nil, err
+	}
+	if opts.NotifierWorkers > 0 {
+		s.notifiers.pool = newNotifierPool(s.logger, opts.NotifierWorkers, opts.NotifierQueueSize)
+	}
 
 	return s, nil
 }
 
-// RegisterNotifier registers a notifier
-func (server *Server) RegisterNotifier(notifier Notifier) {
-	server.notifiers = append(server.notifiers, notifier)
+// wrapDriver guards and caches driver as opts configure
+func wrapDriver(driver Driver, opts *Options) Driver {
+	if driver != nil && (opts.DriverTimeout > 0 || opts.DriverFailureThreshold > 0) {
+		driver = newGuardedDriver(driver, opts)
+	}
+	if driver != nil && opts.StatCacheTTL > 0 {
+		driver = newCachingDriver(driver, opts)
+	}
+	return driver
+}
+
+// SetGlobalRateLimit changes the rate limit shared by all connections in bytes
+// per second, 0 means no limit. It applies to transfers in progress.
+func (server *Server) SetGlobalRateLimit(rate int64) {
+	server.rateLimiter.SetRate(rate)
+}
+
+// RegisterNotifier registers a notifier. It may implement Notifier or only
+// some of CommandNotifier, LoginNotifier, TransferNotifier, FileNotifier and
+// DirNotifier, the hooks it does not implement are skipped. It fails when
+// notifier implements none of the interfaces of notifier.go.
+func (server *Server) RegisterNotifier(notifier interface{}) error {
+	if !isNotifier(notifier) {
+		return fmt.Errorf("ftp: %T implements no notifier interface", notifier)
+	}
+	server.notifiers.list = append(server.notifiers.list, notifier)
+	return nil
 }
 
 // NewConn constructs a new object that will handle the FTP protocol over an active net.TCPConn. The TCP connection
 // should already be open before it is handed to this function.
 func (server *Server) newSession(id string, tcpConn net.Conn) *Session {
+	parent := server.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithCancel(parent)
+
 	return &Session{
-		id:            id,
-		server:        server,
-		controlReader: bufio.NewReader(tcpConn),
-		controlWriter: bufio.NewWriter(tcpConn),
-		curDir:        "/",
-		reqUser:       "",
-		user:          "",
-		renameFrom:    "",
-		lastFilePos:   -1,
-		closed:        false,
-		tls:           false,
-		Conn:          tcpConn,
-		Data:          make(map[string]interface{}),
+		Ctx:             ctx,
+		cancel:          cancel,
+		id:              id,
+		server:          server,
+		controlReader:   bufio.NewReader(tcpConn),
+		controlWriter:   bufio.NewWriter(tcpConn),
+		curDir:          "/",
+		reqUser:         "",
+		user:            "",
+		renameFrom:      "",
+		lastFilePos:     -1,
+		closed:          false,
+		tls:             false,
+		Conn:            tcpConn,
+		rawConn:         tcpConn,
+		Data:            NewStore(),
+		uploadLimiter:   ratelimit.NewWithBurst(rateOrDefault(server.RateLimitUp, server.RateLimit), server.RateLimitBurst),
+		downloadLimiter: ratelimit.NewWithBurst(rateOrDefault(server.RateLimitDown, server.RateLimit), server.RateLimitBurst),
+		transferQuota:   server.TransferQuota,
+		listFilter:      server.ListFilter,
+		listFormat:      server.listFormat(),
+		notifyWorker:    atomic.AddUint32(&server.sessionCount, 1),
 	}
 }
 
-func simpleTLSConfig(certFile, keyFile string) (*tls.Config, error) {
+// rateOrDefault returns rate, or def if rate is not set.
+func rateOrDefault(rate, def int64) int64 {
+	if rate > 0 {
+		return rate
+	}
+	return def
+}
+
+func simpleTLSConfig(opts *Options) (*tls.Config, error) {
 	config := &tls.Config{}
 	if config.NextProtos == nil {
 		config.NextProtos = []string{"ftp"}
@@ -270,10 +1019,22 @@ func simpleTLSConfig(certFile, keyFile string) (*tls.Config, error) {
 
 	var err error
 	config.Certificates = make([]tls.Certificate, 1)
-	config.Certificates[0], err = tls.LoadX509KeyPair(certFile, keyFile)
+	config.Certificates[0], err = tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
 	if err != nil {
 		return nil, err
 	}
+
+	config.ClientAuth = opts.ClientAuth
+	if opts.ClientCAFile != "" {
+		pem, err := ioutil.ReadFile(opts.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		config.ClientCAs = x509.NewCertPool()
+		if !config.ClientCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("ftp: no certificates in %s", opts.ClientCAFile)
+		}
+	}
 	return config, nil
 }
 
@@ -283,23 +1044,7 @@ func simpleTLSConfig(certFile, keyFile string) (*tls.Config, error) {
 // If the server fails to start for any reason, an error will be returned. Common errors are trying to bind to a
 // privileged port or something else is already listening on the same port.
 func (server *Server) ListenAndServe() error {
-	var listener net.Listener
-	var err error
-
-	if server.Options.TLS {
-		server.tlsConfig, err = simpleTLSConfig(server.CertFile, server.KeyFile)
-		if err != nil {
-			return err
-		}
-
-		if server.Options.ExplicitFTPS {
-			listener, err = net.Listen("tcp", server.listenTo)
-		} else {
-			listener, err = tls.Listen("tcp", server.listenTo, server.tlsConfig)
-		}
-	} else {
-		listener, err = net.Listen("tcp", server.listenTo)
-	}
+	listener, err := server.listen()
 	if err != nil {
 		return err
 	}
@@ -312,16 +1057,88 @@ func (server *Server) ListenAndServe() error {
 // Serve accepts connections on a given net.Listener and handles each
 // request in a new goroutine.
 func (server *Server) Serve(l net.Listener) error {
+	if server.Options.TLS && server.tlsConfig == nil {
+		tlsConfig, err := simpleTLSConfig(server.Options)
+		if err != nil {
+			_ = l.Close()
+			return err
+		}
+		server.useVirtualHostTLS(tlsConfig)
+		server.tlsConfig = tlsConfig
+
+		// Implicit FTPS connections are wrapped below, so their
+		// ClientHello can be fingerprinted.
+		server.implicitTLS = !server.Options.ExplicitFTPS
+	}
+
 	server.listener = l
 	server.ctx, server.cancel = context.WithCancel(context.Background())
-	defer server.cancel()
+	defer func() {
+		// Sessions being drained keep their context until Shutdown ends them
+		if !server.draining.Load() {
+			server.cancel()
+		}
+	}()
 
-	sessionID := newSessionID()
+	if server.DebugAddr != "" {
+		if err := server.serveDebug(server.ctx); err != nil {
+			_ = l.Close()
+			return err
+		}
+	}
+	if server.PublicIP == "" && server.PublicIPDiscovery != "" {
+		go server.discoverPublicIP(server.ctx)
+	}
+	if len(server.BandwidthClasses) > 0 {
+		go server.scheduleBandwidth(server.ctx)
+	}
 
+	var tempDelay time.Duration
 	for {
 		rawConn, err := server.listener.Accept()
 		if err != nil {
-			return err
+			// Shutdown and Drain close the listener, that's no failure
+			if server.ctx.Err() != nil || server.draining.Load() {
+				return ErrServerClosed
+			}
+			if !isTemporaryAcceptError(err) {
+				return err
+			}
+			if tempDelay == 0 {
+				tempDelay = minAcceptRetryDelay
+			} else if tempDelay *= 2; tempDelay >= maxAcceptRetryDelay {
+				tempDelay = maxAcceptRetryDelay
+				if server.AcceptErrorCallback != nil {
+					server.AcceptErrorCallback(err)
+				}
+			}
+			server.logger.Printf("", "accepting connection: %v, retrying in %s", err, tempDelay)
+			select {
+			case <-time.After(tempDelay):
+			case <-server.ctx.Done():
+				return ErrServerClosed
+			}
+			continue
+		}
+		tempDelay = 0
+
+		// Floods of connections are refused before costing a session
+		if server.connLimiter != nil && !server.connLimiter.allow(addrIP(rawConn.RemoteAddr()), time.Now()) {
+			_ = rawConn.Close()
+			continue
+		}
+		if server.fds.exhausted() {
+			if !server.implicitTLS {
+				_, _ = io.WriteString(rawConn, "421 Too many open files, try again later\r\n")
+			}
+			_ = rawConn.Close()
+			continue
+		}
+
+		if err = server.tuneConn(rawConn, SocketControl); err != nil {
+			server.logger.Printf("", "setting control socket options: %v", err)
+			_ = rawConn.Close()
+			continue
 		}
 
 		var ctx context.Context
@@ -333,31 +1150,110 @@ func (server *Server) Serve(l net.Listener) error {
 			ctx, cancel = context.WithCancel(context.Background())
 		}
 
-		if server.ConnCallback != nil {
-			rawConn = server.ConnCallback(ctx, rawConn)
+		// ConnCallback wraps the connection the session reads commands
+		// from, the TLS one for implicit FTPS as with a TLS listener.
+		var conn net.Conn
+		var hello *helloConn
+		if server.implicitTLS {
+			hello = &helloConn{Conn: serverConn{
+				Conn:   rawConn,
+				cancel: cancel,
+				ctx:    ctx,
+			}}
+			conn = tls.Server(hello, server.tlsConfig)
+			if server.ConnCallback != nil {
+				conn = server.ConnCallback(ctx, conn)
+			}
+		} else {
+			if server.ConnCallback != nil {
+				rawConn = server.ConnCallback(ctx, rawConn)
+			}
+			conn = serverConn{
+				Conn:   rawConn,
+				cancel: cancel,
+				ctx:    ctx,
+			}
 		}
 
-		conn := serverConn{
-			Conn:   rawConn,
-			cancel: cancel,
-			ctx:    ctx,
+		ftpConn := server.newSession(newSessionID(), conn)
+		if hello != nil {
+			hello.done = ftpConn.recordClientHello
+			ftpConn.tls = true
+			ftpConn.rawConn = hello.Conn
 		}
+		server.serveSession(ftpConn)
+	}
+}
 
-		ftpConn := server.newSession(sessionID, conn)
-		go ftpConn.Serve()
+// serveSession serves sess in a new goroutine, tracked until it returns.
+func (server *Server) serveSession(sess *Session) {
+	server.sessionsMu.Lock()
+	defer server.sessionsMu.Unlock()
+	if server.closing {
+		sess.interrupt()
+		return
+	}
+	if server.sessions == nil {
+		server.sessions = make(map[*Session]struct{})
 	}
+	server.sessions[sess] = struct{}{}
+	server.sessionsWG.Add(1)
+
+	go func() {
+		defer func() {
+			server.sessionsMu.Lock()
+			delete(server.sessions, sess)
+			server.sessionsMu.Unlock()
+			server.sessionsWG.Done()
+		}()
+		// Checked here, a slow BanList must not hold up accepting
+		if server.banned(addrIP(sess.RemoteAddr())) {
+			sess.Close()
+			return
+		}
+		server.connections.Add(1)
+		// In this goroutine too, so that a slow callback only holds up
+		// its own session
+		if server.SessionCallback != nil {
+			server.SessionCallback(sess)
+		}
+		sess.Serve()
+	}()
+}
+
+// closeSessions interrupts the sessions being served and waits for their
+// goroutines to return.
+func (server *Server) closeSessions() {
+	server.sessionsMu.Lock()
+	server.closing = true
+	for sess := range server.sessions {
+		sess.interrupt()
+	}
+	server.sessionsMu.Unlock()
+	server.sessionsWG.Wait()
 }
 
-// Shutdown will gracefully stop a server. Already connected clients will retain their connections
+// Shutdown stops the server: it stops accepting connections, ends the
+// connected sessions, transfers included, and returns once their goroutines
+// have, so that nothing runs on behalf of the server afterwards. With
+// Options.ShutdownDrain, sessions are first given that long to finish their
+// commands.
 func (server *Server) Shutdown() error {
+	var err error
+	if server.ShutdownDrain > 0 {
+		err = server.drain()
+	}
 	if server.cancel != nil {
 		server.cancel()
 	}
 
-	if server.listener != nil {
-		return server.listener.Close()
+	if server.listener != nil && !server.draining.Load() {
+		err = server.listener.Close()
 	}
+	server.closeSessions()
 
-	// Server wasn't started.
-	return nil
+	if server.notifiers.pool != nil {
+		server.notifiers.pool.close()
+	}
+	return err
 }
@@ -11,16 +11,19 @@ import (
 	"crypto/sha256"
 	"crypto/tls"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	mrand "math/rand"
 	"net"
-	"path/filepath"
-	"runtime"
-	"runtime/debug"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/globalcyberalliance/ftp-go/ratelimit"
 )
 
 const (
@@ -28,33 +31,109 @@ const (
 )
 
 type (
-	// Context represents a context the driver may want to know
+	// Context represents a context the driver may want to know. It also
+	// implements context.Context for the command being executed.
 	Context struct {
 		Sess  *Session
-		Data  map[string]interface{} // share data between middlewares
-		Cmd   string                 // request command on this request
-		Param string                 // request param on this request
+		Data  *Store // share data between middlewares for this command
+		Cmd   string // request command on this request
+		Param string // request param on this request
+		// hex digests of an upload by algorithm, see Options.UploadChecksums.
+		// Set for AfterFilePut when the upload succeeded.
+		Checksums map[string]string
 	}
 
 	// Session represents a session between ftp client and the server
 	Session struct {
-		dataConn      DataSocket
-		Conn          net.Conn
-		Ctx           context.Context
+		dataConn DataSocket
+		// guards dataConn for DataConn
+		dataMu sync.Mutex
+		Conn   net.Conn
+		Ctx    context.Context
+		// connection the session was created with, never replaced by TLS
+		// upgrades so that other goroutines may close it
+		rawConn       net.Conn
 		controlReader *bufio.Reader
 		controlWriter *bufio.Writer
 		server        *Server
-		Data          map[string]interface{} // shared data between different commands
+		Data          *Store // shared data between different commands
 		id            string
 		curDir        string
 		reqUser       string
 		user          string
 		renameFrom    string
+		// when RNFR set renameFrom
+		renameAt      time.Time
 		preCommand    string
 		clientSoft    string
 		lastFilePos   int64
 		closed        bool
 		tls           bool
+		epsvAll       bool
+		transferQuota TransferQuota
+		// size announced by ALLO for the next upload, 0 if none
+		allocSize int64
+		// rate limiters per direction, uploads are read from the data
+		// connection and downloads written to it
+		uploadLimiter   *ratelimit.Limiter
+		downloadLimiter *ratelimit.Limiter
+		// name of the bandwidth class the limiters follow, a string
+		bandwidthClass atomic.Value
+		// entries hidden from listings
+		listFilter ListFilter
+		// how LIST formats entries
+		listFormat ListFormat
+		// compatibility workarounds for the client
+		quirks Quirk
+		// message of the day, see SetMOTD
+		motd string
+		// welcome message, see SetWelcomeMessage
+		welcome string
+		// overrides of Options, see SetReadOnly, SetIdleTimeout,
+		// SetTransferStallTimeout and SetRoot, timeouts are nil unless set
+		readOnly     bool
+		idleTimeout  *time.Duration
+		stallTimeout *time.Duration
+		root         string
+		// command being executed, for Profile.Replies
+		command string
+		// notifier worker the session's hooks run on
+		notifyWorker uint32
+		// cancelled on disconnect and shutdown
+		cancel context.CancelFunc
+		// context of the running command, also cancelled by ABOR
+		cmdCtx    context.Context
+		cmdCancel context.CancelFunc
+		// guards cmdCancel, see abortCommand
+		cmdMu sync.Mutex
+		// stops watchControl, set while a transfer runs
+		stopWatching func()
+		// QUIT received during a transfer, see answerDuringTransfer
+		quitAfterTransfer bool
+		// the last line ended with a bare CR, see readLine
+		skipLF bool
+		// serializes replies, which are also sent during transfers
+		controlMu sync.Mutex
+		// command flood accounting, see checkFlood
+		cmdWindow       time.Time
+		cmdCount        int
+		preAuthCommands int
+		// client fingerprint, see Fingerprint
+		bannerSent    time.Time
+		fingerprintMu sync.Mutex
+		fingerprint   Fingerprint
+		// logs the session in place of the server's logger, see SetLogger
+		logger Logger
+		// bytes transferred and transfer in progress, see Stats
+		uploaded   atomic.Int64
+		downloaded atomic.Int64
+		transfer   atomic.Pointer[activeTransfer]
+		// host selected by HOST or TLS SNI, nil for the default one
+		vhost *virtualHost
+		// resumption ticket issued to the session, see SITE TICKET
+		ticket string
+		// REST restored by SITE RESUME, pending until the next transfer
+		resumedRest bool
 	}
 )
 
@@ -63,6 +142,11 @@ func (sess *Session) RemoteAddr() net.Addr {
 	return sess.Conn.RemoteAddr()
 }
 
+// ID returns the id the session is logged with
+func (sess *Session) ID() string {
+	return sess.id
+}
+
 // LoginUser returns the login user name if login
 func (sess *Session) LoginUser() string {
 	return sess.user
@@ -73,9 +157,9 @@ func (sess *Session) IsLogin() bool {
 	return len(sess.user) > 0
 }
 
-// PublicIP returns the public ip of the server
+// PublicIP returns the public ip of the server, either configured or discovered
 func (sess *Session) PublicIP() string {
-	return sess.server.PublicIP
+	return sess.server.publicIP()
 }
 
 // Options returns the server options
@@ -88,28 +172,74 @@ func (sess *Session) Server() *Server {
 	return sess.server
 }
 
-// DataConn returns the data connection
+// SetRateLimit changes the transfer limit of the session in bytes per second,
+// 0 means no limit. It applies to transfers in progress.
+func (sess *Session) SetRateLimit(rate int64) {
+	sess.uploadLimiter.SetRate(rate)
+	sess.downloadLimiter.SetRate(rate)
+}
+
+// SetUploadRateLimit changes the upload limit of the session in bytes per
+// second, 0 means no limit. It applies to transfers in progress.
+func (sess *Session) SetUploadRateLimit(rate int64) {
+	sess.uploadLimiter.SetRate(rate)
+}
+
+// SetDownloadRateLimit changes the download limit of the session in bytes per
+// second, 0 means no limit. It applies to transfers in progress.
+func (sess *Session) SetDownloadRateLimit(rate int64) {
+	sess.downloadLimiter.SetRate(rate)
+}
+
+// RateLimit returns the upload and download limits of the session in bytes per second
+func (sess *Session) RateLimit() (up, down int64) {
+	return sess.uploadLimiter.Rate(), sess.downloadLimiter.Rate()
+}
+
+// DataConn returns the data connection, it is safe to call from any
+// goroutine.
 func (sess *Session) DataConn() DataSocket {
+	sess.dataMu.Lock()
+	defer sess.dataMu.Unlock()
 	return sess.dataConn
 }
 
+// setDataConn replaces the data connection, see DataConn.
+func (sess *Session) setDataConn(dataConn DataSocket) {
+	sess.dataMu.Lock()
+	sess.dataConn = dataConn
+	sess.dataMu.Unlock()
+}
+
+// passiveListenIP returns the IPv4 address PASV announces: the public IP,
+// the data bind address or the control connection's address, whichever is
+// first an IPv4 address. It is empty without any.
 func (sess *Session) passiveListenIP() string {
-	var listenIP string
-	if len(sess.PublicIP()) > 0 {
-		listenIP = sess.PublicIP()
-	} else {
-		listenIP = sess.Conn.LocalAddr().(*net.TCPAddr).IP.String()
+	if ip := publicIPv4(sess.PublicIP()); ip != nil {
+		return ip.String()
 	}
-
-	if listenIP == "::1" {
-		return listenIP
+	if bindIP, err := sess.dataBindIP(); err == nil && bindIP.To4() != nil && !bindIP.IsUnspecified() {
+		return bindIP.To4().String()
+	}
+	addr, ok := sess.Conn.LocalAddr().(*net.TCPAddr)
+	if !ok {
+		// Control connections over Unix sockets are local
+		return "127.0.0.1"
+	}
+	if ip := addr.IP.To4(); ip != nil {
+		return ip.String()
 	}
+	return ""
+}
 
-	lastIdx := strings.LastIndex(listenIP, ":")
-	if lastIdx <= 0 {
-		return listenIP
+// controlNetProtocol returns the network protocol of the control
+// connection as numbered by RFC 2428, 1 for IPv4 and 2 for IPv6. Control
+// connections over Unix sockets count as IPv4.
+func (sess *Session) controlNetProtocol() int {
+	if addr, ok := sess.Conn.LocalAddr().(*net.TCPAddr); ok && addr.IP.To4() == nil {
+		return 2
 	}
-	return listenIP[:lastIdx]
+	return 1
 }
 
 // PassivePort returns the port which could be used by passive mode.
@@ -151,20 +281,62 @@ func newSessionID() string {
 // cleaned up.
 func (sess *Session) Serve() {
 	defer sess.Close()
+	defer sess.server.notifiers.OnDisconnect(&Context{
+		Sess: sess,
+	})
 
 	// Leave a slight delay to close the context (needed to allow the connection to gracefully close).
 	defer func() {
 		if recovery := recover(); recovery != nil {
-			sess.log(fmt.Sprintf("recovered from handle panic; recovered=%v; stack=%v", recovery, string(debug.Stack())))
+			sess.recoverPanic("", "", recovery, true)
 		}
 	}()
 
 	sess.log("Connection Established")
-	sess.writeMessage(220, sess.server.WelcomeMessage)
+	if !sess.onConnect() {
+		return
+	}
+	sess.writeWelcome()
+	sess.bannerSent = time.Now()
+	if sess.tls {
+		// Implicit FTPS, the banner completed the handshake
+		sess.selectTLSVirtualHost()
+	}
+
+	// Unauthenticated clients only get LoginTimeout to log in, the deadline
+	// is lifted once they have.
+	var loginDeadline time.Time
+	if sess.server.LoginTimeout > 0 {
+		loginDeadline = time.Now().Add(sess.server.LoginTimeout)
+	}
 
 	// Read commands.
 	for {
-		line, err := sess.controlReader.ReadString('\n')
+		deadline := sess.controlReadDeadline(loginDeadline)
+		_ = sess.Conn.SetReadDeadline(deadline)
+
+		if sess.endDrainedSession() {
+			break
+		}
+		line, err := sess.readLine()
+		if err != nil && sess.endDrainedSession() {
+			break
+		}
+		if err != nil && !deadline.IsZero() && isTimeout(err) {
+			if deadline.Equal(loginDeadline) {
+				sess.log("Login timeout, disconnecting")
+				sess.writeMessage(421, "Login timeout, closing control connection")
+			} else {
+				sess.log("Idle timeout, disconnecting")
+				sess.writeMessage(421, "Idle timeout, closing control connection")
+			}
+			break
+		}
+		if err == errLineTooLong {
+			sess.log("Command line too long, disconnecting")
+			sess.writeMessage(421, "Command line too long, closing control connection")
+			break
+		}
 		if err != nil {
 			if err != io.EOF {
 				sess.log(fmt.Sprint("Read error:", err))
@@ -173,11 +345,27 @@ func (sess *Session) Serve() {
 			break
 		}
 
+		if msg := sess.checkFlood(); msg != "" {
+			sess.log("Command flood detected, disconnecting: " + msg)
+			sess.writeMessage(421, msg+", closing control connection")
+			break
+		}
+
+		line = sess.telnet(line)
+		sess.recordCommand(line)
 		sess.server.notifiers.BeforeCommand(&Context{
 			Sess: sess,
 		}, line)
 
 		sess.receiveLine(line)
+		if sess.quitAfterTransfer {
+			sess.quitAfterTransfer = false
+			commandQuit{}.Execute(sess, "")
+		}
+
+		if sess.IsLogin() {
+			loginDeadline = time.Time{}
+		}
 
 		// QUIT command closes connection, break to avoid error on reading from a closed socket.
 		if sess.closed {
@@ -188,22 +376,57 @@ func (sess *Session) Serve() {
 	sess.log("Connection Terminated")
 }
 
+// interrupt ends the session from another goroutine: its context is
+// cancelled and its connection closed, Serve then returns and cleans up.
+func (sess *Session) interrupt() {
+	sess.cancel()
+	_ = sess.rawConn.Close()
+}
+
+// onConnect calls Options.OnConnect, it reports false when the connection
+// was rejected, once the client got the reply.
+func (sess *Session) onConnect() bool {
+	if sess.server.OnConnect == nil {
+		return true
+	}
+	err := sess.server.OnConnect(sess)
+	if err == nil {
+		return true
+	}
+	sess.logf("connection rejected: %v", err)
+	code, message := 421, "Service not available, closing control connection"
+	var replyErr *ReplyError
+	if errors.As(err, &replyErr) {
+		code, message = replyErr.Code, replyErr.Message
+	}
+	sess.writeMessage(code, message)
+	return false
+}
+
 // Close will manually close this connection, even if the client isn't ready.
 func (sess *Session) Close() {
+	if sess.cancel != nil {
+		sess.cancel()
+	}
 	sess.Conn.Close()
 	sess.closed = true
 	sess.reqUser = ""
 	sess.user = ""
-	if sess.dataConn != nil {
-		sess.dataConn.Close()
-		sess.dataConn = nil
+	// Taken under dataMu, as Close may run while a transfer or the control
+	// watcher uses it
+	sess.dataMu.Lock()
+	dataConn := sess.dataConn
+	sess.dataConn = nil
+	sess.dataMu.Unlock()
+	if dataConn != nil {
+		dataConn.Close()
 	}
 }
 
 func (sess *Session) upgradeToTLS() error {
 	sess.log("Upgrading connection to TLS")
 
-	tlsConn := tls.Server(sess.Conn, sess.server.tlsConfig)
+	tlsConn := tls.Server(&helloConn{Conn: sess.Conn, done: sess.recordClientHello}, sess.tlsConfig())
 	if err := tlsConn.Handshake(); err != nil {
 		return err
 	}
@@ -212,6 +435,7 @@ func (sess *Session) upgradeToTLS() error {
 	sess.controlReader = bufio.NewReader(tlsConn)
 	sess.controlWriter = bufio.NewWriter(tlsConn)
 	sess.tls = true
+	sess.selectTLSVirtualHost()
 
 	return nil
 }
@@ -219,17 +443,16 @@ func (sess *Session) upgradeToTLS() error {
 // receiveLine accepts a single line FTP command and co-ordinates an
 // appropriate response.
 func (sess *Session) receiveLine(line string) {
+	var command, param string
 	defer func() {
 		if err := recover(); err != nil {
-			buf := make([]byte, 1<<16)
-			buf = buf[:runtime.Stack(buf, false)]
-			sess.logf("handler crashed with error:%v\n%s", err, buf)
+			sess.abortPanickedCommand(strings.ToUpper(command), param, err)
 		}
 	}()
 
-	command, param := sess.parseLine(line)
+	command, param = sess.parseLine(line)
 	cmdGiven := strings.ToUpper(command)
-	sess.server.Logger.PrintCommand(sess.id, command, param)
+	sess.sessionLogger().PrintCommand(sess.id, command, param)
 
 	sess.server.CommandsMu.RLock()
 	defer sess.server.CommandsMu.RUnlock()
@@ -240,29 +463,52 @@ func (sess *Session) receiveLine(line string) {
 		return
 	}
 
-	if cmdObj.RequireParam() && param == "" {
+	if sess.quirks.disables(cmdGiven) {
+		sess.writeMessage(502, "Command not implemented")
+	} else if strings.ContainsAny(param, "\x00\r\n") {
+		// smuggled commands or truncated paths
+		sess.writeMessage(501, "Invalid character in parameter")
+	} else if cmdObj.RequireParam() && param == "" {
 		sess.writeMessage(553, "action aborted, required param missing")
-	} else if sess.server.Options.ForceTLS && !sess.tls && !(cmdObj == sess.server.Commands["AUTH"] && param == "TLS") {
+	} else if sess.server.Options.ForceTLS && !sess.tls && !(cmdObj == sess.server.Commands["AUTH"] && param == "TLS") && cmdObj != sess.server.Commands["HOST"] {
 		sess.writeMessage(534, "Request denied for policy reasons. AUTH TLS required.")
 	} else if cmdObj.RequireAuth() && sess.user == "" {
 		sess.writeMessage(530, "not logged in")
+	} else if sess.deniesWrite(cmdGiven) {
+		sess.writeMessage(550, "Permission denied, read-only session")
 	} else {
+		// RNTO must follow RNFR right away (RFC 959)
+		if cmdGiven != "RNTO" {
+			sess.renameFrom = ""
+		}
+		sess.cmdMu.Lock()
+		sess.cmdCtx, sess.cmdCancel = context.WithCancel(sess.commandContext())
+		sess.cmdMu.Unlock()
+		sess.command = cmdGiven
+		sess.server.countCommand(cmdGiven, param)
 		cmdObj.Execute(sess, param)
-		sess.preCommand = cmdGiven
+		sess.command = ""
+		sess.cmdCancel()
+		sess.cmdMu.Lock()
+		sess.cmdCtx, sess.cmdCancel = nil, nil
+		sess.cmdMu.Unlock()
+		if sess.resumedRest && keepsResumedRest(cmdGiven) {
+			sess.preCommand = "REST"
+		} else {
+			sess.preCommand = cmdGiven
+			sess.resumedRest = false
+		}
+		sess.updateTicket()
 	}
 }
 
+// parseLine splits a command line into the command and its parameter,
+// which is kept as sent, leading spaces included.
 func (sess *Session) parseLine(line string) (string, string) {
-	params := strings.SplitN(strings.Trim(line, "\r\n"), " ", 2)
-	if len(params) == 0 {
-		return "", ""
-	}
-
-	if len(params) == 1 {
-		return params[0], ""
-	}
-
-	return params[0], params[1]
+	line = strings.TrimSuffix(trimTelnet(line), "\n")
+	line = strings.TrimSuffix(line, "\r")
+	command, param, _ := strings.Cut(line, " ")
+	return command, param
 }
 
 func (sess *Session) WriteMessage(code int, message string) {
@@ -271,18 +517,16 @@ func (sess *Session) WriteMessage(code int, message string) {
 
 // writeMessage will send a standard FTP response back to the client.
 func (sess *Session) writeMessage(code int, message string) {
-	sess.server.Logger.PrintResponse(sess.id, code, message)
-	line := fmt.Sprintf("%d %s\r\n", code, message)
-	_, _ = sess.controlWriter.WriteString(line)
-	sess.controlWriter.Flush()
+	sess.writeReply(code, "", sess.phrase(code, message), false)
 }
 
-// writeMessage will send a standard FTP response back to the client.
-func (sess *Session) writeMessageMultiline(code int, message string) {
-	sess.server.Logger.PrintResponse(sess.id, code, message)
-	line := fmt.Sprintf("%d-%s\r\n%d END\r\n", code, message, code)
-	_, _ = sess.controlWriter.WriteString(line)
-	sess.controlWriter.Flush()
+// writeMessageMultiline sends message as a multiline reply as RFC 959
+// describes it: the first line after the code and a dash, the others as
+// they are, closed with last after the code and a space. Lines starting
+// with a digit are indented, so that none passes for the closing line.
+func (sess *Session) writeMessageMultiline(code int, message, last string) {
+	message = strings.TrimRight(strings.ReplaceAll(message, "\r\n", "\n"), "\n")
+	sess.writeReply(code, message, sess.phrase(code, last), true)
 }
 
 func (sess *Session) BuildPath(filename string) string {
@@ -305,57 +549,85 @@ func (sess *Session) BuildPath(filename string) string {
 //
 // The driver implementation is responsible for deciding how to treat this path. They must not read the path off disk.
 // They probably want to prefix the path with something to scope the users access to a sandbox.
-func (sess *Session) buildPath(filename string) (fullPath string) {
-	if len(filename) > 0 && filename[0:1] == "/" {
-		fullPath = filepath.Clean(filename)
-	} else if len(filename) > 0 && filename != "-a" {
-		fullPath = filepath.Clean(sess.curDir + "/" + filename)
-	} else {
-		fullPath = filepath.Clean(sess.curDir)
+func (sess *Session) buildPath(filename string) string {
+	if filename == "-a" {
+		filename = ""
 	}
-	fullPath = strings.Replace(fullPath, "//", "/", -1)
-	fullPath = strings.Replace(fullPath, string(filepath.Separator), "/", -1)
-	return
-}
-
-// sendOutofbandData will send a string to the client via the currently open
-// data socket. Assumes the socket is open and ready to be used.
-func (sess *Session) sendOutofbandData(data []byte) {
-	bytes := len(data)
-	if sess.dataConn != nil {
-		_, _ = sess.dataConn.Write(data)
-		sess.dataConn.Close()
-		sess.dataConn = nil
+	if sess.quirks&QuirkDriveLetters != 0 {
+		filename = stripDriveLetter(filename)
 	}
-	message := "Closing data connection, sent " + strconv.Itoa(bytes) + " bytes"
-	sess.writeMessage(226, message)
+	return CleanPath(sess.curDir, filename)
 }
 
-func (sess *Session) sendOutofBandDataWriter(data io.ReadCloser) error {
+func (sess *Session) sendOutofBandDataWriter(data io.Reader) (int64, error) {
 	bytes, err := io.Copy(sess.dataConn, data)
 	if err != nil {
 		sess.dataConn.Close()
-		sess.dataConn = nil
-		return err
+		sess.setDataConn(nil)
+		return bytes, err
 	}
 
 	message := "Closing data connection, sent " + strconv.Itoa(int(bytes)) + " bytes"
 	sess.writeMessage(226, message)
 	sess.dataConn.Close()
-	sess.dataConn = nil
+	sess.setDataConn(nil)
 
-	return nil
+	return bytes, nil
 }
 
-func (sess *Session) changeCurDir(path string) error {
+// changeCurDir makes path the current directory once the driver confirmed
+// it is an existing directory.
+func (sess *Session) changeCurDir(ctx *Context, path string) error {
+	info, err := sess.driver().Stat(ctx, path)
+	if err != nil {
+		return err
+	}
+	if info == nil {
+		return ErrNotFound
+	}
+	if !info.IsDir() {
+		return ErrNotDir
+	}
 	sess.curDir = path
 	return nil
 }
 
+// SetLogger replaces the logger of the session, nil restores the server's.
+// It must be called before the session is served, from
+// Options.SessionCallback.
+func (sess *Session) SetLogger(logger Logger) {
+	sess.logger = logger
+}
+
+// LogFields returns the fields identifying the session in logs
+func (sess *Session) LogFields() LogFields {
+	return LogFields{
+		SessionID: sess.id,
+		User:      sess.user,
+		RemoteIP:  addrIP(sess.RemoteAddr()),
+	}
+}
+
+// sessionLogger returns the logger of the session, given the session's
+// fields if it takes them, masking secrets with the server's Scrubber.
+func (sess *Session) sessionLogger() Logger {
+	logger := sess.logger
+	if logger == nil {
+		logger = sess.server.logger
+	}
+	if fieldLogger, ok := logger.(FieldLogger); ok {
+		logger = fieldLogger.WithFields(sess.LogFields())
+	}
+	if sess.server.scrubber == nil {
+		return logger
+	}
+	return &scrubbingLogger{Logger: logger, scrubber: sess.server.scrubber}
+}
+
 func (sess *Session) log(message interface{}) {
-	sess.server.logger.Print(sess.id, message)
+	sess.sessionLogger().Print(sess.id, message)
 }
 
 func (sess *Session) logf(format string, v ...interface{}) {
-	sess.server.logger.Printf(sess.id, format, v...)
+	sess.sessionLogger().Printf(sess.id, format, v...)
 }
@@ -5,7 +5,11 @@
 package ftp
 
 import (
+	"fmt"
 	"net"
+	"net/textproto"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -23,6 +27,11 @@ func TestConnBuildPath(t *testing.T) {
 		{"/files/two.txt", "/files/two.txt"},
 		{"files/two.txt", "/files/two.txt"},
 		{"/../../../../etc/passwd", "/etc/passwd"},
+		{`..\..\windows\win.ini`, "/windows/win.ini"},
+		{`C:\temp\one.txt`, "/C:/temp/one.txt"},
+		{"c:", "/c:"},
+		{"..%2f..%2fetc%2fpasswd", "/..%2f..%2fetc%2fpasswd"},
+		{"files//./two.txt/", "/files/two.txt"},
 		{"rclone-test-roxarey8facabob5tuwetet4/hello? sausage/êé/Hello, 世界/ \" ' @ < > & ? + ≠/z.txt", "/rclone-test-roxarey8facabob5tuwetet4/hello? sausage/êé/Hello, 世界/ \" ' @ < > & ? + ≠/z.txt"},
 	}
 	for _, tt := range pathtests {
@@ -33,11 +42,25 @@ func TestConnBuildPath(t *testing.T) {
 			}
 		})
 	}
+
+	// Drive letters are only dropped for the clients sending them
+	c.quirks = QuirkDriveLetters
+	for in, out := range map[string]string{
+		`C:\temp\one.txt`: "/temp/one.txt",
+		"d:/one.txt":      "/one.txt",
+		"c:":              "/c:",
+		"c:one.txt":       "/c:one.txt",
+	} {
+		if s := c.buildPath(in); s != out {
+			t.Errorf("buildPath(%q) = %q, want %q", in, s, out)
+		}
+	}
 }
 
 type mockConn struct {
-	ip   net.IP
-	port int
+	ip     net.IP
+	port   int
+	remote *net.TCPAddr
 }
 
 func (m mockConn) Read(b []byte) (n int, err error) {
@@ -60,7 +83,10 @@ func (m mockConn) LocalAddr() net.Addr {
 }
 
 func (m mockConn) RemoteAddr() net.Addr {
-	return nil
+	if m.remote == nil {
+		return nil
+	}
+	return m.remote
 }
 
 func (m mockConn) SetDeadline(t time.Time) error {
@@ -98,4 +124,253 @@ func TestPassiveListenIP(t *testing.T) {
 	if c.passiveListenIP() != "1.1.1.1" {
 		t.Fatalf("Expected passive listen IP to be 1.1.1.1 but got %s", c.passiveListenIP())
 	}
+
+	// Dual-stack public IPs announce the IPv4 one
+	c = &Session{
+		Conn: mockConn{
+			ip: net.ParseIP("2001:db8::2"),
+		},
+		server: &Server{
+			Options: &Options{
+				PublicIP: "2001:db8::1, 1.1.1.1",
+			},
+		},
+	}
+	if c.passiveListenIP() != "1.1.1.1" {
+		t.Fatalf("Expected passive listen IP to be 1.1.1.1 but got %s", c.passiveListenIP())
+	}
+	if c.controlNetProtocol() != 2 {
+		t.Fatalf("Expected an IPv6 control connection, got protocol %d", c.controlNetProtocol())
+	}
+
+	// IPv6 hosts have no address for PASV
+	c.server.PublicIP = "2001:db8::1"
+	if c.passiveListenIP() != "" {
+		t.Fatalf("Expected no passive listen IP but got %s", c.passiveListenIP())
+	}
+}
+
+func TestCheckPublicIP(t *testing.T) {
+	for publicIP, valid := range map[string]bool{
+		"":                        true,
+		"1.1.1.1":                 true,
+		"2001:db8::1":             true,
+		"1.1.1.1,2001:db8::1":     true,
+		"2001:db8::1, 1.1.1.1":    true,
+		"1.1.1.1,2.2.2.2":         false,
+		"1.1.1.1:21":              false,
+		"ftp.example.com":         false,
+		"1.1.1.1,2001:db8::1,::1": false,
+	} {
+		if err := checkPublicIP(publicIP); (err == nil) != valid {
+			t.Errorf("checkPublicIP(%q) = %v", publicIP, err)
+		}
+	}
+}
+
+func TestCheckDataSource(t *testing.T) {
+	sess := &Session{
+		Conn: mockConn{
+			remote: &net.TCPAddr{IP: net.IPv4(192, 0, 2, 10), Port: 4000},
+		},
+		server: &Server{
+			Options: &Options{},
+		},
+	}
+
+	same := &net.TCPAddr{IP: net.IPv4(192, 0, 2, 10), Port: 5000}
+	neighbour := &net.TCPAddr{IP: net.IPv4(192, 0, 2, 11), Port: 5000}
+	stranger := &net.TCPAddr{IP: net.IPv4(198, 51, 100, 1), Port: 5000}
+
+	if err := sess.checkDataSource(stranger); err != nil {
+		t.Fatalf("expected stranger IP to be accepted by default: %v", err)
+	}
+
+	sess.server.DataSourceCheck = DataSourceCheckIP
+	if err := sess.checkDataSource(same); err != nil {
+		t.Fatalf("expected same IP to be accepted: %v", err)
+	}
+	if err := sess.checkDataSource(neighbour); err == nil {
+		t.Fatal("expected neighbour IP to be rejected")
+	}
+
+	sess.server.DataSourceCheck = DataSourceCheckNetwork
+	if err := sess.checkDataSource(neighbour); err != nil {
+		t.Fatalf("expected neighbour IP to be accepted: %v", err)
+	}
+	if err := sess.checkDataSource(stranger); err == nil {
+		t.Fatal("expected stranger IP to be rejected")
+	}
+
+	sess.server.DataSourceCheck = DataSourceCheckNone
+	if err := sess.checkDataSource(stranger); err != nil {
+		t.Fatalf("expected stranger IP to be accepted: %v", err)
+	}
+}
+
+func TestDataBindIP(t *testing.T) {
+	sess := &Session{
+		server: &Server{
+			Options: &Options{
+				DataBindAddress: "192.0.2.1",
+			},
+		},
+	}
+	ip, err := sess.dataBindIP()
+	if err != nil || !ip.Equal(net.IPv4(192, 0, 2, 1)) {
+		t.Fatalf("expected 192.0.2.1, got %v (%v)", ip, err)
+	}
+
+	loopback, err := net.InterfaceByName("lo")
+	if err != nil {
+		t.Skip("no lo interface")
+	}
+	sess.server.DataBindAddress = loopback.Name
+	ip, err = sess.dataBindIP()
+	if err != nil || !ip.IsLoopback() {
+		t.Fatalf("expected a loopback address, got %v (%v)", ip, err)
+	}
+}
+
+func TestSessionRateLimit(t *testing.T) {
+	s, err := NewServer(&Options{
+		Perm:          NewSimplePerm("test", "test"),
+		RateLimit:     100,
+		RateLimitDown: 200,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sess := s.newSession(newSessionID(), mockConn{})
+	if up, down := sess.RateLimit(); up != 100 || down != 200 {
+		t.Fatalf("expected limits 100/200, got %d/%d", up, down)
+	}
+
+	sess.SetUploadRateLimit(300)
+	if up, down := sess.RateLimit(); up != 300 || down != 200 {
+		t.Fatalf("expected limits 300/200, got %d/%d", up, down)
+	}
+}
+
+// newPipeSession serves a session over an in-memory connection and returns
+// the client side of it, with the 220 greeting already consumed.
+func newPipeSession(t *testing.T, opts *Options) *textproto.Conn {
+	t.Helper()
+
+	if opts.Perm == nil {
+		opts.Perm = NewSimplePerm("test", "test")
+	}
+	if opts.Logger == nil {
+		opts.Logger = new(DiscardLogger)
+	}
+
+	s, err := NewServer(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serverConn, clientConn := net.Pipe()
+	sess := s.newSession(newSessionID(), serverConn)
+	go sess.Serve()
+
+	client := textproto.NewConn(clientConn)
+	t.Cleanup(func() {
+		client.Close()
+	})
+
+	if _, _, err = client.ReadResponse(220); err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+// expectCode sends a command and fails the test unless the reply has the given code.
+func expectCode(t *testing.T, client *textproto.Conn, code int, format string, args ...interface{}) string {
+	t.Helper()
+
+	id, err := client.Cmd(format, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.StartResponse(id)
+	defer client.EndResponse(id)
+
+	_, msg, err := client.ReadResponse(code)
+	if err != nil {
+		t.Fatalf("%s: %v", fmt.Sprintf(format, args...), err)
+	}
+	return msg
+}
+
+func TestLoginTimeout(t *testing.T) {
+	client := newPipeSession(t, &Options{LoginTimeout: 50 * time.Millisecond})
+	if _, _, err := client.ReadResponse(421); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIdleTimeout(t *testing.T) {
+	client := newPipeSession(t, &Options{IdleTimeout: 50 * time.Millisecond})
+	expectCode(t, client, 200, "NOOP")
+	if _, _, err := client.ReadResponse(421); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// siteCounts replies with a multiline message whose lines start with digits
+type siteCounts struct{}
+
+func (siteCounts) IsExtend() bool     { return false }
+func (siteCounts) RequireParam() bool { return false }
+func (siteCounts) RequireAuth() bool  { return false }
+func (siteCounts) Execute(sess *Session, param string) {
+	sess.writeMessageMultiline(211, "Counts:\r\n211 files\n 3 dirs\n", "End")
+}
+
+func TestMultilineReply(t *testing.T) {
+	client := newPipeSession(t, &Options{
+		Auth:         &SimpleAuth{Name: "user", Password: "secret"},
+		SiteCommands: map[string]Command{"COUNTS": siteCounts{}},
+	})
+
+	// readReply returns the raw lines of the reply to command
+	readReply := func(command string, code int) []string {
+		t.Helper()
+		if err := client.PrintfLine("%s", command); err != nil {
+			t.Fatal(err)
+		}
+		var lines []string
+		for {
+			line, err := client.ReadLine()
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines = append(lines, line)
+			if strings.HasPrefix(line, fmt.Sprintf("%d ", code)) {
+				return lines
+			}
+		}
+	}
+
+	if lines, want := readReply("SITE COUNTS", 211), []string{"211-Counts:", " 211 files", " 3 dirs", "211 End"}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("SITE COUNTS: got %q, want %q", lines, want)
+	}
+
+	feats := readReply("FEAT", 211)
+	if feats[0] != "211-Extensions supported:" || feats[len(feats)-1] != "211 End" {
+		t.Errorf("FEAT: got %q", feats)
+	}
+	for _, line := range feats[1 : len(feats)-1] {
+		if !strings.HasPrefix(line, " ") {
+			t.Errorf("FEAT: feature line %q", line)
+		}
+	}
+
+	expectCode(t, client, 331, "USER user")
+	expectCode(t, client, 230, "PASS secret")
+	stat := readReply("STAT", 211)
+	if stat[0] != "211-FTP server status:" || stat[len(stat)-1] != "211 End of status" || len(stat) != 7 {
+		t.Errorf("STAT: got %q", stat)
+	}
 }
@@ -0,0 +1,83 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"crypto/tls"
+	"net"
+	"time"
+)
+
+// SocketKind tells a SocketOptions hook which connection it is tuning.
+type SocketKind int
+
+// Socket kinds passed to Options.SocketOptions
+const (
+	SocketControl SocketKind = iota
+	SocketData
+)
+
+// String implements fmt.Stringer
+func (k SocketKind) String() string {
+	if k == SocketControl {
+		return "control"
+	}
+	return "data"
+}
+
+// tuneConn applies the keepalive, NoDelay and SocketOptions settings to a
+// freshly accepted or dialed connection. Connections that aren't TCP, such
+// as in-memory pipes, are left alone.
+func (server *Server) tuneConn(conn net.Conn, kind SocketKind) error {
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		conn = tlsConn.NetConn()
+	}
+	tcpConn, ok := conn.(*net.TCPConn)
+	if !ok {
+		return nil
+	}
+
+	if server.TCPKeepAlive < 0 {
+		if err := tcpConn.SetKeepAlive(false); err != nil {
+			return err
+		}
+	} else if server.TCPKeepAlive > 0 {
+		if err := tcpConn.SetKeepAlive(true); err != nil {
+			return err
+		}
+		if err := tcpConn.SetKeepAlivePeriod(server.TCPKeepAlive); err != nil {
+			return err
+		}
+	}
+
+	if err := tcpConn.SetNoDelay(!server.DisableNoDelay); err != nil {
+		return err
+	}
+
+	if server.SocketOptions != nil {
+		return server.SocketOptions(tcpConn, kind)
+	}
+	return nil
+}
+
+// controlReadDeadline returns the deadline for the next command read, the
+// earliest of the login deadline and the idle timeout, or the zero time.
+func (sess *Session) controlReadDeadline(loginDeadline time.Time) time.Time {
+	deadline := loginDeadline
+	if timeout := sess.sessionIdleTimeout(); timeout > 0 {
+		idle := time.Now().Add(timeout)
+		if deadline.IsZero() || idle.Before(deadline) {
+			deadline = idle
+		}
+	}
+	return deadline
+}
+
+// setWriteDeadline bounds the next reply write by Options.WriteTimeout.
+func (sess *Session) setWriteDeadline() {
+	if sess.server.WriteTimeout > 0 {
+		_ = sess.Conn.SetWriteDeadline(time.Now().Add(sess.server.WriteTimeout))
+	}
+}
@@ -0,0 +1,196 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"io"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultStatCacheSize = 10000
+
+var (
+	_ Driver       = &cachingDriver{}
+	_ AppendDriver = &cachingDriver{}
+	_ EntryDriver  = &cachingDriver{}
+)
+
+// cachingDriver caches the results of Stat, and of listings, for
+// Options.StatCacheTTL. Paths are cached per user, as drivers may map them
+// to different files for each, and forgotten for every user once written.
+type cachingDriver struct {
+	Driver
+	ttl  time.Duration
+	size int
+
+	lock    sync.Mutex
+	entries map[statKey]statEntry
+	// replaced in tests
+	now func() time.Time
+}
+
+type statKey struct {
+	user string
+	path string
+}
+
+type statEntry struct {
+	info    os.FileInfo
+	expires time.Time
+}
+
+func newCachingDriver(driver Driver, opts *Options) *cachingDriver {
+	return &cachingDriver{
+		Driver:  driver,
+		ttl:     opts.StatCacheTTL,
+		size:    opts.StatCacheSize,
+		entries: make(map[statKey]statEntry),
+		now:     time.Now,
+	}
+}
+
+func cacheUser(ctx *Context) string {
+	if ctx == nil || ctx.Sess == nil {
+		return ""
+	}
+	return ctx.Sess.LoginUser()
+}
+
+func (driver *cachingDriver) get(key statKey) (os.FileInfo, bool) {
+	driver.lock.Lock()
+	defer driver.lock.Unlock()
+
+	entry, ok := driver.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if driver.now().After(entry.expires) {
+		delete(driver.entries, key)
+		return nil, false
+	}
+	return entry.info, true
+}
+
+func (driver *cachingDriver) put(key statKey, info os.FileInfo) {
+	driver.lock.Lock()
+	defer driver.lock.Unlock()
+
+	now := driver.now()
+	if len(driver.entries) >= driver.size {
+		for k, entry := range driver.entries {
+			if now.After(entry.expires) {
+				delete(driver.entries, k)
+			}
+		}
+	}
+	// Still full, any entry makes room
+	for k := range driver.entries {
+		if len(driver.entries) < driver.size {
+			break
+		}
+		delete(driver.entries, k)
+	}
+	driver.entries[key] = statEntry{info: info, expires: now.Add(driver.ttl)}
+}
+
+// forget drops the cached entries of p and of the paths below it, for
+// every user.
+func (driver *cachingDriver) forget(p string) {
+	driver.lock.Lock()
+	defer driver.lock.Unlock()
+
+	prefix := strings.TrimSuffix(p, "/") + "/"
+	for k := range driver.entries {
+		if k.path == p || strings.HasPrefix(k.path, prefix) {
+			delete(driver.entries, k)
+		}
+	}
+}
+
+// Stat implements Driver
+func (driver *cachingDriver) Stat(ctx *Context, p string) (os.FileInfo, error) {
+	key := statKey{user: cacheUser(ctx), path: p}
+	if info, ok := driver.get(key); ok {
+		return info, nil
+	}
+
+	info, err := driver.Driver.Stat(ctx, p)
+	if err == nil && info != nil {
+		driver.put(key, info)
+	}
+	return info, err
+}
+
+// ListDir implements Driver, caching the entries listed
+func (driver *cachingDriver) ListDir(ctx *Context, p string, callback func(os.FileInfo) error) error {
+	user := cacheUser(ctx)
+	return driver.Driver.ListDir(ctx, p, func(info os.FileInfo) error {
+		driver.put(statKey{user: user, path: path.Join(p, info.Name())}, info)
+		return callback(info)
+	})
+}
+
+// ListEntries implements EntryDriver, caching the entries listed
+func (driver *cachingDriver) ListEntries(ctx *Context, p string, callback func(Entry) error) error {
+	user := cacheUser(ctx)
+	return ListEntries(ctx, driver.Driver, p, func(entry Entry) error {
+		driver.put(statKey{user: user, path: entry.Path}, entry.FileInfo)
+		return callback(entry)
+	})
+}
+
+// DeleteDir implements Driver
+func (driver *cachingDriver) DeleteDir(ctx *Context, p string) error {
+	defer driver.forget(p)
+	return driver.Driver.DeleteDir(ctx, p)
+}
+
+// DeleteFile implements Driver
+func (driver *cachingDriver) DeleteFile(ctx *Context, p string) error {
+	defer driver.forget(p)
+	return driver.Driver.DeleteFile(ctx, p)
+}
+
+// Rename implements Driver
+func (driver *cachingDriver) Rename(ctx *Context, fromPath, toPath string) error {
+	defer driver.forget(fromPath)
+	defer driver.forget(toPath)
+	return driver.Driver.Rename(ctx, fromPath, toPath)
+}
+
+// MakeDir implements Driver
+func (driver *cachingDriver) MakeDir(ctx *Context, p string) error {
+	defer driver.forget(p)
+	return driver.Driver.MakeDir(ctx, p)
+}
+
+// PutFile implements Driver
+func (driver *cachingDriver) PutFile(ctx *Context, p string, data io.Reader, offset int64) (int64, error) {
+	defer driver.forget(p)
+	return driver.Driver.PutFile(ctx, p, data, offset)
+}
+
+// AppendFile implements AppendDriver
+func (driver *cachingDriver) AppendFile(ctx *Context, p string, data io.Reader) (int64, error) {
+	defer driver.forget(p)
+	return appendFile(ctx, driver.Driver, p, data)
+}
+
+// forgetCached drops what the stat cache holds about p, a session path, and
+// the paths below it. Writes made through the optional interfaces of
+// baseDriver call it, as they bypass the cache.
+func (sess *Session) forgetCached(p string) {
+	driver := sess.server.driver
+	if sess.vhost != nil {
+		driver = sess.vhost.driver
+	}
+	if cache, ok := driver.(*cachingDriver); ok {
+		cache.forget(sess.realPath(p))
+	}
+}
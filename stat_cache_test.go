@@ -0,0 +1,135 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// countingDriver stats every path as info, counting the calls
+type countingDriver struct {
+	Driver
+	info  os.FileInfo
+	stats int
+}
+
+func (driver *countingDriver) Stat(ctx *Context, p string) (os.FileInfo, error) {
+	driver.stats++
+	return driver.info, nil
+}
+
+func (driver *countingDriver) ListDir(ctx *Context, p string, callback func(os.FileInfo) error) error {
+	return callback(driver.info)
+}
+
+func (driver *countingDriver) PutFile(ctx *Context, p string, data io.Reader, offset int64) (int64, error) {
+	return 0, nil
+}
+
+func TestStatCache(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "file.txt")
+	if err := os.WriteFile(name, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counting := &countingDriver{info: info}
+	driver := newCachingDriver(counting, &Options{
+		StatCacheTTL:  time.Minute,
+		StatCacheSize: 2,
+	})
+	now := time.Unix(0, 0)
+	driver.now = func() time.Time { return now }
+
+	stat := func(p string, want int) {
+		t.Helper()
+		if _, err := driver.Stat(nil, p); err != nil {
+			t.Fatal(err)
+		}
+		if counting.stats != want {
+			t.Fatalf("Stat(%s): driver called %d times, want %d", p, counting.stats, want)
+		}
+	}
+
+	stat("/a", 1)
+	stat("/a", 1)
+
+	// Writes clear the path
+	if _, err = driver.PutFile(nil, "/a", nil, -1); err != nil {
+		t.Fatal(err)
+	}
+	stat("/a", 2)
+
+	// Entries expire
+	now = now.Add(2 * time.Minute)
+	stat("/a", 3)
+
+	// Listings fill the cache
+	if err = driver.ListDir(nil, "/dir", func(os.FileInfo) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	stat("/dir/file.txt", 3)
+
+	// The cache never holds more than StatCacheSize entries
+	stat("/b", 4)
+	if len(driver.entries) > 2 {
+		t.Fatalf("%d cached entries, want at most 2", len(driver.entries))
+	}
+}
+
+// linkingDriver is a countingDriver creating symbolic links
+type linkingDriver struct {
+	countingDriver
+}
+
+func (driver *linkingDriver) Readlink(ctx *Context, p string) (string, error) {
+	return "", os.ErrInvalid
+}
+
+func (driver *linkingDriver) Symlink(ctx *Context, target, link string) error {
+	return nil
+}
+
+func TestStatCacheSymlink(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "file.txt")
+	if err := os.WriteFile(name, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	driver := &linkingDriver{countingDriver{info: info}}
+	client := newPipeSession(t, &Options{
+		Driver:       driver,
+		Auth:         &SimpleAuth{Name: "admin", Password: "admin"},
+		StatCacheTTL: time.Minute,
+	})
+	expectCode(t, client, 331, "USER admin")
+	expectCode(t, client, 230, "PASS admin")
+
+	expectCode(t, client, 213, "SIZE /a")
+	stats := driver.stats
+	expectCode(t, client, 213, "SIZE /a")
+	if driver.stats != stats {
+		t.Fatalf("SIZE of a cached path called Stat")
+	}
+
+	// Writes through the optional interfaces clear the path too
+	expectCode(t, client, 200, "SITE SYMLINK /b /a")
+	stats = driver.stats
+	expectCode(t, client, 213, "SIZE /a")
+	if driver.stats == stats {
+		t.Fatalf("SIZE of a new link was served from the cache")
+	}
+}
@@ -0,0 +1,216 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"io"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// TransferDirection tells uploads from downloads
+type TransferDirection int
+
+const (
+	// TransferUpload is a file sent by the client, with STOR or APPE
+	TransferUpload TransferDirection = iota
+	// TransferDownload is a file sent to the client, with RETR
+	TransferDownload
+)
+
+// String implements fmt.Stringer
+func (d TransferDirection) String() string {
+	if d == TransferDownload {
+		return "download"
+	}
+	return "upload"
+}
+
+// TransferState describes a file transfer in progress
+type TransferState struct {
+	Direction TransferDirection
+	Path      string
+	// bytes transferred so far, from the offset of resumed transfers
+	Bytes int64
+	// average bytes per second since Start
+	Rate  float64
+	Start time.Time
+}
+
+// TransferStats counts the bytes of file uploads and downloads as they
+// went over the data connections, including those of transfers which failed
+// or were aborted.
+type TransferStats struct {
+	Uploaded   int64
+	Downloaded int64
+}
+
+// ServerStats is a snapshot of the activity of the server since it was
+// created
+type ServerStats struct {
+	// when the server was created, and how long ago
+	Start  time.Time
+	Uptime time.Duration
+	// connections accepted as sessions, and sessions being served
+	Connections    int64
+	ActiveSessions int
+	// logins with PASS which succeeded and failed
+	Logins       int64
+	FailedLogins int64
+	// bytes of every session
+	TransferStats
+	// bytes by login user
+	Users map[string]TransferStats
+	// commands executed by name, SITE ones as "SITE" and the subcommand
+	Commands map[string]int64
+	// notifier hooks dropped as their worker's queue was full, see
+	// Options.NotifierQueueSize
+	DroppedNotifications int64
+}
+
+// Stats returns a snapshot of the activity of the server: its sessions,
+// logins, commands and the bytes transferred so far, in total and by user.
+func (server *Server) Stats() ServerStats {
+	sessions, _ := server.sessionCounts()
+	now := time.Now()
+
+	server.statsMu.Lock()
+	defer server.statsMu.Unlock()
+
+	stats := ServerStats{
+		Start:          server.start,
+		Uptime:         now.Sub(server.start),
+		Connections:    server.connections.Load(),
+		ActiveSessions: sessions,
+		Logins:         server.logins.Load(),
+		FailedLogins:   server.failedLogins.Load(),
+		TransferStats:  server.stats,
+		Users:          make(map[string]TransferStats, len(server.userStats)),
+		Commands:       make(map[string]int64, len(server.commandStats)),
+	}
+	for user, userStats := range server.userStats {
+		stats.Users[user] = userStats
+	}
+	for command, count := range server.commandStats {
+		stats.Commands[command] = count
+	}
+	if server.notifiers.pool != nil {
+		stats.DroppedNotifications = server.notifiers.pool.dropped.Load()
+	}
+	return stats
+}
+
+// countCommand counts a command executed, see ServerStats.Commands
+func (server *Server) countCommand(command, param string) {
+	if command == "SITE" {
+		name, _, _ := strings.Cut(param, " ")
+		// Only the subcommands served are counted, not whatever was sent
+		if _, ok := server.SiteCommands[strings.ToUpper(name)]; ok {
+			command += " " + strings.ToUpper(name)
+		}
+	}
+	server.statsMu.Lock()
+	if server.commandStats == nil {
+		server.commandStats = make(map[string]int64)
+	}
+	server.commandStats[command]++
+	server.statsMu.Unlock()
+}
+
+// Stats returns the bytes transferred by the session so far, the transfer
+// in progress excluded. It is safe to call from any goroutine.
+func (sess *Session) Stats() TransferStats {
+	return TransferStats{
+		Uploaded:   sess.uploaded.Load(),
+		Downloaded: sess.downloaded.Load(),
+	}
+}
+
+// Transfer returns the file transfer in progress, nil if none. It is safe
+// to call from any goroutine.
+func (sess *Session) Transfer() *TransferState {
+	transfer := sess.transfer.Load()
+	if transfer == nil {
+		return nil
+	}
+	state := transfer.state(time.Now())
+	return &state
+}
+
+// activeTransfer is the file transfer in progress of a session
+type activeTransfer struct {
+	direction TransferDirection
+	path      string
+	start     time.Time
+	bytes     atomic.Int64
+}
+
+func (transfer *activeTransfer) state(now time.Time) TransferState {
+	state := TransferState{
+		Direction: transfer.direction,
+		Path:      transfer.path,
+		Bytes:     transfer.bytes.Load(),
+		Start:     transfer.start,
+	}
+	if elapsed := now.Sub(transfer.start).Seconds(); elapsed > 0 {
+		state.Rate = float64(state.Bytes) / elapsed
+	}
+	return state
+}
+
+// startTransfer makes a file transfer the session's transfer in progress
+// and returns r, counting the bytes of the transfer as they are read. The
+// func returned ends the transfer and returns the bytes read.
+func (sess *Session) startTransfer(direction TransferDirection, p string, r io.Reader) (io.Reader, func() int64) {
+	transfer := &activeTransfer{
+		direction: direction,
+		path:      p,
+		start:     time.Now(),
+	}
+	sess.transfer.Store(transfer)
+	return &transferReader{r: r, transfer: transfer}, func() int64 {
+		sess.transfer.CompareAndSwap(transfer, nil)
+		return transfer.bytes.Load()
+	}
+}
+
+// transferReader counts the bytes of a transfer as they are read. Downloads
+// sent with sendFile are counted there.
+type transferReader struct {
+	r        io.Reader
+	transfer *activeTransfer
+}
+
+func (r *transferReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.transfer.bytes.Add(int64(n))
+	return n, err
+}
+
+// addTransferred accounts for bytes uploaded and downloaded by the session,
+// in its stats, the server's and against its transfer quota.
+func (sess *Session) addTransferred(up, down int64) {
+	if up == 0 && down == 0 {
+		return
+	}
+	sess.uploaded.Add(up)
+	sess.downloaded.Add(down)
+
+	server := sess.server
+	server.statsMu.Lock()
+	server.stats.Uploaded += up
+	server.stats.Downloaded += down
+	if server.userStats == nil {
+		server.userStats = make(map[string]TransferStats)
+	}
+	userStats := server.userStats[sess.user]
+	userStats.Uploaded += up
+	userStats.Downloaded += down
+	server.userStats[sess.user] = userStats
+	server.statsMu.Unlock()
+
+	sess.addQuotaUsage(up, down)
+}
@@ -0,0 +1,96 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import "sync"
+
+// Store holds values shared by drivers, notifiers and other middlewares,
+// it is safe for concurrent use. Session.Data lives as long as the session
+// and Context.Data as long as one command. Prefer a Key to typed values.
+type Store struct {
+	mu     sync.RWMutex
+	values map[string]interface{}
+}
+
+// NewStore creates an empty Store
+func NewStore() *Store {
+	return &Store{values: make(map[string]interface{})}
+}
+
+// Get returns the value stored under key
+func (s *Store) Get(key string) (interface{}, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	value, ok := s.values[key]
+	return value, ok
+}
+
+// Set stores value under key
+func (s *Store) Set(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = value
+}
+
+// Delete removes the value stored under key
+func (s *Store) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.values, key)
+}
+
+// clone returns a Store holding the same values
+func (s *Store) clone() *Store {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	clone := &Store{values: make(map[string]interface{}, len(s.values))}
+	for key, value := range s.values {
+		clone.values[key] = value
+	}
+	return clone
+}
+
+// Key is a typed Store key. Packages declare their keys once, with names
+// unlikely to clash with other packages':
+//
+//	var userKey = ftp.NewKey[*User]("users.user")
+//
+//	userKey.Set(ctx.Sess.Data, user)
+//	user, ok := userKey.Get(ctx.Sess.Data)
+type Key[T any] struct {
+	name string
+}
+
+// NewKey creates a key stored under name
+func NewKey[T any](name string) Key[T] {
+	return Key[T]{name: name}
+}
+
+// Name returns the name the key is stored under
+func (k Key[T]) Name() string {
+	return k.name
+}
+
+// Get returns the value of the key in s, false when it is not set or holds
+// another type.
+func (k Key[T]) Get(s *Store) (T, bool) {
+	value, ok := s.Get(k.name)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	v, ok := value.(T)
+	return v, ok
+}
+
+// Set sets the key to value in s
+func (k Key[T]) Set(s *Store, value T) {
+	s.Set(k.name, value)
+}
+
+// Delete removes the key from s
+func (k Key[T]) Delete(s *Store) {
+	s.Delete(k.name)
+}
@@ -0,0 +1,52 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	s := NewStore()
+	count := NewKey[int]("test.count")
+
+	if _, ok := count.Get(s); ok {
+		t.Fatal("expected an unset key")
+	}
+
+	count.Set(s, 3)
+	if v, ok := count.Get(s); !ok || v != 3 {
+		t.Fatalf("expected 3, got %v %v", v, ok)
+	}
+
+	s.Set(count.Name(), "three")
+	if _, ok := count.Get(s); ok {
+		t.Fatal("expected a value of another type not to be returned")
+	}
+
+	count.Delete(s)
+	if _, ok := s.Get(count.Name()); ok {
+		t.Fatal("expected the key to be deleted")
+	}
+}
+
+func TestStoreConcurrent(t *testing.T) {
+	s := NewStore()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := NewKey[int]("test." + strconv.Itoa(i%2))
+			for j := 0; j < 100; j++ {
+				key.Set(s, j)
+				key.Get(s)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
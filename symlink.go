@@ -0,0 +1,117 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// SymlinkDriver is implemented by drivers whose backend has symbolic links.
+// Listings then show the targets of the entries whose mode has
+// os.ModeSymlink, and SITE SYMLINK creates links.
+type SymlinkDriver interface {
+	// params  - path of a symbolic link
+	// returns - its target, as stored
+	Readlink(*Context, string) (string, error)
+
+	// params  - target, path of the link to create
+	// returns - nil if the link was created or any error encountered. The
+	//           target is either absolute or relative to the link's
+	//           directory.
+	Symlink(*Context, string, string) error
+}
+
+// linkTarget returns the target of a symbolic link, "" when it cannot be
+// read or is not a link.
+func (sess *Session) linkTarget(ctx *Context, f os.FileInfo, p string) string {
+	if f.Mode()&os.ModeSymlink == 0 {
+		return ""
+	}
+	driver, ok := sess.baseDriver().(SymlinkDriver)
+	if !ok {
+		return ""
+	}
+	target, err := driver.Readlink(ctx, sess.realPath(p))
+	if err != nil {
+		sess.logf("reading link %s: %v", p, err)
+		return ""
+	}
+	// Relative targets are resolved against the link's directory, and
+	// targets outside of the session's root are not disclosed.
+	if !path.IsAbs(target) {
+		target = path.Join(path.Dir(sess.realPath(p)), target)
+	}
+	if !withinRoot(sess.root, path.Clean(target)) {
+		return ""
+	}
+	return sess.sessionPath(path.Clean(target))
+}
+
+// withinRoot reports whether p, a clean absolute path of the driver, is
+// root or below it. Any path is within the empty root.
+func withinRoot(root, p string) bool {
+	if root == "" || root == "/" || p == root {
+		return true
+	}
+	return strings.HasPrefix(p, strings.TrimSuffix(root, "/")+"/")
+}
+
+// commandSiteSymlink responds to SITE SYMLINK target link by creating a
+// symbolic link, when the driver is a SymlinkDriver.
+type commandSiteSymlink struct{}
+
+func (cmd commandSiteSymlink) IsExtend() bool {
+	return false
+}
+
+func (cmd commandSiteSymlink) RequireParam() bool {
+	return true
+}
+
+func (cmd commandSiteSymlink) RequireAuth() bool {
+	return true
+}
+
+func (cmd commandSiteSymlink) Help() string {
+	return "Syntax: SITE SYMLINK <target> <link>"
+}
+
+func (cmd commandSiteSymlink) Execute(sess *Session, param string) {
+	driver, ok := sess.baseDriver().(SymlinkDriver)
+	if !ok {
+		sess.writeMessage(502, "SITE SYMLINK not supported")
+		return
+	}
+
+	target, link := sess.parseLine(param)
+	if target == "" || link == "" {
+		sess.writeMessage(501, "Syntax: SITE SYMLINK <target> <link>")
+		return
+	}
+
+	linkPath := sess.buildPath(link)
+	ctx := Context{
+		Sess:  sess,
+		Cmd:   "SITE SYMLINK",
+		Param: param,
+		Data:  NewStore(),
+	}
+	err := sess.server.notifiers.Intercept(&ctx, linkPath)
+	if err == nil {
+		// A relative target is resolved in the session's view, so that it
+		// cannot climb out of the root.
+		target = sess.realPath(CleanPath(path.Dir(linkPath), target))
+		err = driver.Symlink(&ctx, target, sess.realPath(linkPath))
+		sess.forgetCached(linkPath)
+	}
+	if err != nil {
+		sess.writeError(err, 550, fmt.Sprint("Action not taken: ", err))
+		return
+	}
+	sess.writeMessage(200, "Symlink created")
+}
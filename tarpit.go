@@ -0,0 +1,160 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	defaultLoginTarpitMax    = 30 * time.Second
+	defaultLoginTarpitWindow = 15 * time.Minute
+	defaultLoginBanDuration  = time.Hour
+)
+
+// LoginFailureStore counts the failed logins of each source IP, see
+// Options.LoginFailureStore. Implementations backed by a shared database
+// allow several servers to slow down and ban the same sources.
+type LoginFailureStore interface {
+	// AddLoginFailure records a failed login from ip and returns the
+	// number of logins which failed from ip, this one included, since it
+	// was last quiet for window.
+	AddLoginFailure(ip string, window time.Duration) (int, error)
+
+	// ResetLoginFailures forgets the failed logins from ip.
+	ResetLoginFailures(ip string) error
+}
+
+var _ LoginFailureStore = &loginTarpit{}
+
+// loginTarpit counts the failed logins of each source IP in memory, the
+// default LoginFailureStore.
+type loginTarpit struct {
+	lock      sync.Mutex
+	sources   map[string]*tarpitSource
+	lastSweep time.Time
+}
+
+type tarpitSource struct {
+	failures int
+	last     time.Time
+}
+
+// AddLoginFailure implements LoginFailureStore
+func (tarpit *loginTarpit) AddLoginFailure(ip string, window time.Duration) (int, error) {
+	return tarpit.add(ip, time.Now(), window), nil
+}
+
+// ResetLoginFailures implements LoginFailureStore
+func (tarpit *loginTarpit) ResetLoginFailures(ip string) error {
+	tarpit.succeed(ip)
+	return nil
+}
+
+// add records a failed login from ip at now and returns the failures of
+// ip within window.
+func (tarpit *loginTarpit) add(ip string, now time.Time, window time.Duration) int {
+	tarpit.lock.Lock()
+	defer tarpit.lock.Unlock()
+
+	if tarpit.sources == nil {
+		tarpit.sources = make(map[string]*tarpitSource)
+	}
+	// Sources quiet for a whole window are forgotten
+	if now.Sub(tarpit.lastSweep) >= window {
+		for key, source := range tarpit.sources {
+			if now.Sub(source.last) >= window {
+				delete(tarpit.sources, key)
+			}
+		}
+		tarpit.lastSweep = now
+	}
+
+	source, ok := tarpit.sources[ip]
+	if !ok || now.Sub(source.last) >= window {
+		source = &tarpitSource{}
+		tarpit.sources[ip] = source
+	}
+	source.failures++
+	source.last = now
+	return source.failures
+}
+
+// succeed forgets the failed logins of ip
+func (tarpit *loginTarpit) succeed(ip string) {
+	tarpit.lock.Lock()
+	delete(tarpit.sources, ip)
+	tarpit.lock.Unlock()
+}
+
+// tarpitDelay returns how long to wait before answering a failed login, the
+// failures-th in a row from its source.
+func tarpitDelay(opts *Options, failures int) time.Duration {
+	delay := opts.LoginTarpitDelay
+	for i := 1; i < failures && delay < opts.LoginTarpitMax; i++ {
+		delay *= 2
+	}
+	if delay > opts.LoginTarpitMax {
+		delay = opts.LoginTarpitMax
+	}
+	return delay
+}
+
+// loginFailures returns where failed logins are counted
+func (server *Server) loginFailures() LoginFailureStore {
+	if server.LoginFailureStore != nil {
+		return server.LoginFailureStore
+	}
+	return &server.tarpit
+}
+
+// tarpitFailedLogin delays the answer to a failed login, more for each
+// recent failure from the same source. It returns early when the session
+// ends. It reports whether the source was banned, see
+// Options.MaxLoginFailures.
+func (sess *Session) tarpitFailedLogin() bool {
+	opts := sess.server.Options
+	if opts.LoginTarpitDelay <= 0 && opts.MaxLoginFailures <= 0 {
+		return false
+	}
+	ip := addrIP(sess.RemoteAddr())
+	failures, err := sess.server.loginFailures().AddLoginFailure(ip, opts.LoginTarpitWindow)
+	if err != nil {
+		sess.logf("counting failed logins: %v", err)
+		failures = 1
+	}
+
+	if opts.MaxLoginFailures > 0 && failures >= opts.MaxLoginFailures {
+		sess.logf("Failed login %d from %s, banning it for %s", failures, ip, opts.LoginBanDuration)
+		if err := opts.BanList.Ban(ip, opts.LoginBanDuration); err != nil {
+			sess.logf("banning %s: %v", ip, err)
+		}
+		return true
+	}
+	if opts.LoginTarpitDelay <= 0 {
+		return false
+	}
+
+	delay := tarpitDelay(opts, failures)
+	sess.logf("Failed login, answering in %s", delay)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-sess.Ctx.Done():
+	}
+	return false
+}
+
+// tarpitLogin forgets the failed logins of the session's source
+func (sess *Session) tarpitLogin() {
+	if sess.server.LoginTarpitDelay <= 0 && sess.server.MaxLoginFailures <= 0 {
+		return
+	}
+	if err := sess.server.loginFailures().ResetLoginFailures(addrIP(sess.RemoteAddr())); err != nil {
+		sess.logf("resetting failed logins: %v", err)
+	}
+}
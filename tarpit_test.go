@@ -0,0 +1,81 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoginTarpit(t *testing.T) {
+	opts := optsWithDefaults(&Options{LoginTarpitDelay: time.Second, LoginTarpitMax: 5 * time.Second})
+	var tarpit loginTarpit
+	fail := func(ip string) time.Duration {
+		t.Helper()
+		failures, err := tarpit.AddLoginFailure(ip, opts.LoginTarpitWindow)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tarpitDelay(opts, failures)
+	}
+
+	for i, want := range []time.Duration{1, 2, 4, 5, 5} {
+		if delay := fail("192.0.2.1"); delay != want*time.Second {
+			t.Errorf("failure %d delayed %s, want %s", i+1, delay, want*time.Second)
+		}
+	}
+	if delay := fail("192.0.2.2"); delay != time.Second {
+		t.Errorf("other source delayed %s", delay)
+	}
+
+	// Failures are forgotten after a quiet window or a successful login
+	later := time.Now().Add(opts.LoginTarpitWindow)
+	if failures := tarpit.add("192.0.2.1", later, opts.LoginTarpitWindow); failures != 1 {
+		t.Errorf("%d failures after a quiet window", failures)
+	}
+	fail("192.0.2.2")
+	if err := tarpit.ResetLoginFailures("192.0.2.2"); err != nil {
+		t.Fatal(err)
+	}
+	if delay := fail("192.0.2.2"); delay != time.Second {
+		t.Errorf("delayed %s after a successful login", delay)
+	}
+}
+
+func TestLoginTarpitSession(t *testing.T) {
+	client := newPipeSession(t, &Options{
+		Auth:             &SimpleAuth{Name: "user", Password: "secret"},
+		LoginTarpitDelay: 20 * time.Millisecond,
+	})
+
+	for _, want := range []time.Duration{20, 40} {
+		expectCode(t, client, 331, "USER user")
+		start := time.Now()
+		expectCode(t, client, 530, "PASS wrong")
+		if elapsed := time.Since(start); elapsed < want*time.Millisecond {
+			t.Errorf("failed login answered after %s, want %s", elapsed, want*time.Millisecond)
+		}
+	}
+	expectCode(t, client, 331, "USER user")
+	expectCode(t, client, 230, "PASS secret")
+}
+
+func TestMemoryBanList(t *testing.T) {
+	list := NewMemoryBanList()
+	if err := list.Ban("192.0.2.1", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	_ = list.Ban("192.0.2.2", -time.Second)
+
+	for ip, want := range map[string]bool{"192.0.2.1": true, "192.0.2.2": false, "192.0.2.3": false} {
+		if banned, _ := list.Banned(ip); banned != want {
+			t.Errorf("%s banned %v, want %v", ip, banned, want)
+		}
+	}
+	_ = list.Unban("192.0.2.1")
+	if banned, _ := list.Banned("192.0.2.1"); banned {
+		t.Error("192.0.2.1 still banned")
+	}
+}
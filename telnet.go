@@ -0,0 +1,94 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import "strings"
+
+// Telnet commands, RFC 854. The control connection follows the Telnet
+// protocol, see RFC 959 section 4.1.
+const (
+	telnetSE   = 0xf0 // end of subnegotiation
+	telnetDM   = 0xf2 // data mark, the "synch" sent ahead of ABOR
+	telnetIP   = 0xf4 // interrupt process
+	telnetSB   = 0xfa // start of subnegotiation
+	telnetWILL = 0xfb
+	telnetWONT = 0xfc
+	telnetDO   = 0xfd
+	telnetDONT = 0xfe
+	telnetIAC  = 0xff // interpret as command
+)
+
+// parseTelnet strips the Telnet commands from a command line and returns
+// the replies refusing the options the client offered or asked for, the
+// server supports none. IAC IAC stands for a 0xff byte of the line.
+func parseTelnet(line string) (string, []byte) {
+	if strings.IndexByte(line, telnetIAC) < 0 {
+		return line, nil
+	}
+
+	var (
+		b       strings.Builder
+		replies []byte
+	)
+	for i := 0; i < len(line); i++ {
+		if line[i] != telnetIAC {
+			b.WriteByte(line[i])
+			continue
+		}
+		if i++; i >= len(line) {
+			break
+		}
+
+		switch line[i] {
+		case telnetIAC:
+			b.WriteByte(telnetIAC)
+		case telnetWILL, telnetWONT, telnetDO, telnetDONT:
+			if i+1 >= len(line) {
+				return b.String(), replies
+			}
+			option := line[i+1]
+			switch line[i] {
+			case telnetWILL:
+				replies = append(replies, telnetIAC, telnetDONT, option)
+			case telnetDO:
+				replies = append(replies, telnetIAC, telnetWONT, option)
+			}
+			i++
+		case telnetSB:
+			end := strings.Index(line[i:], string([]byte{telnetIAC, telnetSE}))
+			if end < 0 {
+				return b.String(), replies
+			}
+			i += end + 1
+		}
+		// IP, DM and the other commands without an option are dropped.
+	}
+	return b.String(), replies
+}
+
+// trimTelnet strips the telnet sequences clients may send in front of a
+// command line, including the bare IP and DM bytes some leave behind when
+// sending the synch as urgent data.
+func trimTelnet(line string) string {
+	line, _ = parseTelnet(line)
+	for len(line) > 0 && (line[0] == telnetIP || line[0] == telnetDM) {
+		line = line[1:]
+	}
+	return line
+}
+
+// telnet strips the Telnet commands from a command line read from the
+// client, answering its option negotiation.
+func (sess *Session) telnet(line string) string {
+	line, replies := parseTelnet(line)
+	if len(replies) > 0 {
+		sess.controlMu.Lock()
+		defer sess.controlMu.Unlock()
+		sess.setWriteDeadline()
+		_, _ = sess.controlWriter.Write(replies)
+		sess.controlWriter.Flush()
+	}
+	return line
+}
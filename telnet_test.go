@@ -0,0 +1,79 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"testing"
+)
+
+func TestParseTelnet(t *testing.T) {
+	telnetTests := []struct {
+		line    string // input
+		want    string // expected line
+		replies []byte // expected replies
+	}{
+		{"NOOP\r\n", "NOOP\r\n", nil},
+		{"\xff\xf4\xff\xf2ABOR\r\n", "ABOR\r\n", nil},
+		{"\xff\xfb\x18NOOP\r\n", "NOOP\r\n", []byte{0xff, 0xfe, 0x18}},
+		{"\xff\xfd\x01\xff\xfc\x03NOOP\r\n", "NOOP\r\n", []byte{0xff, 0xfc, 0x01}},
+		{"\xff\xfa\x18\x00xterm\xff\xf0NOOP\r\n", "NOOP\r\n", nil},
+		{"RETR a\xff\xffb\r\n", "RETR a\xffb\r\n", nil},
+		{"NOOP\xff", "NOOP", nil},
+		{"\xf2ABOR\r\n", "\xf2ABOR\r\n", nil},
+	}
+
+	for _, tt := range telnetTests {
+		line, replies := parseTelnet(tt.line)
+		if line != tt.want || !bytes.Equal(replies, tt.replies) {
+			t.Errorf("parseTelnet(%q): expected %q %x, actual %q %x", tt.line, tt.want, tt.replies, line, replies)
+		}
+	}
+
+	if line := trimTelnet("\xf2ABOR"); line != "ABOR" {
+		t.Errorf("trimTelnet kept a bare DM: %q", line)
+	}
+}
+
+func TestTelnetNegotiation(t *testing.T) {
+	s, err := NewServer(&Options{
+		Perm:   NewSimplePerm("test", "test"),
+		Logger: new(DiscardLogger),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	sess := s.newSession(newSessionID(), serverConn)
+	go sess.Serve()
+
+	r := bufio.NewReader(clientConn)
+	if _, err = r.ReadString('\n'); err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		_, _ = clientConn.Write([]byte("\xff\xfb\x18NOOP\r\n"))
+	}()
+	reply := make([]byte, 3)
+	if _, err = io.ReadFull(r, reply); err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{0xff, 0xfe, 0x18}; !bytes.Equal(reply, want) {
+		t.Errorf("negotiation reply %x, want %x", reply, want)
+	}
+	line, err := r.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line != "200 OK\r\n" {
+		t.Errorf("NOOP reply %q", line)
+	}
+}
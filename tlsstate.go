@@ -0,0 +1,53 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+)
+
+// TLSConnectionState returns the state of the TLS connection of the control
+// channel, false before the session upgraded to TLS or on a plain session.
+func (sess *Session) TLSConnectionState() (*tls.ConnectionState, bool) {
+	conn, ok := sess.Conn.(*tls.Conn)
+	if !ok {
+		return nil, false
+	}
+	state := conn.ConnectionState()
+	if !state.HandshakeComplete {
+		return nil, false
+	}
+	return &state, true
+}
+
+// TLSConnectionState returns the state of the TLS connection of the session,
+// see Session.TLSConnectionState.
+func (ctx *Context) TLSConnectionState() (*tls.ConnectionState, bool) {
+	if ctx.Sess == nil {
+		return nil, false
+	}
+	return ctx.Sess.TLSConnectionState()
+}
+
+// PeerCertificates returns the certificates the client presented, leaf
+// first. It is empty unless Options.ClientAuth requests them.
+func (ctx *Context) PeerCertificates() []*x509.Certificate {
+	state, ok := ctx.TLSConnectionState()
+	if !ok {
+		return nil
+	}
+	return state.PeerCertificates
+}
+
+// CipherSuite returns the name of the cipher suite of the session, "" when
+// it does not use TLS.
+func (ctx *Context) CipherSuite() string {
+	state, ok := ctx.TLSConnectionState()
+	if !ok {
+		return ""
+	}
+	return tls.CipherSuiteName(state.CipherSuite)
+}
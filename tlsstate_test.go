@@ -0,0 +1,97 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"net/textproto"
+	"testing"
+	"time"
+)
+
+// testCertificate returns a self-signed certificate for name.
+func testCertificate(t *testing.T, name string) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestTLSConnectionState(t *testing.T) {
+	s, err := NewServer(&Options{
+		Perm:   NewSimplePerm("test", "test"),
+		Logger: new(DiscardLogger),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.tlsConfig = &tls.Config{
+		Certificates: []tls.Certificate{testCertificate(t, "server")},
+		ClientAuth:   tls.RequireAnyClientCert,
+	}
+
+	serverConn, clientConn := net.Pipe()
+	sess := s.newSession(newSessionID(), serverConn)
+	go sess.Serve()
+
+	client := textproto.NewConn(clientConn)
+	defer client.Close()
+	if _, _, err = client.ReadResponse(220); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := &Context{Sess: sess}
+	if _, ok := ctx.TLSConnectionState(); ok {
+		t.Fatal("plain session has a TLS state")
+	}
+
+	expectCode(t, client, 234, "AUTH TLS")
+	tlsConn := tls.Client(clientConn, &tls.Config{
+		InsecureSkipVerify: true,
+		Certificates:       []tls.Certificate{testCertificate(t, "client")},
+	})
+	if err = tlsConn.Handshake(); err != nil {
+		t.Fatal(err)
+	}
+	client = textproto.NewConn(tlsConn)
+	expectCode(t, client, 211, "FEAT")
+
+	state, ok := ctx.TLSConnectionState()
+	if !ok {
+		t.Fatal("no TLS state after AUTH TLS")
+	}
+	if want := tlsConn.ConnectionState().CipherSuite; state.CipherSuite != want {
+		t.Errorf("cipher suite = %x, want %x", state.CipherSuite, want)
+	}
+	if ctx.CipherSuite() == "" {
+		t.Error("no cipher suite name")
+	}
+	certs := ctx.PeerCertificates()
+	if len(certs) != 1 || certs[0].Subject.CommonName != "client" {
+		t.Errorf("peer certificates = %v", certs)
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import "time"
+
+// ActiveTransfers returns the number of file transfers holding one of the
+// Options.MaxTransfers slots.
+func (server *Server) ActiveTransfers() int {
+	return len(server.transferSlots)
+}
+
+// acquireTransfer takes one of the Options.MaxTransfers slots for a file
+// transfer, and a descriptor of Options.MaxFileDescriptors for its file. It
+// returns the func giving them back, or false once the client was told the
+// server is busy, with 450 or 425, or shutting down, with 421.
+func (sess *Session) acquireTransfer() (func(), bool) {
+	if sess.server.draining.Load() {
+		if sess.dataConn != nil {
+			sess.dataConn.Close()
+			sess.setDataConn(nil)
+		}
+		sess.endDrainedSession()
+		sess.Close()
+		return nil, false
+	}
+	release, ok := sess.acquireTransferSlot()
+	if !ok {
+		return nil, false
+	}
+	fds := sess.server.fds
+	if !fds.acquire() {
+		release()
+		if sess.dataConn != nil {
+			sess.dataConn.Close()
+			sess.setDataConn(nil)
+		}
+		sess.writeMessage(425, "Too many open files, try again later")
+		return nil, false
+	}
+	return func() {
+		fds.release()
+		release()
+	}, true
+}
+
+// acquireTransferSlot takes one of the Options.MaxTransfers slots, waiting
+// up to Options.TransferQueueTimeout for one to free up.
+func (sess *Session) acquireTransferSlot() (func(), bool) {
+	slots := sess.server.transferSlots
+	if slots == nil {
+		return func() {}, true
+	}
+	release := func() { <-slots }
+
+	select {
+	case slots <- struct{}{}:
+		return release, true
+	default:
+	}
+
+	if timeout := sess.server.TransferQueueTimeout; timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case slots <- struct{}{}:
+			return release, true
+		case <-timer.C:
+		case <-sess.Ctx.Done():
+		}
+	}
+
+	if sess.dataConn != nil {
+		sess.dataConn.Close()
+		sess.setDataConn(nil)
+	}
+	sess.writeMessage(450, "Too many transfers in progress, try again later")
+	return nil, false
+}
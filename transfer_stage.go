@@ -0,0 +1,63 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import "io"
+
+// TransferStage is a stage of the pipeline files go through between the
+// data connection and the driver, to hash, throttle, scan or inspect
+// transfers without wrapping the driver. Stages are registered with
+// Server.RegisterTransferStage and run in that order: uploads from the data
+// connection to the driver, downloads from the driver to the data
+// connection.
+type TransferStage interface {
+	// Stage returns the reader the transfer of path is read through in
+	// place of r, or r itself to leave it alone. An error refuses the
+	// transfer. An error returned by the reader's Read, for instance in
+	// place of io.EOF once scanned content is rejected, fails the
+	// transfer and is answered to the client, a *ReplyError choosing the
+	// reply. A reader which is an io.Closer is closed once the transfer
+	// ends.
+	Stage(ctx *Context, direction TransferDirection, path string, r io.Reader) (io.Reader, error)
+}
+
+// TransferStageFunc is a func used as a TransferStage
+type TransferStageFunc func(ctx *Context, direction TransferDirection, path string, r io.Reader) (io.Reader, error)
+
+// Stage implements TransferStage
+func (f TransferStageFunc) Stage(ctx *Context, direction TransferDirection, path string, r io.Reader) (io.Reader, error) {
+	return f(ctx, direction, path, r)
+}
+
+// RegisterTransferStage appends stage to the pipeline of transfers, it must
+// be called before serving.
+func (server *Server) RegisterTransferStage(stage TransferStage) {
+	server.transferStages = append(server.transferStages, stage)
+}
+
+// stageTransfer runs r through the registered stages. It returns the func
+// closing them, in the reverse order.
+func (sess *Session) stageTransfer(ctx *Context, direction TransferDirection, p string, r io.Reader) (io.Reader, func(), error) {
+	var closers []io.Closer
+	closeStages := func() {
+		for i := len(closers) - 1; i >= 0; i-- {
+			if err := closers[i].Close(); err != nil {
+				sess.logf("closing transfer stage: %v", err)
+			}
+		}
+	}
+	for _, stage := range sess.server.transferStages {
+		staged, err := stage.Stage(ctx, direction, p, r)
+		if err != nil {
+			closeStages()
+			return nil, nil, err
+		}
+		if closer, ok := staged.(io.Closer); ok && staged != r {
+			closers = append(closers, closer)
+		}
+		r = staged
+	}
+	return r, closeStages, nil
+}
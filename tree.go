@@ -0,0 +1,283 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// maxTreeDepth bounds the directories walked by SITE RMDIR -R
+const maxTreeDepth = 64
+
+// TreeDriver is implemented by drivers removing and moving directory trees
+// natively, which SITE RMDIR -R and SITE MVDIR then use. For other drivers
+// SITE RMDIR -R walks the tree, deleting its files and directories one by
+// one, and SITE MVDIR calls Rename. SITE RMDIR -R also walks the tree when
+// notifiers are registered or Options.CanaryPaths set, for them to see
+// every entry deleted.
+type TreeDriver interface {
+	// params  - path of a directory
+	// returns - nil once the directory and everything in it is removed
+	DeleteTree(*Context, string) error
+
+	// params  - path of a directory, path it is moved to
+	// returns - nil once the directory and everything in it is moved
+	MoveTree(*Context, string, string) error
+}
+
+// treeStats counts the entries of a directory tree
+type treeStats struct {
+	files, dirs int
+}
+
+// walkTree calls fn with every entry below dir, the entries of directories
+// before the directories themselves. Symbolic links are not followed.
+func walkTree(ctx *Context, driver Driver, dir string, depth int, fn func(entry Entry) error) error {
+	if depth > maxTreeDepth {
+		return fmt.Errorf("ftp: %s is nested over %d directories deep", dir, maxTreeDepth)
+	}
+	var entries []Entry
+	if err := ListEntries(ctx, driver, dir, func(entry Entry) error {
+		entries = append(entries, entry)
+		return nil
+	}); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && entry.Mode()&os.ModeSymlink == 0 {
+			if err := walkTree(ctx, driver, entry.Path, depth+1, fn); err != nil {
+				return err
+			}
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteTree removes dir and everything in it. A TreeDriver removes it at
+// once, unless notifiers or canaries have to see every entry deleted.
+func (sess *Session) deleteTree(ctx *Context, dir string) error {
+	if driver, ok := sess.baseDriver().(TreeDriver); ok && len(sess.server.notifiers.list) == 0 && len(sess.server.CanaryPaths) == 0 {
+		defer sess.forgetCached(dir)
+		return driver.DeleteTree(ctx, sess.realPath(dir))
+	}
+	driver := sess.driver()
+	notifiers := &sess.server.notifiers
+	err := walkTree(ctx, driver, dir, 0, func(entry Entry) error {
+		sess.checkCanary(ctx, entry.Path)
+		if entry.IsDir() && entry.Mode()&os.ModeSymlink == 0 {
+			notifiers.BeforeDeleteDir(ctx, entry.Path)
+			err := notifiers.Intercept(ctx, entry.Path)
+			if err == nil {
+				err = driver.DeleteDir(ctx, entry.Path)
+			}
+			notifiers.AfterDirDeleted(ctx, entry.Path, err)
+			return err
+		}
+		notifiers.BeforeDeleteFile(ctx, entry.Path)
+		err := notifiers.Intercept(ctx, entry.Path)
+		if err == nil {
+			err = driver.DeleteFile(ctx, entry.Path)
+		}
+		notifiers.AfterFileDeleted(ctx, entry.Path, err)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	sess.checkCanary(ctx, dir)
+	return driver.DeleteDir(ctx, dir)
+}
+
+// countTree counts the files and directories below dir
+func (sess *Session) countTree(ctx *Context, dir string) (treeStats, error) {
+	var stats treeStats
+	err := walkTree(ctx, sess.driver(), dir, 0, func(entry Entry) error {
+		if entry.IsDir() && entry.Mode()&os.ModeSymlink == 0 {
+			stats.dirs++
+		} else {
+			stats.files++
+		}
+		return nil
+	})
+	return stats, err
+}
+
+// cutTreeFlags returns param without its leading flags, and the flags
+func cutTreeFlags(param string) (string, map[string]bool) {
+	flags := make(map[string]bool)
+	for {
+		flag, rest, _ := strings.Cut(param, " ")
+		if len(flag) != 2 || flag[0] != '-' {
+			return param, flags
+		}
+		flags[strings.ToUpper(flag)] = true
+		param = rest
+	}
+}
+
+// inTree reports whether p is dir or below it
+func inTree(p, dir string) bool {
+	return p == dir || strings.HasPrefix(p, strings.TrimSuffix(dir, "/")+"/")
+}
+
+// commandSiteRmdir responds to SITE RMDIR -R dir by removing the directory
+// and everything in it, once confirmed with -Y. Without -Y, it tells what
+// would be removed. It is only enabled with Options.RecursiveCommands.
+type commandSiteRmdir struct{}
+
+func (cmd commandSiteRmdir) IsExtend() bool {
+	return false
+}
+
+func (cmd commandSiteRmdir) RequireParam() bool {
+	return true
+}
+
+func (cmd commandSiteRmdir) RequireAuth() bool {
+	return true
+}
+
+func (cmd commandSiteRmdir) Help() string {
+	return "Syntax: SITE RMDIR -R [-Y] <dir> (remove a directory tree, once confirmed with -Y)"
+}
+
+func (cmd commandSiteRmdir) Execute(sess *Session, param string) {
+	if !sess.server.RecursiveCommands {
+		sess.writeMessage(502, "SITE RMDIR not enabled")
+		return
+	}
+	param, flags := cutTreeFlags(param)
+	if !flags["-R"] || param == "" {
+		sess.writeMessage(501, cmd.Help())
+		return
+	}
+	p := sess.buildPath(param)
+	if p == "/" {
+		sess.writeMessage(550, "Directory / cannot be deleted")
+		return
+	}
+
+	ctx := Context{
+		Sess:  sess,
+		Cmd:   "SITE RMDIR",
+		Param: param,
+		Data:  NewStore(),
+	}
+	if !flags["-Y"] {
+		info, err := sess.driver().Stat(&ctx, p)
+		if err == nil && !info.IsDir() {
+			err = ErrNotDir
+		}
+		var stats treeStats
+		if err == nil {
+			stats, err = sess.countTree(&ctx, p)
+		}
+		if err != nil {
+			sess.writeError(err, 550, fmt.Sprint("Directory delete failed: ", err))
+			return
+		}
+		sess.writeMessage(200, fmt.Sprintf("%s holds %d files and %d directories, send SITE RMDIR -R -Y %s to remove them",
+			p, stats.files, stats.dirs, param))
+		return
+	}
+
+	sess.server.notifiers.BeforeDeleteDir(&ctx, p)
+	err := sess.server.notifiers.Intercept(&ctx, p)
+	if err == nil {
+		err = sess.deleteTree(&ctx, p)
+	}
+	if err == nil && inTree(sess.curDir, p) {
+		sess.curDir = path.Dir(p)
+	}
+	sess.server.notifiers.AfterDirDeleted(&ctx, p, err)
+	if err != nil {
+		sess.writeError(err, 550, fmt.Sprint("Directory delete failed: ", err))
+		return
+	}
+	sess.writeMessage(250, "Directory tree deleted")
+}
+
+// commandSiteMvdir responds to SITE MVDIR from to by moving the directory
+// from and everything in it. It is only enabled with
+// Options.RecursiveCommands.
+type commandSiteMvdir struct{}
+
+func (cmd commandSiteMvdir) IsExtend() bool {
+	return false
+}
+
+func (cmd commandSiteMvdir) RequireParam() bool {
+	return true
+}
+
+func (cmd commandSiteMvdir) RequireAuth() bool {
+	return true
+}
+
+func (cmd commandSiteMvdir) Help() string {
+	return "Syntax: SITE MVDIR <from> <to> (move a directory tree)"
+}
+
+func (cmd commandSiteMvdir) Execute(sess *Session, param string) {
+	if !sess.server.RecursiveCommands {
+		sess.writeMessage(502, "SITE MVDIR not enabled")
+		return
+	}
+	from, to := sess.parseLine(param)
+	if from == "" || to == "" {
+		sess.writeMessage(501, cmd.Help())
+		return
+	}
+	fromPath, toPath := sess.buildPath(from), sess.buildPath(to)
+	if fromPath == "/" || inTree(toPath, fromPath) {
+		sess.writeMessage(553, fmt.Sprintf("Cannot move %s to %s", fromPath, toPath))
+		return
+	}
+
+	ctx := Context{
+		Sess:  sess,
+		Cmd:   "SITE MVDIR",
+		Param: param,
+		Data:  NewStore(),
+	}
+	info, err := sess.driver().Stat(&ctx, fromPath)
+	if err == nil && !info.IsDir() {
+		err = ErrNotDir
+	}
+	if err != nil {
+		sess.writeError(err, 550, fmt.Sprint("Directory move failed: ", err))
+		return
+	}
+
+	sess.server.notifiers.BeforeRename(&ctx, fromPath, toPath)
+	err = sess.server.notifiers.Intercept(&ctx, fromPath)
+	if err == nil {
+		err = sess.server.notifiers.Intercept(&ctx, toPath)
+	}
+	if err == nil {
+		if driver, ok := sess.baseDriver().(TreeDriver); ok {
+			err = driver.MoveTree(&ctx, sess.realPath(fromPath), sess.realPath(toPath))
+			sess.forgetCached(fromPath)
+			sess.forgetCached(toPath)
+		} else {
+			err = sess.driver().Rename(&ctx, fromPath, toPath)
+		}
+	}
+	if err == nil && inTree(sess.curDir, fromPath) {
+		sess.curDir = toPath + strings.TrimPrefix(sess.curDir, fromPath)
+	}
+	sess.server.notifiers.AfterRename(&ctx, fromPath, toPath, err)
+	if err != nil {
+		sess.writeError(err, 550, fmt.Sprint("Directory move failed: ", err))
+		return
+	}
+	sess.writeMessage(250, "Directory tree moved")
+}
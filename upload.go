@@ -0,0 +1,40 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"path"
+	"strings"
+)
+
+const partialUploadSuffix = ".part"
+
+// partialUploadPath returns the hidden name an upload to p is written to
+// until it completes, see Options.AtomicUploads. It holds the session ID, so
+// that concurrent uploads of the same file don't write to the same one.
+func (sess *Session) partialUploadPath(p string) string {
+	dir, name := path.Split(p)
+	return dir + "." + name + "." + sess.id + partialUploadSuffix
+}
+
+// finishUpload renames a completed upload from its partial name to its
+// final one, or deletes it when the upload failed.
+func (sess *Session) finishUpload(ctx *Context, partialPath, targetPath string, err error) error {
+	if err == nil {
+		err = sess.driver().Rename(ctx, partialPath, targetPath)
+		if err == nil {
+			return nil
+		}
+	}
+	if delErr := sess.driver().DeleteFile(ctx, partialPath); delErr != nil {
+		sess.logf("removing partial upload %s: %v", partialPath, delErr)
+	}
+	return err
+}
+
+// isPartialUpload reports whether name is the partial name of an upload.
+func isPartialUpload(name string) bool {
+	return strings.HasPrefix(name, ".") && strings.HasSuffix(name, partialUploadSuffix)
+}
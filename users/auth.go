@@ -0,0 +1,125 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package users
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/globalcyberalliance/ftp-go"
+	"github.com/globalcyberalliance/ftp-go/credentials"
+)
+
+// sessionKey is the Session.Data key the logged in user record is cached under.
+var sessionKey = ftp.NewKey[*User]("users.user")
+
+var (
+	_ ftp.Auth           = &Auth{}
+	_ ftp.AccountChecker = &Auth{}
+)
+
+// Auth implements ftp.Auth against a Store
+type Auth struct {
+	store Store
+}
+
+// NewAuth creates an Auth checking passwords against store
+func NewAuth(store Store) *Auth {
+	return &Auth{
+		store: store,
+	}
+}
+
+// CheckPasswd implements ftp.Auth
+func (auth *Auth) CheckPasswd(ctx *ftp.Context, name, pass string) (bool, error) {
+	user, err := auth.store.Lookup(name)
+	if errors.Is(err, ErrUserNotFound) {
+		credentials.VerifyDummy(pass)
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if user.Disabled || !VerifyPassword(user.Password, pass) {
+		return false, nil
+	}
+	// Only those who know the password learn why they can't log in
+	if user.Expired(time.Now()) {
+		return false, fmt.Errorf("users: %s expired on %s: %w", name, user.ExpiresAt.Format(time.DateOnly), ftp.ErrAccountExpired)
+	}
+	if user.MustChangePassword {
+		return false, fmt.Errorf("users: %s: %w", name, ftp.ErrPasswordChangeRequired)
+	}
+
+	if ctx != nil && ctx.Sess != nil {
+		sessionKey.Set(ctx.Sess.Data, user)
+		if up := rateOrDefault(user.RateLimitUp, user.RateLimit); up > 0 {
+			ctx.Sess.SetUploadRateLimit(up)
+		}
+		if down := rateOrDefault(user.RateLimitDown, user.RateLimit); down > 0 {
+			ctx.Sess.SetDownloadRateLimit(down)
+		}
+		if user.BandwidthClass != "" {
+			if err = ctx.Sess.SetBandwidthClass(user.BandwidthClass); err != nil {
+				return false, err
+			}
+		}
+		if user.TransferQuota != (ftp.TransferQuota{}) {
+			ctx.Sess.SetTransferQuota(user.TransferQuota)
+		}
+		if user.ListFilter != 0 {
+			ctx.Sess.SetListFilter(user.ListFilter)
+		}
+		if user.MOTD != "" {
+			ctx.Sess.SetMOTD(user.MOTD)
+		}
+	}
+	return true, nil
+}
+
+// CheckAccount implements ftp.AccountChecker, refusing disabled and
+// expired accounts and users who must change their password.
+func (auth *Auth) CheckAccount(ctx *ftp.Context, name string) error {
+	user, err := auth.store.Lookup(name)
+	if err != nil {
+		return err
+	}
+	if user.Disabled {
+		return fmt.Errorf("users: %s is disabled: %w", name, ftp.ErrPermissionDenied)
+	}
+	if user.Expired(time.Now()) {
+		return fmt.Errorf("users: %s expired on %s: %w", name, user.ExpiresAt.Format(time.DateOnly), ftp.ErrAccountExpired)
+	}
+	if user.MustChangePassword {
+		return fmt.Errorf("users: %s: %w", name, ftp.ErrPasswordChangeRequired)
+	}
+	if ctx != nil && ctx.Sess != nil {
+		sessionKey.Set(ctx.Sess.Data, user)
+	}
+	return nil
+}
+
+// rateOrDefault returns rate, or def if rate is not set.
+func rateOrDefault(rate, def int64) int64 {
+	if rate > 0 {
+		return rate
+	}
+	return def
+}
+
+// CurrentUser returns the record of the user logged into the session of ctx.
+func CurrentUser(ctx *ftp.Context, store Store) (*User, error) {
+	if ctx == nil || ctx.Sess == nil {
+		return nil, ErrUserNotFound
+	}
+
+	name := ctx.Sess.LoginUser()
+	if user, ok := sessionKey.Get(ctx.Sess.Data); ok && user.Name == name {
+		return user, nil
+	}
+	return store.Lookup(name)
+}
@@ -0,0 +1,296 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package users
+
+import (
+	"io"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/globalcyberalliance/ftp-go"
+)
+
+var (
+	_ ftp.Driver      = &Driver{}
+	_ ftp.Auth        = &Driver{}
+	_ ftp.SpaceDriver = &Driver{}
+	_ ftp.EntryDriver = &Driver{}
+)
+
+// Driver wraps another ftp.Driver, confining every user to their home
+// directory and enforcing their rights and quota. Driver also implements
+// ftp.Auth, so it is used to check passwords in place of Options.Auth.
+//
+// The bytes stored below a home are counted once, when the quota or the
+// free space of its user is first needed, and then kept up to date as
+// files are uploaded, deleted and overwritten through the Driver. Call
+// ResetUsage after changing a home by other means.
+type Driver struct {
+	*Auth
+	store Store
+	base  ftp.Driver
+
+	usageLock sync.Mutex
+	used      map[string]int64
+}
+
+// NewDriver creates a Driver serving the users of store from base
+func NewDriver(store Store, base ftp.Driver) *Driver {
+	return &Driver{
+		Auth:  NewAuth(store),
+		store: store,
+		base:  base,
+		used:  make(map[string]int64),
+	}
+}
+
+// ResetUsage forgets the bytes counted below the home of the user name,
+// they are counted again when next needed.
+func (driver *Driver) ResetUsage(name string) {
+	driver.usageLock.Lock()
+	delete(driver.used, name)
+	driver.usageLock.Unlock()
+}
+
+// user returns the current user and checks it has the wanted rights.
+func (driver *Driver) user(ctx *ftp.Context, want Permission) (*User, error) {
+	user, err := CurrentUser(ctx, driver.store)
+	if err != nil {
+		return nil, err
+	}
+	if user.Disabled || user.Expired(time.Now()) || !user.Perms.Has(want) {
+		return nil, ErrPermissionDenied
+	}
+	return user, nil
+}
+
+func realPath(user *User, p string) string {
+	return path.Join("/", user.Home, p)
+}
+
+// Stat implements ftp.Driver
+func (driver *Driver) Stat(ctx *ftp.Context, p string) (os.FileInfo, error) {
+	user, err := driver.user(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	return driver.base.Stat(ctx, realPath(user, p))
+}
+
+// ListDir implements ftp.Driver
+func (driver *Driver) ListDir(ctx *ftp.Context, p string, callback func(os.FileInfo) error) error {
+	user, err := driver.user(ctx, PermList)
+	if err != nil {
+		return err
+	}
+	return driver.base.ListDir(ctx, realPath(user, p), callback)
+}
+
+// ListEntries implements ftp.EntryDriver, with the paths of the entries
+// below the user's home.
+func (driver *Driver) ListEntries(ctx *ftp.Context, p string, callback func(ftp.Entry) error) error {
+	user, err := driver.user(ctx, PermList)
+	if err != nil {
+		return err
+	}
+	home := realPath(user, "/")
+	return ftp.ListEntries(ctx, driver.base, realPath(user, p), func(entry ftp.Entry) error {
+		entry.Path = path.Join("/", strings.TrimPrefix(entry.Path, home))
+		return callback(entry)
+	})
+}
+
+// DeleteDir implements ftp.Driver
+func (driver *Driver) DeleteDir(ctx *ftp.Context, p string) error {
+	user, err := driver.user(ctx, PermRmdir)
+	if err != nil {
+		return err
+	}
+	return driver.base.DeleteDir(ctx, realPath(user, p))
+}
+
+// DeleteFile implements ftp.Driver
+func (driver *Driver) DeleteFile(ctx *ftp.Context, p string) error {
+	user, err := driver.user(ctx, PermDelete)
+	if err != nil {
+		return err
+	}
+
+	rPath := realPath(user, p)
+	size := driver.fileSize(ctx, rPath)
+	if err = driver.base.DeleteFile(ctx, rPath); err != nil {
+		return err
+	}
+	driver.addUsage(user, -size)
+	return nil
+}
+
+// Rename implements ftp.Driver
+func (driver *Driver) Rename(ctx *ftp.Context, fromPath string, toPath string) error {
+	user, err := driver.user(ctx, PermRename)
+	if err != nil {
+		return err
+	}
+
+	rFrom, rTo := realPath(user, fromPath), realPath(user, toPath)
+	// A file renamed over another one takes its place
+	var replaced int64
+	if rFrom != rTo {
+		replaced = driver.fileSize(ctx, rTo)
+	}
+	if err = driver.base.Rename(ctx, rFrom, rTo); err != nil {
+		return err
+	}
+	driver.addUsage(user, -replaced)
+	return nil
+}
+
+// MakeDir implements ftp.Driver
+func (driver *Driver) MakeDir(ctx *ftp.Context, p string) error {
+	user, err := driver.user(ctx, PermMkdir)
+	if err != nil {
+		return err
+	}
+	return driver.base.MakeDir(ctx, realPath(user, p))
+}
+
+// GetFile implements ftp.Driver
+func (driver *Driver) GetFile(ctx *ftp.Context, p string, offset int64) (int64, io.ReadCloser, error) {
+	user, err := driver.user(ctx, PermRead)
+	if err != nil {
+		return 0, nil, err
+	}
+	return driver.base.GetFile(ctx, realPath(user, p), offset)
+}
+
+// PutFile implements ftp.Driver
+func (driver *Driver) PutFile(ctx *ftp.Context, destPath string, data io.Reader, offset int64) (int64, error) {
+	user, err := driver.user(ctx, PermWrite)
+	if err != nil {
+		return 0, err
+	}
+
+	rPath := realPath(user, destPath)
+	before := driver.fileSize(ctx, rPath)
+	if user.Quota > 0 {
+		used, err := driver.usage(ctx, user)
+		if err != nil {
+			return 0, err
+		}
+
+		// An upload without REST replaces the existing file, so its size is
+		// given back to the user.
+		if offset < 0 {
+			used -= before
+		}
+		// Usage may be over a quota lowered since, or files grown outside
+		// of the driver.
+		if used >= user.Quota {
+			return 0, ErrQuotaExceeded
+		}
+		data = ftp.NewQuotaReader(data, user.Quota-used, ErrQuotaExceeded)
+	}
+
+	n, err := driver.base.PutFile(ctx, rPath, data, offset)
+	// Even a failed upload may have written part of the file
+	driver.addUsage(user, driver.fileSize(ctx, rPath)-before)
+	return n, err
+}
+
+// Space implements ftp.SpaceDriver, reporting the bytes stored below the
+// user's home and what is left of their quota.
+func (driver *Driver) Space(ctx *ftp.Context) (int64, int64, error) {
+	user, err := driver.user(ctx, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	used, err := driver.usage(ctx, user)
+	if err != nil {
+		return 0, 0, err
+	}
+	if user.Quota <= 0 {
+		return used, -1, nil
+	}
+	return used, max(user.Quota-used, 0), nil
+}
+
+// usage returns the number of bytes stored below the home of user,
+// counting them the first time.
+func (driver *Driver) usage(ctx *ftp.Context, user *User) (int64, error) {
+	driver.usageLock.Lock()
+	used, ok := driver.used[user.Name]
+	driver.usageLock.Unlock()
+	if ok {
+		return used, nil
+	}
+
+	used, err := driver.dirSize(ctx, realPath(user, "/"))
+	if err != nil {
+		return 0, err
+	}
+	driver.usageLock.Lock()
+	defer driver.usageLock.Unlock()
+	// Keep the count of a concurrent walk, it may already include changes
+	// made since
+	if counted, ok := driver.used[user.Name]; ok {
+		return counted, nil
+	}
+	driver.used[user.Name] = used
+	return used, nil
+}
+
+// addUsage adds delta to the bytes counted below the home of user, if
+// they have been counted.
+func (driver *Driver) addUsage(user *User, delta int64) {
+	if delta == 0 {
+		return
+	}
+	driver.usageLock.Lock()
+	if used, ok := driver.used[user.Name]; ok {
+		driver.used[user.Name] = max(used+delta, 0)
+	}
+	driver.usageLock.Unlock()
+}
+
+// fileSize returns the size of the file at p, 0 if there is none.
+func (driver *Driver) fileSize(ctx *ftp.Context, p string) int64 {
+	info, err := driver.base.Stat(ctx, p)
+	if err != nil || info.IsDir() {
+		return 0
+	}
+	return info.Size()
+}
+
+// dirSize returns the number of bytes stored below dir.
+func (driver *Driver) dirSize(ctx *ftp.Context, dir string) (int64, error) {
+	var (
+		total   int64
+		subDirs []string
+	)
+	err := ftp.ListEntries(ctx, driver.base, dir, func(entry ftp.Entry) error {
+		if entry.IsDir() {
+			subDirs = append(subDirs, entry.Path)
+		} else {
+			total += entry.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, subDir := range subDirs {
+		size, err := driver.dirSize(ctx, subDir)
+		if err != nil {
+			return 0, err
+		}
+		total += size
+	}
+	return total, nil
+}
@@ -0,0 +1,22 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package users
+
+import (
+	"github.com/globalcyberalliance/ftp-go/credentials"
+)
+
+// HashPassword returns an argon2id hash of password, suitable for
+// User.Password, see credentials.Hash.
+func HashPassword(password string) (string, error) {
+	return credentials.Hash(password)
+}
+
+// VerifyPassword reports whether password matches a bcrypt or argon2id
+// hash, such as those of HashPassword, see the credentials package.
+func VerifyPassword(hash, password string) bool {
+	ok, _ := credentials.Verify(hash, password)
+	return ok
+}
@@ -0,0 +1,58 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package users
+
+import (
+	"os"
+
+	"github.com/globalcyberalliance/ftp-go"
+)
+
+var _ ftp.Perm = &Perm{}
+
+// Perm implements ftp.Perm for virtual users. Ownership is reported as the
+// configured owner and group, and changes are refused since virtual users do
+// not map to accounts of the underlying storage.
+type Perm struct {
+	owner, group string
+}
+
+// NewPerm creates a Perm
+func NewPerm(owner, group string) *Perm {
+	return &Perm{
+		owner: owner,
+		group: group,
+	}
+}
+
+// GetOwner implements ftp.Perm
+func (p *Perm) GetOwner(string) (string, error) {
+	return p.owner, nil
+}
+
+// GetGroup implements ftp.Perm
+func (p *Perm) GetGroup(string) (string, error) {
+	return p.group, nil
+}
+
+// GetMode implements ftp.Perm
+func (p *Perm) GetMode(string) (os.FileMode, error) {
+	return 0o755, nil
+}
+
+// ChOwner implements ftp.Perm
+func (p *Perm) ChOwner(string, string) error {
+	return ErrPermissionDenied
+}
+
+// ChGroup implements ftp.Perm
+func (p *Perm) ChGroup(string, string) error {
+	return ErrPermissionDenied
+}
+
+// ChMode implements ftp.Perm
+func (p *Perm) ChMode(string, os.FileMode) error {
+	return ErrPermissionDenied
+}
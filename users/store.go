@@ -0,0 +1,184 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package users
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Store looks up virtual users by name.
+type Store interface {
+	// Lookup returns the user with the given name, or ErrUserNotFound.
+	Lookup(name string) (*User, error)
+}
+
+var (
+	_ Store = &MapStore{}
+	_ Store = &SQLStore{}
+)
+
+// MapStore is an in-memory Store.
+type MapStore struct {
+	users map[string]*User
+	lock  sync.RWMutex
+}
+
+// NewMapStore creates a MapStore holding the given users
+func NewMapStore(users ...*User) *MapStore {
+	store := &MapStore{
+		users: make(map[string]*User, len(users)),
+	}
+	for _, user := range users {
+		store.users[user.Name] = user
+	}
+	return store
+}
+
+// Lookup implements Store
+func (store *MapStore) Lookup(name string) (*User, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+
+	user, ok := store.users[name]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// Add adds or replaces a user
+func (store *MapStore) Add(user *User) {
+	store.lock.Lock()
+	store.users[user.Name] = user
+	store.lock.Unlock()
+}
+
+// Remove removes a user
+func (store *MapStore) Remove(name string) {
+	store.lock.Lock()
+	delete(store.users, name)
+	store.lock.Unlock()
+}
+
+// fileFormat is the layout of JSON and YAML user files.
+type fileFormat struct {
+	Users []*User `json:"users" yaml:"users"`
+}
+
+// LoadJSON reads users from a JSON document of the form {"users": [...]}.
+func LoadJSON(r io.Reader) (*MapStore, error) {
+	var f fileFormat
+	if err := json.NewDecoder(r).Decode(&f); err != nil {
+		return nil, fmt.Errorf("users: decode json: %w", err)
+	}
+	return newStoreFromFile(f)
+}
+
+// LoadYAML reads users from a YAML document with a top level "users" list.
+func LoadYAML(r io.Reader) (*MapStore, error) {
+	var f fileFormat
+	if err := yaml.NewDecoder(r).Decode(&f); err != nil {
+		return nil, fmt.Errorf("users: decode yaml: %w", err)
+	}
+	return newStoreFromFile(f)
+}
+
+// LoadFile reads users from a JSON or YAML file, chosen by its extension.
+func LoadFile(name string) (*MapStore, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		return LoadJSON(f)
+	case ".yaml", ".yml":
+		return LoadYAML(f)
+	}
+	return nil, fmt.Errorf("users: unsupported file type %q", name)
+}
+
+func newStoreFromFile(f fileFormat) (*MapStore, error) {
+	for _, user := range f.Users {
+		if user.Name == "" {
+			return nil, errors.New("users: user without a name")
+		}
+	}
+	return NewMapStore(f.Users...), nil
+}
+
+// DefaultSQLQuery is the query used by SQLStore when none is given. It must
+// return the name, password, home, perms, quota, rate_limit, rate_limit_up,
+// rate_limit_down, quota_period, quota_upload, quota_download and disabled
+// columns of a single user, selected by name.
+const DefaultSQLQuery = "SELECT name, password, home, perms, quota, rate_limit, rate_limit_up, rate_limit_down, " +
+	"quota_period, quota_upload, quota_download, disabled FROM ftp_users WHERE name = ?"
+
+// SQLStore is a Store backed by a database/sql database.
+type SQLStore struct {
+	db    *sql.DB
+	query string
+}
+
+// NewSQLStore creates a SQLStore. If query is empty, DefaultSQLQuery is used.
+func NewSQLStore(db *sql.DB, query string) *SQLStore {
+	if query == "" {
+		query = DefaultSQLQuery
+	}
+	return &SQLStore{
+		db:    db,
+		query: query,
+	}
+}
+
+// Lookup implements Store
+func (store *SQLStore) Lookup(name string) (*User, error) {
+	var (
+		user   User
+		perms  string
+		period string
+	)
+	err := store.db.QueryRowContext(context.Background(), store.query, name).Scan(
+		&user.Name,
+		&user.Password,
+		&user.Home,
+		&perms,
+		&user.Quota,
+		&user.RateLimit,
+		&user.RateLimitUp,
+		&user.RateLimitDown,
+		&period,
+		&user.TransferQuota.Upload,
+		&user.TransferQuota.Download,
+		&user.Disabled,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Perms, err = ParsePermission(perms); err != nil {
+		return nil, err
+	}
+	if err = user.TransferQuota.Period.UnmarshalText([]byte(period)); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
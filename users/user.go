@@ -0,0 +1,193 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package users implements virtual user accounts for the FTP server. A Store
+// holds the user records, and the Auth, Perm and Driver types in this package
+// consume it together: Auth verifies passwords, Driver confines each user to
+// their home directory and enforces their rights and quota.
+package users
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/globalcyberalliance/ftp-go"
+)
+
+var (
+	// ErrUserNotFound is returned by a Store when the requested user does not exist.
+	ErrUserNotFound = errors.New("users: user not found")
+
+	// ErrPermissionDenied is returned by the Driver when the user lacks the right for an operation.
+	ErrPermissionDenied = fmt.Errorf("users: %w", ftp.ErrPermissionDenied)
+
+	// ErrQuotaExceeded is returned by the Driver when an upload would exceed the user's quota.
+	ErrQuotaExceeded = fmt.Errorf("users: quota exceeded: %w", ftp.ErrStorageExceeded)
+)
+
+// Permission is a set of rights granted to a virtual user.
+type Permission uint32
+
+const (
+	// PermRead allows downloading files.
+	PermRead Permission = 1 << iota
+	// PermWrite allows uploading and overwriting files.
+	PermWrite
+	// PermList allows listing directories.
+	PermList
+	// PermDelete allows deleting files.
+	PermDelete
+	// PermMkdir allows creating directories.
+	PermMkdir
+	// PermRmdir allows removing directories.
+	PermRmdir
+	// PermRename allows renaming files and directories.
+	PermRename
+
+	// PermReadOnly is the set of rights needed to browse and download.
+	PermReadOnly = PermRead | PermList
+	// PermAll grants every right.
+	PermAll = PermRead | PermWrite | PermList | PermDelete | PermMkdir | PermRmdir | PermRename
+)
+
+var permissionNames = []struct {
+	perm Permission
+	name string
+}{
+	{PermRead, "read"},
+	{PermWrite, "write"},
+	{PermList, "list"},
+	{PermDelete, "delete"},
+	{PermMkdir, "mkdir"},
+	{PermRmdir, "rmdir"},
+	{PermRename, "rename"},
+}
+
+// Has reports whether all the rights in want are granted.
+func (p Permission) Has(want Permission) bool {
+	return p&want == want
+}
+
+// String returns the comma separated names of the granted rights.
+func (p Permission) String() string {
+	if p == PermAll {
+		return "all"
+	}
+
+	var names []string
+	for _, n := range permissionNames {
+		if p.Has(n.perm) {
+			names = append(names, n.name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// ParsePermission parses a comma separated list of right names, such as
+// "read,list,write". The special names "all" and "readonly" are accepted.
+func ParsePermission(s string) (Permission, error) {
+	var p Permission
+	for _, field := range strings.Split(s, ",") {
+		field = strings.ToLower(strings.TrimSpace(field))
+		switch field {
+		case "":
+			continue
+		case "all":
+			p |= PermAll
+			continue
+		case "readonly":
+			p |= PermReadOnly
+			continue
+		}
+
+		found := false
+		for _, n := range permissionNames {
+			if n.name == field {
+				p |= n.perm
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("users: unknown permission %q", field)
+		}
+	}
+	return p, nil
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (p Permission) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (p *Permission) UnmarshalText(text []byte) error {
+	parsed, err := ParsePermission(string(text))
+	if err != nil {
+		return err
+	}
+	*p = parsed
+	return nil
+}
+
+// User describes a virtual user account.
+type User struct {
+	// Name is the login name of the user.
+	Name string `json:"name" yaml:"name"`
+
+	// Password is the hashed password, see HashPassword and VerifyPassword.
+	Password string `json:"password" yaml:"password"`
+
+	// Home is the directory of the underlying driver the user is confined to.
+	// It defaults to "/".
+	Home string `json:"home" yaml:"home"`
+
+	// Perms lists the rights of the user.
+	Perms Permission `json:"perms" yaml:"perms"`
+
+	// Quota is the maximum number of bytes the user may store below Home,
+	// 0 means no limit.
+	Quota int64 `json:"quota" yaml:"quota"`
+
+	// RateLimit is the transfer limit of the user's sessions in bytes per
+	// second, 0 means the server's limit applies.
+	RateLimit int64 `json:"rate_limit" yaml:"rate_limit"`
+
+	// RateLimitUp and RateLimitDown override RateLimit for uploads and
+	// downloads respectively.
+	RateLimitUp   int64 `json:"rate_limit_up" yaml:"rate_limit_up"`
+	RateLimitDown int64 `json:"rate_limit_down" yaml:"rate_limit_down"`
+
+	// BandwidthClass names the ftp.Options.BandwidthClasses entry the
+	// user's sessions are put in, whose limits override the ones above.
+	BandwidthClass string `json:"bandwidth_class" yaml:"bandwidth_class"`
+
+	// TransferQuota limits the bytes the user may transfer per day or month.
+	TransferQuota ftp.TransferQuota `json:"transfer_quota" yaml:"transfer_quota"`
+
+	// ListFilter hides entries from the user's listings, such as
+	// "partial,dotfiles". When empty the server's filter applies.
+	ListFilter ftp.ListFilter `json:"list_filter" yaml:"list_filter"`
+
+	// MOTD is sent to the user after login, instead of the server's.
+	MOTD string `json:"motd" yaml:"motd"`
+
+	// Disabled users can not log in.
+	Disabled bool `json:"disabled" yaml:"disabled"`
+
+	// ExpiresAt is when the account expires, logins are refused with 530
+	// from then on. The zero time means never.
+	ExpiresAt time.Time `json:"expires_at" yaml:"expires_at"`
+
+	// MustChangePassword refuses logins with 532 until the password was
+	// changed and the flag cleared.
+	MustChangePassword bool `json:"must_change_password" yaml:"must_change_password"`
+}
+
+// Expired reports whether the account of the user expired at now.
+func (user *User) Expired(now time.Time) bool {
+	return !user.ExpiresAt.IsZero() && !now.Before(user.ExpiresAt)
+}
@@ -0,0 +1,63 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package users
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/globalcyberalliance/ftp-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePermission(t *testing.T) {
+	p, err := ParsePermission("read, list,write")
+	assert.NoError(t, err)
+	assert.True(t, p.Has(PermRead|PermList|PermWrite))
+	assert.False(t, p.Has(PermDelete))
+	assert.EqualValues(t, "read,write,list", p.String())
+
+	p, err = ParsePermission("all")
+	assert.NoError(t, err)
+	assert.EqualValues(t, PermAll, p)
+
+	_, err = ParsePermission("read,fly")
+	assert.Error(t, err)
+}
+
+func TestPassword(t *testing.T) {
+	hash, err := HashPassword("secret")
+	assert.NoError(t, err)
+	assert.True(t, VerifyPassword(hash, "secret"))
+	assert.False(t, VerifyPassword(hash, "Secret"))
+	assert.False(t, VerifyPassword("secret", "secret"))
+}
+
+func TestLoad(t *testing.T) {
+	store, err := LoadJSON(strings.NewReader(`{"users": [
+		{"name": "alice", "home": "/alice", "perms": "readonly", "quota": 1024, "list_filter": "dotfiles", "motd": "Hello alice"}
+	]}`))
+	assert.NoError(t, err)
+
+	user, err := store.Lookup("alice")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "/alice", user.Home)
+	assert.EqualValues(t, PermReadOnly, user.Perms)
+	assert.EqualValues(t, 1024, user.Quota)
+	assert.EqualValues(t, ftp.HideDotFiles, user.ListFilter)
+	assert.EqualValues(t, "Hello alice", user.MOTD)
+
+	_, err = store.Lookup("bob")
+	assert.ErrorIs(t, err, ErrUserNotFound)
+
+	store, err = LoadYAML(strings.NewReader("users:\n  - name: bob\n    perms: read,write\n    rate_limit: 100\n    list_filter: partial,dotfiles\n"))
+	assert.NoError(t, err)
+
+	user, err = store.Lookup("bob")
+	assert.NoError(t, err)
+	assert.EqualValues(t, PermRead|PermWrite, user.Perms)
+	assert.EqualValues(t, 100, user.RateLimit)
+	assert.EqualValues(t, ftp.HidePartialUploads|ftp.HideDotFiles, user.ListFilter)
+}
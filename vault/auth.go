@@ -0,0 +1,268 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package vault
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/globalcyberalliance/ftp-go"
+	"github.com/globalcyberalliance/ftp-go/credentials"
+)
+
+// DefaultCacheTTL is how long answers are cached when CacheTTL is 0
+const DefaultCacheTTL = 30 * time.Second
+
+var (
+	_ ftp.Auth = &KVAuth{}
+	_ ftp.Auth = &LDAPAuth{}
+)
+
+// KVAuth implements ftp.Auth with the secrets of a KV secrets engine, one
+// per user at Mount/Prefix<name>. The password field of a secret holds a
+// bcrypt or argon2id hash, or the password itself.
+type KVAuth struct {
+	*Client
+
+	// Mount path of the secrets engine, defaults to "secret"
+	Mount string
+
+	// Prefix of the secrets, e.g. "ftp/users/"
+	Prefix string
+
+	// Field of the secrets holding the password, defaults to "password"
+	Field string
+
+	// Version of the secrets engine, 1 or 2, defaults to 2
+	Version int
+
+	// How long secrets are cached, DefaultCacheTTL when 0 and no caching
+	// when negative.
+	CacheTTL time.Duration
+
+	cache cache[kvSecret]
+}
+
+// kvSecret is the password field of a secret, "" for missing secrets
+type kvSecret string
+
+// NewKVAuth creates a KVAuth reading the secrets below prefix in the KV
+// version 2 engine mounted at "secret".
+func NewKVAuth(client *Client, prefix string) *KVAuth {
+	return &KVAuth{
+		Client: client,
+		Prefix: prefix,
+	}
+}
+
+// CheckPasswd implements ftp.Auth
+func (auth *KVAuth) CheckPasswd(ctx *ftp.Context, name, pass string) (bool, error) {
+	// Names are escaped in secret paths, but the dot segments would still
+	// be cleaned away
+	if name == "" || name == "." || name == ".." {
+		return false, nil
+	}
+	secret, ok := auth.cache.get(name, auth.CacheTTL)
+	if !ok {
+		var err error
+		if secret, err = auth.read(requestContext(ctx), name); err != nil {
+			return false, err
+		}
+		auth.cache.set(name, secret, auth.CacheTTL)
+	}
+
+	if secret == "" {
+		return false, nil
+	}
+	if credentials.IsHash(string(secret)) {
+		return credentials.Verify(string(secret), pass)
+	}
+	return credentials.Equal(string(secret), pass), nil
+}
+
+// read returns the password field of the secret of user name
+func (auth *KVAuth) read(ctx context.Context, name string) (kvSecret, error) {
+	mount := auth.Mount
+	if mount == "" {
+		mount = "secret"
+	}
+	field := auth.Field
+	if field == "" {
+		field = "password"
+	}
+	secretPath := path.Join(mount, "data", auth.Prefix+url.PathEscape(name))
+	if auth.Version == 1 {
+		secretPath = path.Join(mount, auth.Prefix+url.PathEscape(name))
+	}
+
+	var reply struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	err := auth.do(ctx, http.MethodGet, secretPath, auth.Token, nil, &reply)
+	if errors.Is(err, errNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	data := reply.Data
+	if auth.Version != 1 {
+		// KV version 2 nests the secret below its metadata
+		data, _ = data["data"].(map[string]interface{})
+	}
+	secret, ok := data[field].(string)
+	if !ok {
+		return "", fmt.Errorf("vault: secret %s has no %s field", secretPath, field)
+	}
+	return kvSecret(secret), nil
+}
+
+// LDAPAuth implements ftp.Auth by logging in to Vault's LDAP auth method,
+// which checks the password against the directory. The token Vault issues
+// is revoked right away.
+type LDAPAuth struct {
+	*Client
+
+	// Mount path of the auth method, defaults to "ldap"
+	Mount string
+
+	// How long successful logins are cached, DefaultCacheTTL when 0 and no
+	// caching when negative. Failed logins are not cached.
+	CacheTTL time.Duration
+
+	cache cache[[]byte]
+	// keys the password digests of cache
+	keyOnce sync.Once
+	key     []byte
+}
+
+// NewLDAPAuth creates an LDAPAuth logging in to the LDAP auth method
+// mounted at "ldap".
+func NewLDAPAuth(client *Client) *LDAPAuth {
+	return &LDAPAuth{
+		Client: client,
+	}
+}
+
+// CheckPasswd implements ftp.Auth
+func (auth *LDAPAuth) CheckPasswd(ctx *ftp.Context, name, pass string) (bool, error) {
+	digest := auth.digest(pass)
+	if cached, ok := auth.cache.get(name, auth.CacheTTL); ok && hmac.Equal(cached, digest) {
+		return true, nil
+	}
+
+	mount := auth.Mount
+	if mount == "" {
+		mount = "ldap"
+	}
+	var reply struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}
+	reqCtx := requestContext(ctx)
+	err := auth.do(reqCtx, http.MethodPost, path.Join("auth", mount, "login", url.PathEscape(name)), "",
+		map[string]string{"password": pass}, &reply)
+	var replyErr *Error
+	if errors.As(err, &replyErr) && replyErr.StatusCode/100 == 4 {
+		// Vault answers 400 to wrong credentials
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if reply.Auth.ClientToken != "" {
+		_ = auth.do(reqCtx, http.MethodPost, "auth/token/revoke-self", reply.Auth.ClientToken, nil, nil)
+	}
+	auth.cache.set(name, digest, auth.CacheTTL)
+	return true, nil
+}
+
+// digest returns a keyed digest of pass, so that cached passwords can't be
+// read from memory.
+func (auth *LDAPAuth) digest(pass string) []byte {
+	auth.keyOnce.Do(func() {
+		auth.key = make([]byte, 32)
+		_, _ = rand.Read(auth.key)
+	})
+	mac := hmac.New(sha256.New, auth.key)
+	mac.Write([]byte(pass))
+	return mac.Sum(nil)
+}
+
+// requestContext returns the context Vault requests of ctx are made with
+func requestContext(ctx *ftp.Context) context.Context {
+	if ctx == nil {
+		return context.Background()
+	}
+	return ctx
+}
+
+// cache keeps values by user name until they expire
+type cache[V any] struct {
+	lock    sync.Mutex
+	entries map[string]cacheEntry[V]
+}
+
+type cacheEntry[V any] struct {
+	value   V
+	expires time.Time
+}
+
+func cacheTTL(ttl time.Duration) time.Duration {
+	if ttl == 0 {
+		return DefaultCacheTTL
+	}
+	return ttl
+}
+
+func (c *cache[V]) get(name string, ttl time.Duration) (V, bool) {
+	var zero V
+	if cacheTTL(ttl) < 0 {
+		return zero, false
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	entry, ok := c.entries[name]
+	if !ok {
+		return zero, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.entries, name)
+		return zero, false
+	}
+	return entry.value, true
+}
+
+func (c *cache[V]) set(name string, value V, ttl time.Duration) {
+	ttl = cacheTTL(ttl)
+	if ttl < 0 {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[string]cacheEntry[V])
+	}
+	// Expired entries of users who did not come back are dropped now
+	now := time.Now()
+	for key, entry := range c.entries {
+		if now.After(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+	c.entries[name] = cacheEntry[V]{value: value, expires: now.Add(ttl)}
+}
@@ -0,0 +1,117 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package vault checks the passwords of FTP users against HashiCorp Vault.
+// KVAuth compares them with secrets of a KV secrets engine, LDAPAuth logs
+// in with them through Vault's LDAP auth method. Both cache their answers
+// for a short while, so that clients logging in again and again don't load
+// Vault.
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const defaultTimeout = 10 * time.Second
+
+// errNotFound is returned for the 404 replies of Vault
+var errNotFound = errors.New("vault: not found")
+
+// Client talks to the HTTP API of a Vault server.
+type Client struct {
+	// Address of Vault, e.g. "https://vault.example.com:8200"
+	Address string
+
+	// Token requests are authenticated with, not needed by LDAPAuth
+	Token string
+
+	// Enterprise namespace, optional
+	Namespace string
+
+	// HTTP client requests are sent with, defaults to one timing out after
+	// 10 seconds.
+	HTTPClient *http.Client
+}
+
+// NewClient creates a Client for the Vault at address, authenticated with
+// token.
+func NewClient(address, token string) *Client {
+	return &Client{
+		Address: address,
+		Token:   token,
+	}
+}
+
+// Error is the reply of Vault to a failed request
+type Error struct {
+	StatusCode int
+	Errors     []string
+}
+
+func (err *Error) Error() string {
+	if len(err.Errors) == 0 {
+		return fmt.Sprintf("vault: status %d", err.StatusCode)
+	}
+	return fmt.Sprintf("vault: status %d: %s", err.StatusCode, strings.Join(err.Errors, ", "))
+}
+
+// do sends a request to path, below /v1/, and decodes the JSON reply into
+// out unless nil. A 404 reply returns errNotFound, other failures an *Error.
+func (client *Client) do(ctx context.Context, method, path, token string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(client.Address, "/")+"/v1/"+path, body)
+	if err != nil {
+		return fmt.Errorf("vault: %w", err)
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if client.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", client.Namespace)
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	httpClient := client.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: defaultTimeout}
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("vault: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errNotFound
+	}
+	if resp.StatusCode/100 != 2 {
+		replyErr := &Error{StatusCode: resp.StatusCode}
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(replyErr)
+		return replyErr
+	}
+	if out == nil {
+		return nil
+	}
+	if err = json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("vault: decoding reply: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package vault
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/globalcyberalliance/ftp-go/credentials"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeVault serves the KV secrets of alice and bob and LDAP logins of
+// carol, counting requests.
+func fakeVault(t *testing.T) (*Client, *atomic.Int32) {
+	hash, err := credentials.HashBcrypt("bob", 4)
+	assert.NoError(t, err)
+
+	var requests atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/secret/data/ftp/alice", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "root", r.Header.Get("X-Vault-Token"))
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"data": map[string]string{"password": "alice"}},
+		})
+	})
+	mux.HandleFunc("/v1/kv/ftp/bob", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]string{"hash": hash},
+		})
+	})
+	mux.HandleFunc("/v1/auth/ldap/login/carol", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		if body["password"] != "carol" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string][]string{"errors": {"ldap operation failed"}})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"auth": map[string]string{"client_token": "carol-token"},
+		})
+	})
+	mux.HandleFunc("/v1/auth/token/revoke-self", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "carol-token", r.Header.Get("X-Vault-Token"))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/v1/secret/data/ftp/broken", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string][]string{"errors": {"sealed"}})
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return NewClient(server.URL, "root"), &requests
+}
+
+func TestKVAuth(t *testing.T) {
+	client, requests := fakeVault(t)
+	auth := NewKVAuth(client, "ftp/")
+
+	ok, err := auth.CheckPasswd(nil, "alice", "alice")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = auth.CheckPasswd(nil, "alice", "wrong")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.EqualValues(t, 1, requests.Load(), "secret not cached")
+
+	ok, err = auth.CheckPasswd(nil, "dave", "dave")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = auth.CheckPasswd(nil, "..", "")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	_, err = auth.CheckPasswd(nil, "broken", "broken")
+	assert.EqualError(t, err, "vault: status 500: sealed")
+
+	v1 := NewKVAuth(client, "ftp/")
+	v1.Mount, v1.Field, v1.Version, v1.CacheTTL = "kv", "hash", 1, -1
+	ok, err = v1.CheckPasswd(nil, "bob", "bob")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = v1.CheckPasswd(nil, "bob", "alice")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestLDAPAuth(t *testing.T) {
+	client, requests := fakeVault(t)
+	auth := NewLDAPAuth(client)
+
+	ok, err := auth.CheckPasswd(nil, "carol", "wrong")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = auth.CheckPasswd(nil, "carol", "carol")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.EqualValues(t, 3, requests.Load())
+
+	// Only the password which succeeded is cached
+	ok, err = auth.CheckPasswd(nil, "carol", "carol")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.EqualValues(t, 3, requests.Load())
+	ok, err = auth.CheckPasswd(nil, "carol", "wrong")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.EqualValues(t, 4, requests.Load())
+}
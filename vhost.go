@@ -0,0 +1,146 @@
+// Copyright 2026 The goftp Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ftp
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// VirtualHost is a host served by the server besides the default one, see
+// Options.VirtualHosts. Clients select it with the HOST command or the
+// server name (SNI) of their TLS handshake.
+type VirtualHost struct {
+	// The driver the host's files are served with. Optional, defaults to
+	// Options.Driver.
+	Driver Driver
+
+	// Certificate and key files of the host, served by TLS handshakes
+	// selecting it. Optional, defaults to Options.CertFile and KeyFile.
+	CertFile string
+	KeyFile  string
+}
+
+// virtualHost is a VirtualHost ready to be served
+type virtualHost struct {
+	name string
+	// VirtualHost.Driver, or Options.Driver, for its optional interfaces
+	baseDriver Driver
+	// baseDriver guarded and cached as Options configure
+	driver Driver
+	// nil without VirtualHost.CertFile
+	cert *tls.Certificate
+	// configuration serving cert, set by Serve
+	tlsConfig *tls.Config
+}
+
+// newVirtualHosts prepares Options.VirtualHosts, by lower case name
+func newVirtualHosts(opts *Options, wrap func(Driver) Driver) (map[string]*virtualHost, error) {
+	if len(opts.VirtualHosts) == 0 {
+		return nil, nil
+	}
+	hosts := make(map[string]*virtualHost, len(opts.VirtualHosts))
+	for name, vh := range opts.VirtualHosts {
+		host := &virtualHost{name: strings.ToLower(name), baseDriver: vh.Driver}
+		if host.name == "" {
+			return nil, errors.New("ftp: virtual host without a name")
+		}
+		if host.baseDriver == nil {
+			host.baseDriver = opts.Driver
+		}
+		host.driver = wrap(host.baseDriver)
+		if vh.CertFile != "" || vh.KeyFile != "" {
+			cert, err := tls.LoadX509KeyPair(vh.CertFile, vh.KeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("ftp: virtual host %s: %w", name, err)
+			}
+			host.cert = &cert
+		}
+		hosts[host.name] = host
+	}
+	return hosts, nil
+}
+
+// virtualHost returns the virtual host named name, nil if none. IPv6
+// literals may be given in brackets, as in URLs.
+func (server *Server) virtualHost(name string) *virtualHost {
+	name = strings.TrimSuffix(strings.TrimPrefix(name, "["), "]")
+	return server.virtualHosts[strings.ToLower(name)]
+}
+
+// useVirtualHostTLS serves the certificates of the virtual hosts from
+// config, by server name and to sessions which selected them.
+func (server *Server) useVirtualHostTLS(config *tls.Config) {
+	if len(server.virtualHosts) == 0 {
+		return
+	}
+	for _, host := range server.virtualHosts {
+		if host.cert != nil {
+			host.tlsConfig = config.Clone()
+			host.tlsConfig.Certificates = []tls.Certificate{*host.cert}
+		}
+	}
+	// Handshakes of unknown names are served the default certificates
+	config.GetCertificate = func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		if host := server.virtualHost(hello.ServerName); host != nil {
+			return host.cert, nil
+		}
+		return nil, nil
+	}
+}
+
+// VirtualHost returns the name of the virtual host the session selected, in
+// lower case, "" for the default host.
+func (sess *Session) VirtualHost() string {
+	if sess.vhost == nil {
+		return ""
+	}
+	return sess.vhost.name
+}
+
+// driver returns the driver of the session's host, confined to the root of
+// the session if any.
+func (sess *Session) driver() Driver {
+	driver := sess.server.driver
+	if sess.vhost != nil {
+		driver = sess.vhost.driver
+	}
+	if sess.root != "" {
+		return &rootedDriver{Driver: driver, root: sess.root}
+	}
+	return driver
+}
+
+// baseDriver returns the driver of the session's host as configured, to
+// check the optional interfaces it implements. The paths given to them are
+// translated with realPath.
+func (sess *Session) baseDriver() Driver {
+	if sess.vhost != nil {
+		return sess.vhost.baseDriver
+	}
+	return sess.server.Driver
+}
+
+// tlsConfig returns the TLS configuration serving the certificate of the
+// session's host.
+func (sess *Session) tlsConfig() *tls.Config {
+	if sess.vhost != nil && sess.vhost.tlsConfig != nil {
+		return sess.vhost.tlsConfig
+	}
+	return sess.server.tlsConfig
+}
+
+// selectTLSVirtualHost selects the virtual host named by the client's TLS
+// handshake, unless HOST selected one already.
+func (sess *Session) selectTLSVirtualHost() {
+	if sess.vhost != nil {
+		return
+	}
+	if state, ok := sess.TLSConnectionState(); ok {
+		sess.vhost = sess.server.virtualHost(state.ServerName)
+	}
+}